@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Changes password, other sessions are revoked by server.
+func (c *GophKeeperClient) ChangePassword(ctx context.Context, oldPassword string, newPassword string) {
+	if paramIsEmpty(oldPassword, "old password") || paramIsEmpty(newPassword, "new password") {
+		return
+	}
+	revoked, err := c.client.ChangePassword(ctx, &pb.ChangePasswordRequest{OldPassword: oldPassword, NewPassword: newPassword})
+	if err != nil {
+		printError(err)
+		return
+	}
+	fmt.Printf("Password has been changed, %d other sessions have been revoked\n", revoked.GetCount())
+}
+
+// Deletes account with all files after confirmation.
+func (c *GophKeeperClient) DeleteAccount(ctx context.Context, password string, code string, confirmed bool) {
+	if paramIsEmpty(password, "password") {
+		return
+	}
+	if !confirmed {
+		answer, err := prompt("Account and all its files will be deleted permanently, type 'yes' to continue: ")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if strings.ToLower(answer) != "yes" {
+			fmt.Println("Account deletion cancelled")
+			return
+		}
+	}
+	deleted, err := c.client.DeleteAccount(ctx, &pb.DeleteAccountRequest{Password: password, Code: code})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	config := config.GetConfig()
+	os.Remove(config.AuthTokenFile)
+	os.Remove(config.RefreshTokenFile)
+	fmt.Printf("Account has been deleted along with %d files\n", deleted.GetFiles())
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Parses token lifetime, days are given with d suffix, e.g. 30d.
+func ParseLifetime(lifetime string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(lifetime, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("wrong lifetime %q", lifetime)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(lifetime)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("wrong lifetime %q", lifetime)
+	}
+	return duration, nil
+}
+
+// Creates api token with its own key pair, private key is saved to keyPath and token is printed once.
+func (c *GophKeeperClient) CreateApiToken(ctx context.Context, name string, scope string, folders []string, lifetime string, keyPath string) {
+	if paramIsEmpty(name, "name") || paramIsEmpty(keyPath, "key-out") {
+		return
+	}
+	ttl, err := ParseLifetime(lifetime)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	privateKey, publicKey, err := encryption.GenerateRsaKeys()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err = os.WriteFile(keyPath, privateKey, 0600); err != nil {
+		fmt.Printf("Cannot save token key: %s\n", err)
+		return
+	}
+	token, err := c.client.CreateApiToken(ctx, &pb.CreateApiTokenRequest{Name: name, Scope: scope, Folders: folders,
+		ExpiresIn: uint64(ttl.Seconds()), PublicKey: publicKey})
+	if err != nil {
+		os.Remove(keyPath)
+		printError(err)
+		return
+	}
+	fmt.Printf("Token %s has been created, it is shown only once:\n%s\n", token.GetInfo().GetId(), token.GetToken())
+	fmt.Printf("Use it with API_TOKEN=<token> CLIENT_PRIVATE_KEY=%s\n", keyPath)
+}
+
+func (c *GophKeeperClient) ListApiTokens(ctx context.Context) {
+	tokens, err := c.client.ListApiTokens(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, token := range tokens.GetTokens() {
+		lastUsed := "never"
+		if token.GetLastUsed() != 0 {
+			lastUsed = time.Unix(int64(token.GetLastUsed()), 0).String()
+		}
+		folders := "all"
+		if len(token.GetFolders()) > 0 {
+			folders = strings.Join(token.GetFolders(), ",")
+		}
+		fmt.Printf("id=%s    name='%s'    scope=%s    folders=%s    created=%s    expires=%s    last_used=%s\n",
+			token.GetId(), token.GetName(), token.GetScope(), folders, time.Unix(int64(token.GetCreated()), 0),
+			time.Unix(int64(token.GetExpires()), 0), lastUsed)
+	}
+}
+
+func (c *GophKeeperClient) RevokeApiToken(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	if _, err := c.client.RevokeApiToken(ctx, &pb.ApiTokenId{Id: id}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Token has been revoked")
+}
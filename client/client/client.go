@@ -2,9 +2,9 @@
 package client
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -12,43 +12,57 @@ import (
 	"os"
 	"time"
 
+	"github.com/valinurovdenis/gophkeeper/internal/app/certs"
 	"github.com/valinurovdenis/gophkeeper/internal/app/config"
 	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
 	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type GophKeeperClient struct {
 	client pb.GophKeeperServiceClient
 }
 
-func NewGophKeeperClient() (*GophKeeperClient, error) {
-	conn, err := grpc.NewClient(":8080", grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Transport settings of connection to server.
+type ConnectionOptions struct {
+	certs.ClientConfig
+
+	// Whether connection is not encrypted, for servers without tls.
+	Plaintext bool
+}
+
+func (o ConnectionOptions) credentials() (credentials.TransportCredentials, error) {
+	if o.Plaintext {
+		return insecure.NewCredentials(), nil
+	}
+	tlsConfig, err := o.TLSConfig()
 	if err != nil {
-		return nil, fmt.Errorf("cannot create client: %w", err)
+		return nil, err
 	}
-	return &GophKeeperClient{client: pb.NewGophKeeperServiceClient(conn)}, nil
+	return credentials.NewTLS(tlsConfig), nil
 }
 
-func AddAuthTokenToContext(ctx context.Context) (context.Context, error) {
-	config := config.GetConfig()
-	var token string
-	if file, err := os.OpenFile(config.AuthTokenFile, os.O_RDONLY, 0644); err == nil {
-		defer file.Close()
-		buf := new(bytes.Buffer)
-		_, err = io.Copy(buf, file)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read token from file %w", err)
-		}
-		token = buf.String()
+// Returns client of server from config, connection is established on first request.
+func NewGophKeeperClient(options ConnectionOptions) (*GophKeeperClient, error) {
+	creds, err := options.credentials()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create client: %w", err)
+	}
+	tokens := &tokenSource{}
+	conn, err := grpc.NewClient(config.GetConfig().ServerURL, grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(tokens.unaryInterceptor), grpc.WithStreamInterceptor(tokens.streamInterceptor))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create client: %w", err)
 	}
-	md := metadata.Pairs("Authorization", token)
-	newCtx := metadata.NewOutgoingContext(ctx, md)
-	return newCtx, nil
+	tokens.client = pb.NewGophKeeperServiceClient(conn)
+	return &GophKeeperClient{client: tokens.client}, nil
 }
 
 func prettifySize(size uint64) string {
@@ -70,53 +84,93 @@ func paramIsEmpty(param string, name string) bool {
 	return false
 }
 
-func (c *GophKeeperClient) uploadFileWithProgress(stream pb.GophKeeperService_UploadFileClient, file *os.File, totalSize uint64, filename string, comment string) {
+// Prints error with broken rules of login or password policy if server sent them.
+func printError(err error) {
+	fmt.Println(err)
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fmt.Printf("  %s %s\n", violation.GetField(), violation.GetDescription())
+			}
+		}
+	}
+}
+
+// Computes sha256 of file plaintext and rewinds file to the beginning.
+func hashFile(file *os.File) ([]byte, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("cannot hash file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("cannot rewind file: %w", err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// Encrypts and uploads content of given size, returns id of uploaded file.
+//
+// File is personal if organization is empty, server sets creation time if created is zero.
+func (c *GophKeeperClient) uploadWithProgress(stream pb.GophKeeperService_UploadFileClient, content io.Reader, totalSize uint64, contentHash []byte, filename string, comment string, organization string, created time.Time) (*pb.FileId, error) {
 	key, err := encryption.GenerateSymmetricFileEncryptionKey()
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
 	encryptedKey, err := encryption.EncryptFileEncryptionKey(key, encryption.ServerPublicKey())
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
+	}
+	encryptedHash, err := encryption.EncryptMetadata(key, contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt content hash: %w", err)
 	}
-	stream.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{Filename: filename, Comment: comment, Size: totalSize, EncryptionKey: encryptedKey}}})
-	reader := bufio.NewReader(file)
+	info := &pb.FileInfo{Filename: filename, Comment: comment, Size: totalSize, EncryptionKey: encryptedKey, ContentHash: encryptedHash, Organization: organization}
+	if !created.IsZero() {
+		info.Created = uint64(created.Unix())
+	}
+	stream.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: info}})
 	buffer := make([]byte, filestorage.ChunkSize)
 	uploadedSize := int64(0)
+	checksum := sha256.New()
 	progressBar := NewProgressBar("Uploading", int64(totalSize))
 	for {
 		progressBar.Set(uploadedSize)
-		n, errProgress := reader.Read(buffer)
-		if errProgress != nil {
-			if errProgress == io.EOF {
-				break
-			}
-			fmt.Println(errProgress)
-			return
+		// Chunks are encrypted independently and re-chunked by server on download,
+		// so every chunk except the last must be full.
+		n, readErr := io.ReadFull(content, buffer)
+		if readErr == io.ErrUnexpectedEOF {
+			readErr = io.EOF
 		}
-		encryptedBuf, errProgress := encryption.EncryptFileData(key, buffer[:n])
-		if errProgress != nil {
-			fmt.Printf("Failed to encrypt data: %s\n", errProgress)
-			return
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
 		}
-		if errProgress = stream.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: encryptedBuf}}); err != nil {
-			fmt.Println(errProgress)
-			return
+		if n > 0 {
+			encryptedBuf, err := encryption.EncryptFileData(key, buffer[:n])
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt data: %w", err)
+			}
+			if err = stream.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: encryptedBuf}}); err != nil {
+				return nil, err
+			}
+			checksum.Write(encryptedBuf)
+			uploadedSize += int64(n)
+		}
+		if readErr == io.EOF {
+			break
 		}
-		uploadedSize += int64(n)
 	}
 	resp, err := stream.CloseAndRecv()
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
 	progressBar.End()
-	fmt.Println("Successfully uploaded, file id: ", resp.GetId().Id)
+	if resp.GetSize() != uint64(uploadedSize) || !bytes.Equal(resp.GetChecksum(), checksum.Sum(nil)) {
+		return nil, fmt.Errorf("uploaded file checksum mismatch, file id: %s", resp.GetId().GetId())
+	}
+	return resp.GetId(), nil
 }
 
-func (c *GophKeeperClient) UploadFile(ctx context.Context, filePath string, filename string, comment string) {
+func (c *GophKeeperClient) UploadFile(ctx context.Context, filePath string, filename string, comment string, organization string) {
 	if paramIsEmpty(filePath, "path") {
 		return
 	}
@@ -131,113 +185,170 @@ func (c *GophKeeperClient) UploadFile(ctx context.Context, filePath string, file
 		fmt.Printf("cannot get file info: %s\n", err)
 		return
 	}
-
-	ctx, err = AddAuthTokenToContext(ctx)
+	contentHash, err := hashFile(file)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+
 	stream, err := c.client.UploadFile(ctx)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	c.uploadFileWithProgress(stream, file, uint64(fileInfo.Size()), filename, comment)
+	fileId, err := c.uploadWithProgress(stream, file, uint64(fileInfo.Size()), contentHash, filename, comment, organization, time.Time{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Successfully uploaded, file id: ", fileId.GetId())
+}
+
+// Error in case downloaded file content differs from uploaded one.
+var ErrIntegrity = errors.New("file integrity check failed: content hash mismatch")
+
+// Error in case file was uploaded without content hash.
+var ErrNoContentHash = errors.New("file has no stored content hash, integrity cannot be verified")
+
+// Opened file download with decrypted file encryption key.
+type fileDownload struct {
+	stream pb.GophKeeperService_DownloadFileClient
+	info   *pb.FileInfo
+	key    []byte
+
+	// Plaintext sha256 from file metainfo, nil for files uploaded without it.
+	contentHash []byte
 }
 
-func (c *GophKeeperClient) downloadFileWithProgress(ctx context.Context, fileId string, file *os.File) {
+// Starts download of file with given id and reads its metainfo.
+func (c *GophKeeperClient) openDownload(ctx context.Context, fileId string) (*fileDownload, error) {
 	stream, err := c.client.DownloadFile(ctx, &pb.FileId{Id: fileId})
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
-	uploadedSize := int64(0)
+	return readDownloadInfo(stream)
+}
 
+// Reads metainfo of started download and decrypts file encryption key with client key.
+func readDownloadInfo(stream pb.GophKeeperService_DownloadFileClient) (*fileDownload, error) {
 	res, err := stream.Recv()
 	if err != nil || res.GetInfo() == nil {
-		fmt.Println("Can't get file metainfo.")
-		return
+		return nil, fmt.Errorf("can't get file metainfo")
 	}
-	encryptedKey, err := encryption.DecryptFileEncryptionKey(res.GetInfo().GetEncryptionKey(), encryption.ClientPrivateKey())
+	download := &fileDownload{stream: stream, info: res.GetInfo()}
+	download.key, err = encryption.DecryptFileEncryptionKey(res.GetInfo().GetEncryptionKey(), encryption.ClientPrivateKey())
 	if err != nil {
-		fmt.Println("can't decrypt encryption key from file metainfo.")
-		return
+		return nil, fmt.Errorf("can't decrypt encryption key from file metainfo")
+	}
+	if len(res.GetInfo().GetContentHash()) > 0 {
+		download.contentHash, err = encryption.DecryptMetadata(download.key, res.GetInfo().GetContentHash())
+		if err != nil {
+			return nil, fmt.Errorf("can't decrypt content hash from file metainfo")
+		}
 	}
-	progressBar := NewProgressBar("Downloading", int64(res.GetInfo().Size))
+	return download, nil
+}
+
+// Decrypts file content into writer and verifies plaintext hash.
+func (d *fileDownload) copyTo(file io.Writer) error {
+	uploadedSize := int64(0)
+	hash := sha256.New()
+	progressBar := NewProgressBar("Downloading", int64(d.info.Size))
 	for {
 		progressBar.Set(uploadedSize)
-		res, err := stream.Recv()
+		res, err := d.stream.Recv()
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			fmt.Println(err)
-			return
+			return err
 		}
 		if len(res.GetChunkData()) > 0 {
-			decryptedData, err := encryption.DecryptFileData(encryptedKey, res.GetChunkData())
+			decryptedData, err := encryption.DecryptFileData(d.key, res.GetChunkData())
 			if err != nil {
-				fmt.Println("error when decrypt file data.")
-				return
+				return fmt.Errorf("error when decrypt file data")
+			}
+			if _, err = file.Write(decryptedData); err != nil {
+				return err
 			}
-			file.Write(decryptedData)
+			hash.Write(decryptedData)
 			uploadedSize += int64(len(res.GetChunkData()))
 		}
 	}
 	progressBar.End()
+	if d.contentHash == nil {
+		return ErrNoContentHash
+	}
+	if !bytes.Equal(d.contentHash, hash.Sum(nil)) {
+		return ErrIntegrity
+	}
+	return nil
+}
+
+// Downloads file with given id, decrypts it into writer and verifies plaintext hash.
+func (c *GophKeeperClient) downloadFileWithProgress(ctx context.Context, fileId string, file io.Writer) error {
+	download, err := c.openDownload(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	return download.copyTo(file)
 }
 
 func (c *GophKeeperClient) DownloadFile(ctx context.Context, filePath string, fileId string) {
 	if paramIsEmpty(filePath, "path") || paramIsEmpty(fileId, "id") {
 		return
 	}
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0644)
+	saveDownload(filePath, func(file io.Writer) error {
+		return c.downloadFileWithProgress(ctx, fileId, file)
+	})
+}
+
+// Saves downloaded file to local path, file is removed if download fails.
+func saveDownload(filePath string, download func(file io.Writer) error) {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 	defer file.Close()
 
-	ctx, err = AddAuthTokenToContext(ctx)
+	err = download(file)
+	if errors.Is(err, ErrNoContentHash) {
+		fmt.Printf("Warning: %s\n", err)
+		return
+	}
 	if err != nil {
+		file.Close()
+		os.Remove(filePath)
 		fmt.Println(err)
-		return
 	}
-	c.downloadFileWithProgress(ctx, fileId, file)
 }
 
-func (c *GophKeeperClient) DeleteFile(ctx context.Context, fileId string) {
+// Downloads file with given id without saving it and checks its integrity.
+func (c *GophKeeperClient) VerifyFile(ctx context.Context, fileId string) {
 	if paramIsEmpty(fileId, "id") {
 		return
 	}
-	ctx, err := AddAuthTokenToContext(ctx)
-	if err != nil {
+	if err := c.downloadFileWithProgress(ctx, fileId, io.Discard); err != nil {
 		fmt.Println(err)
 		return
 	}
-	_, err = c.client.DeleteFile(ctx, &pb.FileId{Id: fileId})
-	if err != nil {
-		fmt.Println(err)
-	}
-	fmt.Println("File has been deleted")
+	fmt.Println("File integrity verified")
 }
 
-func saveAuthToken(header metadata.MD) error {
-	if len(header.Get("Authorization")) == 0 {
-		return fmt.Errorf("empty authorization header")
+func (c *GophKeeperClient) DeleteFile(ctx context.Context, fileId string) {
+	if paramIsEmpty(fileId, "id") {
+		return
 	}
-	token := header.Get("Authorization")[0]
-
-	var err error
-	var file *os.File
-	if file, err = os.OpenFile(config.GetConfig().AuthTokenFile, os.O_CREATE|os.O_WRONLY, 0644); err == nil {
-		defer file.Close()
-		_, err = file.WriteString(token)
+	_, err := c.client.DeleteFile(ctx, &pb.FileId{Id: fileId})
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	return err
+	fmt.Println("File has been deleted")
 }
 
-func (c *GophKeeperClient) Register(ctx context.Context, login string, password string) {
+func (c *GophKeeperClient) Register(ctx context.Context, login string, password string, device string) {
 	if paramIsEmpty(login, "login") || paramIsEmpty(password, "password") {
 		return
 	}
@@ -245,17 +356,17 @@ func (c *GophKeeperClient) Register(ctx context.Context, login string, password
 	var header metadata.MD
 	var err error
 	var serverPublicKey *pb.ServicePublicKey
-	if serverPublicKey, err = c.client.Register(ctx, &pb.UserData{Login: login, Password: password, PublicKey: encryption.ClientPublicKey()}, grpc.Header(&header)); err == nil {
+	if serverPublicKey, err = c.client.Register(ctx, &pb.UserData{Login: login, Password: password, PublicKey: encryption.ClientPublicKey(), Device: device}, grpc.Header(&header)); err == nil {
 		if err = encryption.SaveKeyToFile(serverPublicKey.GetPublicKey(), config.GetConfig().ServerPublicKeyPath); err == nil {
 			err = saveAuthToken(header)
 		}
 	}
 	if err != nil {
-		fmt.Println(err)
+		printError(err)
 	}
 }
 
-func (c *GophKeeperClient) Login(ctx context.Context, login string, password string) {
+func (c *GophKeeperClient) Login(ctx context.Context, login string, password string, device string, code string) {
 	if paramIsEmpty(login, "login") || paramIsEmpty(password, "password") {
 		return
 	}
@@ -263,7 +374,11 @@ func (c *GophKeeperClient) Login(ctx context.Context, login string, password str
 	var header metadata.MD
 	var err error
 	var serverPublicKey *pb.ServicePublicKey
-	if serverPublicKey, err = c.client.Login(ctx, &pb.UserData{Login: login, Password: password, PublicKey: encryption.ClientPublicKey()}, grpc.Header(&header)); err == nil {
+	serverPublicKey, err = c.client.Login(ctx, &pb.UserData{Login: login, Password: password, PublicKey: encryption.ClientPublicKey(), Device: device}, grpc.Header(&header))
+	if challengeId, ok := secondFactorChallenge(err); ok {
+		serverPublicKey, header, err = c.verifySecondFactor(ctx, challengeId, code)
+	}
+	if err == nil {
 		if err = encryption.SaveKeyToFile(serverPublicKey.GetPublicKey(), config.GetConfig().ServerPublicKeyPath); err == nil {
 			err = saveAuthToken(header)
 		}
@@ -274,11 +389,6 @@ func (c *GophKeeperClient) Login(ctx context.Context, login string, password str
 }
 
 func (c *GophKeeperClient) ListFiles(ctx context.Context) {
-	ctx, err := AddAuthTokenToContext(ctx)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
 	listFiles, err := c.client.GetUserFiles(ctx, nil)
 	if err != nil {
 		fmt.Println(err)
@@ -289,6 +399,10 @@ func (c *GophKeeperClient) ListFiles(ctx context.Context) {
 	}
 	for _, val := range listFiles.Files {
 		created := time.Unix(int64(val.Created), 0)
-		fmt.Printf("id=%s    filename='%s'    created=%s    size=%s    comment='%s'\n", val.GetId().GetId(), val.GetFilename(), created, prettifySize(val.GetSize()), val.GetComment())
+		organization := "personal"
+		if val.GetOrganization() != "" {
+			organization = val.GetOrganization()
+		}
+		fmt.Printf("id=%s    filename='%s'    created=%s    size=%s    comment='%s'    organization=%s\n", val.GetId().GetId(), val.GetFilename(), created, prettifySize(val.GetSize()), val.GetComment(), organization)
 	}
 }
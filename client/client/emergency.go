@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Invites trusted contact who gets access after waiting period since request, e.g. 7d.
+func (c *GophKeeperClient) InviteEmergencyContact(ctx context.Context, login string, wait string) {
+	if paramIsEmpty(login, "login") {
+		return
+	}
+	waitPeriod, err := ParseLifetime(wait)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	grant, err := c.client.InviteEmergencyContact(ctx, &pb.InviteRequest{Login: login, WaitPeriod: uint64(waitPeriod.Seconds())})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s has been invited, id: %s\n", grant.GetGrantee(), grant.GetId())
+}
+
+func (c *GophKeeperClient) ListEmergencyAccess(ctx context.Context) {
+	grants, err := c.client.ListEmergencyAccess(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(grants.GetGrants()) == 0 {
+		fmt.Println("No emergency access")
+	}
+	for _, grant := range grants.GetGrants() {
+		granted := ""
+		if grant.GetGranted() != 0 {
+			granted = "    granted=" + time.Unix(int64(grant.GetGranted()), 0).String()
+		}
+		fmt.Printf("id=%s    grantor=%s    contact=%s    status=%s    wait=%s%s\n", grant.GetId(), grant.GetGrantor(),
+			grant.GetGrantee(), grant.GetStatus(), time.Duration(grant.GetWaitPeriod())*time.Second, granted)
+	}
+}
+
+// Accepts invitation, grantor wraps file keys for client public key.
+func (c *GophKeeperClient) AcceptEmergencyAccess(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	if _, err := c.client.AcceptEmergencyAccess(ctx, &pb.AcceptRequest{Id: id, PublicKey: encryption.ClientPublicKey()}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Invitation has been accepted, waiting for confirmation by grantor")
+}
+
+// Wraps keys of all personal files for public key of contact.
+//
+// Files uploaded later are given to contact only after confirming again.
+func (c *GophKeeperClient) ConfirmEmergencyAccess(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	grants, err := c.client.ListEmergencyAccess(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	var contactKey []byte
+	for _, grant := range grants.GetGrants() {
+		if grant.GetId() == id {
+			contactKey = grant.GetPublicKey()
+		}
+	}
+	if len(contactKey) == 0 {
+		fmt.Println("Emergency access is not accepted by contact")
+		return
+	}
+	files, err := c.personalFiles(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	keys := make([]*pb.FileKey, 0, len(files))
+	for _, info := range files {
+		downloadCtx, cancel := context.WithCancel(ctx)
+		download, err := c.openDownload(downloadCtx, info.GetId().GetId())
+		cancel()
+		if err != nil {
+			fmt.Printf("Failed to get key of file %s: %s\n", info.GetId().GetId(), err)
+			return
+		}
+		wrapped, err := encryption.EncryptFileEncryptionKey(download.key, contactKey)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		keys = append(keys, &pb.FileKey{FileId: info.GetId().GetId(), Key: wrapped})
+	}
+	if _, err = c.client.ConfirmEmergencyAccess(ctx, &pb.ConfirmRequest{Id: id, Keys: keys}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Keys of %d files have been given to contact\n", len(keys))
+}
+
+// Requests access to files of grantor, access is granted after waiting period unless grantor rejects it.
+func (c *GophKeeperClient) RequestEmergencyAccess(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	grant, err := c.client.RequestEmergencyAccess(ctx, &pb.EmergencyId{Id: id})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Access has been requested, it is granted at %s unless rejected\n", time.Unix(int64(grant.GetGranted()), 0))
+}
+
+func (c *GophKeeperClient) ApproveEmergencyAccess(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	if _, err := c.client.ApproveEmergencyAccess(ctx, &pb.EmergencyId{Id: id}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Access has been approved")
+}
+
+func (c *GophKeeperClient) RejectEmergencyAccess(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	if _, err := c.client.RejectEmergencyAccess(ctx, &pb.EmergencyId{Id: id}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Access has been rejected")
+}
+
+func (c *GophKeeperClient) RevokeEmergencyAccess(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	if _, err := c.client.RevokeEmergencyAccess(ctx, &pb.EmergencyId{Id: id}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Emergency access has been revoked")
+}
+
+// Lists files of grantor available by approved emergency access.
+func (c *GophKeeperClient) ListEmergencyFiles(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	files, err := c.client.GetEmergencyFiles(ctx, &pb.EmergencyId{Id: id})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(files.GetFiles()) == 0 {
+		fmt.Println("No files")
+	}
+	for _, val := range files.GetFiles() {
+		created := time.Unix(int64(val.Created), 0)
+		fmt.Printf("id=%s    filename='%s'    created=%s    size=%s    comment='%s'\n", val.GetId().GetId(), val.GetFilename(), created, prettifySize(val.GetSize()), val.GetComment())
+	}
+}
+
+// Downloads file of grantor by approved emergency access to local path.
+func (c *GophKeeperClient) DownloadEmergencyFile(ctx context.Context, filePath string, id string, fileId string) {
+	if paramIsEmpty(filePath, "path") || paramIsEmpty(id, "id") || paramIsEmpty(fileId, "file") {
+		return
+	}
+	saveDownload(filePath, func(file io.Writer) error {
+		stream, err := c.client.DownloadEmergencyFile(ctx, &pb.EmergencyFile{Id: id, FileId: fileId})
+		if err != nil {
+			return err
+		}
+		download, err := readDownloadInfo(stream)
+		if err != nil {
+			return err
+		}
+		return download.copyTo(file)
+	})
+}
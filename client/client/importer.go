@@ -0,0 +1,100 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/importer"
+)
+
+// Uploads content unless file with the same content is already stored, returns whether it was uploaded.
+func (c *GophKeeperClient) importContent(ctx context.Context, hashes map[string]bool, content []byte, filename string, comment string, dryRun bool) (bool, error) {
+	contentHash := sha256.Sum256(content)
+	key := hex.EncodeToString(contentHash[:])
+	if hashes[key] {
+		fmt.Printf("Skipped duplicate '%s'\n", filename)
+		return false, nil
+	}
+	hashes[key] = true
+	if dryRun {
+		fmt.Printf("Would import '%s' (%s)\n", filename, comment)
+		return true, nil
+	}
+	fileId, err := c.uploadContent(ctx, bytes.NewReader(content), int64(len(content)), contentHash[:], filename, comment, time.Time{})
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Imported '%s', file id: %s\n", filename, fileId.GetId())
+	return true, nil
+}
+
+// Imports export of other password manager, every entry is stored as json item
+// and its attachments as separate files. In dry run nothing is uploaded.
+func (c *GophKeeperClient) ImportEntries(ctx context.Context, format string, inPath string, dryRun bool) {
+	if paramIsEmpty(inPath, "path") {
+		return
+	}
+	file, err := os.Open(inPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+	result, err := importer.Parse(format, file)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	hashes, err := c.existingHashes(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	entries, attachments, duplicates := 0, 0, 0
+	for _, entry := range result.Entries {
+		content, err := entry.Marshal()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		filename := entry.Filename(entry.Title)
+		imported, err := c.importContent(ctx, hashes, content, filename, entry.Comment(format), dryRun)
+		if err != nil {
+			fmt.Printf("Failed to import '%s': %s\n", filename, err)
+			return
+		}
+		if imported {
+			entries++
+		} else {
+			duplicates++
+		}
+		for _, attachment := range entry.Attachments {
+			comment := fmt.Sprintf("attachment of '%s' imported from %s", entry.Title, format)
+			filename := entry.Filename(attachment.Name)
+			imported, err := c.importContent(ctx, hashes, attachment.Data, filename, comment, dryRun)
+			if err != nil {
+				fmt.Printf("Failed to import '%s': %s\n", filename, err)
+				return
+			}
+			if imported {
+				attachments++
+			} else {
+				duplicates++
+			}
+		}
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Printf("Skipped '%s': %s\n", skipped.Title, skipped.Reason)
+	}
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+	fmt.Printf("%s %d entries and %d attachments, skipped %d duplicates and %d other entries\n",
+		verb, entries, attachments, duplicates, len(result.Skipped))
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Access token is refreshed in advance if it expires sooner.
+const refreshMargin = 30 * time.Second
+
+// Methods called without access token.
+var unauthenticatedMethods = []string{
+	pb.GophKeeperService_Register_FullMethodName,
+	pb.GophKeeperService_Login_FullMethodName,
+	pb.GophKeeperService_RefreshToken_FullMethodName,
+}
+
+// Keeps tokens saved in files and refreshes them when access token expires.
+type tokenSource struct {
+	mu     sync.Mutex
+	client pb.GophKeeperServiceClient
+}
+
+// Reads token from file, absent file means empty token.
+func readToken(path string) string {
+	token, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(token))
+}
+
+// Saves tokens to files readable only by user.
+func saveTokens(access, refresh string) error {
+	config := config.GetConfig()
+	if err := os.WriteFile(config.AuthTokenFile, []byte(access), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(config.RefreshTokenFile, []byte(refresh), 0600)
+}
+
+// Saves tokens from login response headers.
+func saveAuthToken(header metadata.MD) error {
+	if len(header.Get("Authorization")) == 0 {
+		return fmt.Errorf("empty authorization header")
+	}
+	var refresh string
+	if len(header.Get("Refresh-Token")) > 0 {
+		refresh = header.Get("Refresh-Token")[0]
+	}
+	return saveTokens(header.Get("Authorization")[0], refresh)
+}
+
+// Whether access token expires within refresh margin, tokens without expiration never expire.
+func expiresSoon(access string) bool {
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(access, claims); err != nil {
+		return false
+	}
+	return claims.ExpiresAt != nil && time.Until(claims.ExpiresAt.Time) < refreshMargin
+}
+
+// Returns access token, refreshing it if expired or if previous one was rejected.
+//
+// Api token from config is returned as is, it is never refreshed.
+func (s *tokenSource) token(ctx context.Context, rejected string) string {
+	if apiToken := config.GetConfig().ApiToken; apiToken != "" {
+		return apiToken
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	access := readToken(config.GetConfig().AuthTokenFile)
+	if access != rejected && !expiresSoon(access) {
+		return access
+	}
+	if refreshed, err := s.refresh(ctx); err == nil {
+		return refreshed
+	}
+	return access
+}
+
+// Exchanges saved refresh token for new tokens.
+func (s *tokenSource) refresh(ctx context.Context) (string, error) {
+	refresh := readToken(config.GetConfig().RefreshTokenFile)
+	if refresh == "" {
+		return "", fmt.Errorf("no refresh token, login required")
+	}
+	tokens, err := s.client.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: refresh})
+	if err != nil {
+		return "", err
+	}
+	if err := saveTokens(tokens.GetAccessToken(), tokens.GetRefreshToken()); err != nil {
+		return "", err
+	}
+	return tokens.GetAccessToken(), nil
+}
+
+// Adds access token to request, retries once with refreshed token if server rejects it.
+func (s *tokenSource) unaryInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+
+	if slices.Contains(unauthenticatedMethods, method) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	access := s.token(ctx, "")
+	err := invoker(metadata.AppendToOutgoingContext(ctx, "Authorization", access), method, req, reply, cc, opts...)
+	if status.Code(err) != codes.Unauthenticated {
+		return err
+	}
+	if refreshed := s.token(ctx, access); refreshed != access {
+		return invoker(metadata.AppendToOutgoingContext(ctx, "Authorization", refreshed), method, req, reply, cc, opts...)
+	}
+	return err
+}
+
+// Adds access token to stream.
+//
+// Stream is rejected only on first receive, so token is refreshed in advance instead of retrying.
+func (s *tokenSource) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+
+	if slices.Contains(unauthenticatedMethods, method) {
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	return streamer(metadata.AppendToOutgoingContext(ctx, "Authorization", s.token(ctx, "")), desc, cc, method, opts...)
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (c *GophKeeperClient) CreateOrganization(ctx context.Context, name string) {
+	if paramIsEmpty(name, "name") {
+		return
+	}
+	org, err := c.client.CreateOrganization(ctx, &pb.CreateOrganizationRequest{Name: name})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Organization has been created, id: ", org.GetId())
+}
+
+func (c *GophKeeperClient) ListOrganizations(ctx context.Context) {
+	orgs, err := c.client.ListOrganizations(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(orgs.GetOrganizations()) == 0 {
+		fmt.Println("No organizations")
+	}
+	for _, org := range orgs.GetOrganizations() {
+		fmt.Printf("id=%s    name='%s'    role=%s    created=%s\n",
+			org.GetId(), org.GetName(), org.GetRole(), time.Unix(int64(org.GetCreated()), 0))
+	}
+}
+
+// Deletes organization with all its files.
+func (c *GophKeeperClient) DeleteOrganization(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	deleted, err := c.client.DeleteOrganization(ctx, &pb.OrganizationId{Id: id})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Organization has been deleted with %d files\n", deleted.GetFiles())
+}
+
+func (c *GophKeeperClient) ListMembers(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	members, err := c.client.ListMembers(ctx, &pb.OrganizationId{Id: id})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, member := range members.GetMembers() {
+		fmt.Printf("login=%s    role=%s    added=%s\n", member.GetLogin(), member.GetRole(), time.Unix(int64(member.GetAdded()), 0))
+	}
+}
+
+// Adds user to organization or changes role of member.
+func (c *GophKeeperClient) SetMember(ctx context.Context, orgId string, login string, role string) {
+	if paramIsEmpty(orgId, "org") || paramIsEmpty(login, "login") || paramIsEmpty(role, "role") {
+		return
+	}
+	if _, err := c.client.SetMember(ctx, &pb.MemberRequest{OrganizationId: orgId, Login: login, Role: role}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s is %s of organization now\n", login, role)
+}
+
+// Removes member from organization, user leaves organization if login is empty.
+func (c *GophKeeperClient) RemoveMember(ctx context.Context, orgId string, login string) {
+	if paramIsEmpty(orgId, "org") {
+		return
+	}
+	if _, err := c.client.RemoveMember(ctx, &pb.MemberRequest{OrganizationId: orgId, Login: login}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Member has been removed")
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/shamir"
+)
+
+// Splits client private key into recovery shares, any threshold of them restores the key.
+//
+// Shares are printed or saved to outDir one per file.
+func (c *GophKeeperClient) SetupRecovery(shares int, threshold int, outDir string) {
+	der, err := encryption.PrivateKeyDER(encryption.ClientPrivateKey())
+	if err != nil {
+		fmt.Printf("Cannot read client private key: %s\n", err)
+		return
+	}
+	printable, err := shamir.SplitPrintable(der, shares, threshold)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if outDir != "" {
+		if err = os.MkdirAll(outDir, 0700); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	for i, share := range printable {
+		if outDir == "" {
+			fmt.Printf("Share %d of %d:\n%s\n\n", i+1, shares, share)
+			continue
+		}
+		path := filepath.Join(outDir, fmt.Sprintf("share-%d.txt", i+1))
+		if err = os.WriteFile(path, []byte(share+"\n"), 0600); err != nil {
+			fmt.Printf("Cannot save share: %s\n", err)
+			return
+		}
+		fmt.Printf("Share %d of %d has been saved to %s\n", i+1, shares, path)
+	}
+	fmt.Printf("Any %d shares restore client private key, keep them in different places\n", threshold)
+}
+
+// Reads shares from files or from stdin one per line until empty line if no files are given.
+func readShares(paths []string) ([]string, error) {
+	shares := make([]string, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, string(data))
+	}
+	if len(paths) > 0 {
+		return shares, nil
+	}
+	fmt.Println("Enter shares one per line, finish with empty line:")
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			break
+		}
+		shares = append(shares, line)
+	}
+	return shares, scanner.Err()
+}
+
+// Restores client key pair from recovery shares, existing other private key is replaced only if forced.
+func (c *GophKeeperClient) RestoreRecovery(paths []string, privateKeyPath string, publicKeyPath string, force bool) {
+	shares, err := readShares(paths)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	der, err := shamir.CombinePrintable(shares)
+	if err != nil {
+		fmt.Printf("Cannot restore key: %s\n", err)
+		return
+	}
+	privateKey, publicKey, err := encryption.KeysFromPrivateDER(der)
+	if err != nil {
+		fmt.Printf("Cannot restore key: %s\n", err)
+		return
+	}
+	if existing, err := os.ReadFile(privateKeyPath); err == nil {
+		if bytes.Equal(existing, privateKey) {
+			fmt.Println("Client private key is already in place")
+			return
+		}
+		if !force {
+			fmt.Printf("%s contains other private key, use --force to replace it\n", privateKeyPath)
+			return
+		}
+	}
+	if err = os.WriteFile(privateKeyPath, privateKey, 0600); err != nil {
+		fmt.Printf("Cannot save private key: %s\n", err)
+		return
+	}
+	if err = os.WriteFile(publicKeyPath, publicKey, 0644); err != nil {
+		fmt.Printf("Cannot save public key: %s\n", err)
+		return
+	}
+	fmt.Printf("Client keys have been restored to %s and %s\n", privateKeyPath, publicKeyPath)
+}
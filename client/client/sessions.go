@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Default device name of session.
+func DefaultDevice() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return hostname
+}
+
+// Revokes current session and removes saved tokens.
+func (c *GophKeeperClient) Logout(ctx context.Context) {
+	_, err := c.client.Logout(ctx, &emptypb.Empty{})
+	config := config.GetConfig()
+	os.Remove(config.AuthTokenFile)
+	os.Remove(config.RefreshTokenFile)
+	if err != nil {
+		fmt.Printf("Session may be still active on server: %s\n", err)
+		return
+	}
+	fmt.Println("Logged out")
+}
+
+func (c *GophKeeperClient) ListSessions(ctx context.Context) {
+	sessions, err := c.client.ListSessions(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, session := range sessions.GetSessions() {
+		current := ""
+		if session.GetCurrent() {
+			current = "    (current)"
+		}
+		fmt.Printf("id=%s    device='%s'    ip=%s    created=%s    last_seen=%s%s\n", session.GetId(), session.GetDevice(),
+			session.GetIp(), time.Unix(int64(session.GetCreated()), 0), time.Unix(int64(session.GetLastSeen()), 0), current)
+	}
+}
+
+func (c *GophKeeperClient) RevokeSession(ctx context.Context, id string) {
+	if paramIsEmpty(id, "id") {
+		return
+	}
+	if _, err := c.client.RevokeSession(ctx, &pb.SessionId{Id: id}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Session has been revoked")
+}
+
+// Revokes all sessions except current one.
+func (c *GophKeeperClient) RevokeOtherSessions(ctx context.Context) {
+	revoked, err := c.client.RevokeOtherSessions(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%d other sessions have been revoked\n", revoked.GetCount())
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Reason and metadata key of login error asking for second factor, same as in server handlers.
+const (
+	secondFactorRequiredReason = "SECOND_FACTOR_REQUIRED"
+	challengeIdKey             = "challenge_id"
+)
+
+// Returns challenge id if login error asks for second factor code.
+func secondFactorChallenge(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetReason() == secondFactorRequiredReason {
+			return info.GetMetadata()[challengeIdKey], true
+		}
+	}
+	return "", false
+}
+
+// Reads line from terminal after prompt.
+func prompt(text string) (string, error) {
+	fmt.Print(text)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("cannot read code: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Completes login by second factor code, asks for code if it is not given.
+func (c *GophKeeperClient) verifySecondFactor(ctx context.Context, challengeId string, code string) (*pb.ServicePublicKey, metadata.MD, error) {
+	var err error
+	if code == "" {
+		if code, err = prompt("Authentication code or recovery code: "); err != nil {
+			return nil, nil, err
+		}
+	}
+	var header metadata.MD
+	serverPublicKey, err := c.client.VerifySecondFactor(ctx, &pb.SecondFactorRequest{ChallengeId: challengeId, Code: code}, grpc.Header(&header))
+	return serverPublicKey, header, err
+}
+
+// Enables second factor after code from authenticator app confirms it is set up.
+func (c *GophKeeperClient) EnableSecondFactor(ctx context.Context) {
+	secret, err := c.client.EnableSecondFactor(ctx, &emptypb.Empty{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Add key to authenticator app:\n  secret: %s\n  uri: %s\n", secret.GetSecret(), secret.GetUri())
+	code, err := prompt("Code from authenticator app: ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	recoveryCodes, err := c.client.ConfirmSecondFactor(ctx, &pb.SecondFactorCode{Code: code})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Second factor has been enabled. Save recovery codes, each of them can be used once instead of code:")
+	for _, code := range recoveryCodes.GetCodes() {
+		fmt.Printf("  %s\n", code)
+	}
+}
+
+func (c *GophKeeperClient) DisableSecondFactor(ctx context.Context, code string) {
+	var err error
+	if code == "" {
+		if code, err = prompt("Authentication code or recovery code: "); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	if _, err = c.client.DisableSecondFactor(ctx, &pb.SecondFactorCode{Code: code}); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Second factor has been disabled")
+}
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/vaultarchive"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Plaintext sha256 of file, files uploaded without content hash are hashed by downloading.
+func (c *GophKeeperClient) contentHash(ctx context.Context, info *pb.FileInfo) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	download, err := c.openDownload(ctx, info.GetId().GetId())
+	if err != nil {
+		return nil, err
+	}
+	if download.contentHash != nil {
+		return download.contentHash, nil
+	}
+	hash := sha256.New()
+	if err = download.copyTo(hash); err != nil && !errors.Is(err, ErrNoContentHash) {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// Downloads file into archive as item.
+func (c *GophKeeperClient) exportFile(ctx context.Context, writer *vaultarchive.Writer, info *pb.FileInfo) error {
+	hash, err := c.contentHash(ctx, info)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	download, err := c.openDownload(ctx, info.GetId().GetId())
+	if err != nil {
+		return err
+	}
+	item := vaultarchive.Item{
+		Filename: info.GetFilename(),
+		Comment:  info.GetComment(),
+		Created:  time.Unix(int64(info.GetCreated()), 0).UTC(),
+		Modified: time.Unix(int64(info.GetModified()), 0).UTC(),
+		Size:     int64(info.GetSize()),
+		Sha256:   hex.EncodeToString(hash),
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := download.copyTo(pipeWriter)
+		if errors.Is(err, ErrNoContentHash) {
+			err = nil
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	err = writer.WriteItem(item, pipeReader)
+	pipeReader.CloseWithError(err)
+	cancel()
+	<-done
+	return err
+}
+
+// Returns personal files of user, files of organizations are not exported.
+func (c *GophKeeperClient) personalFiles(ctx context.Context) ([]*pb.FileInfo, error) {
+	listFiles, err := c.client.GetUserFiles(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(listFiles.GetFiles(), func(file *pb.FileInfo) bool {
+		return file.GetOrganization() != ""
+	}), nil
+}
+
+// Downloads and decrypts all personal user files into archive encrypted with passphrase.
+func (c *GophKeeperClient) ExportVault(ctx context.Context, outPath string, passphrase string) {
+	if paramIsEmpty(outPath, "out") || paramIsEmpty(passphrase, "passphrase") {
+		return
+	}
+	files, err := c.personalFiles(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	file, err := os.OpenFile(outPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+	err = c.exportFiles(ctx, file, []byte(passphrase), files)
+	if err == nil {
+		err = file.Close()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(outPath)
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Exported %d files to %s\n", len(files), outPath)
+}
+
+func (c *GophKeeperClient) exportFiles(ctx context.Context, file io.Writer, passphrase []byte, files []*pb.FileInfo) error {
+	writer, err := vaultarchive.NewWriter(file, passphrase)
+	if err != nil {
+		return err
+	}
+	for _, info := range files {
+		if err = c.exportFile(ctx, writer, info); err != nil {
+			return fmt.Errorf("failed to export file %s: %w", info.GetId().GetId(), err)
+		}
+	}
+	return writer.Close()
+}
+
+// Plaintext hashes of personal user files, files without stored content hash are not included.
+func (c *GophKeeperClient) existingHashes(ctx context.Context) (map[string]bool, error) {
+	files, err := c.personalFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]bool, len(files))
+	for _, info := range files {
+		downloadCtx, cancel := context.WithCancel(ctx)
+		download, err := c.openDownload(downloadCtx, info.GetId().GetId())
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get content hash of file %s: %w", info.GetId().GetId(), err)
+		}
+		if download.contentHash != nil {
+			hashes[hex.EncodeToString(download.contentHash)] = true
+		}
+	}
+	return hashes, nil
+}
+
+// Uploads content, upload is aborted if content reader fails, e.g. on checksum mismatch.
+// Creation time is set by server if created is zero.
+func (c *GophKeeperClient) uploadContent(ctx context.Context, content io.Reader, size int64, contentHash []byte, filename string, comment string, created time.Time) (*pb.FileId, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.client.UploadFile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.uploadWithProgress(stream, content, uint64(size), contentHash, filename, comment, "", created)
+}
+
+// Uploads all items of archive encrypted with passphrase, skipping files with the same content.
+// In dry run archive is only read and verified.
+func (c *GophKeeperClient) ImportVault(ctx context.Context, inPath string, passphrase string, dryRun bool) {
+	if paramIsEmpty(inPath, "archive path") || paramIsEmpty(passphrase, "passphrase") {
+		return
+	}
+	file, err := os.Open(inPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+	hashes, err := c.existingHashes(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	reader, err := vaultarchive.NewReader(file, []byte(passphrase))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer reader.Close()
+	imported, skipped := 0, 0
+	for {
+		item, content, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			fmt.Printf("Imported %d files before failure\n", imported)
+			return
+		}
+		if hashes[item.Sha256] {
+			fmt.Printf("Skipped duplicate '%s'\n", item.Filename)
+			skipped++
+			continue
+		}
+		if dryRun {
+			if _, err = io.Copy(io.Discard, content); err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Printf("Would import '%s'\n", item.Filename)
+			hashes[item.Sha256] = true
+			imported++
+			continue
+		}
+		contentHash, err := hex.DecodeString(item.Sha256)
+		if err != nil {
+			fmt.Printf("Wrong sha256 of '%s': %s\n", item.Filename, err)
+			return
+		}
+		fileId, err := c.uploadContent(ctx, content, item.Size, contentHash, item.Filename, item.Comment, item.Created)
+		if err != nil {
+			fmt.Printf("Failed to import '%s': %s\n", item.Filename, err)
+			fmt.Printf("Imported %d files before failure\n", imported)
+			return
+		}
+		fmt.Printf("Imported '%s', file id: %s\n", item.Filename, fileId.GetId())
+		hashes[item.Sha256] = true
+		imported++
+	}
+	fmt.Printf("Imported %d files, skipped %d duplicates\n", imported, skipped)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/vaultarchive"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Server client storing uploaded file infos, other methods are not implemented.
+type uploadClientMock struct {
+	pb.GophKeeperServiceClient
+	uploaded []*pb.FileInfo
+}
+
+func (c *uploadClientMock) GetUserFiles(context.Context, *emptypb.Empty, ...grpc.CallOption) (*pb.ListFiles, error) {
+	return &pb.ListFiles{}, nil
+}
+
+func (c *uploadClientMock) UploadFile(context.Context, ...grpc.CallOption) (pb.GophKeeperService_UploadFileClient, error) {
+	return &uploadStreamMock{client: c, checksum: sha256.New()}, nil
+}
+
+type uploadStreamMock struct {
+	grpc.ClientStream
+	client   *uploadClientMock
+	info     *pb.FileInfo
+	checksum hash.Hash
+}
+
+func (s *uploadStreamMock) Send(part *pb.FileStream) error {
+	if info := part.GetInfo(); info != nil {
+		s.info = info
+		return nil
+	}
+	s.checksum.Write(part.GetChunkData())
+	return nil
+}
+
+func (s *uploadStreamMock) CloseAndRecv() (*pb.UploadResponse, error) {
+	s.client.uploaded = append(s.client.uploaded, s.info)
+	return &pb.UploadResponse{Id: &pb.FileId{Id: "id"}, Size: s.info.GetSize(), Checksum: s.checksum.Sum(nil)}, nil
+}
+
+func TestImportVault_KeepsCreated(t *testing.T) {
+	dir := t.TempDir()
+	encryption.UseServerKeyFiles(filepath.Join(dir, "private"), filepath.Join(dir, "public"))
+	require.NoError(t, encryption.CreateKeysIfAbsent(true))
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []byte("secret")
+	checksum := sha256.Sum256(data)
+	var archive bytes.Buffer
+	writer, err := vaultarchive.NewWriter(&archive, []byte("passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteItem(vaultarchive.Item{Filename: "note", Created: created, Modified: created,
+		Size: int64(len(data)), Sha256: hex.EncodeToString(checksum[:])}, bytes.NewReader(data)))
+	require.NoError(t, writer.Close())
+	path := filepath.Join(dir, "vault.enc")
+	require.NoError(t, os.WriteFile(path, archive.Bytes(), 0600))
+
+	client := &uploadClientMock{}
+	(&GophKeeperClient{client: client}).ImportVault(context.Background(), path, "passphrase", false)
+	require.Len(t, client.uploaded, 1)
+	require.Equal(t, uint64(created.Unix()), client.uploaded[0].GetCreated())
+	require.Equal(t, created, time.Unix(int64(client.uploaded[0].GetCreated()), 0).UTC(), "exported item should keep creation time")
+}
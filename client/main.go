@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 	"github.com/valinurovdenis/gophkeeper/client/client"
+	"github.com/valinurovdenis/gophkeeper/internal/app/certs"
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
 	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/importer"
 )
 
 var (
@@ -20,28 +24,70 @@ func AddCommands(rootCmd *cobra.Command) {
 
 }
 
+// Connects to server, package is shadowed by client variable in Execute.
+func connect(options client.ConnectionOptions) (*client.GophKeeperClient, error) {
+	return client.NewGophKeeperClient(options)
+}
+
+// Parses boolean config value, exits on invalid one instead of silently using false.
+func mustParseBool(name string, value string) bool {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Printf("invalid %s flag: %v\n", name, err)
+		os.Exit(1)
+	}
+	return parsed
+}
+
 func Execute() {
-	client, err := client.NewGophKeeperClient()
+	defaultDevice := client.DefaultDevice()
+	config := config.GetConfig()
+	skipVerify := mustParseBool("tls insecure skip verify", config.TLSSkipVerify)
+	plaintext := mustParseBool("plaintext", config.Plaintext)
+	options := client.ConnectionOptions{ClientConfig: certs.ClientConfig{CAFile: config.TLSCAFile,
+		CertFile: config.TLSCertFile, KeyFile: config.TLSKeyFile, InsecureSkipVerify: skipVerify}, Plaintext: plaintext}
+	var client *client.GophKeeperClient
 	encryption.InitData()
 
 	var (
-		filePath string
-		fileId   string
-		login    string
-		password string
-		fileName string
-		comment  string
+		filePath    string
+		fileId      string
+		login       string
+		password    string
+		fileName    string
+		comment     string
+		passphrase  string
+		format      string
+		dryRun      bool
+		device      string
+		code        string
+		newPassword string
+		confirmed   bool
+		name        string
+		scope       string
+		folders     []string
+		lifetime    string
+		org         string
+		role        string
+		wait        string
+		shares      int
+		threshold   int
 	)
 
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-
 	var rootCmd = &cobra.Command{
 		Use:   "gophkeeper",
 		Short: "Gophkeeper file manager",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			client, err = connect(options)
+			return err
+		},
 	}
+	rootCmd.PersistentFlags().StringVar(&options.CAFile, "ca", options.CAFile, "CA of server certificate, system roots are used if empty")
+	rootCmd.PersistentFlags().StringVar(&options.CertFile, "cert", options.CertFile, "client certificate for servers requiring it")
+	rootCmd.PersistentFlags().StringVar(&options.KeyFile, "key", options.KeyFile, "client certificate key")
+	rootCmd.PersistentFlags().BoolVar(&options.InsecureSkipVerify, "insecure-skip-verify", options.InsecureSkipVerify, "do not verify server certificate, only for testing")
+	rootCmd.PersistentFlags().BoolVar(&options.Plaintext, "plaintext", options.Plaintext, "connect without tls to server listening in plaintext")
 
 	var downloadCmd = &cobra.Command{
 		Use:   "download",
@@ -53,16 +99,26 @@ func Execute() {
 	downloadCmd.Flags().StringVar(&filePath, "path", "", "local path")
 	downloadCmd.Flags().StringVar(&fileId, "id", "", "file id")
 
+	var verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Download file with given id and verify its integrity without saving",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.VerifyFile(context.Background(), fileId)
+		},
+	}
+	verifyCmd.Flags().StringVar(&fileId, "id", "", "file id")
+
 	var uploadCmd = &cobra.Command{
 		Use:   "upload",
 		Short: "Upload file with given path",
 		Run: func(cmd *cobra.Command, args []string) {
-			client.UploadFile(context.Background(), filePath, fileName, comment)
+			client.UploadFile(context.Background(), filePath, fileName, comment, org)
 		},
 	}
 	uploadCmd.Flags().StringVar(&filePath, "path", "", "local path")
 	uploadCmd.Flags().StringVar(&comment, "comment", "", "file comment")
 	uploadCmd.Flags().StringVar(&fileName, "name", "", "file name")
+	uploadCmd.Flags().StringVar(&org, "org", "", "id of organization to share file with, personal file if empty")
 
 	var deleteCmd = &cobra.Command{
 		Use:   "delete",
@@ -77,21 +133,24 @@ func Execute() {
 		Use:   "register",
 		Short: "Register user",
 		Run: func(cmd *cobra.Command, args []string) {
-			client.Register(context.Background(), login, password)
+			client.Register(context.Background(), login, password, device)
 		},
 	}
 	registerCmd.Flags().StringVar(&login, "login", "", "user login")
 	registerCmd.Flags().StringVar(&password, "password", "", "user password")
+	registerCmd.Flags().StringVar(&device, "device", defaultDevice, "device name of session")
 
 	var loginCmd = &cobra.Command{
 		Use:   "login",
 		Short: "Login user",
 		Run: func(cmd *cobra.Command, args []string) {
-			client.Login(context.Background(), login, password)
+			client.Login(context.Background(), login, password, device, code)
 		},
 	}
 	loginCmd.Flags().StringVar(&login, "login", "", "user login")
 	loginCmd.Flags().StringVar(&password, "password", "", "user password")
+	loginCmd.Flags().StringVar(&device, "device", defaultDevice, "device name of session")
+	loginCmd.Flags().StringVar(&code, "code", "", "second factor code, asked if required and empty")
 
 	var versionCmd = &cobra.Command{
 		Use:   "version",
@@ -111,7 +170,295 @@ func Execute() {
 		},
 	}
 
-	rootCmd.AddCommand(downloadCmd, uploadCmd, deleteCmd, registerCmd, loginCmd, listFilesCmd, versionCmd)
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export all files to archive encrypted with passphrase",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ExportVault(context.Background(), filePath, passphrase)
+		},
+	}
+	exportCmd.Flags().StringVar(&filePath, "out", "", "archive path, e.g. vault.gkx")
+	exportCmd.Flags().StringVar(&passphrase, "passphrase", "", "archive passphrase")
+
+	var importCmd = &cobra.Command{
+		Use:   "import {file}",
+		Short: "Import exported archive or other password manager export skipping already stored files",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if format == "gkx" {
+				client.ImportVault(context.Background(), args[0], passphrase, dryRun)
+				return
+			}
+			client.ImportEntries(context.Background(), format, args[0], dryRun)
+		},
+	}
+	importCmd.Flags().StringVar(&passphrase, "passphrase", "", "gkx archive passphrase")
+	importCmd.Flags().StringVar(&format, "format", "gkx", fmt.Sprintf("file format: gkx or one of %v", importer.Formats()))
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only report what would be imported")
+
+	var logoutCmd = &cobra.Command{
+		Use:   "logout",
+		Short: "Revoke current session",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.Logout(context.Background())
+		},
+	}
+
+	var sessionsCmd = &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage login sessions",
+	}
+	sessionsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List active sessions",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ListSessions(context.Background())
+		},
+	}, &cobra.Command{
+		Use:   "revoke {id}",
+		Short: "Revoke session with given id",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RevokeSession(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "revoke-all",
+		Short: "Revoke all sessions except current one",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RevokeOtherSessions(context.Background())
+		},
+	})
+
+	var secondFactorCmd = &cobra.Command{
+		Use:   "2fa",
+		Short: "Manage two-factor authentication",
+	}
+	var disableSecondFactorCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Disable two-factor authentication",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.DisableSecondFactor(context.Background(), code)
+		},
+	}
+	disableSecondFactorCmd.Flags().StringVar(&code, "code", "", "authentication code or recovery code, asked if empty")
+	secondFactorCmd.AddCommand(&cobra.Command{
+		Use:   "enable",
+		Short: "Enable two-factor authentication with authenticator app",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.EnableSecondFactor(context.Background())
+		},
+	}, disableSecondFactorCmd)
+
+	var changePasswordCmd = &cobra.Command{
+		Use:   "change-password",
+		Short: "Change password and revoke other sessions",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ChangePassword(context.Background(), password, newPassword)
+		},
+	}
+	changePasswordCmd.Flags().StringVar(&password, "old", "", "current password")
+	changePasswordCmd.Flags().StringVar(&newPassword, "new", "", "new password")
+
+	var deleteAccountCmd = &cobra.Command{
+		Use:   "delete-account",
+		Short: "Delete account with all its files",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.DeleteAccount(context.Background(), password, code, confirmed)
+		},
+	}
+	deleteAccountCmd.Flags().StringVar(&password, "password", "", "user password")
+	deleteAccountCmd.Flags().StringVar(&code, "code", "", "second factor code if enabled")
+	deleteAccountCmd.Flags().BoolVar(&confirmed, "yes", false, "do not ask for confirmation")
+
+	var tokenCmd = &cobra.Command{
+		Use:   "token",
+		Short: "Manage api tokens for automation",
+	}
+	var createTokenCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create api token limited by scope and folders",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.CreateApiToken(context.Background(), name, scope, folders, lifetime, filePath)
+		},
+	}
+	createTokenCmd.Flags().StringVar(&name, "name", "", "token name")
+	createTokenCmd.Flags().StringVar(&scope, "scope", "read", "token scope: read or write")
+	createTokenCmd.Flags().StringArrayVar(&folders, "folder", nil, "folder token is limited to, all files if not set")
+	createTokenCmd.Flags().StringVar(&lifetime, "expires", "30d", "token lifetime, e.g. 12h or 30d")
+	createTokenCmd.Flags().StringVar(&filePath, "key-out", "", "path to save private key of token")
+	tokenCmd.AddCommand(createTokenCmd, &cobra.Command{
+		Use:   "list",
+		Short: "List api tokens",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ListApiTokens(context.Background())
+		},
+	}, &cobra.Command{
+		Use:   "revoke {id}",
+		Short: "Revoke api token with given id",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RevokeApiToken(context.Background(), args[0])
+		},
+	})
+
+	var orgCmd = &cobra.Command{
+		Use:   "org",
+		Short: "Manage organizations sharing files",
+	}
+	var createOrgCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Create organization owned by user",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.CreateOrganization(context.Background(), name)
+		},
+	}
+	createOrgCmd.Flags().StringVar(&name, "name", "", "organization name")
+	var setMemberCmd = &cobra.Command{
+		Use:   "set-member",
+		Short: "Add user to organization or change role of member",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.SetMember(context.Background(), org, login, role)
+		},
+	}
+	setMemberCmd.Flags().StringVar(&org, "org", "", "organization id")
+	setMemberCmd.Flags().StringVar(&login, "login", "", "user login")
+	setMemberCmd.Flags().StringVar(&role, "role", "reader", "member role: owner, admin, writer or reader")
+	var removeMemberCmd = &cobra.Command{
+		Use:   "remove-member",
+		Short: "Remove member from organization",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RemoveMember(context.Background(), org, login)
+		},
+	}
+	removeMemberCmd.Flags().StringVar(&org, "org", "", "organization id")
+	removeMemberCmd.Flags().StringVar(&login, "login", "", "member login, leave organization if empty")
+	orgCmd.AddCommand(createOrgCmd, setMemberCmd, removeMemberCmd, &cobra.Command{
+		Use:   "list",
+		Short: "List organizations of user",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ListOrganizations(context.Background())
+		},
+	}, &cobra.Command{
+		Use:   "members {id}",
+		Short: "List members of organization with given id",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ListMembers(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "delete {id}",
+		Short: "Delete organization with given id and all its files",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.DeleteOrganization(context.Background(), args[0])
+		},
+	})
+
+	var emergencyCmd = &cobra.Command{
+		Use:   "emergency",
+		Short: "Manage emergency access of trusted contacts",
+	}
+	var inviteCmd = &cobra.Command{
+		Use:   "invite",
+		Short: "Invite trusted contact to emergency access of personal files",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.InviteEmergencyContact(context.Background(), login, wait)
+		},
+	}
+	inviteCmd.Flags().StringVar(&login, "login", "", "contact login")
+	inviteCmd.Flags().StringVar(&wait, "wait", "7d", "waiting period since request when access is granted, e.g. 48h or 7d")
+	var emergencyDownloadCmd = &cobra.Command{
+		Use:   "download {id}",
+		Short: "Download file of grantor by approved emergency access",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.DownloadEmergencyFile(context.Background(), filePath, args[0], fileId)
+		},
+	}
+	emergencyDownloadCmd.Flags().StringVar(&filePath, "path", "", "local path")
+	emergencyDownloadCmd.Flags().StringVar(&fileId, "file", "", "file id")
+	emergencyCmd.AddCommand(inviteCmd, emergencyDownloadCmd, &cobra.Command{
+		Use:   "list",
+		Short: "List emergency access given by user and to user",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ListEmergencyAccess(context.Background())
+		},
+	}, &cobra.Command{
+		Use:   "accept {id}",
+		Short: "Accept invitation as trusted contact",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.AcceptEmergencyAccess(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "confirm {id}",
+		Short: "Give keys of personal files to contact who accepted invitation",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ConfirmEmergencyAccess(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "request {id}",
+		Short: "Request access to files of grantor",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RequestEmergencyAccess(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "approve {id}",
+		Short: "Approve requested access before waiting period ends",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ApproveEmergencyAccess(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "reject {id}",
+		Short: "Reject requested or granted access",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RejectEmergencyAccess(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "revoke {id}",
+		Short: "Revoke emergency access or decline invitation",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RevokeEmergencyAccess(context.Background(), args[0])
+		},
+	}, &cobra.Command{
+		Use:   "files {id}",
+		Short: "List files of grantor available by approved emergency access",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client.ListEmergencyFiles(context.Background(), args[0])
+		},
+	})
+
+	var recoveryCmd = &cobra.Command{
+		Use:   "recovery",
+		Short: "Recover client private key from shares",
+	}
+	var setupRecoveryCmd = &cobra.Command{
+		Use:   "setup",
+		Short: "Split client private key into shares, any threshold of them restores it",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.SetupRecovery(shares, threshold, filePath)
+		},
+	}
+	setupRecoveryCmd.Flags().IntVar(&shares, "shares", 5, "number of shares")
+	setupRecoveryCmd.Flags().IntVar(&threshold, "threshold", 3, "number of shares restoring key")
+	setupRecoveryCmd.Flags().StringVar(&filePath, "out", "", "directory to save shares to, shares are printed if empty")
+	var restoreRecoveryCmd = &cobra.Command{
+		Use:   "restore [share files]",
+		Short: "Restore client private key from shares, shares are read from stdin if no files are given",
+		Run: func(cmd *cobra.Command, args []string) {
+			client.RestoreRecovery(args, config.ClientPrivateKeyPath, config.ClientPublicKeyPath, confirmed)
+		},
+	}
+	restoreRecoveryCmd.Flags().BoolVar(&confirmed, "force", false, "replace existing other client private key")
+	recoveryCmd.AddCommand(setupRecoveryCmd, restoreRecoveryCmd)
+
+	rootCmd.AddCommand(downloadCmd, verifyCmd, uploadCmd, deleteCmd, registerCmd, loginCmd, logoutCmd, sessionsCmd, tokenCmd, orgCmd, emergencyCmd, recoveryCmd, secondFactorCmd, changePasswordCmd, deleteAccountCmd, listFilesCmd, exportCmd, importCmd, versionCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
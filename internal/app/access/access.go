@@ -0,0 +1,286 @@
+// Package access contains authorization policy of files, organizations and their members.
+//
+// Personal files are accessible only by their owner, files of organization are
+// accessible by its members according to their roles.
+package access
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Role of organization member.
+type Role string
+
+// Roles of organization members from the most to the least privileged.
+const (
+	// Manages organization, its members and owners.
+	RoleOwner Role = orgstorage.OwnerRole
+
+	// Manages members except owners.
+	RoleAdmin Role = "admin"
+
+	// Uploads and deletes files.
+	RoleWriter Role = "writer"
+
+	// Lists and downloads files.
+	RoleReader Role = "reader"
+)
+
+// Roles ordered from the most to the least privileged.
+var roles = []Role{RoleOwner, RoleAdmin, RoleWriter, RoleReader}
+
+// Action on file or organization.
+type Action int
+
+const (
+	// Listing and downloading files.
+	ActionRead Action = iota
+
+	// Uploading and deleting files.
+	ActionWrite
+
+	// Adding and removing members, changing their roles.
+	ActionManageMembers
+
+	// Deleting organization with its files.
+	ActionDeleteOrganization
+)
+
+// Actions allowed for roles.
+var permissions = map[Role][]Action{
+	RoleOwner:  {ActionRead, ActionWrite, ActionManageMembers, ActionDeleteOrganization},
+	RoleAdmin:  {ActionRead, ActionWrite, ActionManageMembers},
+	RoleWriter: {ActionRead, ActionWrite},
+	RoleReader: {ActionRead},
+}
+
+var (
+	// Error in case user is not allowed to perform action.
+	ErrDenied = errors.New("access denied")
+
+	// Error in case role is unknown.
+	ErrWrongRole = errors.New("wrong role")
+
+	// Error in case organization would be left without owner.
+	ErrLastOwner = orgstorage.ErrLastOwner
+
+	// Error in case organization name is empty.
+	ErrEmptyName = errors.New("empty organization name")
+)
+
+// Parses role name.
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(roles, role) {
+		return "", fmt.Errorf("%w %q: should be one of %v", ErrWrongRole, name, roles)
+	}
+	return role, nil
+}
+
+// Whether role allows action.
+func (r Role) Allows(action Action) bool {
+	return slices.Contains(permissions[r], action)
+}
+
+// Whether role is at least as privileged as other role.
+func (r Role) AtLeast(other Role) bool {
+	return slices.Index(roles, r) <= slices.Index(roles, other)
+}
+
+// Organization with role of user in it.
+type Membership struct {
+	Organization orgstorage.Organization
+	Role         Role
+}
+
+// Authorization policy checked by every file and organization request.
+type Policy struct {
+	Orgs orgstorage.OrgStorage
+}
+
+// New policy with organizations from storage.
+func NewPolicy(orgs orgstorage.OrgStorage) *Policy {
+	return &Policy{Orgs: orgs}
+}
+
+// Returns role of user in organization, ErrDenied if user is not its member.
+func (p *Policy) Role(ctx context.Context, orgId string, login string) (Role, error) {
+	member, err := p.Orgs.GetMember(ctx, orgId, login)
+	if errors.Is(err, orgstorage.ErrMemberNotFound) {
+		return "", ErrDenied
+	}
+	if err != nil {
+		return "", err
+	}
+	return Role(member.Role), nil
+}
+
+// Checks whether user may perform action on resource of owner or organization.
+//
+// Resource without organization is personal and is accessible only by owner.
+func (p *Policy) Authorize(ctx context.Context, login string, owner string, orgId string, action Action) error {
+	if orgId == "" {
+		if owner != login {
+			return ErrDenied
+		}
+		return nil
+	}
+	role, err := p.Role(ctx, orgId, login)
+	if err != nil {
+		return err
+	}
+	if !role.Allows(action) {
+		return ErrDenied
+	}
+	return nil
+}
+
+// Checks whether user may perform action on file.
+func (p *Policy) AuthorizeFile(ctx context.Context, login string, file *pb.FileInfo, action Action) error {
+	return p.Authorize(ctx, login, file.GetLogin(), file.GetOrganization(), action)
+}
+
+// Returns organizations of user with roles.
+func (p *Policy) Memberships(ctx context.Context, login string) ([]Membership, error) {
+	members, err := p.Orgs.ListMemberships(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+	memberships := make([]Membership, 0, len(members))
+	for _, member := range members {
+		org, err := p.Orgs.GetOrganization(ctx, member.OrganizationID)
+		if errors.Is(err, orgstorage.ErrOrganizationNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, Membership{Organization: *org, Role: Role(member.Role)})
+	}
+	return memberships, nil
+}
+
+// Creates organization owned by user.
+//
+// Organization gets its own key wrapping keys of its files, the key is stored encrypted by server key.
+func (p *Policy) CreateOrganization(ctx context.Context, login string, name string) (*orgstorage.Organization, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrEmptyName
+	}
+	key, err := encryption.GenerateSymmetricFileEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := encryption.EncryptFileEncryptionKey(key, encryption.ServerPublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("cannot encrypt organization key: %w", err)
+	}
+	now := time.Now()
+	org := orgstorage.Organization{ID: uuid.NewString(), Name: strings.TrimSpace(name), Key: wrappedKey, Created: now}
+	owner := orgstorage.Member{OrganizationID: org.ID, Login: login, Role: string(RoleOwner), Added: now}
+	if err := p.Orgs.AddOrganization(ctx, org, []orgstorage.Member{owner}); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Returns members of organization, any member may list them.
+func (p *Policy) ListMembers(ctx context.Context, login string, orgId string) ([]orgstorage.Member, error) {
+	if err := p.Authorize(ctx, login, "", orgId, ActionRead); err != nil {
+		return nil, err
+	}
+	return p.Orgs.ListMembers(ctx, orgId)
+}
+
+// Adds member to organization or changes role of existing member.
+//
+// Owners and admins manage members, but only owners grant owner role or change roles of other owners.
+// Last owner can't be demoted, storage checks it atomically with the change.
+func (p *Policy) SetMember(ctx context.Context, login string, orgId string, memberLogin string, role Role) error {
+	actorRole, err := p.Role(ctx, orgId, login)
+	if err != nil {
+		return err
+	}
+	if !actorRole.Allows(ActionManageMembers) || !actorRole.AtLeast(role) {
+		return ErrDenied
+	}
+	current, err := p.Role(ctx, orgId, memberLogin)
+	if err != nil && !errors.Is(err, ErrDenied) {
+		return err
+	}
+	if current != "" && !actorRole.AtLeast(current) {
+		return ErrDenied
+	}
+	return p.Orgs.SetMember(ctx, orgstorage.Member{OrganizationID: orgId, Login: memberLogin, Role: string(role), Added: time.Now()})
+}
+
+// Removes member from organization.
+//
+// Any member may leave organization, other members are removed like their roles are changed.
+func (p *Policy) RemoveMember(ctx context.Context, login string, orgId string, memberLogin string) error {
+	actorRole, err := p.Role(ctx, orgId, login)
+	if err != nil {
+		return err
+	}
+	current, err := p.Role(ctx, orgId, memberLogin)
+	if errors.Is(err, ErrDenied) {
+		return orgstorage.ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if login != memberLogin && (!actorRole.Allows(ActionManageMembers) || !actorRole.AtLeast(current)) {
+		return ErrDenied
+	}
+	return p.Orgs.DeleteMember(ctx, orgId, memberLogin)
+}
+
+// Returns plain key of organization.
+func (p *Policy) organizationKey(ctx context.Context, orgId string) ([]byte, error) {
+	org, err := p.Orgs.GetOrganization(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
+	key, err := encryption.DecryptServerKey(org.Key, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt organization key: %w", err)
+	}
+	return key, nil
+}
+
+// Returns file key stored for file of organization or personal file with id of server key wrapping it.
+//
+// Keys of organization files are wrapped by organization key and have empty key id, keys of personal files by server key.
+func (p *Policy) WrapFileKey(ctx context.Context, orgId string, fileKey []byte) ([]byte, string, error) {
+	if orgId == "" {
+		return encryption.WrapServerKey(fileKey)
+	}
+	key, err := p.organizationKey(ctx, orgId)
+	if err != nil {
+		return nil, "", err
+	}
+	wrapped, err := encryption.EncryptMetadata(key, fileKey)
+	return wrapped, "", err
+}
+
+// Returns plain key of file wrapped by WrapFileKey.
+func (p *Policy) UnwrapFileKey(ctx context.Context, file *pb.FileInfo) ([]byte, error) {
+	if file.GetOrganization() == "" {
+		return encryption.DecryptServerKey(file.GetEncryptionKey(), file.GetKeyId())
+	}
+	key, err := p.organizationKey(ctx, file.GetOrganization())
+	if err != nil {
+		return nil, err
+	}
+	return encryption.DecryptMetadata(key, file.GetEncryptionKey())
+}
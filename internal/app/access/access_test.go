@@ -0,0 +1,98 @@
+package access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+func TestRole_Allows(t *testing.T) {
+	tests := []struct {
+		role    Role
+		allowed []Action
+		denied  []Action
+	}{
+		{role: RoleOwner, allowed: []Action{ActionRead, ActionWrite, ActionManageMembers, ActionDeleteOrganization}},
+		{role: RoleAdmin, allowed: []Action{ActionRead, ActionWrite, ActionManageMembers}, denied: []Action{ActionDeleteOrganization}},
+		{role: RoleWriter, allowed: []Action{ActionRead, ActionWrite}, denied: []Action{ActionManageMembers}},
+		{role: RoleReader, allowed: []Action{ActionRead}, denied: []Action{ActionWrite, ActionManageMembers}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			for _, action := range tt.allowed {
+				require.True(t, tt.role.Allows(action))
+			}
+			for _, action := range tt.denied {
+				require.False(t, tt.role.Allows(action))
+			}
+		})
+	}
+	role, err := ParseRole(" Writer ")
+	require.NoError(t, err)
+	require.Equal(t, RoleWriter, role)
+	_, err = ParseRole("guest")
+	require.ErrorIs(t, err, ErrWrongRole)
+}
+
+func TestPolicy_Members(t *testing.T) {
+	ctx := context.Background()
+	policy := NewPolicy(orgstorage.NewMemoryOrgStorage())
+	privateKey, publicKey, err := encryption.GenerateRsaKeys()
+	require.NoError(t, err)
+	encryption.ServerPrivateKey = func() []byte { return privateKey }
+	encryption.ServerPublicKey = func() []byte { return publicKey }
+
+	org, err := policy.CreateOrganization(ctx, "owner", "team")
+	require.NoError(t, err)
+	require.NoError(t, policy.SetMember(ctx, "owner", org.ID, "admin", RoleAdmin))
+	require.NoError(t, policy.SetMember(ctx, "owner", org.ID, "reader", RoleReader))
+
+	// Admins manage members except owners.
+	require.NoError(t, policy.SetMember(ctx, "admin", org.ID, "writer", RoleWriter))
+	require.ErrorIs(t, policy.SetMember(ctx, "admin", org.ID, "writer", RoleOwner), ErrDenied)
+	require.ErrorIs(t, policy.SetMember(ctx, "admin", org.ID, "owner", RoleReader), ErrDenied)
+	require.ErrorIs(t, policy.RemoveMember(ctx, "admin", org.ID, "owner"), ErrDenied)
+	require.ErrorIs(t, policy.SetMember(ctx, "reader", org.ID, "reader", RoleWriter), ErrDenied)
+	require.ErrorIs(t, policy.SetMember(ctx, "stranger", org.ID, "stranger", RoleReader), ErrDenied)
+
+	// Last owner can neither be demoted nor leave.
+	require.ErrorIs(t, policy.SetMember(ctx, "owner", org.ID, "owner", RoleAdmin), ErrLastOwner)
+	require.ErrorIs(t, policy.RemoveMember(ctx, "owner", org.ID, "owner"), ErrLastOwner)
+	require.NoError(t, policy.SetMember(ctx, "owner", org.ID, "admin", RoleOwner))
+	require.NoError(t, policy.RemoveMember(ctx, "owner", org.ID, "owner"))
+
+	// Members leave by themselves.
+	require.NoError(t, policy.RemoveMember(ctx, "reader", org.ID, "reader"))
+	require.ErrorIs(t, policy.RemoveMember(ctx, "admin", org.ID, "reader"), orgstorage.ErrMemberNotFound)
+	members, err := policy.ListMembers(ctx, "writer", org.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+}
+
+func TestPolicy_FileKeys(t *testing.T) {
+	ctx := context.Background()
+	policy := NewPolicy(orgstorage.NewMemoryOrgStorage())
+	privateKey, publicKey, err := encryption.GenerateRsaKeys()
+	require.NoError(t, err)
+	encryption.ServerPrivateKey = func() []byte { return privateKey }
+	encryption.ServerPublicKey = func() []byte { return publicKey }
+
+	org, err := policy.CreateOrganization(ctx, "owner", "team")
+	require.NoError(t, err)
+	fileKey := []byte("file key")
+	for _, orgId := range []string{"", org.ID} {
+		wrapped, _, err := policy.WrapFileKey(ctx, orgId, fileKey)
+		require.NoError(t, err)
+		key, err := policy.UnwrapFileKey(ctx, &pb.FileInfo{Organization: orgId, EncryptionKey: wrapped})
+		require.NoError(t, err)
+		require.Equal(t, fileKey, key)
+	}
+
+	require.NoError(t, policy.Authorize(ctx, "owner", "owner", "", ActionWrite))
+	require.ErrorIs(t, policy.Authorize(ctx, "other", "owner", "", ActionRead), ErrDenied)
+	require.ErrorIs(t, policy.Authorize(ctx, "other", "owner", org.ID, ActionRead), ErrDenied)
+}
@@ -0,0 +1,63 @@
+// Package apitokenstorage for storing personal api tokens of automation.
+package apitokenstorage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Error in case token is absent, revoked or belongs to other user.
+var ErrApiTokenNotFound = errors.New("api token not found")
+
+// Personal token accepted instead of login session, restricted to scope and folders.
+//
+// Only hash of token secret is stored, file keys are wrapped for public key of token.
+type ApiToken struct {
+	ID        string
+	Login     string
+	Name      string
+	Hash      []byte
+	Scope     string
+	Folders   []string
+	PublicKey []byte
+	Created   time.Time
+	Expires   time.Time
+	LastUsed  time.Time
+}
+
+// Storage of api tokens, revoked token is deleted.
+//
+//go:generate mockery --name ApiTokenStorage
+type ApiTokenStorage interface {
+	// Method for adding new token.
+	AddToken(ctx context.Context, token ApiToken) error
+
+	// Method for getting token by id.
+	GetToken(ctx context.Context, id string) (*ApiToken, error)
+
+	// Method for getting user tokens ordered by creation time.
+	ListTokens(ctx context.Context, login string) ([]ApiToken, error)
+
+	// Method for updating last usage time of token.
+	TouchToken(ctx context.Context, id string, lastUsed time.Time) error
+
+	// Method for deleting user token.
+	DeleteToken(ctx context.Context, login string, id string) error
+
+	// Method for deleting tokens expired before given time, returns number of deleted tokens.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Folders are stored in one column separated by newlines, folder names can't contain them.
+func joinFolders(folders []string) string {
+	return strings.Join(folders, "\n")
+}
+
+func splitFolders(folders string) []string {
+	if folders == "" {
+		return nil
+	}
+	return strings.Split(folders, "\n")
+}
@@ -0,0 +1,104 @@
+package apitokenstorage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stores api tokens in memory, all tokens are lost on restart.
+type MemoryApiTokenStorage struct {
+	mu     sync.Mutex
+	tokens map[string]ApiToken
+}
+
+// New in-memory api token storage.
+func NewMemoryApiTokenStorage() *MemoryApiTokenStorage {
+	return &MemoryApiTokenStorage{tokens: make(map[string]ApiToken)}
+}
+
+func copyToken(token ApiToken) ApiToken {
+	token.Hash = slices.Clone(token.Hash)
+	token.Folders = slices.Clone(token.Folders)
+	token.PublicKey = slices.Clone(token.PublicKey)
+	return token
+}
+
+// Add new token.
+func (s *MemoryApiTokenStorage) AddToken(_ context.Context, token ApiToken) error {
+	s.mu.Lock()
+	s.tokens[token.ID] = copyToken(token)
+	s.mu.Unlock()
+	return nil
+}
+
+// Get token by id.
+func (s *MemoryApiTokenStorage) GetToken(_ context.Context, id string) (*ApiToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return nil, ErrApiTokenNotFound
+	}
+	token = copyToken(token)
+	return &token, nil
+}
+
+// Get user tokens.
+func (s *MemoryApiTokenStorage) ListTokens(_ context.Context, login string) ([]ApiToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tokens := make([]ApiToken, 0)
+	for _, token := range s.tokens {
+		if token.Login == login {
+			tokens = append(tokens, copyToken(token))
+		}
+	}
+	slices.SortFunc(tokens, func(a, b ApiToken) int {
+		if c := a.Created.Compare(b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return tokens, nil
+}
+
+// Update token usage.
+func (s *MemoryApiTokenStorage) TouchToken(_ context.Context, id string, lastUsed time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return ErrApiTokenNotFound
+	}
+	token.LastUsed = lastUsed
+	s.tokens[id] = token
+	return nil
+}
+
+// Delete user token.
+func (s *MemoryApiTokenStorage) DeleteToken(_ context.Context, login string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[id]; !ok || token.Login != login {
+		return ErrApiTokenNotFound
+	}
+	delete(s.tokens, id)
+	return nil
+}
+
+// Delete expired tokens.
+func (s *MemoryApiTokenStorage) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := int64(0)
+	for id, token := range s.tokens {
+		if token.Expires.Before(before) {
+			delete(s.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
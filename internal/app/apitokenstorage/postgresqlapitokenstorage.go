@@ -0,0 +1,119 @@
+package apitokenstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores api tokens in postgresql.
+type PostgresqlApiTokenStorage struct {
+	DB dbtx.DB
+}
+
+// New postgresql api token storage.
+func NewPostgresqlApiTokenStorage(db dbtx.DB) *PostgresqlApiTokenStorage {
+	return &PostgresqlApiTokenStorage{DB: db}
+}
+
+const tokenColumns = "id, login, name, hash, scope, folders, public_key, created, expires, last_used"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPostgresqlToken(row scanner) (*ApiToken, error) {
+	var token ApiToken
+	var folders string
+	var lastUsed sql.NullTime
+	err := row.Scan(&token.ID, &token.Login, &token.Name, &token.Hash, &token.Scope, &folders, &token.PublicKey,
+		&token.Created, &token.Expires, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	token.Folders = splitFolders(folders)
+	token.LastUsed = lastUsed.Time
+	return &token, nil
+}
+
+// Add new token.
+func (s *PostgresqlApiTokenStorage) AddToken(ctx context.Context, token ApiToken) error {
+	var lastUsed sql.NullTime
+	if !token.LastUsed.IsZero() {
+		lastUsed = sql.NullTime{Time: token.LastUsed.UTC(), Valid: true}
+	}
+	_, err := s.DB.ExecContext(ctx, "INSERT into api_tokens ("+tokenColumns+") VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		token.ID, token.Login, token.Name, token.Hash, token.Scope, joinFolders(token.Folders), token.PublicKey,
+		token.Created.UTC(), token.Expires.UTC(), lastUsed)
+	if err != nil {
+		return fmt.Errorf("failed to add api token: %w", err)
+	}
+	return nil
+}
+
+// Get token by id.
+func (s *PostgresqlApiTokenStorage) GetToken(ctx context.Context, id string) (*ApiToken, error) {
+	token, err := scanPostgresqlToken(s.DB.QueryRowContext(ctx, "SELECT "+tokenColumns+" FROM api_tokens WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrApiTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+	return token, nil
+}
+
+// Get user tokens.
+func (s *PostgresqlApiTokenStorage) ListTokens(ctx context.Context, login string) ([]ApiToken, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM api_tokens WHERE login = $1 ORDER BY created, id", login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	defer rows.Close()
+	tokens := make([]ApiToken, 0)
+	for rows.Next() {
+		token, err := scanPostgresqlToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list api tokens: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// Update token usage.
+func (s *PostgresqlApiTokenStorage) TouchToken(ctx context.Context, id string, lastUsed time.Time) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE api_tokens SET last_used = $2 WHERE id = $1", id, lastUsed.UTC())
+	return checkAffected(res, err)
+}
+
+// Delete user token.
+func (s *PostgresqlApiTokenStorage) DeleteToken(ctx context.Context, login string, id string) error {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM api_tokens WHERE id = $1 AND login = $2", id, login)
+	return checkAffected(res, err)
+}
+
+// Delete expired tokens.
+func (s *PostgresqlApiTokenStorage) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM api_tokens WHERE expires < $1", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired api tokens: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Returns ErrApiTokenNotFound if statement changed no token.
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to update api token: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrApiTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package apitokenstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores api tokens in embedded sqlite database, times are stored as unix seconds.
+type SqliteApiTokenStorage struct {
+	DB dbtx.DB
+}
+
+// New sqlite api token storage.
+func NewSqliteApiTokenStorage(db dbtx.DB) *SqliteApiTokenStorage {
+	return &SqliteApiTokenStorage{DB: db}
+}
+
+func scanSqliteToken(row scanner) (*ApiToken, error) {
+	var token ApiToken
+	var folders string
+	var created, expires int64
+	var lastUsed sql.NullInt64
+	err := row.Scan(&token.ID, &token.Login, &token.Name, &token.Hash, &token.Scope, &folders, &token.PublicKey,
+		&created, &expires, &lastUsed)
+	if err != nil {
+		return nil, err
+	}
+	token.Folders = splitFolders(folders)
+	token.Created = time.Unix(created, 0).UTC()
+	token.Expires = time.Unix(expires, 0).UTC()
+	if lastUsed.Valid {
+		token.LastUsed = time.Unix(lastUsed.Int64, 0).UTC()
+	}
+	return &token, nil
+}
+
+// Add new token.
+func (s *SqliteApiTokenStorage) AddToken(ctx context.Context, token ApiToken) error {
+	var lastUsed sql.NullInt64
+	if !token.LastUsed.IsZero() {
+		lastUsed = sql.NullInt64{Int64: token.LastUsed.Unix(), Valid: true}
+	}
+	_, err := s.DB.ExecContext(ctx, "INSERT into api_tokens ("+tokenColumns+") VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		token.ID, token.Login, token.Name, token.Hash, token.Scope, joinFolders(token.Folders), token.PublicKey,
+		token.Created.Unix(), token.Expires.Unix(), lastUsed)
+	if err != nil {
+		return fmt.Errorf("failed to add api token: %w", err)
+	}
+	return nil
+}
+
+// Get token by id.
+func (s *SqliteApiTokenStorage) GetToken(ctx context.Context, id string) (*ApiToken, error) {
+	token, err := scanSqliteToken(s.DB.QueryRowContext(ctx, "SELECT "+tokenColumns+" FROM api_tokens WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrApiTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+	return token, nil
+}
+
+// Get user tokens.
+func (s *SqliteApiTokenStorage) ListTokens(ctx context.Context, login string) ([]ApiToken, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM api_tokens WHERE login = $1 ORDER BY created, id", login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	defer rows.Close()
+	tokens := make([]ApiToken, 0)
+	for rows.Next() {
+		token, err := scanSqliteToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list api tokens: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// Update token usage.
+func (s *SqliteApiTokenStorage) TouchToken(ctx context.Context, id string, lastUsed time.Time) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE api_tokens SET last_used = $2 WHERE id = $1", id, lastUsed.Unix())
+	return checkAffected(res, err)
+}
+
+// Delete user token.
+func (s *SqliteApiTokenStorage) DeleteToken(ctx context.Context, login string, id string) error {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM api_tokens WHERE id = $1 AND login = $2", id, login)
+	return checkAffected(res, err)
+}
+
+// Delete expired tokens.
+func (s *SqliteApiTokenStorage) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM api_tokens WHERE expires < $1", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired api tokens: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,87 @@
+package apitokenstorage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every api token storage implementation must follow.
+func testApiTokenStorage(t *testing.T, storage ApiTokenStorage) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	first := ApiToken{ID: "first", Login: "login", Name: "ci", Hash: []byte("hash"), Scope: "read",
+		Folders: []string{"ci", "deploy/keys"}, PublicKey: []byte("key"), Created: now, Expires: now.Add(time.Hour)}
+	second := ApiToken{ID: "second", Login: "login", Name: "backup", Hash: []byte("other hash"), Scope: "write",
+		PublicKey: []byte("key"), Created: now.Add(time.Second), Expires: now.Add(-time.Minute)}
+	other := ApiToken{ID: "other", Login: "other", Name: "ci", Hash: []byte("hash"), Scope: "read",
+		PublicKey: []byte("key"), Created: now, Expires: now.Add(time.Hour)}
+	for _, token := range []ApiToken{second, first, other} {
+		require.NoError(t, storage.AddToken(ctx, token))
+	}
+
+	got, err := storage.GetToken(ctx, "first")
+	require.NoError(t, err)
+	require.Equal(t, first, *got)
+	_, err = storage.GetToken(ctx, "absent")
+	require.ErrorIs(t, err, ErrApiTokenNotFound)
+
+	tokens, err := storage.ListTokens(ctx, "login")
+	require.NoError(t, err)
+	require.Equal(t, []ApiToken{first, second}, tokens)
+
+	require.NoError(t, storage.TouchToken(ctx, "first", now.Add(time.Minute)))
+	got, err = storage.GetToken(ctx, "first")
+	require.NoError(t, err)
+	require.True(t, now.Add(time.Minute).Equal(got.LastUsed))
+	require.ErrorIs(t, storage.TouchToken(ctx, "absent", now), ErrApiTokenNotFound)
+
+	require.ErrorIs(t, storage.DeleteToken(ctx, "login", "other"), ErrApiTokenNotFound)
+	require.NoError(t, storage.DeleteToken(ctx, "other", "other"))
+	_, err = storage.GetToken(ctx, "other")
+	require.ErrorIs(t, err, ErrApiTokenNotFound)
+
+	deleted, err := storage.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	tokens, err = storage.ListTokens(ctx, "login")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, "first", tokens[0].ID)
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteApiTokenStorage(t *testing.T) {
+	testApiTokenStorage(t, NewSqliteApiTokenStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlApiTokenStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	testApiTokenStorage(t, NewPostgresqlApiTokenStorage(openTestDB(t, "pgx", dsn, migrations.Postgres)))
+}
+
+func TestMemoryApiTokenStorage(t *testing.T) {
+	testApiTokenStorage(t, NewMemoryApiTokenStorage())
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"google.golang.org/grpc/metadata"
+)
+
+// Scopes of api tokens.
+const (
+	// Listing and downloading files.
+	ScopeRead = "read"
+
+	// Uploading and deleting files besides reading.
+	ScopeWrite = "write"
+)
+
+// Prefix of api tokens telling them from jwt access tokens, token is {prefix}{id}.{secret}.
+const ApiTokenPrefix = "gkp_"
+
+// Time last usage of api token is not updated for.
+const apiTokenTouchInterval = time.Minute
+
+var (
+	// Error in case api token is malformed, unknown, revoked or expired.
+	ErrInvalidApiToken = errors.New("invalid api token")
+
+	// Error in case api token settings are wrong.
+	ErrWrongApiToken = errors.New("wrong api token")
+)
+
+// Settings of new api token.
+type ApiTokenRequest struct {
+	Name    string
+	Scope   string
+	Folders []string
+
+	// Key file keys are wrapped with for token instead of user key.
+	PublicKey []byte
+	TTL       time.Duration
+}
+
+// Returns folder in form it is compared with file names, root folder is returned as empty string.
+func NormalizeFolder(folder string) string {
+	folder = strings.Trim(path.Clean("/"+folder), "/")
+	if folder == "." {
+		return ""
+	}
+	return folder
+}
+
+// Whether file with name is inside one of folders, any file is inside empty folders list.
+//
+// Folder is leading part of file name separated by slashes, e.g. file ci/db/password is inside ci and ci/db.
+func InFolders(filename string, folders []string) bool {
+	if len(folders) == 0 {
+		return true
+	}
+	name := NormalizeFolder(filename)
+	for _, folder := range folders {
+		if folder = NormalizeFolder(folder); folder == "" || strings.HasPrefix(name, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Creates api token of user, returns token string shown once and stored token.
+func (a *JwtAuthenticator) CreateApiToken(ctx context.Context, login string, req ApiTokenRequest) (string, *apitokenstorage.ApiToken, error) {
+	if req.Scope != ScopeRead && req.Scope != ScopeWrite {
+		return "", nil, fmt.Errorf("%w: scope should be %s or %s", ErrWrongApiToken, ScopeRead, ScopeWrite)
+	}
+	if req.Name == "" || req.TTL <= 0 || len(req.PublicKey) == 0 {
+		return "", nil, fmt.Errorf("%w: name, lifetime and public key should be set", ErrWrongApiToken)
+	}
+	folders := make([]string, 0, len(req.Folders))
+	for _, folder := range req.Folders {
+		if strings.Contains(folder, "\n") {
+			return "", nil, fmt.Errorf("%w: folder should not contain newlines", ErrWrongApiToken)
+		}
+		folders = append(folders, NormalizeFolder(folder))
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", nil, err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(random)
+	hash := sha256.Sum256([]byte(secret))
+	now := time.Now()
+	token := apitokenstorage.ApiToken{
+		ID:        uuid.NewString(),
+		Login:     login,
+		Name:      req.Name,
+		Hash:      hash[:],
+		Scope:     req.Scope,
+		Folders:   folders,
+		PublicKey: req.PublicKey,
+		Created:   now,
+		Expires:   now.Add(req.TTL),
+	}
+	if err := a.ApiTokens.AddToken(ctx, token); err != nil {
+		return "", nil, err
+	}
+	return ApiTokenPrefix + token.ID + "." + secret, &token, nil
+}
+
+// Returns api tokens of user.
+func (a *JwtAuthenticator) ListApiTokens(ctx context.Context, login string) ([]apitokenstorage.ApiToken, error) {
+	return a.ApiTokens.ListTokens(ctx, login)
+}
+
+// Revokes api token of user.
+func (a *JwtAuthenticator) RevokeApiToken(ctx context.Context, login string, id string) error {
+	return a.ApiTokens.DeleteToken(ctx, login, id)
+}
+
+// Checks api token, returns stored token.
+func (a *JwtAuthenticator) checkApiToken(ctx context.Context, tokenString string) (*apitokenstorage.ApiToken, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(tokenString, ApiTokenPrefix), ".")
+	if !ok {
+		return nil, ErrInvalidApiToken
+	}
+	token, err := a.ApiTokens.GetToken(ctx, id)
+	if err != nil {
+		return nil, ErrInvalidApiToken
+	}
+	hash := sha256.Sum256([]byte(secret))
+	now := time.Now()
+	if subtle.ConstantTimeCompare(hash[:], token.Hash) != 1 || !now.Before(token.Expires) {
+		return nil, ErrInvalidApiToken
+	}
+	if now.Sub(token.LastUsed) > apiTokenTouchInterval {
+		a.ApiTokens.TouchToken(ctx, id, now)
+	}
+	return token, nil
+}
+
+// Returns context with variables of api token: login, public key, token id, scope and folders.
+func (a *JwtAuthenticator) getApiTokenContext(ctx context.Context, tokenString string) (context.Context, error) {
+	token, err := a.checkApiToken(ctx, tokenString)
+	if err != nil || !MatchesCertificate(ctx, token.Login) {
+		return ctx, fmt.Errorf("unauthorized")
+	}
+	md := metadata.Pairs("login", token.Login, "public_key", string(token.PublicKey), "api_token", token.ID, "scope", token.Scope)
+	md.Append("folder", token.Folders...)
+	return metadata.NewIncomingContext(ctx, md), nil
+}
+
+// Returns scope and folders of api token of request, scope is empty if request is authorized by login session.
+func GetApiTokenScope(ctx context.Context) (string, []string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get("api_token")) == 0 {
+		return "", nil
+	}
+	return GetVarFromContext(ctx, "scope"), md.Get("folder")
+}
+
+// Revokes all api tokens of user, returns number of revoked tokens.
+func (a *JwtAuthenticator) RevokeApiTokens(ctx context.Context, login string) (int, error) {
+	tokens, err := a.ApiTokens.ListTokens(ctx, login)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, token := range tokens {
+		err := a.ApiTokens.DeleteToken(ctx, login, token.ID)
+		if err != nil && !errors.Is(err, apitokenstorage.ErrApiTokenNotFound) {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
@@ -4,9 +4,13 @@ package auth
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/tokenstorage"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -42,26 +46,69 @@ type Claims struct {
 	PublicKey string
 }
 
-const tokenExpiration = time.Hour * 3
+const (
+	// Default lifetime of jwt access token.
+	DefaultAccessTokenTTL = time.Minute * 15
+
+	// Default lifetime of refresh token.
+	DefaultRefreshTokenTTL = time.Hour * 24 * 30
+
+	// Default time session is trusted without checking storage.
+	DefaultSessionCacheTTL = time.Second * 30
+)
 
 // Class for authentication via jwt tokens.
 type JwtAuthenticator struct {
 	SecretKey string
+
+	// Lifetimes of issued access and refresh tokens.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Storage of issued refresh tokens.
+	Tokens tokenstorage.TokenStorage
+
+	// Storage of login sessions, access token is accepted only while its session exists.
+	Sessions sessionstorage.SessionStorage
+
+	// Storage of api tokens accepted instead of access tokens.
+	ApiTokens apitokenstorage.ApiTokenStorage
+
+	// Time session is trusted without checking storage,
+	// session revoked on other server instance is rejected after it.
+	SessionCacheTTL time.Duration
+
+	sessionCache *sessionCache
+	challenges   *challenges
+
+	loginThrottle   *throttle
+	addressThrottle *throttle
 }
 
 // Returns new authenticator.
-// Requires secret key for jwt and storage for generatings user ids.
+// Requires secret key for jwt, refresh tokens, sessions and api tokens are kept in memory until storages are set.
 func NewAuthenticator(secretKey string) *JwtAuthenticator {
 	return &JwtAuthenticator{
-		SecretKey: secretKey,
+		SecretKey:       secretKey,
+		AccessTokenTTL:  DefaultAccessTokenTTL,
+		RefreshTokenTTL: DefaultRefreshTokenTTL,
+		Tokens:          tokenstorage.NewMemoryTokenStorage(),
+		Sessions:        sessionstorage.NewMemorySessionStorage(),
+		ApiTokens:       apitokenstorage.NewMemoryApiTokenStorage(),
+		SessionCacheTTL: DefaultSessionCacheTTL,
+		sessionCache:    newSessionCache(),
+		challenges:      newChallenges(),
+		loginThrottle:   newThrottle(DefaultLoginThrottle),
+		addressThrottle: newThrottle(DefaultAddressThrottle),
 	}
 }
 
-// Builds jwt string from given user id.
-func (a *JwtAuthenticator) BuildJWTString(login string, publicKey []byte) (string, error) {
+// Builds jwt string from given user id, session id is stored in jti claim.
+func (a *JwtAuthenticator) BuildJWTString(login string, publicKey []byte, session string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenExpiration)),
+			ID:        session,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.AccessTokenTTL)),
 		},
 		Login:     login,
 		PublicKey: string(publicKey),
@@ -101,10 +148,13 @@ func (a *JwtAuthenticator) GetJwtClaims(tokenString string) (*Claims, error) {
 func (a *JwtAuthenticator) getAuthContext(ctx context.Context) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	authorization := md.Get("Authorization")
+	if ok && len(authorization) > 0 && strings.HasPrefix(authorization[0], ApiTokenPrefix) {
+		return a.getApiTokenContext(ctx, authorization[0])
+	}
 	if ok && len(authorization) > 0 {
 		claims, err := a.GetJwtClaims(authorization[0])
-		if err == nil {
-			md = metadata.Pairs("login", claims.Login, "public_key", claims.PublicKey)
+		if err == nil && MatchesCertificate(ctx, claims.Login) && a.checkSession(ctx, claims.ID, claims.Login) == nil {
+			md = metadata.Pairs("login", claims.Login, "public_key", claims.PublicKey, "session", claims.ID)
 			return metadata.NewIncomingContext(ctx, md), nil
 		}
 	}
@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestJwtAuthenticator_testJWTToken(t *testing.T) {
@@ -13,7 +17,7 @@ func TestJwtAuthenticator_testJWTToken(t *testing.T) {
 	publicKey := "qwerty"
 	secretKey := "asdf"
 	authenticator := NewAuthenticator(secretKey)
-	validTokenString, err := authenticator.BuildJWTString(login, []byte(publicKey))
+	validTokenString, err := authenticator.BuildJWTString(login, []byte(publicKey), "session")
 	require.NoError(t, err)
 	wrongSigningMethodTokenString, _ := jwt.NewWithClaims(jwt.SigningMethodRS256,
 		jwt.RegisteredClaims{}).SignedString([]byte(secretKey))
@@ -38,3 +42,132 @@ func TestJwtAuthenticator_testJWTToken(t *testing.T) {
 		})
 	}
 }
+
+func TestJwtAuthenticator_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	authenticator := NewAuthenticator("asdf")
+	tokens, err := authenticator.IssueTokens(ctx, "kulebaka", []byte("qwerty"), "laptop")
+	require.NoError(t, err)
+	claims, err := authenticator.GetJwtClaims(tokens.Access)
+	require.NoError(t, err)
+	assert.Equal(t, "kulebaka", claims.Login)
+
+	_, err = authenticator.RefreshTokens(ctx, "unknown")
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	rotated, err := authenticator.RefreshTokens(ctx, tokens.Refresh)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.Refresh, rotated.Refresh)
+	claims, err = authenticator.GetJwtClaims(rotated.Access)
+	require.NoError(t, err)
+	assert.Equal(t, "qwerty", claims.PublicKey)
+
+	// Reuse of exchanged token revokes whole family including rotated token.
+	_, err = authenticator.RefreshTokens(ctx, tokens.Refresh)
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = authenticator.RefreshTokens(ctx, rotated.Refresh)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestJwtAuthenticator_ExpiredRefreshToken(t *testing.T) {
+	authenticator := NewAuthenticator("asdf")
+	authenticator.RefreshTokenTTL = -time.Second
+	tokens, err := authenticator.IssueTokens(context.Background(), "kulebaka", nil, "laptop")
+	require.NoError(t, err)
+	_, err = authenticator.RefreshTokens(context.Background(), tokens.Refresh)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestJwtAuthenticator_Sessions(t *testing.T) {
+	ctx := context.Background()
+	authenticator := NewAuthenticator("asdf")
+	authContext := func(access string) error {
+		_, err := authenticator.getAuthContext(metadata.NewIncomingContext(ctx, metadata.Pairs("Authorization", access)))
+		return err
+	}
+	laptop, err := authenticator.IssueTokens(ctx, "kulebaka", nil, "laptop")
+	require.NoError(t, err)
+	phone, err := authenticator.IssueTokens(ctx, "kulebaka", nil, "phone")
+	require.NoError(t, err)
+	require.NoError(t, authContext(laptop.Access))
+	require.NoError(t, authContext(phone.Access))
+
+	sessions, err := authenticator.ListSessions(ctx, "kulebaka")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	claims, err := authenticator.GetJwtClaims(laptop.Access)
+	require.NoError(t, err)
+
+	// Token without session is rejected.
+	noSession, err := authenticator.BuildJWTString("kulebaka", nil, "")
+	require.NoError(t, err)
+	require.Error(t, authContext(noSession))
+
+	// Other user can't revoke session.
+	require.ErrorIs(t, authenticator.RevokeSession(ctx, "other", claims.ID), sessionstorage.ErrSessionNotFound)
+
+	revoked, err := authenticator.RevokeOtherSessions(ctx, "kulebaka", claims.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	require.Error(t, authContext(phone.Access))
+	_, err = authenticator.RefreshTokens(ctx, phone.Refresh)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+	require.NoError(t, authContext(laptop.Access))
+
+	require.NoError(t, authenticator.RevokeSession(ctx, "kulebaka", claims.ID))
+	require.Error(t, authContext(laptop.Access))
+	_, err = authenticator.RefreshTokens(ctx, laptop.Refresh)
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := newThrottle(ThrottleSettings{FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second,
+		LockoutAttempts: 6, LockoutDuration: time.Minute, Window: time.Hour})
+	now := time.Now()
+	delays := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, time.Minute}
+	for i, delay := range delays {
+		locked := throttle.fail("key", now)
+		assert.Equal(t, i == len(delays)-1, locked)
+		assert.Equal(t, delay, throttle.wait("key", now), "failure %d", i+1)
+	}
+	assert.Equal(t, time.Duration(0), throttle.wait("other", now))
+	assert.Equal(t, time.Duration(0), throttle.wait("key", now.Add(2*time.Hour)))
+
+	throttle.fail("key", now)
+	throttle.reset("key")
+	assert.Equal(t, time.Duration(0), throttle.wait("key", now))
+}
+
+func TestThrottle_InFlight(t *testing.T) {
+	throttle := newThrottle(ThrottleSettings{FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second,
+		LockoutAttempts: 6, LockoutDuration: time.Minute, Window: time.Hour})
+	now := time.Now()
+	// Parallel attempts are allowed only while their failures would not delay next attempt.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, time.Duration(0), throttle.reserve("key", now), "attempt %d", i+1)
+	}
+	assert.Equal(t, time.Second, throttle.reserve("key", now))
+	for i := 0; i < 3; i++ {
+		throttle.fail("key", now)
+		throttle.release("key")
+	}
+	assert.Equal(t, time.Second, throttle.reserve("key", now))
+
+	// Successful attempt releases reservation without counting failure.
+	assert.Equal(t, time.Duration(0), throttle.reserve("other", now))
+	throttle.reset("other")
+	throttle.release("other")
+	assert.Equal(t, time.Duration(0), throttle.wait("other", now))
+	assert.Empty(t, throttle.entries["other"])
+}
+
+func TestInFolders(t *testing.T) {
+	assert.True(t, InFolders("anything", nil))
+	assert.True(t, InFolders("/ci/db/password", []string{"ci"}))
+	assert.True(t, InFolders("ci/db/password", []string{"/ci/db/"}))
+	assert.True(t, InFolders("personal", []string{"/"}))
+	assert.False(t, InFolders("ci", []string{"ci"}))
+	assert.False(t, InFolders("cid/password", []string{"ci"}))
+	assert.False(t, InFolders("ci/../personal/password", []string{"ci"}))
+	assert.False(t, InFolders("personal/password", []string{"ci", "deploy"}))
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/policy"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Returns login of verified client certificate of connection, login is taken from common name.
+// Returns empty string if client has not sent certificate.
+func CertificateLogin(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return policy.NormalizeLogin(info.State.VerifiedChains[0][0].Subject.CommonName)
+}
+
+// Whether client certificate of connection, if any, is issued for login.
+func MatchesCertificate(ctx context.Context, login string) bool {
+	certLogin := CertificateLogin(ctx)
+	return certLogin == "" || certLogin == policy.NormalizeLogin(login)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Time given to enter second factor code after password is checked.
+	ChallengeTTL = 5 * time.Minute
+
+	// Number of wrong codes after which challenge is dropped and password is asked again.
+	MaxChallengeAttempts = 5
+)
+
+// Error in case challenge is unknown, expired, completed or out of attempts.
+var ErrChallengeNotFound = errors.New("second factor challenge not found")
+
+// Login waiting for second factor code.
+type Challenge struct {
+	Login     string
+	PublicKey []byte
+	Device    string
+
+	expires  time.Time
+	attempts int
+}
+
+// Challenges of logins waiting for second factor, kept in memory of instance checking password.
+type challenges struct {
+	mu      sync.Mutex
+	pending map[string]*Challenge
+}
+
+func newChallenges() *challenges {
+	return &challenges{pending: make(map[string]*Challenge)}
+}
+
+// Creates challenge for login whose password is checked, returns challenge id.
+func (a *JwtAuthenticator) NewChallenge(login string, publicKey []byte, device string) string {
+	id := uuid.NewString()
+	now := time.Now()
+	a.challenges.mu.Lock()
+	defer a.challenges.mu.Unlock()
+	for id, challenge := range a.challenges.pending {
+		if !now.Before(challenge.expires) {
+			delete(a.challenges.pending, id)
+		}
+	}
+	a.challenges.pending[id] = &Challenge{Login: login, PublicKey: publicKey, Device: device, expires: now.Add(ChallengeTTL)}
+	return id
+}
+
+// Returns pending challenge.
+func (a *JwtAuthenticator) GetChallenge(id string) (*Challenge, error) {
+	a.challenges.mu.Lock()
+	defer a.challenges.mu.Unlock()
+	challenge, ok := a.challenges.pending[id]
+	if !ok || !time.Now().Before(challenge.expires) {
+		delete(a.challenges.pending, id)
+		return nil, ErrChallengeNotFound
+	}
+	copied := *challenge
+	return &copied, nil
+}
+
+// Counts wrong code, challenge is dropped after MaxChallengeAttempts.
+func (a *JwtAuthenticator) FailChallenge(id string) {
+	a.challenges.mu.Lock()
+	defer a.challenges.mu.Unlock()
+	if challenge, ok := a.challenges.pending[id]; ok {
+		challenge.attempts++
+		if challenge.attempts >= MaxChallengeAttempts {
+			delete(a.challenges.pending, id)
+		}
+	}
+}
+
+// Completes challenge, returns ErrChallengeNotFound if it is already completed.
+func (a *JwtAuthenticator) CompleteChallenge(id string) error {
+	a.challenges.mu.Lock()
+	defer a.challenges.mu.Unlock()
+	if _, ok := a.challenges.pending[id]; !ok {
+		return ErrChallengeNotFound
+	}
+	delete(a.challenges.pending, id)
+	return nil
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/tokenstorage"
+)
+
+var (
+	// Error in case refresh token is unknown or expired.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// Error in case refresh token was already exchanged, whole token family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// Pair of access and refresh tokens.
+type Tokens struct {
+	Access        string
+	Refresh       string
+	AccessExpires time.Time
+}
+
+// Hash under which refresh token is stored.
+func hashRefreshToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// Issues tokens for new login from device, refresh token family is new session.
+func (a *JwtAuthenticator) IssueTokens(ctx context.Context, login string, publicKey []byte, device string) (*Tokens, error) {
+	session := uuid.NewString()
+	if err := a.startSession(ctx, session, login, device); err != nil {
+		return nil, err
+	}
+	return a.issueTokens(ctx, session, login, publicKey)
+}
+
+// Exchanges refresh token for new pair of tokens.
+//
+// Each refresh token can be exchanged once, reuse of exchanged token means it
+// was stolen, so its session is revoked.
+func (a *JwtAuthenticator) RefreshTokens(ctx context.Context, refresh string) (*Tokens, error) {
+	now := time.Now()
+	token, err := a.Tokens.UseToken(ctx, hashRefreshToken(refresh), now)
+	if errors.Is(err, tokenstorage.ErrTokenReused) {
+		err := a.RevokeSession(ctx, token.Login, token.Family)
+		if err != nil && !errors.Is(err, sessionstorage.ErrSessionNotFound) {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if errors.Is(err, tokenstorage.ErrTokenNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = a.Sessions.TouchSession(ctx, token.Family, peerIP(ctx), now)
+	if errors.Is(err, sessionstorage.ErrSessionNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.issueTokens(ctx, token.Family, token.Login, token.PublicKey)
+}
+
+func (a *JwtAuthenticator) issueTokens(ctx context.Context, family, login string, publicKey []byte) (*Tokens, error) {
+	now := time.Now()
+	access, err := a.BuildJWTString(login, publicKey, family)
+	if err != nil {
+		return nil, err
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(random)
+	err = a.Tokens.AddToken(ctx, tokenstorage.RefreshToken{
+		Hash:      hashRefreshToken(refresh),
+		Family:    family,
+		Login:     login,
+		PublicKey: publicKey,
+		Created:   now,
+		Expires:   now.Add(a.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{Access: access, Refresh: refresh, AccessExpires: now.Add(a.AccessTokenTTL)}, nil
+}
+
+// Periodically deletes expired refresh and api tokens and sessions until context is done.
+//
+// Session inactive for refresh token lifetime has no valid refresh tokens left.
+func (a *JwtAuthenticator) RunTokenCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			a.Tokens.DeleteExpired(ctx, now)
+			a.ApiTokens.DeleteExpired(ctx, now)
+			a.Sessions.DeleteInactive(ctx, now.Add(-a.RefreshTokenTTL))
+			a.sessionCache.prune(now)
+		}
+	}
+}
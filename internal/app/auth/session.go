@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+	"google.golang.org/grpc/peer"
+)
+
+// Error in case access token has no session or its session is revoked.
+var ErrSessionRevoked = errors.New("session revoked")
+
+// Sessions recently found in storage with time until which they are trusted.
+type sessionCache struct {
+	mu    sync.Mutex
+	valid map[string]time.Time
+}
+
+func newSessionCache() *sessionCache {
+	return &sessionCache{valid: make(map[string]time.Time)}
+}
+
+func (c *sessionCache) contains(id string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.valid[id]
+	if ok && !now.Before(until) {
+		delete(c.valid, id)
+		return false
+	}
+	return ok
+}
+
+func (c *sessionCache) add(id string, until time.Time) {
+	c.mu.Lock()
+	c.valid[id] = until
+	c.mu.Unlock()
+}
+
+func (c *sessionCache) remove(id string) {
+	c.mu.Lock()
+	delete(c.valid, id)
+	c.mu.Unlock()
+}
+
+// Removes entries not trusted anymore.
+func (c *sessionCache) prune(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, until := range c.valid {
+		if !now.Before(until) {
+			delete(c.valid, id)
+		}
+	}
+}
+
+// Address of client sending request, empty if unknown.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// Checks that session of access token is not revoked.
+//
+// Session found in storage is trusted for SessionCacheTTL, its activity is updated on every check.
+func (a *JwtAuthenticator) checkSession(ctx context.Context, id string, login string) error {
+	if id == "" {
+		return ErrSessionRevoked
+	}
+	now := time.Now()
+	if a.sessionCache.contains(id, now) {
+		return nil
+	}
+	session, err := a.Sessions.GetSession(ctx, id)
+	if errors.Is(err, sessionstorage.ErrSessionNotFound) || (err == nil && session.Login != login) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+	a.Sessions.TouchSession(ctx, id, peerIP(ctx), now)
+	a.sessionCache.add(id, now.Add(a.SessionCacheTTL))
+	return nil
+}
+
+// Starts new session for login from device.
+func (a *JwtAuthenticator) startSession(ctx context.Context, id string, login string, device string) error {
+	now := time.Now()
+	return a.Sessions.AddSession(ctx, sessionstorage.Session{
+		ID:       id,
+		Login:    login,
+		Device:   device,
+		IP:       peerIP(ctx),
+		Created:  now,
+		LastSeen: now,
+	})
+}
+
+// Returns user sessions.
+func (a *JwtAuthenticator) ListSessions(ctx context.Context, login string) ([]sessionstorage.Session, error) {
+	return a.Sessions.ListSessions(ctx, login)
+}
+
+// Revokes user session and its refresh tokens, access tokens of session are rejected at once.
+func (a *JwtAuthenticator) RevokeSession(ctx context.Context, login string, id string) error {
+	if err := a.Sessions.DeleteSession(ctx, login, id); err != nil {
+		return err
+	}
+	a.sessionCache.remove(id)
+	return a.Tokens.DeleteFamily(ctx, id)
+}
+
+// Revokes all user sessions except given one, returns number of revoked sessions.
+func (a *JwtAuthenticator) RevokeOtherSessions(ctx context.Context, login string, keep string) (int, error) {
+	sessions, err := a.Sessions.ListSessions(ctx, login)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == keep {
+			continue
+		}
+		err := a.RevokeSession(ctx, login, session.ID)
+		if err != nil && !errors.Is(err, sessionstorage.ErrSessionNotFound) {
+			return revoked, err
+		}
+		if err == nil {
+			revoked++
+		}
+	}
+	return revoked, nil
+}
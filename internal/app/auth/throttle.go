@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Error in case login is throttled after failed attempts.
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Settings of failed attempts tracking for one kind of key.
+type ThrottleSettings struct {
+	// Failures allowed without delay.
+	FreeAttempts int
+
+	// Delay after first failure exceeding free attempts, doubled on every next failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	// Failures after which key is locked for LockoutDuration.
+	LockoutAttempts int
+	LockoutDuration time.Duration
+
+	// Failures are forgotten after this time without new failures.
+	Window time.Duration
+}
+
+var (
+	// Default throttling of attempts to one login.
+	DefaultLoginThrottle = ThrottleSettings{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute,
+		LockoutAttempts: 10, LockoutDuration: 15 * time.Minute, Window: time.Hour}
+
+	// Default throttling of attempts from one address, higher as many users may share address.
+	DefaultAddressThrottle = ThrottleSettings{FreeAttempts: 20, BaseDelay: time.Second, MaxDelay: time.Minute,
+		LockoutAttempts: 100, LockoutDuration: 15 * time.Minute, Window: time.Hour}
+)
+
+// Entries are pruned on failure once there are that many of them.
+const throttlePruneSize = 1024
+
+type attempts struct {
+	failures    int
+	lastFailure time.Time
+
+	// Attempts allowed but not finished yet, they may fail too.
+	inFlight int
+}
+
+// Tracks failed attempts by key in memory of instance.
+type throttle struct {
+	settings ThrottleSettings
+	mu       sync.Mutex
+	entries  map[string]*attempts
+}
+
+func newThrottle(settings ThrottleSettings) *throttle {
+	return &throttle{settings: settings, entries: make(map[string]*attempts)}
+}
+
+// Time after last failure during which next attempt is rejected.
+func (t *throttle) delay(failures int) time.Duration {
+	s := t.settings
+	switch {
+	case failures >= s.LockoutAttempts:
+		return s.LockoutDuration
+	case failures <= s.FreeAttempts:
+		return 0
+	}
+	delay := s.BaseDelay
+	for i := s.FreeAttempts + 1; i < failures && delay < s.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, s.MaxDelay)
+}
+
+func (t *throttle) expired(entry *attempts, now time.Time) bool {
+	return now.Sub(entry.lastFailure) > max(t.settings.Window, t.delay(entry.failures))
+}
+
+// Returns entry of key or nil, failures of expired entry are forgotten.
+func (t *throttle) current(key string, now time.Time) *attempts {
+	entry, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	if t.expired(entry, now) {
+		if entry.inFlight == 0 {
+			delete(t.entries, key)
+			return nil
+		}
+		entry.failures = 0
+	}
+	return entry
+}
+
+// Returns time to wait before next attempt, zero if attempt is allowed.
+func (t *throttle) wait(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.current(key, now)
+	if entry == nil {
+		return 0
+	}
+	return max(entry.lastFailure.Add(t.delay(entry.failures)).Sub(now), 0)
+}
+
+// Reserves attempt unless it has to wait, returns time to wait otherwise.
+//
+// Attempts in flight are counted as failures so that parallel attempts can't pass
+// before failures of previous ones are counted. Reserved attempt must be released.
+func (t *throttle) reserve(key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.current(key, now)
+	if entry == nil {
+		entry = &attempts{}
+		t.entries[key] = entry
+	}
+	if wait := entry.lastFailure.Add(t.delay(entry.failures)).Sub(now); wait > 0 {
+		return wait
+	}
+	if delay := t.delay(entry.failures + entry.inFlight); entry.inFlight > 0 && delay > 0 {
+		return delay
+	}
+	entry.inFlight++
+	return 0
+}
+
+// Releases reserved attempt, its failure should be counted before.
+func (t *throttle) release(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	if !ok || entry.inFlight == 0 {
+		return
+	}
+	entry.inFlight--
+	if entry.inFlight == 0 && entry.failures == 0 {
+		delete(t.entries, key)
+	}
+}
+
+// Counts failure, returns whether key has just been locked.
+func (t *throttle) fail(key string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.entries) >= throttlePruneSize {
+		for key, entry := range t.entries {
+			if entry.inFlight == 0 && t.expired(entry, now) {
+				delete(t.entries, key)
+			}
+		}
+	}
+	entry := t.current(key, now)
+	if entry == nil {
+		entry = &attempts{}
+		t.entries[key] = entry
+	}
+	entry.failures++
+	entry.lastFailure = now
+	return entry.failures == t.settings.LockoutAttempts
+}
+
+func (t *throttle) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[key]
+	if !ok {
+		return
+	}
+	if entry.inFlight == 0 {
+		delete(t.entries, key)
+		return
+	}
+	entry.failures = 0
+}
+
+// Hash compared for absent users so that response time does not reveal whether login exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Spends the same time as checking password of existing user.
+func DummyComparePassword(password string) {
+	ComparePasswordHash(dummyPasswordHash(), password)
+}
+
+// Reserves login attempt for login and client address before password is checked.
+// Returns ThrottledError if any of them has too many recent or unfinished attempts.
+//
+// Reserved attempt must be finished by FinishLoginAttempt after its failure or success is counted.
+func (a *JwtAuthenticator) ReserveLoginAttempt(ctx context.Context, login string) error {
+	now := time.Now()
+	if wait := a.loginThrottle.reserve(login, now); wait > 0 {
+		return &ThrottledError{RetryAfter: wait}
+	}
+	if wait := a.addressThrottle.reserve(peerIP(ctx), now); wait > 0 {
+		a.loginThrottle.release(login)
+		return &ThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Releases login attempt reserved by ReserveLoginAttempt.
+func (a *JwtAuthenticator) FinishLoginAttempt(ctx context.Context, login string) {
+	a.loginThrottle.release(login)
+	a.addressThrottle.release(peerIP(ctx))
+}
+
+// Counts failed login attempt, lockouts are written to audit log.
+func (a *JwtAuthenticator) LoginFailed(ctx context.Context, login string) {
+	now := time.Now()
+	ip := peerIP(ctx)
+	if a.loginThrottle.fail(login, now) {
+		logger.Audit("login_locked", zap.String("login", login), zap.String("ip", ip),
+			zap.Duration("duration", a.loginThrottle.settings.LockoutDuration))
+	}
+	if a.addressThrottle.fail(ip, now) {
+		logger.Audit("address_locked", zap.String("ip", ip), zap.String("login", login),
+			zap.Duration("duration", a.addressThrottle.settings.LockoutDuration))
+	}
+}
+
+// Forgets failed attempts of login after successful login.
+//
+// Failures of address are kept so that attacker can't reset them by logging in to own account.
+func (a *JwtAuthenticator) LoginSucceeded(login string) {
+	a.loginThrottle.reset(login)
+}
+
+// Replaces throttling settings.
+func (a *JwtAuthenticator) SetThrottleSettings(login ThrottleSettings, address ThrottleSettings) {
+	a.loginThrottle = newThrottle(login)
+	a.addressThrottle = newThrottle(address)
+}
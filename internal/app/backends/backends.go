@@ -0,0 +1,155 @@
+// Package backends contains registry of file blob and metadata storage backends.
+//
+// Backends register themselves by name in init function of their package,
+// so that importing package is enough to make backend selectable from config:
+//
+//	func init() {
+//		backends.RegisterBlob("my", func(section json.RawMessage) (filestorage.StreamingFileStorage, error) {
+//			...
+//		})
+//	}
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/tokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+)
+
+// Error in case backend with given name is not registered.
+var ErrUnknownBackend = errors.New("unknown backend")
+
+// Creates blob storage from backend config section, section is nil if absent.
+type BlobFactory func(section json.RawMessage) (filestorage.StreamingFileStorage, error)
+
+// Creates metadata storages from backend config section, section is nil if absent.
+type MetadataFactory func(section json.RawMessage) (*Metadata, error)
+
+// Storages of file metainfo, users, sessions, refresh and api tokens, organizations, emergency access
+// and replication status of mirror blob backend sharing one database.
+type Metadata struct {
+	Files       metadatastorage.MetadataStorage
+	Users       userstorage.UserStorage
+	Tokens      tokenstorage.TokenStorage
+	Sessions    sessionstorage.SessionStorage
+	ApiTokens   apitokenstorage.ApiTokenStorage
+	Orgs        orgstorage.OrgStorage
+	Emergency   emergencystorage.EmergencyStorage
+	Replication filestorage.ReplicationStatusStore
+
+	// Schema migrator, nil if backend has no schema.
+	Migrator *migrations.Migrator
+
+	// Releases database connection.
+	Close func() error
+
+	// Runs read on storages of consistent read-only snapshot, nil if backend has no snapshots.
+	// Storages passed to read are valid only until it returns.
+	Snapshot func(ctx context.Context, read func(snapshot *Metadata) error) error
+}
+
+var (
+	mu                sync.RWMutex
+	blobFactories     = make(map[string]BlobFactory)
+	metadataFactories = make(map[string]MetadataFactory)
+)
+
+// Registers blob backend, panics if name is already registered.
+func RegisterBlob(name string, factory BlobFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := blobFactories[name]; ok {
+		panic(fmt.Sprintf("blob backend %q registered twice", name))
+	}
+	blobFactories[name] = factory
+}
+
+// Registers metadata backend, panics if name is already registered.
+func RegisterMetadata(name string, factory MetadataFactory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := metadataFactories[name]; ok {
+		panic(fmt.Sprintf("metadata backend %q registered twice", name))
+	}
+	metadataFactories[name] = factory
+}
+
+// Creates blob storage of registered backend.
+func OpenBlob(name string, section json.RawMessage) (filestorage.StreamingFileStorage, error) {
+	mu.RLock()
+	factory, ok := blobFactories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q for blobs, available: %v", ErrUnknownBackend, name, BlobBackends())
+	}
+	storage, err := factory(section)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s blob backend: %w", name, err)
+	}
+	return storage, nil
+}
+
+// Creates metadata storages of registered backend.
+func OpenMetadata(name string, section json.RawMessage) (*Metadata, error) {
+	mu.RLock()
+	factory, ok := metadataFactories[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q for metadata, available: %v", ErrUnknownBackend, name, MetadataBackends())
+	}
+	metadata, err := factory(section)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s metadata backend: %w", name, err)
+	}
+	if metadata.Close == nil {
+		metadata.Close = func() error { return nil }
+	}
+	return metadata, nil
+}
+
+// Names of registered blob backends.
+func BlobBackends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sortedKeys(blobFactories)
+}
+
+// Names of registered metadata backends.
+func MetadataBackends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return sortedKeys(metadataFactories)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Decodes backend config section over default settings.
+func DecodeSection(section json.RawMessage, settings any) error {
+	if len(section) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(section, settings); err != nil {
+		return fmt.Errorf("wrong backend config: %w", err)
+	}
+	return nil
+}
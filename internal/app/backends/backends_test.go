@@ -0,0 +1,116 @@
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+)
+
+func TestRegisterBlob(t *testing.T) {
+	var got FsConfig
+	RegisterBlob("test", func(section json.RawMessage) (filestorage.StreamingFileStorage, error) {
+		if err := DecodeSection(section, &got); err != nil {
+			return nil, err
+		}
+		return filestorage.NewMemoryFileStorage(), nil
+	})
+	require.Contains(t, BlobBackends(), "test")
+	require.Panics(t, func() { RegisterBlob("test", nil) })
+
+	_, err := OpenBlob("test", json.RawMessage(`{"path": "/tmp/test"}`))
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/test", got.Path)
+	_, err = OpenBlob("test", json.RawMessage(`{"path": 1}`))
+	require.Error(t, err)
+
+	_, err = OpenBlob("unknown", nil)
+	require.ErrorIs(t, err, ErrUnknownBackend)
+	_, err = OpenMetadata("unknown", nil)
+	require.ErrorIs(t, err, ErrUnknownBackend)
+}
+
+func TestBuiltinBackends(t *testing.T) {
+	require.Subset(t, BlobBackends(), []string{"s3", "fs", "memory"})
+	require.Subset(t, MetadataBackends(), []string{"postgres", "sqlite", "memory"})
+
+	root := t.TempDir()
+	fileStorage, err := OpenBlob("fs", json.RawMessage(`{"path": "`+filepath.Join(root, "blobs")+`"}`))
+	require.NoError(t, err)
+	require.IsType(t, &filestorage.FsFileStorage{}, fileStorage)
+	require.DirExists(t, filepath.Join(root, "blobs"))
+
+	metadata, err := OpenMetadata("memory", nil)
+	require.NoError(t, err)
+	require.Nil(t, metadata.Migrator)
+	require.NotNil(t, metadata.ApiTokens, "memory backend should have all storages of database ones")
+	require.NotNil(t, metadata.Orgs)
+	require.NotNil(t, metadata.Emergency)
+	require.NoError(t, metadata.Close())
+
+	metadata, err = OpenMetadata("sqlite", json.RawMessage(`{"dsn": "sqlite://`+filepath.Join(root, "keeper.db")+`"}`))
+	require.NoError(t, err)
+	defer metadata.Close()
+	_, err = metadata.Migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, metadata.Files.Ping())
+	_, err = metadata.Replication.ListMissing(context.Background())
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(root, "keeper.db"))
+}
+
+func TestMirrorBackend(t *testing.T) {
+	root := t.TempDir()
+	section := `{"primary": {"backend": "memory"}, "secondary": {"backend": "fs", "config": {"path": "` + root + `"}},
+		"repair_interval": "10m"}`
+	fileStorage, err := OpenBlob("mirror", json.RawMessage(section))
+	require.NoError(t, err)
+	require.IsType(t, &filestorage.MirrorFileStorage{}, fileStorage)
+	require.Equal(t, 10*time.Minute, fileStorage.(*filestorage.MirrorFileStorage).RepairInterval)
+
+	_, err = OpenBlob("mirror", json.RawMessage(`{"primary": {"backend": "memory"}, "secondary": {"backend": "mirror"}}`))
+	require.Error(t, err)
+}
+
+func TestDatabaseSnapshot(t *testing.T) {
+	ctx := context.Background()
+	section := json.RawMessage(`{"dsn": "sqlite://` + filepath.Join(t.TempDir(), "keeper.db") + `"}`)
+	metadata, err := OpenMetadata("sqlite", section)
+	require.NoError(t, err)
+	defer metadata.Close()
+	_, err = metadata.Migrator.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, metadata.Users.AddUser(ctx, userstorage.User{Login: "before", PasswordHash: []byte("hash")}))
+
+	// Sqlite holds the only connection in snapshot, so concurrent write comes from other process.
+	other, err := OpenMetadata("sqlite", section)
+	require.NoError(t, err)
+	defer other.Close()
+	err = metadata.Snapshot(ctx, func(snapshot *Metadata) error {
+		users, err := snapshot.Users.ListUsers(ctx)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		require.NoError(t, other.Users.AddUser(ctx, userstorage.User{Login: "after", PasswordHash: []byte("hash")}))
+		users, err = snapshot.Users.ListUsers(ctx)
+		require.NoError(t, err)
+		require.Len(t, users, 1, "snapshot should not see writes made after it started")
+		err = snapshot.Orgs.AddOrganization(ctx, orgstorage.Organization{ID: "team", Name: "team"}, nil)
+		require.ErrorIs(t, err, dbtx.ErrNestedTx)
+		return nil
+	})
+	require.NoError(t, err)
+	users, err := metadata.Users.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+
+	memory, err := OpenMetadata("memory", nil)
+	require.NoError(t, err)
+	require.Nil(t, memory.Snapshot)
+}
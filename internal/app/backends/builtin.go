@@ -0,0 +1,210 @@
+package backends
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/replicationstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/tokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	_ "modernc.org/sqlite"
+)
+
+// Scheme of database dsn for embedded sqlite database.
+const sqliteScheme = "sqlite://"
+
+// Whether database dsn points to embedded sqlite database.
+func IsSqliteDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, sqliteScheme) || strings.HasPrefix(dsn, "file:")
+}
+
+// Settings of local directory blob backend.
+type FsConfig struct {
+	Path string `json:"path"`
+}
+
+// Settings of one side of mirror blob backend, config section of backend is used if config is absent.
+type MirrorSideConfig struct {
+	Backend string          `json:"backend"`
+	Config  json.RawMessage `json:"config"`
+}
+
+// Settings of mirror blob backend.
+type MirrorConfig struct {
+	Primary   MirrorSideConfig `json:"primary"`
+	Secondary MirrorSideConfig `json:"secondary"`
+
+	// Interval of background repair run by server, disabled if empty.
+	RepairInterval string `json:"repair_interval"`
+}
+
+// Settings of database metadata backends.
+type DatabaseConfig struct {
+	DSN string `json:"dsn"`
+}
+
+func init() {
+	RegisterBlob("s3", openS3)
+	RegisterBlob("fs", openFs)
+	RegisterBlob("mirror", openMirror)
+	RegisterBlob("memory", func(json.RawMessage) (filestorage.StreamingFileStorage, error) {
+		return filestorage.NewMemoryFileStorage(), nil
+	})
+	RegisterMetadata("postgres", openPostgres)
+	RegisterMetadata("sqlite", openSqlite)
+	RegisterMetadata("memory", func(json.RawMessage) (*Metadata, error) {
+		return &Metadata{Files: metadatastorage.NewMemoryStorage(), Users: userstorage.NewMemoryUserStorage(),
+			Tokens: tokenstorage.NewMemoryTokenStorage(), Sessions: sessionstorage.NewMemorySessionStorage(),
+			ApiTokens: apitokenstorage.NewMemoryApiTokenStorage(), Orgs: orgstorage.NewMemoryOrgStorage(),
+			Emergency: emergencystorage.NewMemoryEmergencyStorage(), Replication: filestorage.NewMemoryReplicationStatusStore()}, nil
+	})
+}
+
+func openS3(section json.RawMessage) (filestorage.StreamingFileStorage, error) {
+	settings := filestorage.DefaultS3Config()
+	if err := DecodeSection(section, &settings); err != nil {
+		return nil, err
+	}
+	return filestorage.NewS3FileStorage(settings)
+}
+
+func openFs(section json.RawMessage) (filestorage.StreamingFileStorage, error) {
+	settings := FsConfig{Path: config.GetConfig().StoragePath}
+	if err := DecodeSection(section, &settings); err != nil {
+		return nil, err
+	}
+	return filestorage.NewFsFileStorage(settings.Path)
+}
+
+func openMirror(section json.RawMessage) (filestorage.StreamingFileStorage, error) {
+	var settings MirrorConfig
+	if err := DecodeSection(section, &settings); err != nil {
+		return nil, err
+	}
+	var sides []filestorage.StreamingFileStorage
+	for _, side := range []MirrorSideConfig{settings.Primary, settings.Secondary} {
+		if side.Backend == "" || side.Backend == "mirror" {
+			return nil, fmt.Errorf("mirror side backend should be set and not be mirror")
+		}
+		sideSection := side.Config
+		if len(sideSection) == 0 {
+			sideSection = config.GetConfig().Backends[side.Backend]
+		}
+		storage, err := OpenBlob(side.Backend, sideSection)
+		if err != nil {
+			return nil, err
+		}
+		sides = append(sides, storage)
+	}
+	// Status is replaced by storage of metadata backend once it is opened.
+	mirror := filestorage.NewMirrorFileStorage(sides[0], sides[1], filestorage.NewMemoryReplicationStatusStore())
+	if settings.RepairInterval != "" {
+		interval, err := time.ParseDuration(settings.RepairInterval)
+		if err != nil {
+			return nil, fmt.Errorf("wrong repair interval: %w", err)
+		}
+		mirror.RepairInterval = interval
+	}
+	return mirror, nil
+}
+
+func openPostgres(section json.RawMessage) (*Metadata, error) {
+	settings := DatabaseConfig{DSN: config.GetConfig().Database}
+	if err := DecodeSection(section, &settings); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("pgx", settings.DSN)
+	if err != nil {
+		return nil, err
+	}
+	storages := func(db dbtx.DB) *Metadata {
+		return &Metadata{Files: metadatastorage.NewPostgresqlStorageStorage(db), Users: userstorage.NewPostgresqlUserStorage(db),
+			ApiTokens: apitokenstorage.NewPostgresqlApiTokenStorage(db), Orgs: orgstorage.NewPostgresqlOrgStorage(db),
+			Emergency: emergencystorage.NewPostgresqlEmergencyStorage(db)}
+	}
+	metadata, err := newDatabaseMetadata(db, migrations.Postgres, storages,
+		&sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	metadata.Tokens = tokenstorage.NewPostgresqlTokenStorage(db)
+	metadata.Sessions = sessionstorage.NewPostgresqlSessionStorage(db)
+	metadata.Replication = replicationstorage.NewPostgresqlReplicationStorage(db)
+	return metadata, nil
+}
+
+// Opens embedded sqlite database.
+//
+// Sqlite allows single writer, so connections are limited to one and
+// concurrent access from other processes waits for lock instead of failing.
+func openSqlite(section json.RawMessage) (*Metadata, error) {
+	settings := DatabaseConfig{DSN: config.GetConfig().Database}
+	if err := DecodeSection(section, &settings); err != nil {
+		return nil, err
+	}
+	dsn := strings.TrimPrefix(settings.DSN, sqliteScheme)
+	if dsn == "" {
+		return nil, fmt.Errorf("empty sqlite database path")
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	dsn += separator + "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	storages := func(db dbtx.DB) *Metadata {
+		return &Metadata{Files: metadatastorage.NewSqliteStorage(db), Users: userstorage.NewSqliteUserStorage(db),
+			ApiTokens: apitokenstorage.NewSqliteApiTokenStorage(db), Orgs: orgstorage.NewSqliteOrgStorage(db),
+			Emergency: emergencystorage.NewSqliteEmergencyStorage(db)}
+	}
+	// Read transaction of sqlite sees database as of its first read. It holds the only connection,
+	// so snapshot reads should be short and must not use storages opened on db.
+	metadata, err := newDatabaseMetadata(db, migrations.Sqlite, storages, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	metadata.Tokens = tokenstorage.NewSqliteTokenStorage(db)
+	metadata.Sessions = sessionstorage.NewSqliteSessionStorage(db)
+	metadata.Replication = replicationstorage.NewSqliteReplicationStorage(db)
+	return metadata, nil
+}
+
+// Metadata of database with storages opened by given function, also used for snapshots in transaction of given options.
+func newDatabaseMetadata(db *sql.DB, dialect migrations.Dialect, storages func(db dbtx.DB) *Metadata,
+	snapshotOptions *sql.TxOptions) (*Metadata, error) {
+	migrator, err := migrations.NewMigrator(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	metadata := storages(db)
+	metadata.Migrator = migrator
+	metadata.Close = db.Close
+	metadata.Snapshot = func(ctx context.Context, read func(snapshot *Metadata) error) error {
+		tx, err := db.BeginTx(ctx, snapshotOptions)
+		if err != nil {
+			return fmt.Errorf("failed to begin snapshot: %w", err)
+		}
+		defer tx.Rollback()
+		return read(storages(dbtx.Tx{Tx: tx}))
+	}
+	return metadata, nil
+}
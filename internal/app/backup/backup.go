@@ -0,0 +1,753 @@
+// Package backup creates and restores archives of the whole vault.
+//
+// Archive is zstd compressed tar with entries in order:
+//
+//	keys.enc       server key pair encrypted with operator passphrase
+//	blobs/{id}     blobs of committed files, already encrypted by clients
+//	users.json     users with password hashes
+//	files.json     committed files metainfo
+//	organizations.json  organizations with members, their keys are encrypted by server key
+//	api_tokens.json     api tokens with hashes of their secrets
+//	emergency.json      emergency access grants with file keys wrapped for contacts
+//	manifest.json  counts and sha256 checksums of blobs
+//
+// Blobs are written before metainfo so that restore can stream them to storage
+// and verify everything against manifest at the end.
+//
+// Sessions and refresh tokens are not archived, users log in again after restore.
+package backup
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Archive format version, version 2 added api tokens and emergency access.
+const FormatVersion = 2
+
+// Oldest archive format version which can be restored.
+const minFormatVersion = 1
+
+// Archive entry names.
+const (
+	keysEntry     = "keys.enc"
+	blobsDir      = "blobs"
+	usersEntry    = "users.json"
+	filesEntry    = "files.json"
+	orgsEntry     = "organizations.json"
+	tokensEntry   = "api_tokens.json"
+	grantsEntry   = "emergency.json"
+	manifestEntry = "manifest.json"
+)
+
+var (
+	// Error in case restore target already contains data.
+	ErrNotEmpty = errors.New("restore target is not empty")
+
+	// Error in case archive content doesn't match its manifest.
+	ErrCorrupted = errors.New("backup archive is corrupted")
+
+	// Error in case blob differs from checksum saved on upload.
+	ErrChecksumMismatch = errors.New("blob checksum mismatch")
+)
+
+// Storages of the whole vault.
+type Vault struct {
+	Files metadatastorage.MetadataStorage
+	Users userstorage.UserStorage
+	Blobs filestorage.StreamingFileStorage
+
+	// Organizations with members, not archived if nil.
+	Orgs orgstorage.OrgStorage
+
+	// Api tokens, not archived if nil.
+	ApiTokens apitokenstorage.ApiTokenStorage
+
+	// Emergency access grants with their keys, not archived if nil.
+	Emergency emergencystorage.EmergencyStorage
+
+	// Runs read on storages of consistent read-only snapshot of metainfo, blobs are not read in it.
+	// If nil, e.g. for memory backend, storages are read one by one and records created meanwhile may be missed.
+	Snapshot func(ctx context.Context, read func(snapshot Vault) error) error
+}
+
+// Archived organization with its members.
+type Organization struct {
+	orgstorage.Organization
+	Members []orgstorage.Member
+}
+
+// Archived emergency access grant with file keys wrapped for contact.
+type EmergencyGrant struct {
+	emergencystorage.Grant
+	Keys []emergencystorage.Key
+}
+
+// Server rsa key pair in pem format.
+type Keys struct {
+	PrivateKey []byte `json:"private_key"`
+	PublicKey  []byte `json:"public_key"`
+
+	// Retired private keys by id, some stored keys may still be wrapped by them.
+	Retired map[string][]byte `json:"retired,omitempty"`
+}
+
+// Archived blob description.
+type BlobInfo struct {
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// Archive content description for verification.
+type Manifest struct {
+	Version int                 `json:"version"`
+	Created time.Time           `json:"created"`
+	Users   int                 `json:"users"`
+	Files   int                 `json:"files"`
+	Blobs   map[string]BlobInfo `json:"blobs"`
+
+	Organizations   int `json:"organizations,omitempty"`
+	ApiTokens       int `json:"api_tokens,omitempty"`
+	EmergencyGrants int `json:"emergency_grants,omitempty"`
+}
+
+// Result of backup or restore.
+type Report struct {
+	Users int   `json:"users"`
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+
+	Organizations   int `json:"organizations,omitempty"`
+	ApiTokens       int `json:"api_tokens,omitempty"`
+	EmergencyGrants int `json:"emergency_grants,omitempty"`
+
+	// Files deleted while backup was running or referencing deleted user or organization.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// Writer counting written bytes and their sha256.
+type hashingWriter struct {
+	writer  io.Writer
+	hash    hash.Hash
+	written int64
+}
+
+func newHashingWriter(writer io.Writer) *hashingWriter {
+	return &hashingWriter{writer: writer, hash: sha256.New()}
+}
+
+func (w *hashingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.hash.Write(p[:n])
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *hashingWriter) info() BlobInfo {
+	return BlobInfo{Size: w.written, Sha256: hex.EncodeToString(w.hash.Sum(nil))}
+}
+
+// Metainfo of vault read at start of backup.
+type metainfo struct {
+	files  *pb.ListFiles
+	users  []userstorage.User
+	orgs   []Organization
+	tokens []apitokenstorage.ApiToken
+	grants []EmergencyGrant
+}
+
+// Reads metainfo of vault in snapshot if vault has snapshots.
+func readMetainfo(ctx context.Context, vault Vault) (*metainfo, error) {
+	if vault.Snapshot == nil {
+		return readStorages(ctx, vault)
+	}
+	var meta *metainfo
+	err := vault.Snapshot(ctx, func(snapshot Vault) error {
+		var err error
+		meta, err = readStorages(ctx, snapshot)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metainfo snapshot: %w", err)
+	}
+	return meta, nil
+}
+
+// Files are read before users and organizations they reference, so that without snapshot
+// records deleted meanwhile are rather missing than referenced.
+func readStorages(ctx context.Context, vault Vault) (*metainfo, error) {
+	files, err := vault.Files.GetAllFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := vault.Users.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := listOrganizations(ctx, vault)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := listApiTokens(ctx, vault, users)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := listEmergencyGrants(ctx, vault, users)
+	if err != nil {
+		return nil, err
+	}
+	return &metainfo{files: files, users: users, orgs: orgs, tokens: tokens, grants: grants}, nil
+}
+
+// Writes archive of vault.
+//
+// Metainfo is read once at start in one snapshot of database, blobs are immutable so they match it.
+// Without snapshot reads are best effort, files, members, tokens and grants referencing records deleted
+// meanwhile are left out so that archive is consistent.
+// Files deleted before their blob is copied are skipped.
+func Backup(ctx context.Context, vault Vault, keys Keys, passphrase []byte, writer io.Writer) (*Report, error) {
+	meta, err := readMetainfo(ctx, vault)
+	if err != nil {
+		return nil, err
+	}
+	allFiles, users, orgs, tokens, grants := meta.files, meta.users, meta.orgs, meta.tokens, meta.grants
+	logins := make(map[string]bool, len(users))
+	for _, user := range users {
+		logins[user.Login] = true
+	}
+	orgIds := make(map[string]bool, len(orgs))
+	for i, org := range orgs {
+		orgIds[org.ID] = true
+		orgs[i].Members = slices.DeleteFunc(org.Members, func(member orgstorage.Member) bool { return !logins[member.Login] })
+	}
+
+	zstdWriter, err := zstd.NewWriter(writer)
+	if err != nil {
+		return nil, err
+	}
+	defer zstdWriter.Close()
+	tarWriter := tar.NewWriter(zstdWriter)
+	created := time.Now()
+
+	keysData, err := json.Marshal(keys)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKeys, err := encryption.EncryptWithPassphrase(passphrase, keysData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt server keys: %w", err)
+	}
+	if err = writeEntry(tarWriter, keysEntry, encryptedKeys, created); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Users: len(users), Organizations: len(orgs)}
+	manifest := Manifest{Version: FormatVersion, Created: created, Users: len(users), Organizations: len(orgs),
+		Blobs: make(map[string]BlobInfo)}
+	files := make([]*pb.FileInfo, 0, len(allFiles.GetFiles()))
+	for _, file := range allFiles.GetFiles() {
+		if file.GetState() != pb.FileState_COMMITTED {
+			continue
+		}
+		if !logins[file.GetLogin()] || (file.GetOrganization() != "" && !orgIds[file.GetOrganization()]) {
+			report.Skipped = append(report.Skipped, file.GetId().GetId())
+			continue
+		}
+		info, err := backupBlob(ctx, vault, tarWriter, file, created)
+		if errors.Is(err, errDeleted) {
+			report.Skipped = append(report.Skipped, file.GetId().GetId())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		manifest.Blobs[file.GetId().GetId()] = info
+		report.Bytes += info.Size
+		files = append(files, file)
+	}
+	manifest.Files = len(files)
+	report.Files = len(files)
+	archived := make(map[string]bool, len(files))
+	for _, file := range files {
+		archived[file.GetId().GetId()] = true
+	}
+	for i, grant := range grants {
+		grants[i].Keys = slices.DeleteFunc(grant.Keys, func(key emergencystorage.Key) bool { return !archived[key.FileID] })
+	}
+	manifest.ApiTokens, report.ApiTokens = len(tokens), len(tokens)
+	manifest.EmergencyGrants, report.EmergencyGrants = len(grants), len(grants)
+
+	usersData, err := json.Marshal(users)
+	if err != nil {
+		return nil, err
+	}
+	filesData, err := protojson.Marshal(&pb.ListFiles{Files: files})
+	if err != nil {
+		return nil, err
+	}
+	orgsData, err := json.Marshal(orgs)
+	if err != nil {
+		return nil, err
+	}
+	tokensData, err := json.Marshal(tokens)
+	if err != nil {
+		return nil, err
+	}
+	grantsData, err := json.Marshal(grants)
+	if err != nil {
+		return nil, err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{{usersEntry, usersData}, {filesEntry, filesData}, {orgsEntry, orgsData}, {tokensEntry, tokensData},
+		{grantsEntry, grantsData}, {manifestEntry, manifestData}} {
+		if err = writeEntry(tarWriter, entry.name, entry.data, created); err != nil {
+			return nil, err
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err = zstdWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return report, nil
+}
+
+// Returns organizations of vault with members, nothing if vault has no organizations storage.
+func listOrganizations(ctx context.Context, vault Vault) ([]Organization, error) {
+	orgs := make([]Organization, 0)
+	if vault.Orgs == nil {
+		return orgs, nil
+	}
+	stored, err := vault.Orgs.ListOrganizations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range stored {
+		members, err := vault.Orgs.ListMembers(ctx, org.ID)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, Organization{Organization: org, Members: members})
+	}
+	return orgs, nil
+}
+
+// Returns api tokens of given users, nothing if vault has no api tokens storage.
+func listApiTokens(ctx context.Context, vault Vault, users []userstorage.User) ([]apitokenstorage.ApiToken, error) {
+	tokens := make([]apitokenstorage.ApiToken, 0)
+	if vault.ApiTokens == nil {
+		return tokens, nil
+	}
+	for _, user := range users {
+		userTokens, err := vault.ApiTokens.ListTokens(ctx, user.Login)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, userTokens...)
+	}
+	return tokens, nil
+}
+
+// Returns emergency access grants between given users with their keys, nothing if vault has no emergency storage.
+func listEmergencyGrants(ctx context.Context, vault Vault, users []userstorage.User) ([]EmergencyGrant, error) {
+	grants := make([]EmergencyGrant, 0)
+	if vault.Emergency == nil {
+		return grants, nil
+	}
+	logins := make(map[string]bool, len(users))
+	for _, user := range users {
+		logins[user.Login] = true
+	}
+	for _, user := range users {
+		given, err := vault.Emergency.ListGrants(ctx, user.Login)
+		if err != nil {
+			return nil, err
+		}
+		for _, grant := range given {
+			// Grant is listed for both grantor and contact, it is archived once with grantor.
+			if grant.Grantor != user.Login || !logins[grant.Grantee] {
+				continue
+			}
+			keys, err := vault.Emergency.ListKeys(ctx, grant.ID)
+			if err != nil {
+				return nil, err
+			}
+			grants = append(grants, EmergencyGrant{Grant: grant, Keys: keys})
+		}
+	}
+	return grants, nil
+}
+
+// Error in case file was deleted while its blob was copied.
+var errDeleted = errors.New("file deleted during backup")
+
+// Copies blob to archive entry of declared file size.
+//
+// Entry of failed blob is padded with zeros and left out of manifest.
+func backupBlob(ctx context.Context, vault Vault, tarWriter *tar.Writer, file *pb.FileInfo, created time.Time) (BlobInfo, error) {
+	fileId := file.GetId().GetId()
+	size := int64(file.GetSize())
+	err := tarWriter.WriteHeader(&tar.Header{Name: path.Join(blobsDir, fileId), Mode: 0600, Size: size, ModTime: created})
+	if err != nil {
+		return BlobInfo{}, fmt.Errorf("failed to write archive: %w", err)
+	}
+	blobWriter := newHashingWriter(&limitedWriter{writer: tarWriter, left: size})
+	err = filestorage.DownloadTo(ctx, vault.Blobs, fileId, blobWriter)
+	info := blobWriter.info()
+	if err == nil && info.Size != size {
+		err = fmt.Errorf("blob %s size %d differs from file size %d", fileId, info.Size, size)
+	}
+	if err == nil && len(file.GetChecksum()) > 0 && info.Sha256 != hex.EncodeToString(file.GetChecksum()) {
+		err = fmt.Errorf("%w: %s", ErrChecksumMismatch, fileId)
+	}
+	if err == nil {
+		return info, nil
+	}
+	if padErr := pad(tarWriter, size-info.Size); padErr != nil {
+		return BlobInfo{}, padErr
+	}
+	if current, getErr := vault.Files.GetFileById(ctx, fileId); getErr != nil || current.GetState() != pb.FileState_COMMITTED {
+		return BlobInfo{}, errDeleted
+	}
+	return BlobInfo{}, fmt.Errorf("failed to backup blob %s: %w", fileId, err)
+}
+
+// Writer failing when more than given bytes are written.
+type limitedWriter struct {
+	writer io.Writer
+	left   int64
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > w.left {
+		return 0, fmt.Errorf("blob is larger than file size")
+	}
+	n, err := w.writer.Write(p)
+	w.left -= int64(n)
+	return n, err
+}
+
+// Writes zeros to fill tar entry.
+func pad(writer io.Writer, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	_, err := io.CopyN(writer, zeroReader{}, size)
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func writeEntry(tarWriter *tar.Writer, name string, data []byte, modTime time.Time) error {
+	err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime})
+	if err == nil {
+		_, err = tarWriter.Write(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// Restores archive into empty vault and returns server keys from it.
+//
+// Blobs are uploaded while archive is read, metainfo is written only after
+// all blobs are verified against manifest. Storages have no common transaction,
+// so on failure written metainfo and uploaded blobs are deleted and restore may be retried.
+func Restore(ctx context.Context, vault Vault, passphrase []byte, reader io.Reader) (*Report, *Keys, error) {
+	if err := checkEmpty(ctx, vault); err != nil {
+		return nil, nil, err
+	}
+	zstdReader, err := zstd.NewReader(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer zstdReader.Close()
+
+	uploaded := make(map[string]BlobInfo)
+	written := &restored{}
+	report, keys, err := restore(ctx, vault, passphrase, tar.NewReader(zstdReader), uploaded, written)
+	if err != nil {
+		// Rollback is done even if restore was cancelled.
+		cleanupCtx := context.WithoutCancel(ctx)
+		rollbackErr := written.rollback(cleanupCtx, vault)
+		for fileId := range uploaded {
+			if deleteErr := vault.Blobs.Delete(cleanupCtx, fileId); deleteErr != nil {
+				rollbackErr = errors.Join(rollbackErr, fmt.Errorf("failed to delete blob %s: %w", fileId, deleteErr))
+			}
+		}
+		if rollbackErr != nil {
+			return nil, nil, fmt.Errorf("%w, rollback failed, restore target should be cleaned by hand: %w", err, rollbackErr)
+		}
+		return nil, nil, err
+	}
+	return report, keys, nil
+}
+
+// Metainfo written by restore, removed if restore fails.
+type restored struct {
+	users  []string
+	orgs   []string
+	files  []string
+	tokens []apitokenstorage.ApiToken
+	grants []string
+}
+
+// Deletes written metainfo in reverse order, returns errors of failed deletes.
+func (r *restored) rollback(ctx context.Context, vault Vault) error {
+	var errs []error
+	for _, id := range r.grants {
+		if err := vault.Emergency.DeleteGrant(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete emergency access %s: %w", id, err))
+		}
+	}
+	for _, token := range r.tokens {
+		if err := vault.ApiTokens.DeleteToken(ctx, token.Login, token.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete api token %s: %w", token.ID, err))
+		}
+	}
+	for _, id := range r.files {
+		if err := vault.Files.DeleteFileInfo(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete file %s: %w", id, err))
+		}
+	}
+	for _, id := range r.orgs {
+		if err := vault.Orgs.DeleteOrganization(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete organization %s: %w", id, err))
+		}
+	}
+	for _, login := range r.users {
+		if err := vault.Users.DeleteUser(ctx, login); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete user %s: %w", login, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkEmpty(ctx context.Context, vault Vault) error {
+	users, err := vault.Users.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	files, err := vault.Files.GetAllFiles(ctx)
+	if err != nil {
+		return err
+	}
+	blobs, err := vault.Blobs.List(ctx)
+	if err != nil {
+		return err
+	}
+	orgs, err := listOrganizations(ctx, vault)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 || len(files.GetFiles()) > 0 || len(blobs) > 0 || len(orgs) > 0 {
+		return fmt.Errorf("%w: %d users, %d organizations, %d files, %d blobs", ErrNotEmpty,
+			len(users), len(orgs), len(files.GetFiles()), len(blobs))
+	}
+	return nil
+}
+
+func restore(ctx context.Context, vault Vault, passphrase []byte, tarReader *tar.Reader,
+	uploaded map[string]BlobInfo, written *restored) (*Report, *Keys, error) {
+
+	var keys *Keys
+	var manifest *Manifest
+	var users []userstorage.User
+	var files pb.ListFiles
+	var orgs []Organization
+	var tokens []apitokenstorage.ApiToken
+	var grants []EmergencyGrant
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if dir, fileId := path.Split(header.Name); dir == blobsDir+"/" {
+			if keys == nil {
+				return nil, nil, fmt.Errorf("%w: server keys should precede blobs", ErrCorrupted)
+			}
+			// Blob may be left out of manifest, it is checked after manifest is read.
+			hashingReader := &hashingReader{reader: tarReader, hash: sha256.New()}
+			if err = vault.Blobs.Upload(ctx, hashingReader, header.Size, fileId); err != nil {
+				return nil, nil, fmt.Errorf("failed to restore blob %s: %w", fileId, err)
+			}
+			uploaded[fileId] = BlobInfo{Size: hashingReader.read, Sha256: hex.EncodeToString(hashingReader.hash.Sum(nil))}
+			continue
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		switch header.Name {
+		case keysEntry:
+			decrypted, err := encryption.DecryptWithPassphrase(passphrase, data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to decrypt server keys: %w", err)
+			}
+			keys = &Keys{}
+			err = json.Unmarshal(decrypted, keys)
+		case usersEntry:
+			err = json.Unmarshal(data, &users)
+		case filesEntry:
+			err = protojson.Unmarshal(data, &files)
+		case orgsEntry:
+			err = json.Unmarshal(data, &orgs)
+		case tokensEntry:
+			err = json.Unmarshal(data, &tokens)
+		case grantsEntry:
+			err = json.Unmarshal(data, &grants)
+		case manifestEntry:
+			manifest = &Manifest{}
+			err = json.Unmarshal(data, manifest)
+		default:
+			err = fmt.Errorf("unknown entry %q", header.Name)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+		}
+	}
+	if err := verify(manifest, keys, users, files.GetFiles(), uploaded); err != nil {
+		return nil, nil, err
+	}
+	if len(orgs) != manifest.Organizations {
+		return nil, nil, fmt.Errorf("%w: %d organizations, manifest has %d", ErrCorrupted, len(orgs), manifest.Organizations)
+	}
+	if len(orgs) > 0 && vault.Orgs == nil {
+		return nil, nil, fmt.Errorf("archive has organizations, but vault has no organizations storage")
+	}
+	if len(tokens) != manifest.ApiTokens || len(grants) != manifest.EmergencyGrants {
+		return nil, nil, fmt.Errorf("%w: %d api tokens and %d emergency grants, manifest has %d and %d", ErrCorrupted,
+			len(tokens), len(grants), manifest.ApiTokens, manifest.EmergencyGrants)
+	}
+	if len(tokens) > 0 && vault.ApiTokens == nil {
+		return nil, nil, fmt.Errorf("archive has api tokens, but vault has no api tokens storage")
+	}
+	if len(grants) > 0 && vault.Emergency == nil {
+		return nil, nil, fmt.Errorf("archive has emergency access, but vault has no emergency storage")
+	}
+	for fileId := range uploaded {
+		if _, ok := manifest.Blobs[fileId]; !ok {
+			vault.Blobs.Delete(ctx, fileId)
+			delete(uploaded, fileId)
+		}
+	}
+
+	report := &Report{Users: len(users), Files: len(files.GetFiles()), Organizations: len(orgs),
+		ApiTokens: len(tokens), EmergencyGrants: len(grants)}
+	for _, user := range users {
+		if err := vault.Users.AddUser(ctx, user); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore user %s: %w", user.Login, err)
+		}
+		written.users = append(written.users, user.Login)
+	}
+	for _, org := range orgs {
+		if err := vault.Orgs.AddOrganization(ctx, org.Organization, org.Members); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore organization %s: %w", org.ID, err)
+		}
+		written.orgs = append(written.orgs, org.ID)
+	}
+	for _, file := range files.GetFiles() {
+		if err := vault.Files.AddFileInfo(ctx, file); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore file %s: %w", file.GetId().GetId(), err)
+		}
+		written.files = append(written.files, file.GetId().GetId())
+		report.Bytes += int64(file.GetSize())
+	}
+	for _, token := range tokens {
+		if err := vault.ApiTokens.AddToken(ctx, token); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore api token %s: %w", token.ID, err)
+		}
+		written.tokens = append(written.tokens, token)
+	}
+	for _, grant := range grants {
+		if err := vault.Emergency.AddGrant(ctx, grant.Grant); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore emergency access %s: %w", grant.ID, err)
+		}
+		written.grants = append(written.grants, grant.ID)
+		if err := vault.Emergency.SetKeys(ctx, grant.ID, grant.Keys); err != nil {
+			return nil, nil, fmt.Errorf("failed to restore emergency access %s: %w", grant.ID, err)
+		}
+	}
+	return report, keys, nil
+}
+
+// Checks archive content against manifest and files checksums.
+func verify(manifest *Manifest, keys *Keys, users []userstorage.User, files []*pb.FileInfo, uploaded map[string]BlobInfo) error {
+	switch {
+	case manifest == nil:
+		return fmt.Errorf("%w: no manifest", ErrCorrupted)
+	case manifest.Version < minFormatVersion || manifest.Version > FormatVersion:
+		return fmt.Errorf("%w: unsupported format version %d", ErrCorrupted, manifest.Version)
+	case keys == nil:
+		return fmt.Errorf("%w: no server keys", ErrCorrupted)
+	case len(users) != manifest.Users:
+		return fmt.Errorf("%w: %d users, manifest has %d", ErrCorrupted, len(users), manifest.Users)
+	case len(files) != manifest.Files || len(manifest.Blobs) != manifest.Files:
+		return fmt.Errorf("%w: %d files, manifest has %d", ErrCorrupted, len(files), manifest.Files)
+	}
+	for _, file := range files {
+		fileId := file.GetId().GetId()
+		expected, ok := manifest.Blobs[fileId]
+		if !ok {
+			return fmt.Errorf("%w: file %s is not in manifest", ErrCorrupted, fileId)
+		}
+		if got, ok := uploaded[fileId]; !ok || got != expected {
+			return fmt.Errorf("%w: blob %s is missing or differs from manifest", ErrCorrupted, fileId)
+		}
+		if len(file.GetChecksum()) > 0 && expected.Sha256 != hex.EncodeToString(file.GetChecksum()) {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, fileId)
+		}
+	}
+	return nil
+}
+
+// Reader counting read bytes and their sha256.
+type hashingReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	read   int64
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.read += int64(n)
+	return n, err
+}
@@ -0,0 +1,272 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	testKeys       = Keys{PrivateKey: []byte("private"), PublicKey: []byte("public")}
+	testPassphrase = []byte("passphrase")
+)
+
+func newVault() Vault {
+	return Vault{
+		Files: metadatastorage.NewMemoryStorage(),
+		Users: userstorage.NewMemoryUserStorage(),
+		Blobs: filestorage.NewMemoryFileStorage(),
+		Orgs:  orgstorage.NewMemoryOrgStorage(),
+
+		ApiTokens: apitokenstorage.NewMemoryApiTokenStorage(),
+		Emergency: emergencystorage.NewMemoryEmergencyStorage(),
+	}
+}
+
+var testOrganization = Organization{
+	Organization: orgstorage.Organization{ID: "team", Name: "team", Key: []byte("key"), Created: time.Unix(1, 0).UTC()},
+	Members:      []orgstorage.Member{{OrganizationID: "team", Login: "user", Role: "owner", Added: time.Unix(1, 0).UTC()}},
+}
+
+var testApiToken = apitokenstorage.ApiToken{ID: "token", Login: "user", Name: "ci", Hash: []byte("hash"), Scope: "read",
+	Folders: []string{"docs"}, PublicKey: []byte("public"), Created: time.Unix(1, 0).UTC(), Expires: time.Unix(2, 0).UTC()}
+
+var testGrant = EmergencyGrant{
+	Grant: emergencystorage.Grant{ID: "grant", Grantor: "user", Grantee: "contact", WaitPeriod: time.Hour,
+		Status: "accepted", PublicKey: []byte("public"), Created: time.Unix(1, 0).UTC()},
+	Keys: []emergencystorage.Key{{GrantID: "grant", FileID: "a", Key: []byte("key")}},
+}
+
+func addFile(t *testing.T, vault Vault, id string, data []byte, state pb.FileState) *pb.FileInfo {
+	ctx := context.Background()
+	checksum := sha256.Sum256(data)
+	file := &pb.FileInfo{Id: &pb.FileId{Id: id}, Filename: id, Login: "user", Size: uint64(len(data)),
+		EncryptionKey: []byte("key"), Checksum: checksum[:], State: state}
+	require.NoError(t, vault.Files.AddFileInfo(ctx, file))
+	require.NoError(t, vault.Blobs.Upload(ctx, bytes.NewReader(data), int64(len(data)), id))
+	return file
+}
+
+func newFilledVault(t *testing.T) (Vault, []*pb.FileInfo) {
+	vault := newVault()
+	ctx := context.Background()
+	require.NoError(t, vault.Users.AddUser(ctx, userstorage.User{Login: "contact", PasswordHash: []byte("hash")}))
+	require.NoError(t, vault.Users.AddUser(ctx, userstorage.User{Login: "user", PasswordHash: []byte("hash")}))
+	files := []*pb.FileInfo{
+		addFile(t, vault, "a", bytes.Repeat([]byte("a"), 3*filestorage.ChunkSize+1), pb.FileState_COMMITTED),
+		addFile(t, vault, "b", []byte{}, pb.FileState_COMMITTED),
+	}
+	addFile(t, vault, "pending", []byte("pending"), pb.FileState_PENDING)
+	require.NoError(t, vault.Orgs.AddOrganization(ctx, testOrganization.Organization, testOrganization.Members))
+	require.NoError(t, vault.ApiTokens.AddToken(ctx, testApiToken))
+	require.NoError(t, vault.Emergency.AddGrant(ctx, testGrant.Grant))
+	require.NoError(t, vault.Emergency.SetKeys(ctx, testGrant.ID, testGrant.Keys))
+	return vault, files
+}
+
+func TestBackupRestore(t *testing.T) {
+	ctx := context.Background()
+	source, files := newFilledVault(t)
+	var archive bytes.Buffer
+	report, err := Backup(ctx, source, testKeys, testPassphrase, &archive)
+	require.NoError(t, err)
+	require.Equal(t, &Report{Users: 2, Files: 2, Bytes: int64(3*filestorage.ChunkSize + 1), Organizations: 1,
+		ApiTokens: 1, EmergencyGrants: 1}, report)
+
+	target := newVault()
+	restored, keys, err := Restore(ctx, target, testPassphrase, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, report, restored)
+	require.Equal(t, &testKeys, keys)
+
+	users, err := target.Users.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []userstorage.User{{Login: "contact", PasswordHash: []byte("hash")}, {Login: "user", PasswordHash: []byte("hash")}}, users)
+	org, err := target.Orgs.GetOrganization(ctx, testOrganization.ID)
+	require.NoError(t, err)
+	require.Equal(t, testOrganization.Organization, *org)
+	members, err := target.Orgs.ListMembers(ctx, testOrganization.ID)
+	require.NoError(t, err)
+	require.Equal(t, testOrganization.Members, members)
+	tokens, err := target.ApiTokens.ListTokens(ctx, "user")
+	require.NoError(t, err)
+	require.Equal(t, []apitokenstorage.ApiToken{testApiToken}, tokens)
+	grant, err := target.Emergency.GetGrant(ctx, testGrant.ID)
+	require.NoError(t, err)
+	require.Equal(t, testGrant.Grant, *grant)
+	grantKeys, err := target.Emergency.ListKeys(ctx, testGrant.ID)
+	require.NoError(t, err)
+	require.Equal(t, testGrant.Keys, grantKeys)
+	for _, file := range files {
+		got, err := target.Files.GetFileById(ctx, file.GetId().GetId())
+		require.NoError(t, err)
+		// Storages set modification time on insert.
+		got.Modified = file.GetModified()
+		require.True(t, proto.Equal(file, got))
+
+		var expected, data bytes.Buffer
+		require.NoError(t, filestorage.DownloadTo(ctx, source.Blobs, file.GetId().GetId(), &expected))
+		require.NoError(t, filestorage.DownloadTo(ctx, target.Blobs, file.GetId().GetId(), &data))
+		require.Equal(t, expected.Bytes(), data.Bytes())
+	}
+	blobs, err := target.Blobs.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, blobs, 2)
+}
+
+func TestRestore_Failures(t *testing.T) {
+	ctx := context.Background()
+	source, _ := newFilledVault(t)
+	var archive bytes.Buffer
+	_, err := Backup(ctx, source, testKeys, testPassphrase, &archive)
+	require.NoError(t, err)
+
+	t.Run("not empty", func(t *testing.T) {
+		_, _, err := Restore(ctx, source, testPassphrase, bytes.NewReader(archive.Bytes()))
+		require.ErrorIs(t, err, ErrNotEmpty)
+	})
+	t.Run("wrong passphrase", func(t *testing.T) {
+		target := newVault()
+		_, _, err := Restore(ctx, target, []byte("wrong"), bytes.NewReader(archive.Bytes()))
+		require.Error(t, err)
+		require.NoError(t, checkEmpty(ctx, target))
+	})
+	t.Run("truncated", func(t *testing.T) {
+		target := newVault()
+		_, _, err := Restore(ctx, target, testPassphrase, bytes.NewReader(archive.Bytes()[:archive.Len()/2]))
+		require.Error(t, err)
+		require.NoError(t, checkEmpty(ctx, target))
+	})
+}
+
+// Api token storage failing to add tokens.
+type failingApiTokens struct {
+	*apitokenstorage.MemoryApiTokenStorage
+}
+
+func (s failingApiTokens) AddToken(context.Context, apitokenstorage.ApiToken) error {
+	return errors.New("storage is unavailable")
+}
+
+func TestRestore_Rollback(t *testing.T) {
+	ctx := context.Background()
+	source, _ := newFilledVault(t)
+	var archive bytes.Buffer
+	_, err := Backup(ctx, source, testKeys, testPassphrase, &archive)
+	require.NoError(t, err)
+
+	target := newVault()
+	tokens := target.ApiTokens
+	target.ApiTokens = failingApiTokens{MemoryApiTokenStorage: tokens.(*apitokenstorage.MemoryApiTokenStorage)}
+	_, _, err = Restore(ctx, target, testPassphrase, bytes.NewReader(archive.Bytes()))
+	require.ErrorContains(t, err, "storage is unavailable")
+	require.NoError(t, checkEmpty(ctx, target), "restored metainfo should be rolled back")
+
+	target.ApiTokens = tokens
+	report, _, err := Restore(ctx, target, testPassphrase, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err, "restore should be retried after failure")
+	require.Equal(t, 1, report.ApiTokens)
+}
+
+func TestBackup_DanglingReferences(t *testing.T) {
+	ctx := context.Background()
+	vault, _ := newFilledVault(t)
+	orphan := addFile(t, vault, "orphan", []byte("data"), pb.FileState_COMMITTED)
+	orphan.Login = "deleted"
+	require.NoError(t, vault.Files.DeleteFileInfo(ctx, "orphan"))
+	require.NoError(t, vault.Files.AddFileInfo(ctx, orphan))
+	require.NoError(t, vault.Users.DeleteUser(ctx, "contact"))
+
+	var archive bytes.Buffer
+	report, err := Backup(ctx, vault, testKeys, testPassphrase, &archive)
+	require.NoError(t, err)
+	require.Equal(t, []string{"orphan"}, report.Skipped)
+	require.Equal(t, 2, report.Files)
+	require.Zero(t, report.EmergencyGrants, "grant of deleted contact should be left out")
+
+	target := newVault()
+	_, _, err = Restore(ctx, target, testPassphrase, &archive)
+	require.NoError(t, err)
+	_, err = target.Files.GetFileById(ctx, "orphan")
+	require.Error(t, err)
+}
+
+func TestBackup_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	vault, _ := newFilledVault(t)
+	snapshot := vault
+	// Users changed after snapshot started are not seen by backup.
+	vault.Users = userstorage.NewMemoryUserStorage()
+	vault.Snapshot = func(ctx context.Context, read func(Vault) error) error {
+		return read(snapshot)
+	}
+	report, err := Backup(ctx, vault, testKeys, testPassphrase, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Users)
+	require.Equal(t, 2, report.Files)
+	require.Empty(t, report.Skipped)
+
+	vault.Snapshot = func(context.Context, func(Vault) error) error {
+		return errors.New("database is unavailable")
+	}
+	_, err = Backup(ctx, vault, testKeys, testPassphrase, &bytes.Buffer{})
+	require.ErrorContains(t, err, "database is unavailable")
+}
+
+func TestBackup_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	vault, _ := newFilledVault(t)
+	corrupted := addFile(t, vault, "corrupted", []byte("data"), pb.FileState_COMMITTED)
+	require.NoError(t, vault.Blobs.Delete(ctx, "corrupted"))
+	require.NoError(t, vault.Blobs.Upload(ctx, bytes.NewReader([]byte("datb")), 4, corrupted.GetId().GetId()))
+
+	_, err := Backup(ctx, vault, testKeys, testPassphrase, &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+}
+
+// Metadata storage deleting file when it is read, as if user deleted it during backup.
+type deletingStorage struct {
+	*metadatastorage.MemoryStorage
+	blobs filestorage.StreamingFileStorage
+}
+
+func (s *deletingStorage) GetAllFiles(ctx context.Context) (*pb.ListFiles, error) {
+	files, err := s.MemoryStorage.GetAllFiles(ctx)
+	if err == nil {
+		s.MemoryStorage.DeleteFileInfo(ctx, "a")
+		s.blobs.Delete(ctx, "a")
+	}
+	return files, err
+}
+
+func TestBackup_ConcurrentDelete(t *testing.T) {
+	ctx := context.Background()
+	vault, _ := newFilledVault(t)
+	vault.Files = &deletingStorage{MemoryStorage: vault.Files.(*metadatastorage.MemoryStorage), blobs: vault.Blobs}
+	var archive bytes.Buffer
+	report, err := Backup(ctx, vault, testKeys, testPassphrase, &archive)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, report.Skipped)
+	require.Equal(t, 1, report.Files)
+
+	target := newVault()
+	restored, _, err := Restore(ctx, target, testPassphrase, &archive)
+	require.NoError(t, err)
+	require.Equal(t, 1, restored.Files)
+	blobs, err := target.Blobs.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+}
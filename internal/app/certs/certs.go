@@ -0,0 +1,263 @@
+// Package certs for tls configs of grpc transport and self-signed certificates of local deployments.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Settings of server tls.
+type ServerConfig struct {
+	CertFile string
+	KeyFile  string
+
+	// CA of client certificates, client certificates are not asked if empty.
+	ClientCAFile string
+
+	// Whether connections without client certificate are rejected.
+	RequireClientCert bool
+}
+
+// Returns tls config of server.
+func (c ServerConfig) TLSConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if c.ClientCAFile != "" {
+		if config.ClientCAs, err = loadPool(c.ClientCAFile); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	} else if c.RequireClientCert {
+		return nil, fmt.Errorf("client CA should be set to require client certificates")
+	}
+	return config, nil
+}
+
+// Settings of client tls.
+type ClientConfig struct {
+	// CA of server certificate, system roots are used if empty.
+	CAFile string
+
+	// Client certificate, not sent if empty.
+	CertFile string
+	KeyFile  string
+
+	// Whether server certificate is accepted without verification, only for testing.
+	InsecureSkipVerify bool
+}
+
+// Returns tls config of client.
+func (c ClientConfig) TLSConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: c.InsecureSkipVerify}
+	var err error
+	if c.CAFile != "" {
+		if config.RootCAs, err = loadPool(c.CAFile); err != nil {
+			return nil, err
+		}
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// Names of generated files in certificates directory.
+const (
+	CAFile        = "ca.pem"
+	CAKeyFile     = "ca-key.pem"
+	ServerFile    = "server.pem"
+	ServerKeyFile = "server-key.pem"
+)
+
+// Names of client certificate and key files for login.
+func ClientFiles(login string) (string, string) {
+	return "client-" + login + ".pem", "client-" + login + "-key.pem"
+}
+
+// Settings of generated certificates.
+type GenerateConfig struct {
+	Dir string
+
+	// Dns names and ip addresses of server.
+	Hosts []string
+
+	// Logins of issued client certificates, login is stored in common name.
+	Clients []string
+
+	Validity time.Duration
+}
+
+// Generates self-signed CA, server certificate and client certificates in directory.
+//
+// Existing CA is reused so that certificates for new clients can be issued later,
+// existing server and client certificates are overwritten.
+func Generate(c GenerateConfig) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create certificates directory: %w", err)
+	}
+	ca, caKey, err := loadCA(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		ca, caKey, err = generateCA(c.Dir, c.Validity)
+	}
+	if err != nil {
+		return err
+	}
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "gophkeeper server"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range c.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else {
+			server.DNSNames = append(server.DNSNames, host)
+		}
+	}
+	if err = issue(c.Dir, ServerFile, ServerKeyFile, server, ca, caKey, c.Validity); err != nil {
+		return err
+	}
+	for _, login := range c.Clients {
+		client := &x509.Certificate{
+			Subject:     pkix.Name{CommonName: login},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}
+		certFile, keyFile := ClientFiles(login)
+		if err = issue(c.Dir, certFile, keyFile, client, ca, caKey, c.Validity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, CAFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	certBlock, keyBlock := decodePEM(certPEM), decodePEM(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, fmt.Errorf("wrong CA in %s", dir)
+	}
+	cert, err := x509.ParseCertificate(certBlock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+	return cert, key, nil
+}
+
+func decodePEM(data []byte) []byte {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil
+	}
+	return block.Bytes
+}
+
+func generateCA(dir string, validity time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "gophkeeper CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := sign(template, template, &key.PublicKey, key, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = writeFiles(dir, CAFile, CAKeyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+func issue(dir, certFile, keyFile string, template, ca *x509.Certificate, caKey *ecdsa.PrivateKey, validity time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := sign(template, ca, &key.PublicKey, caKey, validity)
+	if err != nil {
+		return err
+	}
+	return writeFiles(dir, certFile, keyFile, der, key)
+}
+
+func sign(template, parent *x509.Certificate, public *ecdsa.PublicKey, signer *ecdsa.PrivateKey, validity time.Duration) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(validity)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate %s: %w", template.Subject.CommonName, err)
+	}
+	return der, nil
+}
+
+func writeFiles(dir, certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err = os.WriteFile(filepath.Join(dir, certFile), certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, keyFile), keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package certs
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Runs tls handshake over pipe, returns common name of client certificate seen by server.
+func handshake(t *testing.T, server, client *tls.Config) (string, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	serverErr := make(chan error, 1)
+	var clientLogin string
+	go func() {
+		conn := tls.Server(serverConn, server)
+		err := conn.Handshake()
+		if err == nil && len(conn.ConnectionState().VerifiedChains) > 0 {
+			clientLogin = conn.ConnectionState().VerifiedChains[0][0].Subject.CommonName
+		}
+		serverConn.Close()
+		serverErr <- err
+	}()
+	err := tls.Client(clientConn, client).Handshake()
+	clientConn.Close()
+	if serverErr := <-serverErr; err == nil {
+		err = serverErr
+	}
+	return clientLogin, err
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	require.NoError(t, Generate(GenerateConfig{Dir: dir, Hosts: []string{"localhost", "127.0.0.1"},
+		Clients: []string{"alice"}, Validity: time.Hour}))
+	ca, err := os.ReadFile(path(CAFile))
+	require.NoError(t, err)
+	require.NoError(t, Generate(GenerateConfig{Dir: dir, Hosts: []string{"localhost"}, Clients: []string{"bob"}, Validity: time.Hour}))
+	reused, err := os.ReadFile(path(CAFile))
+	require.NoError(t, err)
+	require.Equal(t, ca, reused, "existing CA should be reused")
+
+	server, err := ServerConfig{CertFile: path(ServerFile), KeyFile: path(ServerKeyFile), ClientCAFile: path(CAFile)}.TLSConfig()
+	require.NoError(t, err)
+	for _, login := range []string{"alice", "bob"} {
+		certFile, keyFile := ClientFiles(login)
+		client, err := ClientConfig{CAFile: path(CAFile), CertFile: path(certFile), KeyFile: path(keyFile)}.TLSConfig()
+		require.NoError(t, err)
+		client.ServerName = "localhost"
+		got, err := handshake(t, server, client)
+		require.NoError(t, err)
+		require.Equal(t, login, got)
+	}
+
+	anonymous, err := ClientConfig{CAFile: path(CAFile)}.TLSConfig()
+	require.NoError(t, err)
+	anonymous.ServerName = "localhost"
+	got, err := handshake(t, server, anonymous)
+	require.NoError(t, err, "client certificate should be optional")
+	require.Empty(t, got)
+
+	_, err = ServerConfig{CertFile: path(ServerFile), KeyFile: path(ServerKeyFile), RequireClientCert: true}.TLSConfig()
+	require.Error(t, err, "client CA is required")
+	required, err := ServerConfig{CertFile: path(ServerFile), KeyFile: path(ServerKeyFile), ClientCAFile: path(CAFile),
+		RequireClientCert: true}.TLSConfig()
+	require.NoError(t, err)
+	_, err = handshake(t, required, anonymous)
+	require.Error(t, err)
+
+	untrusted, err := ClientConfig{}.TLSConfig()
+	require.NoError(t, err)
+	untrusted.ServerName = "localhost"
+	_, err = handshake(t, server, untrusted)
+	require.Error(t, err, "self-signed server certificate should not be trusted without CA")
+	skipVerify, err := ClientConfig{InsecureSkipVerify: true}.TLSConfig()
+	require.NoError(t, err)
+	_, err = handshake(t, server, skipVerify)
+	require.NoError(t, err)
+}
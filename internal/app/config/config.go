@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"strconv"
 )
 
 // Struct contains all service settings.
@@ -20,13 +21,38 @@ type Config struct {
 	S3SecretKey          string `env:"S3_SECRET_KEY" json:"s3_secret_key"`
 	S3Region             string `env:"S3_REGION" json:"s3_region"`
 	S3Bucket             string `env:"S3_BUCKET" json:"s3_bucket"`
+	StorageBackend       string `env:"STORAGE_BACKEND" json:"storage_backend"`
+	StoragePath          string `env:"STORAGE_PATH" json:"storage_path"`
+	MetadataBackend      string `env:"METADATA_BACKEND" json:"metadata_backend"`
 	SecretKey            string `env:"SECRET_KEY"`
 	LogLevel             string `env:"LOG_LEVEL"`
 	AuthTokenFile        string `env:"AUTH_TOKEN_FILE"`
+	RefreshTokenFile     string `env:"REFRESH_TOKEN_FILE"`
+	ApiToken             string `env:"API_TOKEN"`
+	AccessTokenTTL       string `env:"ACCESS_TOKEN_TTL" json:"access_token_ttl"`
+	RefreshTokenTTL      string `env:"REFRESH_TOKEN_TTL" json:"refresh_token_ttl"`
+	TLSCertFile          string `env:"TLS_CERT" json:"tls_cert"`
+	TLSKeyFile           string `env:"TLS_KEY" json:"tls_key"`
+	TLSClientCAFile      string `env:"TLS_CLIENT_CA" json:"tls_client_ca"`
+	TLSRequireClientCert string `env:"TLS_REQUIRE_CLIENT_CERT" json:"tls_require_client_cert"`
+	TLSCAFile            string `env:"TLS_CA" json:"tls_ca"`
+	TLSSkipVerify        string `env:"TLS_INSECURE_SKIP_VERIFY" json:"tls_insecure_skip_verify"`
+	Plaintext            string `env:"PLAINTEXT" json:"plaintext"`
+	PasswordMinLength    string `env:"PASSWORD_MIN_LENGTH" json:"password_min_length"`
+	PasswordMinStrength  string `env:"PASSWORD_MIN_STRENGTH" json:"password_min_strength"`
 	ServerPublicKeyPath  string `env:"SERVER_PUBLIC_KEY"`
 	ServerPrivateKeyPath string `env:"SERVER_PRIVATE_KEY"`
 	ClientPublicKeyPath  string `env:"CLIENT_PUBLIC_KEY"`
 	ClientPrivateKeyPath string `env:"CLIENT_PRIVATE_KEY"`
+	FsckInterval         string `env:"FSCK_INTERVAL" json:"fsck_interval"`
+	FsckGracePeriod      string `env:"FSCK_GRACE_PERIOD" json:"fsck_grace_period"`
+	FsckRepair           string `env:"FSCK_REPAIR" json:"fsck_repair"`
+	KeyRotationInterval  string `env:"KEY_ROTATION_INTERVAL" json:"key_rotation_interval"`
+	KeyRotationBatch     string `env:"KEY_ROTATION_BATCH" json:"key_rotation_batch"`
+	Dev                  string `env:"DEV" json:"dev"`
+
+	// Storage backend settings by backend name, read only from config file.
+	Backends map[string]json.RawMessage `json:"backends"`
 }
 
 // Default config values.
@@ -38,13 +64,59 @@ var DefaultConfig = Config{
 	S3SecretKey:          "minioadmin",
 	S3Region:             "localhost",
 	S3Bucket:             "gopher",
+	StorageBackend:       "s3",
+	StoragePath:          "/var/lib/gophkeeper",
+	MetadataBackend:      "",
 	SecretKey:            "SECRET_KEY",
 	LogLevel:             "info",
 	AuthTokenFile:        ".config",
+	RefreshTokenFile:     ".refresh_token",
+	ApiToken:             "",
+	AccessTokenTTL:       "15m",
+	RefreshTokenTTL:      "720h",
+	TLSCertFile:          "",
+	TLSKeyFile:           "",
+	TLSClientCAFile:      "",
+	TLSRequireClientCert: "false",
+	TLSCAFile:            "",
+	TLSSkipVerify:        "false",
+	Plaintext:            "false",
+	PasswordMinLength:    "10",
+	PasswordMinStrength:  "3",
 	ServerPublicKeyPath:  ".rsa_server_public",
 	ServerPrivateKeyPath: ".rsa_server_private",
 	ClientPublicKeyPath:  ".rsa_client_public",
 	ClientPrivateKeyPath: ".rsa_client_private",
+	FsckInterval:         "",
+	FsckGracePeriod:      "24h",
+	FsckRepair:           "false",
+	KeyRotationInterval:  "1m",
+	KeyRotationBatch:     "100",
+	Dev:                  "false",
+}
+
+// String flag which can be set without value like boolean flag.
+type boolStringFlag struct {
+	value *string
+}
+
+func (f boolStringFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+	return *f.value
+}
+
+func (f boolStringFlag) Set(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return err
+	}
+	*f.value = value
+	return nil
+}
+
+func (f boolStringFlag) IsBoolFlag() bool {
+	return true
 }
 
 // Parse command line flags.
@@ -56,13 +128,38 @@ func parseFlags(config *Config) {
 	flag.StringVar(&config.S3SecretKey, "s", DefaultConfig.S3SecretKey, "files s3 secret key")
 	flag.StringVar(&config.S3Region, "d", DefaultConfig.S3Region, "files s3 region")
 	flag.StringVar(&config.S3Bucket, "f", DefaultConfig.S3Bucket, "files s3 bucket")
+	flag.StringVar(&config.StorageBackend, "b", DefaultConfig.StorageBackend, "files storage backend: s3, fs, memory or registered one")
+	flag.StringVar(&config.StoragePath, "p", DefaultConfig.StoragePath, "files directory for fs storage backend")
+	flag.StringVar(&config.MetadataBackend, "metadata-backend", DefaultConfig.MetadataBackend, "metadata storage backend: postgres, sqlite, memory or registered one, chosen by database dsn if empty")
 	flag.StringVar(&config.SecretKey, "q", DefaultConfig.SecretKey, "secret key")
 	flag.StringVar(&config.LogLevel, "w", DefaultConfig.LogLevel, "log level")
 	flag.StringVar(&config.AuthTokenFile, "e", DefaultConfig.AuthTokenFile, "server public key path")
+	flag.StringVar(&config.RefreshTokenFile, "refresh-token-file", DefaultConfig.RefreshTokenFile, "client refresh token path")
+	flag.StringVar(&config.AccessTokenTTL, "access-token-ttl", DefaultConfig.AccessTokenTTL, "lifetime of access tokens")
+	flag.StringVar(&config.RefreshTokenTTL, "refresh-token-ttl", DefaultConfig.RefreshTokenTTL, "lifetime of refresh tokens")
+	flag.StringVar(&config.TLSCertFile, "tls-cert", DefaultConfig.TLSCertFile, "server tls certificate path, server listens in plaintext if empty")
+	flag.StringVar(&config.TLSKeyFile, "tls-key", DefaultConfig.TLSKeyFile, "server tls key path")
+	flag.StringVar(&config.TLSClientCAFile, "tls-client-ca", DefaultConfig.TLSClientCAFile, "CA of client certificates, client certificates are not asked if empty")
+	flag.StringVar(&config.PasswordMinLength, "password-min-length", DefaultConfig.PasswordMinLength, "minimal length of user passwords")
+	flag.StringVar(&config.PasswordMinStrength, "password-min-strength", DefaultConfig.PasswordMinStrength, "minimal strength score of user passwords from 0 to 4")
 	flag.StringVar(&config.ServerPublicKeyPath, "r", DefaultConfig.ServerPublicKeyPath, "server public key path")
 	flag.StringVar(&config.ServerPrivateKeyPath, "t", DefaultConfig.ServerPrivateKeyPath, "server private key path")
 	flag.StringVar(&config.ClientPublicKeyPath, "y", DefaultConfig.ClientPublicKeyPath, "client public key path")
 	flag.StringVar(&config.ClientPrivateKeyPath, "u", DefaultConfig.ClientPrivateKeyPath, "client private key path")
+	flag.StringVar(&config.FsckInterval, "fsck-interval", DefaultConfig.FsckInterval, "background storage consistency check interval, disabled if empty")
+	flag.StringVar(&config.FsckGracePeriod, "fsck-grace-period", DefaultConfig.FsckGracePeriod, "age of orphaned blobs and stale files to be repaired")
+	flag.StringVar(&config.FsckRepair, "fsck-repair", DefaultConfig.FsckRepair, "repair storage in background consistency check")
+	flag.StringVar(&config.KeyRotationInterval, "key-rotation-interval", DefaultConfig.KeyRotationInterval, "interval of re-wrapping keys after server key rotation, disabled if empty")
+	flag.StringVar(&config.KeyRotationBatch, "key-rotation-batch", DefaultConfig.KeyRotationBatch, "number of file keys re-wrapped per batch")
+	config.Backends = DefaultConfig.Backends
+	config.Dev = DefaultConfig.Dev
+	config.TLSRequireClientCert = DefaultConfig.TLSRequireClientCert
+	flag.Var(boolStringFlag{value: &config.TLSRequireClientCert}, "tls-require-client-cert", "reject connections without client certificate")
+	config.ApiToken = DefaultConfig.ApiToken
+	config.TLSCAFile = DefaultConfig.TLSCAFile
+	config.TLSSkipVerify = DefaultConfig.TLSSkipVerify
+	config.Plaintext = DefaultConfig.Plaintext
+	flag.Var(boolStringFlag{value: &config.Dev}, "dev", "run with in-memory storages, all data is lost on exit")
 	flag.Parse()
 }
 
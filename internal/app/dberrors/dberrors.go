@@ -0,0 +1,27 @@
+// Package dberrors recognizes errors of database drivers used by storages.
+package dberrors
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	pgxconn "github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Whether error is postgresql unique constraint violation.
+func IsPostgresqlUniqueViolation(err error) bool {
+	if e, ok := err.(*pgconn.PgError); ok {
+		return e.Code == pgerrcode.UniqueViolation
+	}
+	var e *pgxconn.PgError
+	return errors.As(err, &e) && e.Code == pgerrcode.UniqueViolation
+}
+
+// Whether error is sqlite unique or primary key constraint violation.
+func IsSqliteUniqueViolation(err error) bool {
+	var e *sqlite.Error
+	return errors.As(err, &e) && (e.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || e.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE)
+}
@@ -0,0 +1,37 @@
+// Package dbtx lets database storages run on connection pool or inside transaction.
+package dbtx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Error in case storage starts transaction inside transaction.
+var ErrNestedTx = errors.New("nested transactions are not supported")
+
+// Connection pool or transaction storages run queries on, *sql.DB implements it.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	PingContext(ctx context.Context) error
+}
+
+// Transaction used as storage database, e.g. for reads from one snapshot.
+//
+// Storage methods which start own transaction fail with ErrNestedTx.
+type Tx struct {
+	*sql.Tx
+}
+
+func (Tx) BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error) {
+	return nil, ErrNestedTx
+}
+
+// Checks that connection of transaction is alive.
+func (t Tx) PingContext(ctx context.Context) error {
+	var one int
+	return t.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
@@ -0,0 +1,252 @@
+// Package emergency contains state machine of emergency access given to trusted contacts.
+//
+// Grantor invites contact, contact accepts invitation with its public key, grantor confirms it by wrapping
+// keys of personal files for that key. Contact may then request access, grantor is notified through audit log
+// and gets access approved early or rejected, unless rejected access is granted after waiting period.
+package emergency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
+	"go.uber.org/zap"
+)
+
+// Statuses of emergency access in order of transitions.
+const (
+	// Contact is invited and has not accepted invitation yet.
+	StatusInvited = "invited"
+
+	// Contact accepted invitation and gave its public key.
+	StatusAccepted = "accepted"
+
+	// Grantor wrapped file keys for contact, contact may request access.
+	StatusConfirmed = "confirmed"
+
+	// Contact requested access, grantor may reject it within waiting period.
+	StatusRequested = "requested"
+
+	// Contact may list and download files of grantor.
+	StatusApproved = "approved"
+)
+
+var (
+	// Error in case user is not the side of emergency access allowed to perform action.
+	ErrDenied = errors.New("access denied")
+
+	// Error in case action is not allowed in current status of emergency access.
+	ErrWrongStatus = errors.New("action is not allowed in current status of emergency access")
+
+	// Error in case user invites himself.
+	ErrSelfInvite = errors.New("can't invite yourself")
+
+	// Error in case waiting period is not positive or too long.
+	ErrWrongWaitPeriod = errors.New("waiting period should be positive and at most a year")
+
+	// Error in case contact accepts invitation without public key.
+	ErrNoPublicKey = errors.New("public key is required")
+)
+
+// Longest waiting period of emergency access.
+const MaxWaitPeriod = 365 * 24 * time.Hour
+
+// Emergency access state machine over grant storage.
+type Manager struct {
+	Grants emergencystorage.EmergencyStorage
+}
+
+// New manager of grants from storage.
+func NewManager(grants emergencystorage.EmergencyStorage) *Manager {
+	return &Manager{Grants: grants}
+}
+
+// Returns status of grant at given time, requested access is approved after waiting period.
+func Status(grant emergencystorage.Grant, now time.Time) string {
+	if grant.Status == StatusRequested && !now.Before(grant.Requested.Add(grant.WaitPeriod)) {
+		return StatusApproved
+	}
+	return grant.Status
+}
+
+// Returns grant with current status.
+func withStatus(grant emergencystorage.Grant) emergencystorage.Grant {
+	grant.Status = Status(grant, time.Now())
+	return grant
+}
+
+// Returns grant if user is its grantor or grantee depending on asGrantor.
+func (m *Manager) getGrant(ctx context.Context, login string, id string, asGrantor bool) (*emergencystorage.Grant, error) {
+	grant, err := m.Grants.GetGrant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if asGrantor && grant.Grantor != login || !asGrantor && grant.Grantee != login {
+		return nil, emergencystorage.ErrGrantNotFound
+	}
+	return grant, nil
+}
+
+// Moves grant from one status to other checking that nobody changed it meanwhile.
+func (m *Manager) transit(ctx context.Context, grant emergencystorage.Grant, from []string, to string) (*emergencystorage.Grant, error) {
+	stored := grant.Status
+	current := Status(grant, time.Now())
+	allowed := false
+	for _, status := range from {
+		allowed = allowed || current == status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s", ErrWrongStatus, current)
+	}
+	grant.Status = to
+	if err := m.Grants.UpdateGrant(ctx, grant, stored); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// Invites contact to emergency access of grantor personal files.
+func (m *Manager) Invite(ctx context.Context, grantor string, grantee string, waitPeriod time.Duration) (*emergencystorage.Grant, error) {
+	if grantor == grantee {
+		return nil, ErrSelfInvite
+	}
+	if waitPeriod <= 0 || waitPeriod > MaxWaitPeriod {
+		return nil, ErrWrongWaitPeriod
+	}
+	grant := emergencystorage.Grant{ID: uuid.NewString(), Grantor: grantor, Grantee: grantee, WaitPeriod: waitPeriod,
+		Status: StatusInvited, Created: time.Now()}
+	if err := m.Grants.AddGrant(ctx, grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// Returns grants given by user or to user with current statuses.
+func (m *Manager) List(ctx context.Context, login string) ([]emergencystorage.Grant, error) {
+	grants, err := m.Grants.ListGrants(ctx, login)
+	if err != nil {
+		return nil, err
+	}
+	for i := range grants {
+		grants[i] = withStatus(grants[i])
+	}
+	return grants, nil
+}
+
+// Accepts invitation by contact, file keys will be wrapped for given public key.
+func (m *Manager) Accept(ctx context.Context, grantee string, id string, publicKey []byte) (*emergencystorage.Grant, error) {
+	if len(publicKey) == 0 {
+		return nil, ErrNoPublicKey
+	}
+	grant, err := m.getGrant(ctx, grantee, id, false)
+	if err != nil {
+		return nil, err
+	}
+	grant.PublicKey = publicKey
+	return m.transit(ctx, *grant, []string{StatusInvited}, StatusAccepted)
+}
+
+// Returns grant of grantor waiting for file keys, keys may be updated until grant is revoked.
+func (m *Manager) ConfirmableGrant(ctx context.Context, grantor string, id string) (*emergencystorage.Grant, error) {
+	grant, err := m.getGrant(ctx, grantor, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if grant.Status == StatusInvited {
+		return nil, fmt.Errorf("%w: %s", ErrWrongStatus, grant.Status)
+	}
+	return grant, nil
+}
+
+// Replaces file keys wrapped by grantor for contact, accepted grant becomes confirmed.
+// Keys are kept only if grant was not revoked or changed meanwhile.
+func (m *Manager) Confirm(ctx context.Context, grantor string, id string, keys []emergencystorage.Key) (*emergencystorage.Grant, error) {
+	grant, err := m.ConfirmableGrant(ctx, grantor, id)
+	if err != nil {
+		return nil, err
+	}
+	stored := grant.Status
+	if stored == StatusAccepted {
+		grant.Status = StatusConfirmed
+	}
+	if err = m.Grants.ConfirmGrant(ctx, *grant, stored, keys); err != nil {
+		return nil, err
+	}
+	confirmed := withStatus(*grant)
+	return &confirmed, nil
+}
+
+// Requests access by contact, grantor is notified through audit log.
+func (m *Manager) Request(ctx context.Context, grantee string, id string) (*emergencystorage.Grant, error) {
+	grant, err := m.getGrant(ctx, grantee, id, false)
+	if err != nil {
+		return nil, err
+	}
+	grant.Requested = time.Now()
+	requested, err := m.transit(ctx, *grant, []string{StatusConfirmed}, StatusRequested)
+	if err != nil {
+		return nil, err
+	}
+	logger.Audit("emergency_access_requested", zap.String("id", id), zap.String("grantor", grant.Grantor),
+		zap.String("grantee", grantee), zap.Time("granted", grant.Requested.Add(grant.WaitPeriod)))
+	return requested, nil
+}
+
+// Approves requested access by grantor before waiting period ends.
+func (m *Manager) Approve(ctx context.Context, grantor string, id string) (*emergencystorage.Grant, error) {
+	grant, err := m.getGrant(ctx, grantor, id, true)
+	if err != nil {
+		return nil, err
+	}
+	approved, err := m.transit(ctx, *grant, []string{StatusRequested}, StatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	logger.Audit("emergency_access_approved", zap.String("id", id), zap.String("grantor", grantor),
+		zap.String("grantee", grant.Grantee))
+	return approved, nil
+}
+
+// Rejects requested or approved access by grantor, contact may request it again later.
+func (m *Manager) Reject(ctx context.Context, grantor string, id string) (*emergencystorage.Grant, error) {
+	grant, err := m.getGrant(ctx, grantor, id, true)
+	if err != nil {
+		return nil, err
+	}
+	grant.Requested = time.Time{}
+	rejected, err := m.transit(ctx, *grant, []string{StatusRequested, StatusApproved}, StatusConfirmed)
+	if err != nil {
+		return nil, err
+	}
+	logger.Audit("emergency_access_rejected", zap.String("id", id), zap.String("grantor", grantor),
+		zap.String("grantee", grant.Grantee))
+	return rejected, nil
+}
+
+// Revokes grant by grantor or declines it by contact.
+func (m *Manager) Revoke(ctx context.Context, login string, id string) error {
+	grant, err := m.Grants.GetGrant(ctx, id)
+	if err != nil {
+		return err
+	}
+	if grant.Grantor != login && grant.Grantee != login {
+		return emergencystorage.ErrGrantNotFound
+	}
+	return m.Grants.DeleteGrant(ctx, id)
+}
+
+// Returns approved grant of contact, ErrDenied if access is not approved.
+func (m *Manager) ApprovedGrant(ctx context.Context, grantee string, id string) (*emergencystorage.Grant, error) {
+	grant, err := m.getGrant(ctx, grantee, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if Status(*grant, time.Now()) != StatusApproved {
+		return nil, ErrDenied
+	}
+	return grant, nil
+}
@@ -0,0 +1,78 @@
+package emergency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		grant emergencystorage.Grant
+		want  string
+	}{
+		{name: "confirmed", grant: emergencystorage.Grant{Status: StatusConfirmed, WaitPeriod: time.Hour}, want: StatusConfirmed},
+		{name: "waiting", grant: emergencystorage.Grant{Status: StatusRequested, WaitPeriod: time.Hour,
+			Requested: now.Add(-time.Minute)}, want: StatusRequested},
+		{name: "waited", grant: emergencystorage.Grant{Status: StatusRequested, WaitPeriod: time.Hour,
+			Requested: now.Add(-time.Hour)}, want: StatusApproved},
+		{name: "approved", grant: emergencystorage.Grant{Status: StatusApproved, WaitPeriod: time.Hour,
+			Requested: now}, want: StatusApproved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Status(tt.grant, now))
+		})
+	}
+}
+
+func TestManager_WaitingPeriod(t *testing.T) {
+	ctx := context.Background()
+	storage := emergencystorage.NewMemoryEmergencyStorage()
+	manager := NewManager(storage)
+
+	_, err := manager.Invite(ctx, "grantor", "contact", 0)
+	require.ErrorIs(t, err, ErrWrongWaitPeriod)
+	_, err = manager.Invite(ctx, "grantor", "contact", MaxWaitPeriod+time.Second)
+	require.ErrorIs(t, err, ErrWrongWaitPeriod)
+	grant, err := manager.Invite(ctx, "grantor", "contact", time.Hour)
+	require.NoError(t, err)
+	_, err = manager.Confirm(ctx, "grantor", grant.ID, nil)
+	require.ErrorIs(t, err, ErrWrongStatus)
+	_, err = manager.Accept(ctx, "contact", grant.ID, nil)
+	require.ErrorIs(t, err, ErrNoPublicKey)
+	_, err = manager.Accept(ctx, "contact", grant.ID, []byte("key"))
+	require.NoError(t, err)
+	_, err = manager.Confirm(ctx, "grantor", grant.ID, []emergencystorage.Key{{FileID: "file", Key: []byte("file key")}})
+	require.NoError(t, err)
+	_, err = manager.Request(ctx, "contact", grant.ID)
+	require.NoError(t, err)
+	_, err = manager.ApprovedGrant(ctx, "contact", grant.ID)
+	require.ErrorIs(t, err, ErrDenied)
+
+	// Access is granted when waiting period ends without rejection.
+	requested, err := storage.GetGrant(ctx, grant.ID)
+	require.NoError(t, err)
+	requested.Requested = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, storage.UpdateGrant(ctx, *requested, StatusRequested))
+	_, err = manager.ApprovedGrant(ctx, "contact", grant.ID)
+	require.NoError(t, err)
+	grants, err := manager.List(ctx, "grantor")
+	require.NoError(t, err)
+	require.Equal(t, StatusApproved, grants[0].Status)
+	_, err = manager.Request(ctx, "contact", grant.ID)
+	require.ErrorIs(t, err, ErrWrongStatus)
+
+	// Grantor still may withdraw granted access.
+	_, err = manager.Reject(ctx, "grantor", grant.ID)
+	require.NoError(t, err)
+	_, err = manager.ApprovedGrant(ctx, "contact", grant.ID)
+	require.ErrorIs(t, err, ErrDenied)
+	require.ErrorIs(t, manager.Revoke(ctx, "stranger", grant.ID), emergencystorage.ErrGrantNotFound)
+	require.NoError(t, manager.Revoke(ctx, "contact", grant.ID))
+}
@@ -0,0 +1,84 @@
+// Package emergencystorage for storing emergency access of trusted contacts.
+package emergencystorage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// Error in case grant is absent or was revoked.
+	ErrGrantNotFound = errors.New("emergency access not found")
+
+	// Error in case contact already has emergency access of grantor.
+	ErrGrantExists = errors.New("emergency access for contact already exists")
+
+	// Error in case status of grant was changed by concurrent request.
+	ErrStatusChanged = errors.New("emergency access status has changed")
+
+	// Error in case file key was not wrapped for contact.
+	ErrKeyNotFound = errors.New("file key not found")
+)
+
+// Emergency access of trusted contact to personal files of grantor.
+//
+// Contact gets access after requesting it unless grantor rejects the request within waiting period.
+type Grant struct {
+	ID         string
+	Grantor    string
+	Grantee    string
+	WaitPeriod time.Duration
+	Status     string
+
+	// Public key of contact file keys are wrapped for, empty until contact accepts invitation.
+	PublicKey []byte
+
+	Created time.Time
+
+	// Time of access request, zero if access was not requested.
+	Requested time.Time
+}
+
+// File key wrapped by grantor for public key of contact.
+type Key struct {
+	GrantID string
+	FileID  string
+	Key     []byte
+}
+
+// Storage of emergency access grants, revoked grant is deleted with its keys.
+//
+//go:generate mockery --name EmergencyStorage
+type EmergencyStorage interface {
+	// Method for adding new grant, returns ErrGrantExists if contact already has grant of grantor.
+	AddGrant(ctx context.Context, grant Grant) error
+
+	// Method for getting grant by id.
+	GetGrant(ctx context.Context, id string) (*Grant, error)
+
+	// Method for getting grants given by user or to user ordered by creation time.
+	ListGrants(ctx context.Context, login string) ([]Grant, error)
+
+	// Method for replacing status, public key and request time of grant, returns ErrStatusChanged unless grant has given status.
+	UpdateGrant(ctx context.Context, grant Grant, status string) error
+
+	// Method for deleting grant with its keys.
+	DeleteGrant(ctx context.Context, id string) error
+
+	// Method for deleting grants given by user or to user, returns number of deleted grants.
+	DeleteUserGrants(ctx context.Context, login string) (int64, error)
+
+	// Method for replacing all file keys of grant.
+	SetKeys(ctx context.Context, grantID string, keys []Key) error
+
+	// Method for replacing file keys of grant and updating it like UpdateGrant in one transaction,
+	// returns ErrStatusChanged unless grant has given status.
+	ConfirmGrant(ctx context.Context, grant Grant, status string, keys []Key) error
+
+	// Method for getting file keys of grant ordered by file id.
+	ListKeys(ctx context.Context, grantID string) ([]Key, error)
+
+	// Method for getting file key of grant.
+	GetKey(ctx context.Context, grantID string, fileID string) ([]byte, error)
+}
@@ -0,0 +1,173 @@
+package emergencystorage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Stores emergency access grants in memory, all grants are lost on restart.
+type MemoryEmergencyStorage struct {
+	mu     sync.Mutex
+	grants map[string]Grant
+	keys   map[string]map[string][]byte
+}
+
+// New in-memory emergency access storage.
+func NewMemoryEmergencyStorage() *MemoryEmergencyStorage {
+	return &MemoryEmergencyStorage{grants: make(map[string]Grant), keys: make(map[string]map[string][]byte)}
+}
+
+func copyGrant(grant Grant) Grant {
+	grant.PublicKey = slices.Clone(grant.PublicKey)
+	return grant
+}
+
+// Add new grant.
+func (s *MemoryEmergencyStorage) AddGrant(_ context.Context, grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stored := range s.grants {
+		if stored.Grantor == grant.Grantor && stored.Grantee == grant.Grantee {
+			return ErrGrantExists
+		}
+	}
+	s.grants[grant.ID] = copyGrant(grant)
+	return nil
+}
+
+// Get grant by id.
+func (s *MemoryEmergencyStorage) GetGrant(_ context.Context, id string) (*Grant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grant, ok := s.grants[id]
+	if !ok {
+		return nil, ErrGrantNotFound
+	}
+	grant = copyGrant(grant)
+	return &grant, nil
+}
+
+// Get grants given by user or to user.
+func (s *MemoryEmergencyStorage) ListGrants(_ context.Context, login string) ([]Grant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grants := make([]Grant, 0)
+	for _, grant := range s.grants {
+		if grant.Grantor == login || grant.Grantee == login {
+			grants = append(grants, copyGrant(grant))
+		}
+	}
+	slices.SortFunc(grants, func(a, b Grant) int {
+		if c := a.Created.Compare(b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return grants, nil
+}
+
+// Update grant having given status.
+func (s *MemoryEmergencyStorage) UpdateGrant(_ context.Context, grant Grant, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateGrant(grant, status)
+}
+
+func (s *MemoryEmergencyStorage) updateGrant(grant Grant, status string) error {
+	stored, ok := s.grants[grant.ID]
+	if !ok {
+		return ErrGrantNotFound
+	}
+	if stored.Status != status {
+		return ErrStatusChanged
+	}
+	stored.Status = grant.Status
+	stored.PublicKey = slices.Clone(grant.PublicKey)
+	stored.Requested = grant.Requested
+	s.grants[grant.ID] = stored
+	return nil
+}
+
+// Delete grant with keys.
+func (s *MemoryEmergencyStorage) DeleteGrant(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.grants[id]; !ok {
+		return ErrGrantNotFound
+	}
+	delete(s.grants, id)
+	delete(s.keys, id)
+	return nil
+}
+
+// Delete grants given by user or to user.
+func (s *MemoryEmergencyStorage) DeleteUserGrants(_ context.Context, login string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := int64(0)
+	for id, grant := range s.grants {
+		if grant.Grantor == login || grant.Grantee == login {
+			delete(s.grants, id)
+			delete(s.keys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Replace file keys of grant.
+func (s *MemoryEmergencyStorage) SetKeys(_ context.Context, grantID string, keys []Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.grants[grantID]; !ok {
+		return ErrGrantNotFound
+	}
+	s.setKeys(grantID, keys)
+	return nil
+}
+
+// Replace file keys of grant and update grant if it has given status.
+func (s *MemoryEmergencyStorage) ConfirmGrant(_ context.Context, grant Grant, status string, keys []Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.updateGrant(grant, status); err != nil {
+		return err
+	}
+	s.setKeys(grant.ID, keys)
+	return nil
+}
+
+func (s *MemoryEmergencyStorage) setKeys(grantID string, keys []Key) {
+	grantKeys := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		grantKeys[key.FileID] = slices.Clone(key.Key)
+	}
+	s.keys[grantID] = grantKeys
+}
+
+// Get file keys of grant.
+func (s *MemoryEmergencyStorage) ListKeys(_ context.Context, grantID string) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]Key, 0, len(s.keys[grantID]))
+	for fileID, key := range s.keys[grantID] {
+		keys = append(keys, Key{GrantID: grantID, FileID: fileID, Key: slices.Clone(key)})
+	}
+	slices.SortFunc(keys, func(a, b Key) int {
+		return strings.Compare(a.FileID, b.FileID)
+	})
+	return keys, nil
+}
+
+// Get file key of grant.
+func (s *MemoryEmergencyStorage) GetKey(_ context.Context, grantID string, fileID string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[grantID][fileID]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return slices.Clone(key), nil
+}
@@ -0,0 +1,288 @@
+package emergencystorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dberrors"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores emergency access grants in postgresql.
+type PostgresqlEmergencyStorage struct {
+	DB dbtx.DB
+}
+
+// New postgresql emergency access storage.
+func NewPostgresqlEmergencyStorage(db dbtx.DB) *PostgresqlEmergencyStorage {
+	return &PostgresqlEmergencyStorage{DB: db}
+}
+
+const grantColumns = "id, grantor, grantee, wait_period, status, public_key, created, requested"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPostgresqlGrant(row scanner) (*Grant, error) {
+	var grant Grant
+	var waitPeriod int64
+	var requested sql.NullTime
+	err := row.Scan(&grant.ID, &grant.Grantor, &grant.Grantee, &waitPeriod, &grant.Status, &grant.PublicKey,
+		&grant.Created, &requested)
+	if err != nil {
+		return nil, err
+	}
+	grant.WaitPeriod = time.Duration(waitPeriod) * time.Second
+	if len(grant.PublicKey) == 0 {
+		grant.PublicKey = nil
+	}
+	grant.Requested = requested.Time
+	return &grant, nil
+}
+
+// Public key column is not null, absent key is stored empty.
+func nonNilKey(key []byte) []byte {
+	if key == nil {
+		return []byte{}
+	}
+	return key
+}
+
+func postgresqlRequested(grant Grant) sql.NullTime {
+	if grant.Requested.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: grant.Requested.UTC(), Valid: true}
+}
+
+// Add new grant.
+func (s *PostgresqlEmergencyStorage) AddGrant(ctx context.Context, grant Grant) error {
+	_, err := s.DB.ExecContext(ctx, "INSERT into emergency_grants ("+grantColumns+") VALUES($1, $2, $3, $4, $5, $6, $7, $8)",
+		grant.ID, grant.Grantor, grant.Grantee, int64(grant.WaitPeriod.Seconds()), grant.Status, nonNilKey(grant.PublicKey),
+		grant.Created.UTC(), postgresqlRequested(grant))
+	if dberrors.IsPostgresqlUniqueViolation(err) {
+		return ErrGrantExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add emergency access: %w", err)
+	}
+	return nil
+}
+
+// Get grant by id.
+func (s *PostgresqlEmergencyStorage) GetGrant(ctx context.Context, id string) (*Grant, error) {
+	grant, err := scanPostgresqlGrant(s.DB.QueryRowContext(ctx, "SELECT "+grantColumns+" FROM emergency_grants WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGrantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency access: %w", err)
+	}
+	return grant, nil
+}
+
+// Get grants given by user or to user.
+func (s *PostgresqlEmergencyStorage) ListGrants(ctx context.Context, login string) ([]Grant, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT "+grantColumns+" FROM emergency_grants WHERE grantor = $1 OR grantee = $1 ORDER BY created, id", login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emergency access: %w", err)
+	}
+	defer rows.Close()
+	grants := make([]Grant, 0)
+	for rows.Next() {
+		grant, err := scanPostgresqlGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list emergency access: %w", err)
+		}
+		grants = append(grants, *grant)
+	}
+	return grants, rows.Err()
+}
+
+// Update grant having given status.
+func (s *PostgresqlEmergencyStorage) UpdateGrant(ctx context.Context, grant Grant, status string) error {
+	res, err := s.DB.ExecContext(ctx,
+		"UPDATE emergency_grants SET status = $3, public_key = $4, requested = $5 WHERE id = $1 AND status = $2",
+		grant.ID, status, grant.Status, nonNilKey(grant.PublicKey), postgresqlRequested(grant))
+	return checkUpdated(ctx, s.DB, grant.ID, res, err)
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Returns ErrStatusChanged if update changed no rows of existing grant.
+func checkUpdated(ctx context.Context, db querier, id string, res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to update emergency access: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected > 0 {
+		return nil
+	}
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM emergency_grants WHERE id = $1)", id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to update emergency access: %w", err)
+	}
+	if !exists {
+		return ErrGrantNotFound
+	}
+	return ErrStatusChanged
+}
+
+// Delete grant with keys.
+func (s *PostgresqlEmergencyStorage) DeleteGrant(ctx context.Context, id string) error {
+	return deleteGrant(ctx, s.DB, id)
+}
+
+func deleteGrant(ctx context.Context, db dbtx.DB, id string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM emergency_keys WHERE grant_id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete file keys: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM emergency_grants WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete emergency access: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrGrantNotFound
+	}
+	return tx.Commit()
+}
+
+// Delete grants given by user or to user.
+func (s *PostgresqlEmergencyStorage) DeleteUserGrants(ctx context.Context, login string) (int64, error) {
+	return deleteUserGrants(ctx, s.DB, login)
+}
+
+func deleteUserGrants(ctx context.Context, db dbtx.DB, login string) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, "DELETE FROM emergency_keys WHERE grant_id IN "+
+		"(SELECT id FROM emergency_grants WHERE grantor = $1 OR grantee = $1)", login)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete file keys: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM emergency_grants WHERE grantor = $1 OR grantee = $1", login)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete emergency access: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// Replace file keys of grant.
+func (s *PostgresqlEmergencyStorage) SetKeys(ctx context.Context, grantID string, keys []Key) error {
+	return setKeys(ctx, s.DB, grantID, keys)
+}
+
+// Replace file keys of grant and update grant if it has given status.
+func (s *PostgresqlEmergencyStorage) ConfirmGrant(ctx context.Context, grant Grant, status string, keys []Key) error {
+	return confirmGrant(ctx, s.DB, grant, status, postgresqlRequested(grant), keys)
+}
+
+// Updated row stays locked until keys are replaced, so concurrent revoke waits for transaction.
+func confirmGrant(ctx context.Context, db dbtx.DB, grant Grant, status string, requested any, keys []Key) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		"UPDATE emergency_grants SET status = $3, public_key = $4, requested = $5 WHERE id = $1 AND status = $2",
+		grant.ID, status, grant.Status, nonNilKey(grant.PublicKey), requested)
+	if err = checkUpdated(ctx, tx, grant.ID, res, err); err != nil {
+		return err
+	}
+	if err = replaceKeys(ctx, tx, grant.ID, keys); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setKeys(ctx context.Context, db dbtx.DB, grantID string, keys []Key) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM emergency_grants WHERE id = $1)", grantID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to set file keys: %w", err)
+	}
+	if !exists {
+		return ErrGrantNotFound
+	}
+	if err = replaceKeys(ctx, tx, grantID, keys); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceKeys(ctx context.Context, tx *sql.Tx, grantID string, keys []Key) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM emergency_keys WHERE grant_id = $1", grantID); err != nil {
+		return fmt.Errorf("failed to set file keys: %w", err)
+	}
+	for _, key := range keys {
+		_, err := tx.ExecContext(ctx, "INSERT into emergency_keys (grant_id, file_id, key) VALUES($1, $2, $3)",
+			grantID, key.FileID, key.Key)
+		if err != nil {
+			return fmt.Errorf("failed to set file keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// Get file keys of grant.
+func (s *PostgresqlEmergencyStorage) ListKeys(ctx context.Context, grantID string) ([]Key, error) {
+	return listKeys(ctx, s.DB, grantID)
+}
+
+func listKeys(ctx context.Context, db dbtx.DB, grantID string) ([]Key, error) {
+	rows, err := db.QueryContext(ctx, "SELECT grant_id, file_id, key FROM emergency_keys WHERE grant_id = $1 ORDER BY file_id", grantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file keys: %w", err)
+	}
+	defer rows.Close()
+	keys := make([]Key, 0)
+	for rows.Next() {
+		var key Key
+		if err := rows.Scan(&key.GrantID, &key.FileID, &key.Key); err != nil {
+			return nil, fmt.Errorf("failed to list file keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Get file key of grant.
+func (s *PostgresqlEmergencyStorage) GetKey(ctx context.Context, grantID string, fileID string) ([]byte, error) {
+	return getKey(ctx, s.DB, grantID, fileID)
+}
+
+func getKey(ctx context.Context, db dbtx.DB, grantID string, fileID string) ([]byte, error) {
+	var key []byte
+	err := db.QueryRowContext(ctx, "SELECT key FROM emergency_keys WHERE grant_id = $1 AND file_id = $2", grantID, fileID).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file key: %w", err)
+	}
+	return key, nil
+}
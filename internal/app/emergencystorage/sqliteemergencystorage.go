@@ -0,0 +1,132 @@
+package emergencystorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dberrors"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores emergency access grants in embedded sqlite database, times are stored as unix seconds.
+type SqliteEmergencyStorage struct {
+	DB dbtx.DB
+}
+
+// New sqlite emergency access storage.
+func NewSqliteEmergencyStorage(db dbtx.DB) *SqliteEmergencyStorage {
+	return &SqliteEmergencyStorage{DB: db}
+}
+
+func scanSqliteGrant(row scanner) (*Grant, error) {
+	var grant Grant
+	var waitPeriod, created int64
+	var requested sql.NullInt64
+	err := row.Scan(&grant.ID, &grant.Grantor, &grant.Grantee, &waitPeriod, &grant.Status, &grant.PublicKey,
+		&created, &requested)
+	if err != nil {
+		return nil, err
+	}
+	grant.WaitPeriod = time.Duration(waitPeriod) * time.Second
+	if len(grant.PublicKey) == 0 {
+		grant.PublicKey = nil
+	}
+	grant.Created = time.Unix(created, 0).UTC()
+	if requested.Valid {
+		grant.Requested = time.Unix(requested.Int64, 0).UTC()
+	}
+	return &grant, nil
+}
+
+func sqliteRequested(grant Grant) sql.NullInt64 {
+	if grant.Requested.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: grant.Requested.Unix(), Valid: true}
+}
+
+// Add new grant.
+func (s *SqliteEmergencyStorage) AddGrant(ctx context.Context, grant Grant) error {
+	_, err := s.DB.ExecContext(ctx, "INSERT into emergency_grants ("+grantColumns+") VALUES($1, $2, $3, $4, $5, $6, $7, $8)",
+		grant.ID, grant.Grantor, grant.Grantee, int64(grant.WaitPeriod.Seconds()), grant.Status, nonNilKey(grant.PublicKey),
+		grant.Created.Unix(), sqliteRequested(grant))
+	if dberrors.IsSqliteUniqueViolation(err) {
+		return ErrGrantExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add emergency access: %w", err)
+	}
+	return nil
+}
+
+// Get grant by id.
+func (s *SqliteEmergencyStorage) GetGrant(ctx context.Context, id string) (*Grant, error) {
+	grant, err := scanSqliteGrant(s.DB.QueryRowContext(ctx, "SELECT "+grantColumns+" FROM emergency_grants WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGrantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emergency access: %w", err)
+	}
+	return grant, nil
+}
+
+// Get grants given by user or to user.
+func (s *SqliteEmergencyStorage) ListGrants(ctx context.Context, login string) ([]Grant, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT "+grantColumns+" FROM emergency_grants WHERE grantor = $1 OR grantee = $1 ORDER BY created, id", login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emergency access: %w", err)
+	}
+	defer rows.Close()
+	grants := make([]Grant, 0)
+	for rows.Next() {
+		grant, err := scanSqliteGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list emergency access: %w", err)
+		}
+		grants = append(grants, *grant)
+	}
+	return grants, rows.Err()
+}
+
+// Update grant having given status.
+func (s *SqliteEmergencyStorage) UpdateGrant(ctx context.Context, grant Grant, status string) error {
+	res, err := s.DB.ExecContext(ctx,
+		"UPDATE emergency_grants SET status = $3, public_key = $4, requested = $5 WHERE id = $1 AND status = $2",
+		grant.ID, status, grant.Status, nonNilKey(grant.PublicKey), sqliteRequested(grant))
+	return checkUpdated(ctx, s.DB, grant.ID, res, err)
+}
+
+// Delete grant with keys.
+func (s *SqliteEmergencyStorage) DeleteGrant(ctx context.Context, id string) error {
+	return deleteGrant(ctx, s.DB, id)
+}
+
+// Delete grants given by user or to user.
+func (s *SqliteEmergencyStorage) DeleteUserGrants(ctx context.Context, login string) (int64, error) {
+	return deleteUserGrants(ctx, s.DB, login)
+}
+
+// Replace file keys of grant.
+func (s *SqliteEmergencyStorage) SetKeys(ctx context.Context, grantID string, keys []Key) error {
+	return setKeys(ctx, s.DB, grantID, keys)
+}
+
+// Replace file keys of grant and update grant if it has given status.
+func (s *SqliteEmergencyStorage) ConfirmGrant(ctx context.Context, grant Grant, status string, keys []Key) error {
+	return confirmGrant(ctx, s.DB, grant, status, sqliteRequested(grant), keys)
+}
+
+// Get file keys of grant.
+func (s *SqliteEmergencyStorage) ListKeys(ctx context.Context, grantID string) ([]Key, error) {
+	return listKeys(ctx, s.DB, grantID)
+}
+
+// Get file key of grant.
+func (s *SqliteEmergencyStorage) GetKey(ctx context.Context, grantID string, fileID string) ([]byte, error) {
+	return getKey(ctx, s.DB, grantID, fileID)
+}
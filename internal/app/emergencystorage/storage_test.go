@@ -0,0 +1,121 @@
+package emergencystorage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every emergency access storage implementation must follow.
+func testEmergencyStorage(t *testing.T, storage EmergencyStorage) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	alice, bob, carol := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	first := Grant{ID: uuid.NewString(), Grantor: alice, Grantee: bob, WaitPeriod: 48 * time.Hour, Status: "invited", Created: now}
+	second := Grant{ID: uuid.NewString(), Grantor: carol, Grantee: alice, WaitPeriod: time.Hour, Status: "accepted",
+		PublicKey: []byte("key"), Created: now.Add(time.Second)}
+	require.NoError(t, storage.AddGrant(ctx, first))
+	require.NoError(t, storage.AddGrant(ctx, second))
+	require.ErrorIs(t, storage.AddGrant(ctx, Grant{ID: uuid.NewString(), Grantor: alice, Grantee: bob, Status: "invited", Created: now}),
+		ErrGrantExists)
+
+	got, err := storage.GetGrant(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first, *got)
+	_, err = storage.GetGrant(ctx, uuid.NewString())
+	require.ErrorIs(t, err, ErrGrantNotFound)
+	grants, err := storage.ListGrants(ctx, alice)
+	require.NoError(t, err)
+	require.Equal(t, []Grant{first, second}, grants)
+	grants, err = storage.ListGrants(ctx, bob)
+	require.NoError(t, err)
+	require.Equal(t, []Grant{first}, grants)
+
+	// Grant is updated only from expected status.
+	requested := first
+	requested.Status = "requested"
+	requested.PublicKey = []byte("bob key")
+	requested.Requested = now.Add(time.Minute)
+	require.NoError(t, storage.UpdateGrant(ctx, requested, "invited"))
+	require.ErrorIs(t, storage.UpdateGrant(ctx, requested, "invited"), ErrStatusChanged)
+	require.ErrorIs(t, storage.UpdateGrant(ctx, Grant{ID: uuid.NewString()}, "invited"), ErrGrantNotFound)
+	got, err = storage.GetGrant(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, requested, *got)
+
+	keys := []Key{{GrantID: first.ID, FileID: "b", Key: []byte("b key")}, {GrantID: first.ID, FileID: "a", Key: []byte("a key")}}
+	require.NoError(t, storage.SetKeys(ctx, first.ID, keys))
+	require.NoError(t, storage.SetKeys(ctx, first.ID, keys))
+	require.ErrorIs(t, storage.SetKeys(ctx, uuid.NewString(), keys), ErrGrantNotFound)
+	listed, err := storage.ListKeys(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, []Key{keys[1], keys[0]}, listed)
+
+	// Keys of grant changed meanwhile are left untouched.
+	confirmed := requested
+	confirmed.Status = "confirmed"
+	require.ErrorIs(t, storage.ConfirmGrant(ctx, confirmed, "invited", keys[:1]), ErrStatusChanged)
+	require.ErrorIs(t, storage.ConfirmGrant(ctx, Grant{ID: uuid.NewString()}, "invited", keys), ErrGrantNotFound)
+	listed, err = storage.ListKeys(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, []Key{keys[1], keys[0]}, listed)
+	require.NoError(t, storage.ConfirmGrant(ctx, confirmed, "requested", keys))
+	got, err = storage.GetGrant(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, confirmed, *got)
+	key, err := storage.GetKey(ctx, first.ID, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("a key"), key)
+	_, err = storage.GetKey(ctx, second.ID, "a")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, storage.DeleteGrant(ctx, first.ID))
+	require.ErrorIs(t, storage.DeleteGrant(ctx, first.ID), ErrGrantNotFound)
+	listed, err = storage.ListKeys(ctx, first.ID)
+	require.NoError(t, err)
+	require.Empty(t, listed, "keys should be deleted with grant")
+
+	deleted, err := storage.DeleteUserGrants(ctx, alice)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	grants, err = storage.ListGrants(ctx, carol)
+	require.NoError(t, err)
+	require.Empty(t, grants)
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteEmergencyStorage(t *testing.T) {
+	testEmergencyStorage(t, NewSqliteEmergencyStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlEmergencyStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	testEmergencyStorage(t, NewPostgresqlEmergencyStorage(openTestDB(t, "pgx", dsn, migrations.Postgres)))
+}
+
+func TestMemoryEmergencyStorage(t *testing.T) {
+	testEmergencyStorage(t, NewMemoryEmergencyStorage())
+}
@@ -14,24 +14,34 @@ import (
 	"os"
 
 	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	"golang.org/x/crypto/scrypt"
 )
 
 // Singleton variables for pem keys.
 var (
-	serverPrivateKey *[]byte = nil
-	serverPublicKey  *[]byte = nil
 	clientPrivateKey *[]byte = nil
 	clientPublicKey  *[]byte = nil
 	appConfig                = config.DefaultConfig
 )
 
+// Sizes of generated rsa keys, server key wraps keys of all files and is larger.
+const (
+	clientKeyBits = 1024
+	serverKeyBits = 2048
+)
+
 // Generate private, public rsa keys.
-func getRsaKeys() ([]byte, []byte, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+func getRsaKeys(bits int) ([]byte, []byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot generate rsa key: %w", err)
 	}
 
+	return encodeRsaKeys(privateKey)
+}
+
+// Encodes private, public rsa keys in pem.
+func encodeRsaKeys(privateKey *rsa.PrivateKey) ([]byte, []byte, error) {
 	privateKeyDER := x509.MarshalPKCS1PrivateKey(privateKey)
 	privateKeyBlock := &pem.Block{
 		Type:  "RSA PRIVATE KEY",
@@ -54,6 +64,32 @@ func getRsaKeys() ([]byte, []byte, error) {
 	return privateKeyPEM, publicKeyPEM, nil
 }
 
+// Returns der of pem private rsa key, e.g. to split it into recovery shares.
+func PrivateKeyDER(privateKeyPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("cannot decode private rsa key")
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+		return nil, fmt.Errorf("cannot parse private rsa key: %w", err)
+	}
+	return block.Bytes, nil
+}
+
+// Returns private, public rsa keys in pem restored from der of private key.
+func KeysFromPrivateDER(privateKeyDER []byte) ([]byte, []byte, error) {
+	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyDER)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse private rsa key: %w", err)
+	}
+	return encodeRsaKeys(privateKey)
+}
+
+// Generates private, public rsa keys pair in pem, e.g. for api tokens.
+func GenerateRsaKeys() ([]byte, []byte, error) {
+	return getRsaKeys(clientKeyBits)
+}
+
 // Saves key to file.
 func SaveKeyToFile(key []byte, filepath string) error {
 	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY, 0644)
@@ -69,15 +105,17 @@ func SaveKeyToFile(key []byte, filepath string) error {
 func CreateKeysIfAbsent(isServer bool) error {
 	var privateKeyPath string
 	var publicKeyPath string
+	bits := clientKeyBits
 	if isServer {
 		privateKeyPath = appConfig.ServerPrivateKeyPath
 		publicKeyPath = appConfig.ServerPublicKeyPath
+		bits = serverKeyBits
 	} else {
 		privateKeyPath = appConfig.ClientPrivateKeyPath
 		publicKeyPath = appConfig.ClientPublicKeyPath
 	}
 	if _, err := os.Stat(privateKeyPath); err != nil {
-		privateKey, publicKey, err := getRsaKeys()
+		privateKey, publicKey, err := getRsaKeys(bits)
 		if err != nil {
 			return err
 		}
@@ -112,8 +150,7 @@ var (
 // Init encryption data.
 func InitData() {
 	appConfig = config.GetConfig()
-	ServerPrivateKey = getCachedKeyFromFile(appConfig.ServerPrivateKeyPath, serverPrivateKey)
-	ServerPublicKey = getCachedKeyFromFile(appConfig.ServerPublicKeyPath, serverPublicKey)
+	UseServerKeyFiles(appConfig.ServerPrivateKeyPath, appConfig.ServerPublicKeyPath)
 	ClientPrivateKey = getCachedKeyFromFile(appConfig.ClientPrivateKeyPath, clientPrivateKey)
 	ClientPublicKey = getCachedKeyFromFile(appConfig.ClientPublicKeyPath, clientPublicKey)
 }
@@ -141,6 +178,9 @@ func DecryptFileEncryptionKey(encryptedFileKey, encryptionKey []byte) ([]byte, e
 		return nil, fmt.Errorf("empty encryption key, relogin may required")
 	}
 	block, _ := pem.Decode(encryptionKey)
+	if block == nil {
+		return nil, fmt.Errorf("cannot decode private rsa key")
+	}
 	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
 	if err != nil {
 		return nil, err
@@ -184,3 +224,79 @@ func DecryptFileData(key, data []byte) ([]byte, error) {
 	stream.XORKeyStream(decrypted, data)
 	return decrypted, nil
 }
+
+// Encrypt small metadata value with symmetric file encryption key.
+// Uses authenticated encryption, random nonce is prepended to ciphertext.
+func EncryptMetadata(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Decrypt metadata value encrypted by EncryptMetadata.
+func DecryptMetadata(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted metadata too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// Salt size for passphrase key derivation.
+const passphraseSaltSize = 16
+
+// Derive symmetric key from passphrase with scrypt.
+func passphraseKey(passphrase, salt []byte) ([]byte, error) {
+	return scrypt.Key(passphrase, salt, 1<<15, 8, 1, 32)
+}
+
+// Encrypt data with key derived from passphrase, random salt is prepended to result.
+func EncryptWithPassphrase(passphrase, data []byte) ([]byte, error) {
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	key, err := passphraseKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := EncryptMetadata(key, data)
+	if err != nil {
+		return nil, err
+	}
+	return append(salt, encrypted...), nil
+}
+
+// Decrypt data encrypted by EncryptWithPassphrase.
+func DecryptWithPassphrase(passphrase, data []byte) ([]byte, error) {
+	if len(data) < passphraseSaltSize {
+		return nil, fmt.Errorf("encrypted data too short")
+	}
+	key, err := passphraseKey(passphrase, data[:passphraseSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	decrypted, err := DecryptMetadata(key, data[passphraseSaltSize:])
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or corrupted data: %w", err)
+	}
+	return decrypted, nil
+}
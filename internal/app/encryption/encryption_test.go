@@ -0,0 +1,195 @@
+package encryption
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptMetadata(t *testing.T) {
+	key, err := GenerateSymmetricFileEncryptionKey()
+	require.NoError(t, err)
+	data := []byte("metadata value")
+
+	encrypted, err := EncryptMetadata(key, data)
+	require.NoError(t, err)
+	require.NotEqual(t, data, encrypted)
+
+	decrypted, err := DecryptMetadata(key, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	encrypted[len(encrypted)-1] ^= 1
+	_, err = DecryptMetadata(key, encrypted)
+	require.Error(t, err)
+
+	_, err = DecryptMetadata(key, []byte("short"))
+	require.Error(t, err)
+}
+
+func TestKeysFromPrivateDER(t *testing.T) {
+	privateKey, publicKey, err := GenerateRsaKeys()
+	require.NoError(t, err)
+	der, err := PrivateKeyDER(privateKey)
+	require.NoError(t, err)
+
+	restoredPrivate, restoredPublic, err := KeysFromPrivateDER(der)
+	require.NoError(t, err)
+	require.Equal(t, privateKey, restoredPrivate)
+	require.Equal(t, publicKey, restoredPublic)
+
+	_, err = PrivateKeyDER(publicKey)
+	require.Error(t, err)
+	_, _, err = KeysFromPrivateDER([]byte("not a key"))
+	require.Error(t, err)
+}
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	data := []byte("server keys")
+	encrypted, err := EncryptWithPassphrase([]byte("passphrase"), data)
+	require.NoError(t, err)
+
+	decrypted, err := DecryptWithPassphrase([]byte("passphrase"), encrypted)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	_, err = DecryptWithPassphrase([]byte("wrong"), encrypted)
+	require.Error(t, err)
+	_, err = DecryptWithPassphrase([]byte("passphrase"), []byte("short"))
+	require.Error(t, err)
+}
+
+func TestPassphraseStream(t *testing.T) {
+	passphrase := []byte("passphrase")
+	for _, size := range []int{0, 100, passphraseSegmentSize, 3*passphraseSegmentSize + 5} {
+		data := bytes.Repeat([]byte("x"), size)
+		var encrypted bytes.Buffer
+		writer, err := NewPassphraseWriter(&encrypted, passphrase)
+		require.NoError(t, err)
+		_, err = writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		reader, err := NewPassphraseReader(bytes.NewReader(encrypted.Bytes()), passphrase)
+		require.NoError(t, err)
+		decrypted, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.Equal(t, data, decrypted)
+
+		reader, err = NewPassphraseReader(bytes.NewReader(encrypted.Bytes()), []byte("wrong"))
+		require.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		require.Error(t, err)
+
+		reader, err = NewPassphraseReader(bytes.NewReader(encrypted.Bytes()[:encrypted.Len()-1]), passphrase)
+		require.NoError(t, err)
+		_, err = io.ReadAll(reader)
+		require.Error(t, err)
+	}
+
+	// Stream cut on segment boundary is detected by missing final segment.
+	var encrypted bytes.Buffer
+	writer, err := NewPassphraseWriter(&encrypted, passphrase)
+	require.NoError(t, err)
+	_, err = writer.Write(bytes.Repeat([]byte("x"), 2*passphraseSegmentSize))
+	require.NoError(t, err)
+	headerAndSegment := passphraseSaltSize + streamNoncePrefixSize + 4 + passphraseSegmentSize + 16
+	reader, err := NewPassphraseReader(bytes.NewReader(encrypted.Bytes()[:headerAndSegment]), passphrase)
+	require.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	require.ErrorIs(t, err, ErrTruncatedStream)
+}
+
+func TestRotateServerKeys(t *testing.T) {
+	savedConfig, savedPrivate, savedPublic := appConfig, ServerPrivateKey, ServerPublicKey
+	t.Cleanup(func() {
+		appConfig, ServerPrivateKey, ServerPublicKey = savedConfig, savedPrivate, savedPublic
+		ReloadServerKeys()
+	})
+	dir := t.TempDir()
+	UseServerKeyFiles(filepath.Join(dir, "private"), filepath.Join(dir, "public"))
+	require.NoError(t, CreateKeysIfAbsent(true))
+
+	oldId := ServerKeyID()
+	fileKey, err := GenerateSymmetricFileEncryptionKey()
+	require.NoError(t, err)
+	wrapped, err := EncryptFileEncryptionKey(fileKey, ServerPublicKey())
+	require.NoError(t, err)
+	require.True(t, IsWrappedByServerKey(wrapped))
+
+	retiredId, newId, err := RotateServerKeys()
+	require.NoError(t, err)
+	require.Equal(t, oldId, retiredId)
+	require.NotEqual(t, oldId, newId)
+	require.Equal(t, newId, ServerKeyID())
+	ids, err := RetiredServerKeyIDs()
+	require.NoError(t, err)
+	require.Equal(t, []string{oldId}, ids)
+	require.False(t, IsWrappedByServerKey(wrapped))
+	for _, id := range []string{oldId, ""} {
+		key, err := DecryptServerKey(wrapped, id)
+		require.NoError(t, err)
+		require.Equal(t, fileKey, key)
+	}
+	_, err = DecryptServerKey(wrapped, newId)
+	require.Error(t, err)
+
+	require.NoError(t, RetireServerKey(oldId))
+	ids, err = RetiredServerKeyIDs()
+	require.NoError(t, err)
+	require.Empty(t, ids)
+	_, err = DecryptServerKey(wrapped, "")
+	require.ErrorIs(t, err, ErrUnknownServerKey)
+	_, err = DecryptServerKey(wrapped, oldId)
+	require.ErrorIs(t, err, ErrUnknownServerKey)
+}
+
+// Environment variable with key directory of TestRotateServerKeys_OtherProcess helper.
+const rotateKeysDirEnv = "TEST_ROTATE_KEYS_DIR"
+
+// Rotates keys in given directory when run as separate process, like rotate-keys command next to running server.
+func TestRotateServerKeys_Helper(t *testing.T) {
+	dir := os.Getenv(rotateKeysDirEnv)
+	if dir == "" {
+		t.Skip("run by TestRotateServerKeys_OtherProcess")
+	}
+	UseServerKeyFiles(filepath.Join(dir, "private"), filepath.Join(dir, "public"))
+	_, _, err := RotateServerKeys()
+	require.NoError(t, err)
+}
+
+func TestRotateServerKeys_OtherProcess(t *testing.T) {
+	savedConfig, savedPrivate, savedPublic := appConfig, ServerPrivateKey, ServerPublicKey
+	t.Cleanup(func() {
+		appConfig, ServerPrivateKey, ServerPublicKey = savedConfig, savedPrivate, savedPublic
+		ReloadServerKeys()
+	})
+	dir := t.TempDir()
+	UseServerKeyFiles(filepath.Join(dir, "private"), filepath.Join(dir, "public"))
+	require.NoError(t, CreateKeysIfAbsent(true))
+	fileKey, err := GenerateSymmetricFileEncryptionKey()
+	require.NoError(t, err)
+	oldWrapped, oldId, err := WrapServerKey(fileKey)
+	require.NoError(t, err)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRotateServerKeys_Helper$")
+	cmd.Env = append(os.Environ(), rotateKeysDirEnv+"="+dir)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// Keys are read again without reload, new file keys are wrapped by new server key.
+	wrapped, newId, err := WrapServerKey(fileKey)
+	require.NoError(t, err)
+	require.NotEqual(t, oldId, newId)
+	require.Equal(t, newId, ServerKeyID())
+	for id, wrapped := range map[string][]byte{oldId: oldWrapped, newId: wrapped} {
+		key, err := DecryptServerKey(wrapped, id)
+		require.NoError(t, err)
+		require.Equal(t, fileKey, key)
+	}
+}
@@ -0,0 +1,261 @@
+package encryption
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error in case wrapped key can't be decrypted by any server key.
+var ErrUnknownServerKey = errors.New("key is not wrapped by any server key")
+
+// Suffix of private key path for retired server keys, e.g. .rsa_server_private.retired-{id}.
+const retiredKeySuffix = ".retired-"
+
+// Server keys read from files.
+//
+// Current key wraps new file keys, retired keys are kept until nothing wrapped by them is left.
+type serverKeyring struct {
+	mu      sync.Mutex
+	private keyFile
+	public  keyFile
+	retired map[string][]byte
+}
+
+// Key read from file along with file info to notice when file is replaced.
+type keyFile struct {
+	data []byte
+	info os.FileInfo
+}
+
+var serverKeys = &serverKeyring{}
+
+// Reads key again when its file is replaced, e.g. when keys are rotated by other process,
+// missing key is read again on next call since it may be created later.
+func (k *serverKeyring) readKey(key *keyFile, path string) []byte {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return key.data
+	}
+	if key.data != nil && os.SameFile(key.info, info) && key.info.ModTime().Equal(info.ModTime()) &&
+		key.info.Size() == info.Size() {
+		return key.data
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return key.data
+	}
+	key.data, key.info = data, info
+	return key.data
+}
+
+func (k *serverKeyring) privateKey() []byte {
+	return k.readKey(&k.private, appConfig.ServerPrivateKeyPath)
+}
+
+func (k *serverKeyring) publicKey() []byte {
+	return k.readKey(&k.public, appConfig.ServerPublicKeyPath)
+}
+
+func (k *serverKeyring) retiredKey(id string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key, ok := k.retired[id]; ok {
+		return key, nil
+	}
+	key, err := os.ReadFile(RetiredServerKeyPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: retired server key %s is removed", ErrUnknownServerKey, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read retired server key %s: %w", id, err)
+	}
+	if k.retired == nil {
+		k.retired = make(map[string][]byte)
+	}
+	k.retired[id] = key
+	return key, nil
+}
+
+// Forgets read server keys, e.g. when key paths are changed.
+func ReloadServerKeys() {
+	serverKeys.mu.Lock()
+	defer serverKeys.mu.Unlock()
+	serverKeys.private = keyFile{}
+	serverKeys.public = keyFile{}
+	serverKeys.retired = nil
+}
+
+// Reads server keys from given paths, retired keys are looked for next to private key.
+// Keys are read again once their files are replaced, so keys rotated by other process are used on next call.
+func UseServerKeyFiles(privateKeyPath string, publicKeyPath string) {
+	appConfig.ServerPrivateKeyPath = privateKeyPath
+	appConfig.ServerPublicKeyPath = publicKeyPath
+	ReloadServerKeys()
+	ServerPrivateKey = serverKeys.privateKey
+	ServerPublicKey = serverKeys.publicKey
+}
+
+// Returns id of rsa key, hex of truncated sha256 of public key.
+func KeyID(publicKeyPEM []byte) string {
+	data := publicKeyPEM
+	if block, _ := pem.Decode(publicKeyPEM); block != nil {
+		data = block.Bytes
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:8])
+}
+
+// Returns id of current server key stored alongside keys wrapped by it.
+func ServerKeyID() string {
+	return KeyID(ServerPublicKey())
+}
+
+// Returns path of retired server private key with given id.
+func RetiredServerKeyPath(id string) string {
+	return appConfig.ServerPrivateKeyPath + retiredKeySuffix + id
+}
+
+// Returns sorted ids of retired server keys.
+func RetiredServerKeyIDs() ([]string, error) {
+	paths, err := filepath.Glob(RetiredServerKeyPath("*"))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ids = append(ids, strings.TrimPrefix(path, appConfig.ServerPrivateKeyPath+retiredKeySuffix))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Decrypts key wrapped by server key with given id.
+//
+// Keys wrapped before key ids were stored have empty id, they are tried with current and then retired keys.
+func DecryptServerKey(wrapped []byte, keyId string) ([]byte, error) {
+	if keyId == "" {
+		return decryptWithAnyServerKey(wrapped)
+	}
+	if keyId == ServerKeyID() {
+		return DecryptFileEncryptionKey(wrapped, ServerPrivateKey())
+	}
+	private, err := serverKeys.retiredKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptFileEncryptionKey(wrapped, private)
+}
+
+func decryptWithAnyServerKey(wrapped []byte) ([]byte, error) {
+	if key, err := DecryptFileEncryptionKey(wrapped, ServerPrivateKey()); err == nil {
+		return key, nil
+	}
+	ids, err := RetiredServerKeyIDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		private, err := serverKeys.retiredKey(id)
+		if err != nil {
+			return nil, err
+		}
+		if key, err := DecryptFileEncryptionKey(wrapped, private); err == nil {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownServerKey
+}
+
+// Wraps key by current server key, returns wrapped key with id of server key.
+func WrapServerKey(key []byte) ([]byte, string, error) {
+	public := ServerPublicKey()
+	wrapped, err := EncryptFileEncryptionKey(key, public)
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapped, KeyID(public), nil
+}
+
+// Whether key is wrapped by current server key.
+func IsWrappedByServerKey(wrapped []byte) bool {
+	_, err := DecryptFileEncryptionKey(wrapped, ServerPrivateKey())
+	return err == nil
+}
+
+// Writes file via temporary file so that readers never see partially written key.
+func writeKeyFile(path string, key []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, key, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Generates new server key, current one is kept as retired until nothing wrapped by it is left.
+//
+// Returns ids of retired and new keys.
+func RotateServerKeys() (string, string, error) {
+	private, err := os.ReadFile(appConfig.ServerPrivateKeyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot read server private key: %w", err)
+	}
+	public, err := os.ReadFile(appConfig.ServerPublicKeyPath)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot read server public key: %w", err)
+	}
+	retiredId := KeyID(public)
+	file, err := os.OpenFile(RetiredServerKeyPath(retiredId), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot retire server key: %w", err)
+	}
+	_, err = file.Write(private)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("cannot retire server key: %w", err)
+	}
+
+	newPrivate, newPublic, err := getRsaKeys(serverKeyBits)
+	if err != nil {
+		return "", "", err
+	}
+	// Private key goes first, readers of new public key find its private key in place.
+	if err = writeKeyFile(appConfig.ServerPrivateKeyPath, newPrivate, 0600); err != nil {
+		return "", "", fmt.Errorf("cannot save server private key: %w", err)
+	}
+	if err = writeKeyFile(appConfig.ServerPublicKeyPath, newPublic, 0644); err != nil {
+		return "", "", fmt.Errorf("cannot save server public key: %w", err)
+	}
+	ReloadServerKeys()
+	return retiredId, KeyID(newPublic), nil
+}
+
+// Returns time server key with given id was retired.
+func RetiredServerKeyTime(id string) (time.Time, error) {
+	info, err := os.Stat(RetiredServerKeyPath(id))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot stat retired server key %s: %w", id, err)
+	}
+	return info.ModTime(), nil
+}
+
+// Removes retired server key, nothing should be wrapped by it anymore.
+func RetireServerKey(id string) error {
+	if err := os.Remove(RetiredServerKeyPath(id)); err != nil {
+		return fmt.Errorf("cannot remove retired server key: %w", err)
+	}
+	ReloadServerKeys()
+	return nil
+}
@@ -0,0 +1,191 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream encrypted with passphrase is salt || nonce prefix followed by segments.
+// Segment is uint32 length with final flag in high bit and authenticated ciphertext
+// of up to passphraseSegmentSize plaintext bytes. Segment nonce is nonce prefix ||
+// segment number || final flag, so reordered, dropped or truncated segments fail to decrypt.
+const (
+	passphraseSegmentSize = 64 * 1024
+	streamNoncePrefixSize = 7
+	finalSegmentFlag      = 1 << 31
+)
+
+// Error in case encrypted stream ends before final segment.
+var ErrTruncatedStream = errors.New("encrypted stream is truncated")
+
+func newStreamCipher(passphrase, salt []byte) (cipher.AEAD, error) {
+	key, err := passphraseKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func segmentNonce(prefix []byte, counter uint32, final bool) []byte {
+	nonce := make([]byte, 0, streamNoncePrefixSize+5)
+	nonce = append(nonce, prefix...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if final {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// Writer encrypting stream with key derived from passphrase.
+type passphraseWriter struct {
+	writer  io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	buf     []byte
+	closed  bool
+}
+
+// Creates writer encrypting data with key derived from passphrase.
+// Close must be called to write final segment, it doesn't close underlying writer.
+func NewPassphraseWriter(writer io.Writer, passphrase []byte) (io.WriteCloser, error) {
+	header := make([]byte, passphraseSaltSize+streamNoncePrefixSize)
+	if _, err := io.ReadFull(rand.Reader, header); err != nil {
+		return nil, err
+	}
+	aead, err := newStreamCipher(passphrase, header[:passphraseSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(header); err != nil {
+		return nil, err
+	}
+	return &passphraseWriter{writer: writer, aead: aead, prefix: header[passphraseSaltSize:],
+		buf: make([]byte, 0, passphraseSegmentSize)}, nil
+}
+
+func (w *passphraseWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("write to closed encrypted stream")
+	}
+	written := 0
+	for len(p) > 0 {
+		// Full segment is kept until more data arrives, last one is written by Close as final.
+		if len(w.buf) == passphraseSegmentSize {
+			if err := w.writeSegment(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):passphraseSegmentSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (w *passphraseWriter) writeSegment(final bool) error {
+	sealed := w.aead.Seal(nil, segmentNonce(w.prefix, w.counter, final), w.buf, nil)
+	length := uint32(len(sealed))
+	if final {
+		length |= finalSegmentFlag
+	}
+	w.counter++
+	w.buf = w.buf[:0]
+	if _, err := w.writer.Write(binary.BigEndian.AppendUint32(nil, length)); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(sealed)
+	return err
+}
+
+func (w *passphraseWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.writeSegment(true)
+}
+
+// Reader decrypting stream written by passphrase writer.
+type passphraseReader struct {
+	reader  *bufio.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	plain   []byte
+	final   bool
+}
+
+// Creates reader decrypting data written by NewPassphraseWriter.
+func NewPassphraseReader(reader io.Reader, passphrase []byte) (io.Reader, error) {
+	header := make([]byte, passphraseSaltSize+streamNoncePrefixSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("failed to read encrypted stream header: %w", err)
+	}
+	aead, err := newStreamCipher(passphrase, header[:passphraseSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	return &passphraseReader{reader: bufio.NewReader(reader), aead: aead, prefix: header[passphraseSaltSize:]}, nil
+}
+
+func (r *passphraseReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.final {
+			return 0, io.EOF
+		}
+		if err := r.readSegment(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *passphraseReader) readSegment() error {
+	var lengthBuf [4]byte
+	if _, err := io.ReadFull(r.reader, lengthBuf[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncatedStream
+		}
+		return err
+	}
+	length := binary.BigEndian.Uint32(lengthBuf[:])
+	final := length&finalSegmentFlag != 0
+	length &^= finalSegmentFlag
+	if length > passphraseSegmentSize+uint32(r.aead.Overhead()) {
+		return fmt.Errorf("wrong passphrase or corrupted data: segment too large")
+	}
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(r.reader, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncatedStream
+		}
+		return err
+	}
+	plain, err := r.aead.Open(sealed[:0], segmentNonce(r.prefix, r.counter, final), sealed, nil)
+	if err != nil {
+		return fmt.Errorf("wrong passphrase or corrupted data: %w", err)
+	}
+	if final {
+		if _, err = r.reader.Peek(1); err != io.EOF {
+			return fmt.Errorf("corrupted data: trailing bytes after final segment")
+		}
+	}
+	r.counter++
+	r.plain = plain
+	r.final = final
+	return nil
+}
@@ -3,6 +3,8 @@ package filestorage
 
 import (
 	"context"
+	"io"
+	"time"
 
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
 )
@@ -14,11 +16,21 @@ type StreamingFileStorage interface {
 	// Get file chunks.
 	Download(stream pb.GophKeeperService_DownloadFileServer, fileId string) error
 
-	// Write file chunks.
-	Upload(stream pb.GophKeeperService_UploadFileServer, fileSize int64, fileId string) error
+	// Write file of given size from reader.
+	Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error
 
 	// Delete file.
 	Delete(ctx context.Context, fileId string) error
+
+	// List all stored files.
+	List(ctx context.Context) ([]FileObject, error)
+}
+
+// Stored file blob description.
+type FileObject struct {
+	Id       string
+	Size     int64
+	Modified time.Time
 }
 
 // Chunk size for file streaming.
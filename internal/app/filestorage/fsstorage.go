@@ -0,0 +1,147 @@
+package filestorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Error in case file id cannot be used as file name.
+var ErrInvalidFileId = errors.New("invalid file id")
+
+// Prefix of temporary files being written.
+const tmpFilePrefix = ".upload-"
+
+// Storage contains file blobs in local directory.
+//
+// Blobs are sharded into subdirectories by first characters of id: {root}/ab/cd/abcd...
+type FsFileStorage struct {
+	root string
+}
+
+func NewFsFileStorage(root string) (*FsFileStorage, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &FsFileStorage{root: root}, nil
+}
+
+// Get sharded blob path by file id.
+func (s *FsFileStorage) path(fileId string) (string, error) {
+	if len(fileId) < 4 || strings.HasPrefix(fileId, ".") || strings.ContainsAny(fileId, `/\`) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidFileId, fileId)
+	}
+	return filepath.Join(s.root, fileId[:2], fileId[2:4], fileId), nil
+}
+
+func (s *FsFileStorage) Download(stream pb.GophKeeperService_DownloadFileServer, fileId string) error {
+	path, err := s.path(fileId)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer file.Close()
+	return FromReader2FileStream(file, stream)
+}
+
+// Writes blob to temporary file, syncs it and renames it to blob path,
+// so that blob is either fully written or absent.
+func (s *FsFileStorage) Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error {
+	path, err := s.path(fileId)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, tmpFilePrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err = io.CopyN(tmp, contextReader{ctx: ctx, reader: reader}, fileSize); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return syncDir(dir)
+}
+
+func (s *FsFileStorage) Delete(_ context.Context, fileId string) error {
+	path, err := s.path(fileId)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+func (s *FsFileStorage) List(ctx context.Context) ([]FileObject, error) {
+	var files []FileObject
+	err := filepath.WalkDir(s.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, FileObject{Id: entry.Name(), Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return files, nil
+}
+
+// Flush directory entry so that rename survives crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Reader which stops reading when context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
@@ -0,0 +1,48 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFsFileStorage(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewFsFileStorage(root)
+	require.NoError(t, err)
+	testFileStorage(t, storage)
+
+	const fileId = "0123456789"
+	err = storage.Upload(context.Background(), bytes.NewReader([]byte("data")), 4, fileId)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(root, "01", "23", fileId))
+}
+
+func TestFsFileStorage_UploadIncomplete(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	storage, err := NewFsFileStorage(root)
+	require.NoError(t, err)
+
+	const fileId = "0123456789"
+	err = storage.Upload(ctx, bytes.NewReader([]byte("short")), 100, fileId)
+	require.Error(t, err)
+	_, err = os.Stat(filepath.Join(root, "01", "23", fileId))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	entries, err := os.ReadDir(filepath.Join(root, "01", "23"))
+	require.NoError(t, err)
+	require.Empty(t, entries, "temporary file should be removed")
+}
+
+func TestFsFileStorage_InvalidId(t *testing.T) {
+	storage, err := NewFsFileStorage(t.TempDir())
+	require.NoError(t, err)
+	for _, fileId := range []string{"", "abc", "../../etc/passwd", "ab/cd", ".upload-123"} {
+		err = storage.Upload(context.Background(), bytes.NewReader(nil), 0, fileId)
+		require.ErrorIs(t, err, ErrInvalidFileId)
+	}
+}
@@ -0,0 +1,75 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Storage contains file blobs in memory, all files are lost on restart.
+type MemoryFileStorage struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data     []byte
+	modified time.Time
+}
+
+func NewMemoryFileStorage() *MemoryFileStorage {
+	return &MemoryFileStorage{files: make(map[string]memoryFile)}
+}
+
+func (s *MemoryFileStorage) Download(stream pb.GophKeeperService_DownloadFileServer, fileId string) error {
+	s.mu.RLock()
+	file, ok := s.files[fileId]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("failed to download: %w", fs.ErrNotExist)
+	}
+	// Stored data is never modified in place so it can be read without lock.
+	return FromReader2FileStream(bytes.NewReader(file.data), stream)
+}
+
+// Largest buffer allocated before blob data is received.
+const maxPreallocated = 4 * ChunkSize
+
+// Reads whole blob before storing it, so that blob is either fully written or absent.
+func (s *MemoryFileStorage) Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error {
+	var buf bytes.Buffer
+	// Declared size comes from client, so only small blobs are preallocated.
+	if fileSize > 0 {
+		buf.Grow(int(min(fileSize, maxPreallocated)))
+	}
+	if _, err := io.CopyN(&buf, contextReader{ctx: ctx, reader: reader}, fileSize); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	s.mu.Lock()
+	s.files[fileId] = memoryFile{data: buf.Bytes(), modified: time.Now()}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryFileStorage) Delete(_ context.Context, fileId string) error {
+	s.mu.Lock()
+	delete(s.files, fileId)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryFileStorage) List(_ context.Context) ([]FileObject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	files := make([]FileObject, 0, len(s.files))
+	for id, file := range s.files {
+		files = append(files, FileObject{Id: id, Size: int64(len(file.data)), Modified: file.modified})
+	}
+	return files, nil
+}
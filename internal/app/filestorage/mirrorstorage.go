@@ -0,0 +1,310 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"go.uber.org/zap"
+)
+
+// Error returned to side upload when its reader is abandoned.
+var errSideAbandoned = errors.New("mirror side abandoned")
+
+// Error in case copied object differs from source one.
+var ErrChecksumMismatch = errors.New("copied object checksum differs from source")
+
+// Storage writes every file to primary and secondary storages and reads from primary
+// with fallback to secondary.
+//
+// Upload succeeds if file reached at least one side, missing side is recorded
+// in replication status and re-copied by Repair.
+type MirrorFileStorage struct {
+	primary   StreamingFileStorage
+	secondary StreamingFileStorage
+	status    ReplicationStatusStore
+
+	// Interval of background repair started by server, disabled if zero.
+	RepairInterval time.Duration
+}
+
+func NewMirrorFileStorage(primary, secondary StreamingFileStorage, status ReplicationStatusStore) *MirrorFileStorage {
+	return &MirrorFileStorage{primary: primary, secondary: secondary, status: status}
+}
+
+// Replaces replication status storage, e.g. by one in metadata database shared with other processes.
+func (s *MirrorFileStorage) UseReplicationStatus(status ReplicationStatusStore) {
+	s.status = status
+}
+
+func (s *MirrorFileStorage) side(side MirrorSide) StreamingFileStorage {
+	if side == Primary {
+		return s.primary
+	}
+	return s.secondary
+}
+
+// Download stream remembering whether any chunk was sent.
+type sentTrackingStream struct {
+	pb.GophKeeperService_DownloadFileServer
+	sent bool
+}
+
+func (s *sentTrackingStream) Send(filePart *pb.FileStream) error {
+	s.sent = true
+	return s.GophKeeperService_DownloadFileServer.Send(filePart)
+}
+
+// Falls back to secondary only if primary failed before sending anything,
+// otherwise client would receive duplicated chunks.
+func (s *MirrorFileStorage) Download(stream pb.GophKeeperService_DownloadFileServer, fileId string) error {
+	tracking := &sentTrackingStream{GophKeeperService_DownloadFileServer: stream}
+	err := s.primary.Download(tracking, fileId)
+	if err == nil || tracking.sent {
+		return err
+	}
+	logger.Log.Warn("Primary storage download failed, reading from secondary", zap.String("id", fileId), zap.Error(err))
+	return s.secondary.Download(stream, fileId)
+}
+
+// Writer which writes to all alive pipes, pipe is dropped when its side stops reading.
+type fanOutWriter struct {
+	pipes []*io.PipeWriter
+}
+
+func (w *fanOutWriter) Write(p []byte) (int, error) {
+	alive := 0
+	for i, pipe := range w.pipes {
+		if pipe == nil {
+			continue
+		}
+		if _, err := pipe.Write(p); err != nil {
+			w.pipes[i] = nil
+			continue
+		}
+		alive++
+	}
+	if alive == 0 {
+		return 0, errSideAbandoned
+	}
+	return len(p), nil
+}
+
+// Streams file to both sides simultaneously.
+func (s *MirrorFileStorage) Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error {
+	sides := []MirrorSide{Primary, Secondary}
+	errs := make([]error, len(sides))
+	writer := &fanOutWriter{pipes: make([]*io.PipeWriter, len(sides))}
+	var wg sync.WaitGroup
+	for i, side := range sides {
+		pipeReader, pipeWriter := io.Pipe()
+		writer.pipes[i] = pipeWriter
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.side(side).Upload(ctx, pipeReader, fileSize, fileId)
+			pipeReader.CloseWithError(errSideAbandoned)
+		}()
+	}
+	_, copyErr := io.CopyN(writer, reader, fileSize)
+	for _, pipe := range writer.pipes {
+		if pipe != nil {
+			pipe.CloseWithError(copyErr)
+		}
+	}
+	wg.Wait()
+
+	if copyErr != nil && !errors.Is(copyErr, errSideAbandoned) {
+		return fmt.Errorf("failed to upload file: %w", copyErr)
+	}
+	if errs[0] != nil && errs[1] != nil {
+		return fmt.Errorf("failed to upload file to both sides: %w", errors.Join(errs...))
+	}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		logger.Log.Warn("Mirrored upload failed on one side", zap.String("id", fileId),
+			zap.String("side", string(sides[i])), zap.Error(err))
+		if statusErr := s.status.MarkMissing(ctx, fileId, sides[i], err); statusErr != nil {
+			return fmt.Errorf("failed to record replication status: %w", statusErr)
+		}
+	}
+	return nil
+}
+
+func (s *MirrorFileStorage) Delete(ctx context.Context, fileId string) error {
+	err := errors.Join(s.primary.Delete(ctx, fileId), s.secondary.Delete(ctx, fileId))
+	if err != nil {
+		return err
+	}
+	return s.status.MarkReplicated(ctx, fileId)
+}
+
+// Lists files present on any side, primary entry is preferred.
+func (s *MirrorFileStorage) List(ctx context.Context) ([]FileObject, error) {
+	primary, err := s.primary.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secondary, err := s.secondary.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(primary))
+	for _, file := range primary {
+		seen[file.Id] = true
+	}
+	for _, file := range secondary {
+		if !seen[file.Id] {
+			primary = append(primary, file)
+		}
+	}
+	return primary, nil
+}
+
+// Get objects missing on some side.
+func (s *MirrorFileStorage) ReplicationStatus(ctx context.Context) ([]ReplicationStatus, error) {
+	return s.status.ListMissing(ctx)
+}
+
+// Result of mirror repair.
+type RepairReport struct {
+	Pending  int               `json:"pending"`
+	Repaired []string          `json:"repaired"`
+	Dropped  []string          `json:"dropped"`
+	Failed   map[string]string `json:"failed"`
+}
+
+// Copies objects recorded as missing from the side having them.
+//
+// Object is marked replicated only after its copy on missing side is verified to have
+// same checksum as source one. Records of objects absent on both sides, e.g. deleted ones, are dropped.
+func (s *MirrorFileStorage) Repair(ctx context.Context) (*RepairReport, error) {
+	missing, err := s.status.ListMissing(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &RepairReport{Pending: len(missing), Failed: make(map[string]string)}
+	if len(missing) == 0 {
+		return report, nil
+	}
+	sizes := make(map[MirrorSide]map[string]int64)
+	for _, side := range []MirrorSide{Primary, Secondary} {
+		files, err := s.side(side).List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s files: %w", side, err)
+		}
+		sizes[side] = make(map[string]int64, len(files))
+		for _, file := range files {
+			sizes[side][file.Id] = file.Size
+		}
+	}
+	for _, record := range missing {
+		source := Primary
+		if record.Missing == Primary {
+			source = Secondary
+		}
+		targetSize, onTarget := sizes[record.Missing][record.Id]
+		size, onSource := sizes[source][record.Id]
+		switch {
+		case !onSource && !onTarget:
+			report.Dropped = append(report.Dropped, record.Id)
+		case !onSource:
+			// Only copy can't be verified, record is kept for operator.
+			report.Failed[record.Id] = fmt.Sprintf("object is absent on %s side", source)
+			continue
+		default:
+			err = s.replicate(ctx, record.Id, source, record.Missing, size, onTarget && targetSize == size)
+			if err != nil {
+				report.Failed[record.Id] = err.Error()
+				if err = s.status.MarkMissing(ctx, record.Id, record.Missing, err); err != nil {
+					return report, err
+				}
+				continue
+			}
+			report.Repaired = append(report.Repaired, record.Id)
+		}
+		if err = s.status.MarkReplicated(ctx, record.Id); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// Copies object to target side unless same object is there already, copy is verified by checksum.
+func (s *MirrorFileStorage) replicate(ctx context.Context, fileId string, source, target MirrorSide, size int64, present bool) error {
+	sourceChecksum, err := checksum(ctx, s.side(source), fileId)
+	if err != nil {
+		return fmt.Errorf("failed to read %s copy: %w", source, err)
+	}
+	if present {
+		if targetChecksum, err := checksum(ctx, s.side(target), fileId); err == nil && bytes.Equal(sourceChecksum, targetChecksum) {
+			return nil
+		}
+	}
+	if err = copyFile(ctx, s.side(source), s.side(target), fileId, size); err != nil {
+		return err
+	}
+	targetChecksum, err := checksum(ctx, s.side(target), fileId)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s copy: %w", target, err)
+	}
+	if !bytes.Equal(sourceChecksum, targetChecksum) {
+		return fmt.Errorf("%w on %s side", ErrChecksumMismatch, target)
+	}
+	return nil
+}
+
+// Runs repair with given interval until context is done.
+func (s *MirrorFileStorage) RunRepairPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Repair(ctx)
+			if err != nil {
+				logger.Log.Error("Mirror repair failed", zap.Error(err))
+				continue
+			}
+			if report.Pending > 0 {
+				logger.Log.Info("Mirror repair finished", zap.Int("pending", report.Pending),
+					zap.Int("repaired", len(report.Repaired)), zap.Int("dropped", len(report.Dropped)),
+					zap.Int("failed", len(report.Failed)))
+			}
+		}
+	}
+}
+
+// Returns sha256 checksum of stored file.
+func checksum(ctx context.Context, storage StreamingFileStorage, fileId string) ([]byte, error) {
+	hash := sha256.New()
+	if err := DownloadTo(ctx, storage, fileId, hash); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// Copies file between storages.
+func copyFile(ctx context.Context, source, target StreamingFileStorage, fileId string, size int64) error {
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pipeWriter.CloseWithError(DownloadTo(ctx, source, fileId, pipeWriter))
+	}()
+	err := target.Upload(ctx, pipeReader, size, fileId)
+	pipeReader.CloseWithError(errSideAbandoned)
+	<-done
+	return err
+}
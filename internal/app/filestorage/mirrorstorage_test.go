@@ -0,0 +1,146 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+var errBroken = errors.New("broken")
+
+// Memory storage which can be switched to fail.
+type brokenStorage struct {
+	*MemoryFileStorage
+	broken bool
+}
+
+func (s *brokenStorage) Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error {
+	if s.broken {
+		return errBroken
+	}
+	return s.MemoryFileStorage.Upload(ctx, reader, fileSize, fileId)
+}
+
+func (s *brokenStorage) Download(stream pb.GophKeeperService_DownloadFileServer, fileId string) error {
+	if s.broken {
+		return errBroken
+	}
+	return s.MemoryFileStorage.Download(stream, fileId)
+}
+
+func newTestMirror() (*MirrorFileStorage, *brokenStorage, *brokenStorage) {
+	primary := &brokenStorage{MemoryFileStorage: NewMemoryFileStorage()}
+	secondary := &brokenStorage{MemoryFileStorage: NewMemoryFileStorage()}
+	return NewMirrorFileStorage(primary, secondary, NewMemoryReplicationStatusStore()), primary, secondary
+}
+
+func download(storage StreamingFileStorage, fileId string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := DownloadTo(context.Background(), storage, fileId, &buf)
+	return buf.Bytes(), err
+}
+
+func TestMirrorFileStorage(t *testing.T) {
+	mirror, _, _ := newTestMirror()
+	testFileStorage(t, mirror)
+}
+
+func TestMirrorFileStorage_Replication(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("data"), ChunkSize)
+	tests := []struct {
+		name    string
+		broken  MirrorSide
+		missing []string
+	}{
+		{name: "both", missing: nil},
+		{name: "primary_broken", broken: Primary, missing: []string{"0123456789"}},
+		{name: "secondary_broken", broken: Secondary, missing: []string{"0123456789"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirror, primary, secondary := newTestMirror()
+			broken := map[MirrorSide]*brokenStorage{Primary: primary, Secondary: secondary}[tt.broken]
+			if broken != nil {
+				broken.broken = true
+			}
+			const fileId = "0123456789"
+			require.NoError(t, mirror.Upload(ctx, bytes.NewReader(data), int64(len(data)), fileId))
+
+			downloaded, err := download(mirror, fileId)
+			require.NoError(t, err)
+			require.Equal(t, data, downloaded)
+
+			statuses, err := mirror.ReplicationStatus(ctx)
+			require.NoError(t, err)
+			require.Len(t, statuses, len(tt.missing))
+			if broken == nil {
+				return
+			}
+			require.Equal(t, tt.broken, statuses[0].Missing)
+
+			report, err := mirror.Repair(ctx)
+			require.NoError(t, err)
+			require.Contains(t, report.Failed, fileId, "repair should fail while side is broken")
+
+			broken.broken = false
+			report, err = mirror.Repair(ctx)
+			require.NoError(t, err)
+			require.Equal(t, []string{fileId}, report.Repaired)
+			statuses, err = mirror.ReplicationStatus(ctx)
+			require.NoError(t, err)
+			require.Empty(t, statuses)
+			downloaded, err = download(broken.MemoryFileStorage, fileId)
+			require.NoError(t, err)
+			require.Equal(t, data, downloaded)
+		})
+	}
+}
+
+func TestMirrorFileStorage_BothBroken(t *testing.T) {
+	ctx := context.Background()
+	mirror, primary, secondary := newTestMirror()
+	primary.broken, secondary.broken = true, true
+	err := mirror.Upload(ctx, bytes.NewReader([]byte("data")), 4, "0123456789")
+	require.ErrorIs(t, err, errBroken)
+	statuses, err := mirror.ReplicationStatus(ctx)
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+}
+
+func TestMirrorFileStorage_RepairDeleted(t *testing.T) {
+	ctx := context.Background()
+	mirror, _, _ := newTestMirror()
+	require.NoError(t, mirror.status.MarkMissing(ctx, "deleted", Secondary, errBroken))
+	report, err := mirror.Repair(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"deleted"}, report.Dropped)
+}
+
+func TestMirrorFileStorage_RepairVerifiesCopy(t *testing.T) {
+	ctx := context.Background()
+	mirror, primary, secondary := newTestMirror()
+	data := []byte("data")
+	require.NoError(t, primary.Upload(ctx, bytes.NewReader(data), 4, "stale"))
+	require.NoError(t, secondary.Upload(ctx, bytes.NewReader([]byte("old!")), 4, "stale"))
+	require.NoError(t, mirror.status.MarkMissing(ctx, "stale", Secondary, errBroken))
+	require.NoError(t, secondary.Upload(ctx, bytes.NewReader(data), 4, "orphan"))
+	require.NoError(t, mirror.status.MarkMissing(ctx, "orphan", Secondary, errBroken))
+
+	report, err := mirror.Repair(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"stale"}, report.Repaired, "object of same size but other content should be copied again")
+	require.Contains(t, report.Failed, "orphan", "object absent on source side can't be verified")
+	downloaded, err := download(secondary, "stale")
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+	statuses, err := mirror.ReplicationStatus(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.Equal(t, "orphan", statuses[0].Id)
+}
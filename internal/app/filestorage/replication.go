@@ -0,0 +1,84 @@
+package filestorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Side of mirrored storage.
+type MirrorSide string
+
+const (
+	Primary   MirrorSide = "primary"
+	Secondary MirrorSide = "secondary"
+)
+
+// Object which failed to reach one side of mirrored storage.
+type ReplicationStatus struct {
+	Id       string     `json:"id"`
+	Missing  MirrorSide `json:"missing"`
+	Error    string     `json:"error"`
+	Attempts int        `json:"attempts"`
+	Updated  time.Time  `json:"updated"`
+}
+
+// Storage of objects to be re-copied between sides of mirrored storage.
+type ReplicationStatusStore interface {
+	// Records that object is missing on given side, increments attempts if already recorded.
+	MarkMissing(ctx context.Context, fileId string, side MirrorSide, cause error) error
+
+	// Removes record of object present on both sides or deleted.
+	MarkReplicated(ctx context.Context, fileId string) error
+
+	// List objects missing on some side.
+	ListMissing(ctx context.Context) ([]ReplicationStatus, error)
+}
+
+// Replication status kept in memory, lost on restart and not seen by other processes.
+type MemoryReplicationStatusStore struct {
+	mu      sync.Mutex
+	records map[string]ReplicationStatus
+}
+
+func NewMemoryReplicationStatusStore() *MemoryReplicationStatusStore {
+	return &MemoryReplicationStatusStore{records: make(map[string]ReplicationStatus)}
+}
+
+func (s *MemoryReplicationStatusStore) MarkMissing(_ context.Context, fileId string, side MirrorSide, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[fileId] = newReplicationStatus(s.records[fileId], fileId, side, cause)
+	return nil
+}
+
+func (s *MemoryReplicationStatusStore) MarkReplicated(_ context.Context, fileId string) error {
+	s.mu.Lock()
+	delete(s.records, fileId)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryReplicationStatusStore) ListMissing(_ context.Context) ([]ReplicationStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedStatuses(s.records), nil
+}
+
+func newReplicationStatus(prev ReplicationStatus, fileId string, side MirrorSide, cause error) ReplicationStatus {
+	status := ReplicationStatus{Id: fileId, Missing: side, Attempts: prev.Attempts + 1, Updated: time.Now()}
+	if cause != nil {
+		status.Error = cause.Error()
+	}
+	return status
+}
+
+func sortedStatuses(records map[string]ReplicationStatus) []ReplicationStatus {
+	statuses := make([]ReplicationStatus, 0, len(records))
+	for _, record := range records {
+		statuses = append(statuses, record)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Id < statuses[j].Id })
+	return statuses
+}
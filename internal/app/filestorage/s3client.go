@@ -12,25 +12,46 @@ import (
 	"github.com/valinurovdenis/gophkeeper/internal/app/config"
 )
 
+// S3 connection settings.
+type S3Config struct {
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+}
+
+// S3 settings from service config.
+func DefaultS3Config() S3Config {
+	config := config.GetConfig()
+	return S3Config{
+		Endpoint:  config.S3Endpoint,
+		AccessKey: config.S3AccessKey,
+		SecretKey: config.S3SecretKey,
+		Region:    config.S3Region,
+		Bucket:    config.S3Bucket,
+	}
+}
+
 // Client for s3.
 type S3Client struct {
 	client *minio.Client
+	config S3Config
 }
 
-func NewS3Client() (*S3Client, error) {
-	client, err := getClient()
+func NewS3Client(config S3Config) (*S3Client, error) {
+	client, err := getClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create s3 client: %w", err)
 	}
-	return &S3Client{client: client}, nil
+	return &S3Client{client: client, config: config}, nil
 }
 
 // Get minio client.
-func getClient() (*minio.Client, error) {
-	config := config.GetConfig()
+func getClient(config S3Config) (*minio.Client, error) {
 	useSSL := false
-	minioClient, errInit := minio.New(config.S3Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(config.S3AccessKey, config.S3SecretKey, ""),
+	minioClient, errInit := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
 		Secure: useSSL,
 	})
 	if errInit != nil {
@@ -41,9 +62,8 @@ func getClient() (*minio.Client, error) {
 
 // Make minio bucket.
 func (c *S3Client) MakeBucket(ctx context.Context) error {
-	config := config.GetConfig()
-	bucketName := config.S3Bucket
-	err := c.client.MakeBucket(ctx, config.S3Bucket, minio.MakeBucketOptions{Region: config.S3Region})
+	bucketName := c.config.Bucket
+	err := c.client.MakeBucket(ctx, bucketName, minio.MakeBucketOptions{Region: c.config.Region})
 	if err != nil {
 		exists, errBucketExists := c.client.BucketExists(ctx, bucketName)
 		if errBucketExists == nil && exists {
@@ -58,8 +78,7 @@ func (c *S3Client) MakeBucket(ctx context.Context) error {
 
 // Upload file to minio.
 func (c *S3Client) UploadFile(ctx context.Context, fileReader io.Reader, fileName string, fileSize int64) error {
-	config := config.GetConfig()
-	_, err := c.client.PutObject(ctx, config.S3Bucket, fileName, fileReader, fileSize, minio.PutObjectOptions{})
+	_, err := c.client.PutObject(ctx, c.config.Bucket, fileName, fileReader, fileSize, minio.PutObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to upload file: %w", err)
 	}
@@ -68,8 +87,7 @@ func (c *S3Client) UploadFile(ctx context.Context, fileReader io.Reader, fileNam
 
 // Download file from minio.
 func (c *S3Client) DownloadFile(ctx context.Context, fileName string) (io.Reader, error) {
-	config := config.GetConfig()
-	reader, err := c.client.GetObject(ctx, config.S3Bucket, fileName, minio.GetObjectOptions{})
+	reader, err := c.client.GetObject(ctx, c.config.Bucket, fileName, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
@@ -78,10 +96,21 @@ func (c *S3Client) DownloadFile(ctx context.Context, fileName string) (io.Reader
 
 // Delete file from minio.
 func (c *S3Client) DeleteFile(ctx context.Context, fileName string) error {
-	config := config.GetConfig()
-	err := c.client.RemoveObject(ctx, config.S3Bucket, fileName, minio.RemoveObjectOptions{})
+	err := c.client.RemoveObject(ctx, c.config.Bucket, fileName, minio.RemoveObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 	return nil
 }
+
+// List all files in minio bucket.
+func (c *S3Client) ListFiles(ctx context.Context) ([]minio.ObjectInfo, error) {
+	var objects []minio.ObjectInfo
+	for object := range c.client.ListObjects(ctx, c.config.Bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", object.Err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
@@ -4,6 +4,7 @@ package filestorage
 import (
 	"context"
 	"fmt"
+	"io"
 
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
 )
@@ -13,8 +14,8 @@ type S3FileStorage struct {
 	client *S3Client
 }
 
-func NewS3FileStorage() (*S3FileStorage, error) {
-	cl, err := NewS3Client()
+func NewS3FileStorage(config S3Config) (*S3FileStorage, error) {
+	cl, err := NewS3Client(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create s3 client: %w", err)
 	}
@@ -29,11 +30,22 @@ func (s *S3FileStorage) Download(stream pb.GophKeeperService_DownloadFileServer,
 	return FromReader2FileStream(reader, stream)
 }
 
-func (s *S3FileStorage) Upload(stream pb.GophKeeperService_UploadFileServer, fileSize int64, fileId string) error {
-	reader := NewFileStreamReader(stream)
-	return s.client.UploadFile(stream.Context(), reader, fileId, fileSize)
+func (s *S3FileStorage) Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error {
+	return s.client.UploadFile(ctx, reader, fileId, fileSize)
 }
 
 func (s *S3FileStorage) Delete(ctx context.Context, fileId string) error {
 	return s.client.DeleteFile(ctx, fileId)
 }
+
+func (s *S3FileStorage) List(ctx context.Context) ([]FileObject, error) {
+	objects, err := s.client.ListFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]FileObject, 0, len(objects))
+	for _, object := range objects {
+		files = append(files, FileObject{Id: object.Key, Size: object.Size, Modified: object.LastModified})
+	}
+	return files, nil
+}
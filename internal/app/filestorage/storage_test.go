@@ -0,0 +1,90 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc"
+)
+
+type downloadStreamMock struct {
+	grpc.ServerStream
+	data *[]byte
+}
+
+func (s downloadStreamMock) Send(filePart *pb.FileStream) error {
+	*s.data = append(*s.data, filePart.GetChunkData()...)
+	return nil
+}
+
+// Checks behaviour every file storage implementation must follow.
+func testFileStorage(t *testing.T, storage StreamingFileStorage) {
+	ctx := context.Background()
+
+	const fileId = "0123456789"
+	data := make([]byte, 3*ChunkSize+1)
+	rand.Read(data)
+	err := storage.Upload(ctx, bytes.NewReader(data), int64(len(data)), fileId)
+	require.NoError(t, err)
+
+	downloaded := make([]byte, 0)
+	err = storage.Download(downloadStreamMock{data: &downloaded}, fileId)
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+
+	files, err := storage.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, fileId, files[0].Id)
+	require.Equal(t, int64(len(data)), files[0].Size)
+
+	require.NoError(t, storage.Delete(ctx, fileId))
+	require.NoError(t, storage.Delete(ctx, fileId))
+	files, err = storage.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, files)
+	require.Error(t, storage.Download(downloadStreamMock{data: &downloaded}, fileId))
+
+	err = storage.Upload(ctx, bytes.NewReader([]byte("short")), 100, fileId)
+	require.Error(t, err)
+	err = storage.Upload(ctx, bytes.NewReader([]byte("short")), math.MaxInt64, fileId)
+	require.Error(t, err, "declared size should not be allocated upfront")
+	files, err = storage.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, files, "incomplete upload should not be stored")
+}
+
+func TestMemoryFileStorage(t *testing.T) {
+	testFileStorage(t, NewMemoryFileStorage())
+}
+
+func TestMemoryFileStorage_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryFileStorage()
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fileId := fmt.Sprintf("file%d", i)
+			data := []byte(fileId)
+			require.NoError(t, storage.Upload(ctx, bytes.NewReader(data), int64(len(data)), fileId))
+			downloaded := make([]byte, 0)
+			require.NoError(t, storage.Download(downloadStreamMock{data: &downloaded}, fileId))
+			require.Equal(t, data, downloaded)
+			_, err := storage.List(ctx)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	files, err := storage.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 10)
+}
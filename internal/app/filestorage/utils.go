@@ -1,11 +1,16 @@
 package filestorage
 
 import (
+	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 
 	"github.com/google/uuid"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc"
 )
 
 // Get file identifier for storing.
@@ -27,7 +32,9 @@ func FromReader2FileStream(reader io.Reader, stream StreamSender) error {
 		if err != nil && err != io.EOF {
 			return fmt.Errorf("error when read file: %w", err)
 		}
-		stream.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: buf[:n]}})
+		if sendErr := stream.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: buf[:n]}}); sendErr != nil {
+			return fmt.Errorf("error when send file: %w", sendErr)
+		}
 		if err == io.EOF {
 			break
 		}
@@ -35,17 +42,45 @@ func FromReader2FileStream(reader io.Reader, stream StreamSender) error {
 	return nil
 }
 
+// Download stream which writes received chunks to writer.
+type writerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	writer io.Writer
+}
+
+func (s writerStream) Send(filePart *pb.FileStream) error {
+	_, err := s.writer.Write(filePart.GetChunkData())
+	return err
+}
+
+func (s writerStream) Context() context.Context {
+	return s.ctx
+}
+
+// Downloads file from storage to writer.
+func DownloadTo(ctx context.Context, storage StreamingFileStorage, fileId string, writer io.Writer) error {
+	return storage.Download(writerStream{ctx: ctx, writer: writer}, fileId)
+}
+
 // Utils for converting stream to io.reader.
 type StreamReciever interface {
 	Recv() (*pb.FileStream, error)
 }
 
+// Error in case received file size differs from declared one.
+var ErrSizeMismatch = errors.New("received file size differs from declared")
+
 // Read from stream to reader.
+//
+// Counts received bytes and computes sha256 checksum of them.
 type FileStreamReader struct {
 	stream     StreamReciever
 	buffer     []byte
 	bufferSize *int
 	pos        *int
+	received   *int64
+	hash       hash.Hash
 }
 
 // New reader from stream to reader.
@@ -54,7 +89,10 @@ func NewFileStreamReader(stream StreamReciever) *FileStreamReader {
 	*pos = 0
 	bufferSize := new(int)
 	*bufferSize = 0
-	return &FileStreamReader{stream: stream, buffer: make([]byte, ChunkSize), pos: pos, bufferSize: bufferSize}
+	received := new(int64)
+	*received = 0
+	return &FileStreamReader{stream: stream, buffer: make([]byte, ChunkSize), pos: pos, bufferSize: bufferSize,
+		received: received, hash: sha256.New()}
 }
 
 // Define Read function for io.reader.
@@ -74,7 +112,36 @@ func (w FileStreamReader) Read(p []byte) (int, error) {
 			return 0, fmt.Errorf("failed to upload: %w", err)
 		}
 
-		*w.bufferSize = copy(w.buffer, resp.GetChunkData())
+		chunk := resp.GetChunkData()
+		if len(chunk) > len(w.buffer) {
+			w.buffer = make([]byte, len(chunk))
+		}
+		*w.bufferSize = copy(w.buffer, chunk)
 		*w.pos = 0
+		*w.received += int64(len(chunk))
+		w.hash.Write(chunk)
 	}
 }
+
+// Number of bytes received from stream.
+func (w FileStreamReader) Received() int64 {
+	return *w.received
+}
+
+// Sha256 checksum of bytes received from stream.
+func (w FileStreamReader) Checksum() []byte {
+	return w.hash.Sum(nil)
+}
+
+// Checks that stream has ended and exactly expected number of bytes was received.
+func (w FileStreamReader) Verify(expectedSize int64) error {
+	if *w.received <= expectedSize {
+		if _, err := io.Copy(io.Discard, io.LimitReader(w, 1)); err != nil {
+			return err
+		}
+	}
+	if *w.received != expectedSize {
+		return fmt.Errorf("%w: declared %d, received %d", ErrSizeMismatch, expectedSize, *w.received)
+	}
+	return nil
+}
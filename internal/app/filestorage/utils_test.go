@@ -3,6 +3,7 @@ package filestorage
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"io"
 	"testing"
 
@@ -62,3 +63,35 @@ func TestFileStreamReader(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, initialData, getData)
 }
+
+func TestFileStreamReader_Verify(t *testing.T) {
+	const msgLength = 10000
+	initialData := make([]byte, msgLength)
+	rand.Read(initialData)
+	checksum := sha256.Sum256(initialData)
+	tests := []struct {
+		name         string
+		expectedSize int64
+		readSize     int64
+		wantErr      bool
+	}{
+		{name: "exact", expectedSize: msgLength, readSize: msgLength, wantErr: false},
+		{name: "declared_bigger", expectedSize: msgLength + 1, readSize: msgLength, wantErr: true},
+		{name: "declared_smaller", expectedSize: msgLength - 1, readSize: msgLength - 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamReciever := TestReciever{data: initialData[:], ind: new(int), chunkSize: 1001}
+			fileReader := NewFileStreamReader(streamReciever)
+			io.CopyN(io.Discard, fileReader, tt.readSize)
+			err := fileReader.Verify(tt.expectedSize)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrSizeMismatch)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, int64(msgLength), fileReader.Received())
+			require.Equal(t, checksum[:], fileReader.Checksum())
+		})
+	}
+}
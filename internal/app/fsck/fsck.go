@@ -0,0 +1,204 @@
+// Package fsck checks consistency between stored file blobs and files metainfo.
+package fsck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"go.uber.org/zap"
+)
+
+// Error in case storages are inconsistent and were not repaired.
+var ErrInconsistent = errors.New("storage is inconsistent")
+
+// Blob without any file metainfo.
+type OrphanedBlob struct {
+	Id       string    `json:"id"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// File metainfo pointing at missing blob.
+type MissingBlob struct {
+	Id    string `json:"id"`
+	Login string `json:"login"`
+	State string `json:"state"`
+}
+
+// File metainfo with size different from blob size.
+type SizeMismatch struct {
+	Id       string `json:"id"`
+	Login    string `json:"login"`
+	Expected uint64 `json:"expected"`
+	Actual   int64  `json:"actual"`
+}
+
+// File metainfo stuck in pending or deleting state.
+type StaleFile struct {
+	Id       string    `json:"id"`
+	Login    string    `json:"login"`
+	State    string    `json:"state"`
+	Modified time.Time `json:"modified"`
+}
+
+// Result of consistency check.
+type Report struct {
+	Started        time.Time      `json:"started"`
+	Blobs          int            `json:"blobs"`
+	Files          int            `json:"files"`
+	OrphanedBlobs  []OrphanedBlob `json:"orphaned_blobs"`
+	MissingBlobs   []MissingBlob  `json:"missing_blobs"`
+	SizeMismatches []SizeMismatch `json:"size_mismatches"`
+	StaleFiles     []StaleFile    `json:"stale_files"`
+	DeletedBlobs   []string       `json:"deleted_blobs"`
+	DeletedFiles   []string       `json:"deleted_files"`
+	Errors         []string       `json:"errors"`
+}
+
+// Whether any inconsistency has been found.
+func (r *Report) HasProblems() bool {
+	return len(r.OrphanedBlobs) > 0 || len(r.MissingBlobs) > 0 || len(r.SizeMismatches) > 0 ||
+		len(r.StaleFiles) > 0 || len(r.Errors) > 0
+}
+
+// Whether some inconsistencies are left after repair.
+func (r *Report) Unrepaired() bool {
+	if len(r.MissingBlobs) > 0 || len(r.SizeMismatches) > 0 || len(r.Errors) > 0 {
+		return true
+	}
+	for _, blob := range r.OrphanedBlobs {
+		if !slices.Contains(r.DeletedBlobs, blob.Id) {
+			return true
+		}
+	}
+	for _, file := range r.StaleFiles {
+		if !slices.Contains(r.DeletedFiles, file.Id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compares blob storage with metainfo storage.
+type Checker struct {
+	fileStorage     filestorage.StreamingFileStorage
+	metaDataStorage metadatastorage.MetadataStorage
+}
+
+func NewChecker(fileStorage filestorage.StreamingFileStorage, metaDataStorage metadatastorage.MetadataStorage) *Checker {
+	return &Checker{fileStorage: fileStorage, metaDataStorage: metaDataStorage}
+}
+
+// Runs consistency check.
+//
+// Orphaned blobs and stale pending or deleting files older than grace period are removed if repair is set.
+// Files pointing at missing blobs and size mismatches are only reported.
+func (c *Checker) Check(ctx context.Context, repair bool, grace time.Duration) (*Report, error) {
+	report := &Report{Started: time.Now()}
+	files, err := c.metaDataStorage.GetAllFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files metainfo: %w", err)
+	}
+	blobs, err := c.fileStorage.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	report.Files = len(files.GetFiles())
+	report.Blobs = len(blobs)
+	deadline := report.Started.Add(-grace)
+
+	blobsById := make(map[string]filestorage.FileObject, len(blobs))
+	for _, blob := range blobs {
+		blobsById[blob.Id] = blob
+	}
+	filesById := make(map[string]*pb.FileInfo, len(files.GetFiles()))
+	for _, file := range files.GetFiles() {
+		filesById[file.GetId().GetId()] = file
+	}
+
+	for _, blob := range blobs {
+		if _, ok := filesById[blob.Id]; ok {
+			continue
+		}
+		report.OrphanedBlobs = append(report.OrphanedBlobs, OrphanedBlob{Id: blob.Id, Size: blob.Size, Modified: blob.Modified})
+		if repair && blob.Modified.Before(deadline) {
+			c.deleteBlob(ctx, report, blob.Id)
+		}
+	}
+
+	for _, file := range files.GetFiles() {
+		id := file.GetId().GetId()
+		modified := time.Unix(int64(file.GetModified()), 0)
+		blob, hasBlob := blobsById[id]
+		if file.GetState() != pb.FileState_COMMITTED {
+			if modified.Before(deadline) {
+				report.StaleFiles = append(report.StaleFiles, StaleFile{Id: id, Login: file.GetLogin(), State: file.GetState().String(), Modified: modified})
+				if repair {
+					c.deleteFile(ctx, report, id, hasBlob)
+				}
+			}
+			continue
+		}
+		if !hasBlob {
+			report.MissingBlobs = append(report.MissingBlobs, MissingBlob{Id: id, Login: file.GetLogin(), State: file.GetState().String()})
+			continue
+		}
+		if blob.Size != int64(file.GetSize()) {
+			report.SizeMismatches = append(report.SizeMismatches, SizeMismatch{Id: id, Login: file.GetLogin(), Expected: file.GetSize(), Actual: blob.Size})
+		}
+	}
+	return report, nil
+}
+
+func (c *Checker) deleteBlob(ctx context.Context, report *Report, fileId string) bool {
+	if err := c.fileStorage.Delete(ctx, fileId); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to delete blob %s: %s", fileId, err))
+		return false
+	}
+	report.DeletedBlobs = append(report.DeletedBlobs, fileId)
+	return true
+}
+
+// Removes stale file blob and metainfo.
+func (c *Checker) deleteFile(ctx context.Context, report *Report, fileId string, hasBlob bool) {
+	if hasBlob && !c.deleteBlob(ctx, report, fileId) {
+		return
+	}
+	if err := c.metaDataStorage.DeleteFileInfo(ctx, fileId); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to delete file metainfo %s: %s", fileId, err))
+		return
+	}
+	report.DeletedFiles = append(report.DeletedFiles, fileId)
+}
+
+// Runs consistency check with given interval until context is done.
+func (c *Checker) RunPeriodically(ctx context.Context, interval time.Duration, repair bool, grace time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := c.Check(ctx, repair, grace)
+			if err != nil {
+				logger.Log.Error("storage consistency check failed", zap.Error(err))
+				continue
+			}
+			data, _ := json.Marshal(report)
+			if report.HasProblems() {
+				logger.Log.Warn("storage consistency check found problems", zap.ByteString("report", data))
+			} else {
+				logger.Log.Info("storage consistency check passed", zap.Int("files", report.Files), zap.Int("blobs", report.Blobs))
+			}
+		}
+	}
+}
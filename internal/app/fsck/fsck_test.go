@@ -0,0 +1,73 @@
+package fsck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/mocks"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+func TestChecker_Check(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+	files := &pb.ListFiles{Files: []*pb.FileInfo{
+		{Id: &pb.FileId{Id: "good"}, Login: "login", Size: 10, Modified: uint64(old.Unix())},
+		{Id: &pb.FileId{Id: "missing"}, Login: "login", Size: 10, Modified: uint64(old.Unix())},
+		{Id: &pb.FileId{Id: "mismatch"}, Login: "login", Size: 10, Modified: uint64(old.Unix())},
+		{Id: &pb.FileId{Id: "stale"}, Login: "login", Size: 10, Modified: uint64(old.Unix()), State: pb.FileState_PENDING},
+		{Id: &pb.FileId{Id: "uploading"}, Login: "login", Size: 10, Modified: uint64(now.Unix()), State: pb.FileState_PENDING},
+	}}
+	blobs := []filestorage.FileObject{
+		{Id: "good", Size: 10, Modified: old},
+		{Id: "mismatch", Size: 5, Modified: old},
+		{Id: "stale", Size: 3, Modified: old},
+		{Id: "uploading", Size: 3, Modified: now},
+		{Id: "orphan", Size: 1, Modified: old},
+		{Id: "fresh_orphan", Size: 1, Modified: now},
+	}
+
+	tests := []struct {
+		name   string
+		repair bool
+	}{
+		{name: "check", repair: false},
+		{name: "repair", repair: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetadataStorage := mocks.NewMetadataStorage(t)
+			mockStreamingFileStorage := mocks.NewStreamingFileStorage(t)
+			mockMetadataStorage.On("GetAllFiles", ctx).Return(files, nil).Once()
+			mockStreamingFileStorage.On("List", ctx).Return(blobs, nil).Once()
+			if tt.repair {
+				mockStreamingFileStorage.On("Delete", ctx, "orphan").Return(nil).Once()
+				mockStreamingFileStorage.On("Delete", ctx, "stale").Return(nil).Once()
+				mockMetadataStorage.On("DeleteFileInfo", ctx, "stale").Return(nil).Once()
+			}
+
+			checker := NewChecker(mockStreamingFileStorage, mockMetadataStorage)
+			report, err := checker.Check(ctx, tt.repair, time.Hour)
+			require.NoError(t, err)
+			require.Equal(t, 5, report.Files)
+			require.Equal(t, 6, report.Blobs)
+			require.Len(t, report.OrphanedBlobs, 2)
+			require.Equal(t, []MissingBlob{{Id: "missing", Login: "login", State: "COMMITTED"}}, report.MissingBlobs)
+			require.Equal(t, []SizeMismatch{{Id: "mismatch", Login: "login", Expected: 10, Actual: 5}}, report.SizeMismatches)
+			require.Len(t, report.StaleFiles, 1)
+			require.True(t, report.HasProblems())
+			require.True(t, report.Unrepaired())
+			if tt.repair {
+				require.ElementsMatch(t, []string{"orphan", "stale"}, report.DeletedBlobs)
+				require.Equal(t, []string{"stale"}, report.DeletedFiles)
+			} else {
+				require.Empty(t, report.DeletedBlobs)
+				require.Empty(t, report.DeletedFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Changes password and revokes all other sessions of user.
+//
+// Client keys and file keys are not derived from password, so nothing has to be re-encrypted.
+func (h *GophKeeperHandlerGrpc) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.RevokedSessions, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	user, err := h.userStorage.GetUser(ctx, login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if auth.ComparePasswordHash(user.PasswordHash, req.GetOldPassword()) != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "wrong password")
+	}
+	if req.GetNewPassword() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "empty password")
+	}
+	if violations := h.PasswordPolicy.Check(req.GetNewPassword(), login); len(violations) > 0 {
+		return nil, policyViolated(violations)
+	}
+	hashedPassword, err := auth.HashPassword(req.GetNewPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if err := h.userStorage.ChangePassword(ctx, login, hashedPassword); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	revoked, err := h.auth.RevokeOtherSessions(ctx, login, auth.GetVarFromContext(ctx, "session"))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.RevokedSessions{Count: uint64(revoked)}, nil
+}
+
+// Deletes all files and sessions of user and user itself.
+//
+// Password and second factor code if enabled confirm deletion. Files are deleted
+// before user, so failed deletion can be repeated after login. User leaves organizations,
+// ones without other members are deleted, last owner should transfer ownership first.
+func (h *GophKeeperHandlerGrpc) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeletedAccount, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	user, err := h.userStorage.GetUser(ctx, login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if auth.ComparePasswordHash(user.PasswordHash, req.GetPassword()) != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "wrong password")
+	}
+	if user.SecondFactor.Enabled {
+		err = h.checkCode(ctx, user, req.GetCode())
+		if errors.Is(err, errWrongCode) {
+			return nil, status.Errorf(codes.PermissionDenied, err.Error())
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, err.Error())
+		}
+	}
+	orgFiles, err := h.service.LeaveOrganizations(ctx, login)
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	if _, err := h.auth.RevokeOtherSessions(ctx, login, ""); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if _, err := h.auth.RevokeApiTokens(ctx, login); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if _, err := h.service.Emergency.Grants.DeleteUserGrants(ctx, login); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	deleted, err := h.service.DeleteUserFiles(ctx, login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if err := h.userStorage.DeleteUser(ctx, login); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.DeletedAccount{Files: uint64(deleted + orgFiles)}, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/service"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Methods allowed for api tokens by scope, other methods require login session.
+var apiTokenMethods = map[string][]string{
+	auth.ScopeRead: {
+		pb.GophKeeperService_GetUserFiles_FullMethodName,
+		pb.GophKeeperService_DownloadFile_FullMethodName,
+	},
+	auth.ScopeWrite: {
+		pb.GophKeeperService_GetUserFiles_FullMethodName,
+		pb.GophKeeperService_DownloadFile_FullMethodName,
+		pb.GophKeeperService_UploadFile_FullMethodName,
+		pb.GophKeeperService_DeleteFile_FullMethodName,
+	},
+}
+
+// Error of request outside of api token scope.
+var errOutOfScope = errors.New("request is out of api token scope")
+
+// Rejects method not allowed for scope of api token of authorized request.
+func checkApiTokenMethod(ctx context.Context, method string) error {
+	scope, _ := auth.GetApiTokenScope(ctx)
+	if scope != "" && !slices.Contains(apiTokenMethods[scope], method) {
+		return status.Errorf(codes.PermissionDenied, errOutOfScope.Error())
+	}
+	return nil
+}
+
+// Wraps unary handler so that it is called only if method is allowed for api token.
+func scopedHandler(method string, handler grpc.UnaryHandler) grpc.UnaryHandler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		if err := checkApiTokenMethod(ctx, method); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Wraps stream handler so that it is called only if method is allowed for api token.
+func scopedStreamHandler(method string, handler grpc.StreamHandler) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		if err := checkApiTokenMethod(stream.Context(), method); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// Whether file is inside folders of api token of request, any file is allowed for login session.
+func fileInScope(ctx context.Context, filename string) bool {
+	_, folders := auth.GetApiTokenScope(ctx)
+	return auth.InFolders(filename, folders)
+}
+
+// Rejects request to file outside folders of api token, request is rejected too if file can't be checked.
+func (h *GophKeeperHandlerGrpc) checkFileScope(ctx context.Context, fileId *pb.FileId) error {
+	if _, folders := auth.GetApiTokenScope(ctx); len(folders) == 0 {
+		return nil
+	}
+	info, err := h.service.GetFileInfo(ctx, fileId, auth.GetVarFromContext(ctx, "login"))
+	switch {
+	case errors.Is(err, metadatastorage.ErrFileNotFound):
+		return status.Errorf(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrNotOwn):
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return status.Errorf(codes.Internal, err.Error())
+	}
+	if !fileInScope(ctx, info.GetFilename()) {
+		return status.Errorf(codes.PermissionDenied, errOutOfScope.Error())
+	}
+	return nil
+}
+
+// Upload stream rejecting file outside folders of api token.
+type scopedUploadStream struct {
+	pb.GophKeeperService_UploadFileServer
+}
+
+func (s scopedUploadStream) Recv() (*pb.FileStream, error) {
+	req, err := s.GophKeeperService_UploadFileServer.Recv()
+	if info := req.GetInfo(); err == nil && info != nil && !fileInScope(s.Context(), info.GetFilename()) {
+		return nil, errOutOfScope
+	}
+	return req, err
+}
+
+func apiTokenInfo(token apitokenstorage.ApiToken) *pb.ApiTokenInfo {
+	info := &pb.ApiTokenInfo{
+		Id:      token.ID,
+		Name:    token.Name,
+		Scope:   token.Scope,
+		Folders: token.Folders,
+		Created: uint64(token.Created.Unix()),
+		Expires: uint64(token.Expires.Unix()),
+	}
+	if !token.LastUsed.IsZero() {
+		info.LastUsed = uint64(token.LastUsed.Unix())
+	}
+	return info
+}
+
+func (h *GophKeeperHandlerGrpc) CreateApiToken(ctx context.Context, req *pb.CreateApiTokenRequest) (*pb.ApiToken, error) {
+	tokenString, token, err := h.auth.CreateApiToken(ctx, auth.GetVarFromContext(ctx, "login"), auth.ApiTokenRequest{
+		Name:      req.GetName(),
+		Scope:     req.GetScope(),
+		Folders:   req.GetFolders(),
+		PublicKey: req.GetPublicKey(),
+		TTL:       time.Duration(req.GetExpiresIn()) * time.Second,
+	})
+	if errors.Is(err, auth.ErrWrongApiToken) {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.ApiToken{Token: tokenString, Info: apiTokenInfo(*token)}, nil
+}
+
+func (h *GophKeeperHandlerGrpc) ListApiTokens(ctx context.Context, _ *emptypb.Empty) (*pb.ApiTokens, error) {
+	tokens, err := h.auth.ListApiTokens(ctx, auth.GetVarFromContext(ctx, "login"))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	res := &pb.ApiTokens{}
+	for _, token := range tokens {
+		res.Tokens = append(res.Tokens, apiTokenInfo(token))
+	}
+	return res, nil
+}
+
+func (h *GophKeeperHandlerGrpc) RevokeApiToken(ctx context.Context, req *pb.ApiTokenId) (*emptypb.Empty, error) {
+	err := h.auth.RevokeApiToken(ctx, auth.GetVarFromContext(ctx, "login"), req.GetId())
+	if errors.Is(err, apitokenstorage.ErrApiTokenNotFound) {
+		return nil, status.Errorf(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergency"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/service"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Converts error of emergency access request to grpc status.
+func emergencyError(err error) error {
+	switch {
+	case errors.Is(err, emergency.ErrDenied), errors.Is(err, service.ErrNotOwn):
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	case errors.Is(err, emergencystorage.ErrGrantNotFound), errors.Is(err, emergencystorage.ErrKeyNotFound),
+		errors.Is(err, userstorage.ErrUserNotFound):
+		return status.Errorf(codes.NotFound, err.Error())
+	case errors.Is(err, emergencystorage.ErrGrantExists):
+		return status.Errorf(codes.AlreadyExists, err.Error())
+	case errors.Is(err, emergency.ErrWrongStatus), errors.Is(err, emergencystorage.ErrStatusChanged),
+		errors.Is(err, service.ErrNotCommitted):
+		return status.Errorf(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, emergency.ErrSelfInvite), errors.Is(err, emergency.ErrWrongWaitPeriod),
+		errors.Is(err, emergency.ErrNoPublicKey):
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	default:
+		return status.Errorf(codes.Internal, err.Error())
+	}
+}
+
+// Converts grant to message, public key of contact is shown only to grantor.
+func grantMessage(login string, grant *emergencystorage.Grant) *pb.Grant {
+	res := &pb.Grant{
+		Id:         grant.ID,
+		Grantor:    grant.Grantor,
+		Grantee:    grant.Grantee,
+		WaitPeriod: uint64(grant.WaitPeriod.Seconds()),
+		Status:     grant.Status,
+		Created:    uint64(grant.Created.Unix()),
+	}
+	if !grant.Requested.IsZero() {
+		res.Requested = uint64(grant.Requested.Unix())
+		res.Granted = uint64(grant.Requested.Add(grant.WaitPeriod).Unix())
+	}
+	if login == grant.Grantor {
+		res.PublicKey = grant.PublicKey
+	}
+	return res
+}
+
+// Invites registered user as trusted contact of user of request.
+func (h *GophKeeperHandlerGrpc) InviteEmergencyContact(ctx context.Context, req *pb.InviteRequest) (*pb.Grant, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	user, err := h.findUser(ctx, req.GetLogin())
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	// Checked before conversion as long period overflows duration.
+	if req.GetWaitPeriod() > uint64(emergency.MaxWaitPeriod/time.Second) {
+		return nil, emergencyError(emergency.ErrWrongWaitPeriod)
+	}
+	grant, err := h.service.Emergency.Invite(ctx, login, user.Login, time.Duration(req.GetWaitPeriod())*time.Second)
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return grantMessage(login, grant), nil
+}
+
+// Lists emergency access given by user and to user.
+func (h *GophKeeperHandlerGrpc) ListEmergencyAccess(ctx context.Context, _ *emptypb.Empty) (*pb.Grants, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	grants, err := h.service.Emergency.List(ctx, login)
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	res := &pb.Grants{}
+	for _, grant := range grants {
+		res.Grants = append(res.Grants, grantMessage(login, &grant))
+	}
+	return res, nil
+}
+
+// Accepts invitation, public key of session is used if request has none.
+func (h *GophKeeperHandlerGrpc) AcceptEmergencyAccess(ctx context.Context, req *pb.AcceptRequest) (*pb.Grant, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	publicKey := req.GetPublicKey()
+	if len(publicKey) == 0 {
+		publicKey = []byte(auth.GetVarFromContext(ctx, "public_key"))
+	}
+	grant, err := h.service.Emergency.Accept(ctx, login, req.GetId(), publicKey)
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return grantMessage(login, grant), nil
+}
+
+// Saves file keys wrapped by grantor for contact.
+func (h *GophKeeperHandlerGrpc) ConfirmEmergencyAccess(ctx context.Context, req *pb.ConfirmRequest) (*pb.Grant, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	keys := make([]emergencystorage.Key, 0, len(req.GetKeys()))
+	for _, key := range req.GetKeys() {
+		keys = append(keys, emergencystorage.Key{GrantID: req.GetId(), FileID: key.GetFileId(), Key: key.GetKey()})
+	}
+	grant, err := h.service.ConfirmEmergencyAccess(ctx, login, req.GetId(), keys)
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return grantMessage(login, grant), nil
+}
+
+func (h *GophKeeperHandlerGrpc) RequestEmergencyAccess(ctx context.Context, req *pb.EmergencyId) (*pb.Grant, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	grant, err := h.service.Emergency.Request(ctx, login, req.GetId())
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return grantMessage(login, grant), nil
+}
+
+func (h *GophKeeperHandlerGrpc) ApproveEmergencyAccess(ctx context.Context, req *pb.EmergencyId) (*pb.Grant, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	grant, err := h.service.Emergency.Approve(ctx, login, req.GetId())
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return grantMessage(login, grant), nil
+}
+
+func (h *GophKeeperHandlerGrpc) RejectEmergencyAccess(ctx context.Context, req *pb.EmergencyId) (*pb.Grant, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	grant, err := h.service.Emergency.Reject(ctx, login, req.GetId())
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return grantMessage(login, grant), nil
+}
+
+// Revokes emergency access by grantor or declines it by contact.
+func (h *GophKeeperHandlerGrpc) RevokeEmergencyAccess(ctx context.Context, req *pb.EmergencyId) (*emptypb.Empty, error) {
+	if err := h.service.Emergency.Revoke(ctx, auth.GetVarFromContext(ctx, "login"), req.GetId()); err != nil {
+		return nil, emergencyError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Lists files of grantor available by approved emergency access.
+func (h *GophKeeperHandlerGrpc) GetEmergencyFiles(ctx context.Context, req *pb.EmergencyId) (*pb.ListFiles, error) {
+	files, err := h.service.GetEmergencyFiles(ctx, auth.GetVarFromContext(ctx, "login"), req.GetId())
+	if err != nil {
+		return nil, emergencyError(err)
+	}
+	return files, nil
+}
+
+func (h *GophKeeperHandlerGrpc) DownloadEmergencyFile(req *pb.EmergencyFile, srv pb.GophKeeperService_DownloadEmergencyFileServer) error {
+	if err := h.service.DownloadEmergencyFile(req, srv, auth.GetVarFromContext(srv.Context(), "login")); err != nil {
+		return emergencyError(err)
+	}
+	return nil
+}
@@ -3,14 +3,19 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"slices"
 
 	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
 	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
 	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
+	"github.com/valinurovdenis/gophkeeper/internal/app/policy"
 	"github.com/valinurovdenis/gophkeeper/internal/app/service"
+	"github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
 	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -23,46 +28,78 @@ type GophKeeperHandlerGrpc struct {
 	service     service.GophKeeperService
 	auth        auth.JwtAuthenticator
 	userStorage userstorage.UserStorage
+
+	// Rules checked on registration, zero policies allow anything.
+	LoginPolicy    policy.LoginPolicy
+	PasswordPolicy policy.PasswordPolicy
 }
 
 func NewGophKeeperHandler(service service.GophKeeperService, auth auth.JwtAuthenticator, userStorage userstorage.UserStorage) (*GophKeeperHandlerGrpc, error) {
 	encryption.CreateKeysIfAbsent(true)
-	return &GophKeeperHandlerGrpc{service: service, auth: auth, userStorage: userStorage}, nil
+	return &GophKeeperHandlerGrpc{service: service, auth: auth, userStorage: userStorage,
+		LoginPolicy: policy.DefaultLoginPolicy, PasswordPolicy: policy.DefaultPasswordPolicy}, nil
 }
 
 const (
-	RegisterMethod = "/gophkeeper.GophKeeperService/Register"
-	LoginMethod    = "/gophkeeper.GophKeeperService/Login"
+	RegisterMethod           = "/gophkeeper.GophKeeperService/Register"
+	LoginMethod              = "/gophkeeper.GophKeeperService/Login"
+	RefreshTokenMethod       = "/gophkeeper.GophKeeperService/RefreshToken"
+	VerifySecondFactorMethod = "/gophkeeper.GophKeeperService/VerifySecondFactor"
 )
 
-var authMethods = []string{RegisterMethod, LoginMethod}
+var authMethods = []string{RegisterMethod, LoginMethod, RefreshTokenMethod, VerifySecondFactorMethod}
 
-// Defines handlers with interceptors.
-func KeeperGrpcRouter(gophKeeperHandler GophKeeperHandlerGrpc) *grpc.Server {
+// Defines handlers with interceptors, options like transport credentials are passed to grpc server.
+func KeeperGrpcRouter(gophKeeperHandler GophKeeperHandlerGrpc, opts ...grpc.ServerOption) *grpc.Server {
 	authorizationInterceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler) (interface{}, error) {
 
 		if !slices.Contains(authMethods, info.FullMethod) {
-			return gophKeeperHandler.auth.CheckAuth(ctx, req, info, handler)
+			return gophKeeperHandler.auth.CheckAuth(ctx, req, info, scopedHandler(info.FullMethod, handler))
 		}
 		return handler(ctx, req)
 	}
 	authorizationStreamInterceptor := func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		if !slices.Contains(authMethods, info.FullMethod) {
-			return gophKeeperHandler.auth.CheckStreamAuth(srv, stream, info, handler)
+			return gophKeeperHandler.auth.CheckStreamAuth(srv, stream, info, scopedStreamHandler(info.FullMethod, handler))
 		}
 		return handler(srv, stream)
 	}
 
-	srv := grpc.NewServer(
+	srv := grpc.NewServer(append(opts,
 		grpc.ChainUnaryInterceptor(logger.RequestLoggerInterceptor(), authorizationInterceptor),
-		grpc.ChainStreamInterceptor(logger.RequestStreamLoggerInterceptor(), authorizationStreamInterceptor))
+		grpc.ChainStreamInterceptor(logger.RequestStreamLoggerInterceptor(), authorizationStreamInterceptor))...)
 	pb.RegisterGophKeeperServiceServer(srv, &gophKeeperHandler)
 
 	return srv
 }
 
+// Error of registration with login or password breaking policy, broken rules are listed in BadRequest details.
+func policyViolated(violations []policy.Violation) error {
+	details := &errdetails.BadRequest{}
+	for _, violation := range violations {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       violation.Field,
+			Description: violation.Description,
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, "login or password does not satisfy policy").WithDetails(details)
+	if err != nil {
+		return status.Errorf(codes.Internal, err.Error())
+	}
+	return st.Err()
+}
+
 func (h *GophKeeperHandlerGrpc) Register(ctx context.Context, user *pb.UserData) (*pb.ServicePublicKey, error) {
+	user.Login = policy.NormalizeLogin(user.GetLogin())
+	violations := h.LoginPolicy.Check(user.GetLogin())
+	violations = append(violations, h.PasswordPolicy.Check(user.GetPassword(), user.GetLogin())...)
+	if len(violations) > 0 {
+		return nil, policyViolated(violations)
+	}
+	if !auth.MatchesCertificate(ctx, user.GetLogin()) {
+		return nil, errOtherCertificateLogin
+	}
 	var err error
 	var hashedPassword []byte
 	if hashedPassword, err = auth.HashPassword(user.GetPassword()); err == nil {
@@ -74,43 +111,173 @@ func (h *GophKeeperHandlerGrpc) Register(ctx context.Context, user *pb.UserData)
 		}
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
-	token, err := h.auth.BuildJWTString(user.GetLogin(), user.GetPublicKey())
-	if err != nil {
+	if err := h.setTokens(ctx, user); err != nil {
 		return nil, err
 	}
-	grpc.SetHeader(ctx, metadata.Pairs("Authorization", token))
 	return &pb.ServicePublicKey{PublicKey: encryption.ServerPublicKey()}, nil
 }
 
+// Error of login with unknown user or wrong password, the same for both so that existing logins are not revealed.
+var errWrongCredentials = status.Errorf(codes.PermissionDenied, "wrong login or password")
+
+// Error of login or registration with client certificate issued for other login.
+var errOtherCertificateLogin = status.Errorf(codes.PermissionDenied, "client certificate is issued for other login")
+
+// Reserves login attempt, rejects it if login or client address has too many recent failures.
+// Reserved attempt must be finished by FinishLoginAttempt.
+func (h *GophKeeperHandlerGrpc) reserveLoginAttempt(ctx context.Context, login string) error {
+	var throttled *auth.ThrottledError
+	if err := h.auth.ReserveLoginAttempt(ctx, login); errors.As(err, &throttled) {
+		return status.Errorf(codes.ResourceExhausted, err.Error())
+	}
+	return nil
+}
+
+// Finds user by normalized login, users registered before normalization are found by login as is.
+func (h *GophKeeperHandlerGrpc) findUser(ctx context.Context, login string) (*userstorage.User, error) {
+	user, err := h.userStorage.GetUser(ctx, policy.NormalizeLogin(login))
+	if errors.Is(err, userstorage.ErrUserNotFound) && policy.NormalizeLogin(login) != login {
+		return h.userStorage.GetUser(ctx, login)
+	}
+	return user, err
+}
+
 func (h *GophKeeperHandlerGrpc) Login(ctx context.Context, user *pb.UserData) (*pb.ServicePublicKey, error) {
-	existingUser, err := h.userStorage.GetUser(ctx, user.GetLogin())
+	login := policy.NormalizeLogin(user.GetLogin())
+	if err := h.reserveLoginAttempt(ctx, login); err != nil {
+		return nil, err
+	}
+	defer h.auth.FinishLoginAttempt(ctx, login)
+	if !auth.MatchesCertificate(ctx, login) {
+		return nil, errOtherCertificateLogin
+	}
+	existingUser, err := h.findUser(ctx, user.GetLogin())
 	if err != nil || existingUser == nil {
-		return nil, status.Errorf(codes.NotFound, "error getting user by login")
+		auth.DummyComparePassword(user.GetPassword())
+		h.auth.LoginFailed(ctx, login)
+		return nil, errWrongCredentials
 	}
 	if auth.ComparePasswordHash(existingUser.PasswordHash, user.GetPassword()) != nil {
-		return nil, status.Errorf(codes.PermissionDenied, "wrong password")
+		h.auth.LoginFailed(ctx, login)
+		return nil, errWrongCredentials
 	}
-	token, err := h.auth.BuildJWTString(user.GetLogin(), user.GetPublicKey())
-	if err != nil {
+	user.Login = existingUser.Login
+	// Failures are forgotten only after second factor is checked too.
+	if existingUser.SecondFactor.Enabled {
+		return nil, secondFactorRequired(h.auth.NewChallenge(user.GetLogin(), user.GetPublicKey(), user.GetDevice()))
+	}
+	h.auth.LoginSucceeded(login)
+	if err := h.setTokens(ctx, user); err != nil {
 		return nil, err
 	}
-	grpc.SetHeader(ctx, metadata.Pairs("Authorization", token))
 	return &pb.ServicePublicKey{PublicKey: encryption.ServerPublicKey()}, nil
 }
 
+// Issues access and refresh tokens in response headers.
+func (h *GophKeeperHandlerGrpc) setTokens(ctx context.Context, user *pb.UserData) error {
+	tokens, err := h.auth.IssueTokens(ctx, user.GetLogin(), user.GetPublicKey(), user.GetDevice())
+	if err != nil {
+		return status.Errorf(codes.Internal, err.Error())
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("Authorization", tokens.Access, "Refresh-Token", tokens.Refresh))
+	return nil
+}
+
+// Exchanges refresh token for new pair of tokens.
+func (h *GophKeeperHandlerGrpc) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.Tokens, error) {
+	tokens, err := h.auth.RefreshTokens(ctx, req.GetRefreshToken())
+	if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.Tokens{
+		AccessToken:        tokens.Access,
+		RefreshToken:       tokens.Refresh,
+		AccessTokenExpires: uint64(tokens.AccessExpires.Unix()),
+	}, nil
+}
+
+// Revokes session of request.
+func (h *GophKeeperHandlerGrpc) Logout(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	err := h.auth.RevokeSession(ctx, login, auth.GetVarFromContext(ctx, "session"))
+	if err != nil && !errors.Is(err, sessionstorage.ErrSessionNotFound) {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *GophKeeperHandlerGrpc) ListSessions(ctx context.Context, _ *emptypb.Empty) (*pb.Sessions, error) {
+	current := auth.GetVarFromContext(ctx, "session")
+	sessions, err := h.auth.ListSessions(ctx, auth.GetVarFromContext(ctx, "login"))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	res := &pb.Sessions{}
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, &pb.SessionInfo{
+			Id:       session.ID,
+			Device:   session.Device,
+			Ip:       session.IP,
+			Created:  uint64(session.Created.Unix()),
+			LastSeen: uint64(session.LastSeen.Unix()),
+			Current:  session.ID == current,
+		})
+	}
+	return res, nil
+}
+
+func (h *GophKeeperHandlerGrpc) RevokeSession(ctx context.Context, id *pb.SessionId) (*emptypb.Empty, error) {
+	err := h.auth.RevokeSession(ctx, auth.GetVarFromContext(ctx, "login"), id.GetId())
+	if errors.Is(err, sessionstorage.ErrSessionNotFound) {
+		return nil, status.Errorf(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Revokes all user sessions except session of request.
+func (h *GophKeeperHandlerGrpc) RevokeOtherSessions(ctx context.Context, _ *emptypb.Empty) (*pb.RevokedSessions, error) {
+	revoked, err := h.auth.RevokeOtherSessions(ctx, auth.GetVarFromContext(ctx, "login"), auth.GetVarFromContext(ctx, "session"))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.RevokedSessions{Count: uint64(revoked)}, nil
+}
+
 func (h *GophKeeperHandlerGrpc) GetUserFiles(ctx context.Context, _ *emptypb.Empty) (*pb.ListFiles, error) {
 	login := auth.GetVarFromContext(ctx, "login")
 	files, err := h.service.GetUserFiles(ctx, login)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
+	files.Files = slices.DeleteFunc(files.Files, func(file *pb.FileInfo) bool {
+		return !fileInScope(ctx, file.GetFilename())
+	})
 	return files, nil
 }
 
 func (h *GophKeeperHandlerGrpc) UploadFile(srv pb.GophKeeperService_UploadFileServer) error {
 	login := auth.GetVarFromContext(srv.Context(), "login")
-	err := h.service.UploadFile(srv, login)
+	err := h.service.UploadFile(scopedUploadStream{srv}, login)
 	if err != nil {
+		if errors.Is(err, errOutOfScope) {
+			return status.Errorf(codes.PermissionDenied, errOutOfScope.Error())
+		}
+		if err == service.ErrNotOwn {
+			return status.Errorf(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, filestorage.ErrSizeMismatch) || errors.Is(err, service.ErrFileTooLarge) {
+			return status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		// Client wrapped file key by server key which has been rotated and removed.
+		if errors.Is(err, encryption.ErrUnknownServerKey) {
+			return status.Errorf(codes.FailedPrecondition, err.Error())
+		}
 		return status.Errorf(codes.Internal, err.Error())
 	}
 	return nil
@@ -119,11 +286,21 @@ func (h *GophKeeperHandlerGrpc) UploadFile(srv pb.GophKeeperService_UploadFileSe
 func (h *GophKeeperHandlerGrpc) DownloadFile(fileId *pb.FileId, srv pb.GophKeeperService_DownloadFileServer) error {
 	login := auth.GetVarFromContext(srv.Context(), "login")
 	publicKey := auth.GetVarFromContext(srv.Context(), "public_key")
+	if err := h.checkFileScope(srv.Context(), fileId); err != nil {
+		return err
+	}
 	err := h.service.DownloadFile(fileId, srv, login, []byte(publicKey))
 	if err != nil {
 		if err == service.ErrNotOwn {
 			return status.Errorf(codes.PermissionDenied, err.Error())
 		}
+		if err == service.ErrNotCommitted {
+			return status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		// File key is wrapped by server key which has been rotated and removed.
+		if errors.Is(err, encryption.ErrUnknownServerKey) {
+			return status.Errorf(codes.FailedPrecondition, err.Error())
+		}
 		return status.Errorf(codes.Internal, err.Error())
 	}
 	return nil
@@ -131,12 +308,18 @@ func (h *GophKeeperHandlerGrpc) DownloadFile(fileId *pb.FileId, srv pb.GophKeepe
 
 func (h *GophKeeperHandlerGrpc) DeleteFile(ctx context.Context, fileId *pb.FileId) (*emptypb.Empty, error) {
 	login := auth.GetVarFromContext(ctx, "login")
+	if err := h.checkFileScope(ctx, fileId); err != nil {
+		return nil, err
+	}
 	err := h.service.DeleteFile(ctx, fileId, login)
 	if err != nil {
 		if err == service.ErrNotOwn {
 			return nil, status.Errorf(codes.PermissionDenied, err.Error())
 		}
+		if err == service.ErrNotCommitted {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, err.Error())
 	}
-	return nil, nil
+	return &emptypb.Empty{}, nil
 }
@@ -2,18 +2,41 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
 	"log"
+	"math"
 	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	"github.com/valinurovdenis/gophkeeper/internal/app/certs"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergency"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/policy"
 	"github.com/valinurovdenis/gophkeeper/internal/app/service"
+	"github.com/valinurovdenis/gophkeeper/internal/app/totp"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
 	"github.com/valinurovdenis/gophkeeper/internal/mocks"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -22,15 +45,18 @@ import (
 const bufSize = 1024 * 1024
 const secretKey = "secret_key"
 
-func initHandlers(mockMetadataStorage *mocks.MetadataStorage,
-	mockStreamingFileStorage *mocks.StreamingFileStorage,
-	mockUserStorage *mocks.UserStorage,
+func initHandlers(mockMetadataStorage metadatastorage.MetadataStorage,
+	mockStreamingFileStorage filestorage.StreamingFileStorage,
+	mockUserStorage userstorage.UserStorage,
 	auth *auth.JwtAuthenticator) (*grpc.Server, *bufconn.Listener) {
 
-	lis := bufconn.Listen(bufSize)
 	service, _ := service.NewGophKeeperService(mockStreamingFileStorage, mockMetadataStorage)
-	grpcHandler := GophKeeperHandlerGrpc{service: *service, auth: *auth, userStorage: mockUserStorage}
-	grpcSrv := KeeperGrpcRouter(grpcHandler)
+	return serveHandler(GophKeeperHandlerGrpc{service: *service, auth: *auth, userStorage: mockUserStorage})
+}
+
+func serveHandler(grpcHandler GophKeeperHandlerGrpc, opts ...grpc.ServerOption) (*grpc.Server, *bufconn.Listener) {
+	lis := bufconn.Listen(bufSize)
+	grpcSrv := KeeperGrpcRouter(grpcHandler, opts...)
 	go func() {
 		if err := grpcSrv.Serve(lis); err != nil {
 			log.Fatalf("Server exited with error: %v", err)
@@ -75,3 +101,770 @@ func TestShortenerHandlerGrpc_TestUnauthorized(t *testing.T) {
 	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
 	grpcSrv.Stop()
 }
+
+// Generates pem rsa keys pair.
+func generateRsaKeys(t *testing.T) ([]byte, []byte) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: publicKeyDER})
+}
+
+// Replaces server keys by generated ones until test ends, returns public key.
+func useServerKeys(t *testing.T) []byte {
+	serverPrivateKey, serverPublicKey := generateRsaKeys(t)
+	privateKey, publicKey := encryption.ServerPrivateKey, encryption.ServerPublicKey
+	t.Cleanup(func() { encryption.ServerPrivateKey, encryption.ServerPublicKey = privateKey, publicKey })
+	encryption.ServerPrivateKey = func() []byte { return serverPrivateKey }
+	encryption.ServerPublicKey = func() []byte { return serverPublicKey }
+	return serverPublicKey
+}
+
+// Starts server with memory file and user storages, server is stopped when test ends.
+func startMemoryServer(t *testing.T, metadataStorage metadatastorage.MetadataStorage,
+	authenticator *auth.JwtAuthenticator) pb.GophKeeperServiceClient {
+
+	grpcSrv, lis := initHandlers(metadataStorage, filestorage.NewMemoryFileStorage(), userstorage.NewMemoryUserStorage(), authenticator)
+	t.Cleanup(grpcSrv.Stop)
+	conn := getGrpcConn(t, lis)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGophKeeperServiceClient(conn)
+}
+
+// Registers user and returns context authorized by its access token.
+func registerUser(t *testing.T, grpcClient pb.GophKeeperServiceClient, user *pb.UserData) context.Context {
+	var header metadata.MD
+	_, err := grpcClient.Register(context.Background(), user, grpc.Header(&header))
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "Authorization", header.Get("Authorization")[0])
+}
+
+func TestShortenerHandlerGrpc_MemoryStorages(t *testing.T) {
+	serverPublicKey := useServerKeys(t)
+	clientPrivateKey, clientPublicKey := generateRsaKeys(t)
+
+	auth := auth.NewAuthenticator(secretKey)
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), auth)
+
+	var header metadata.MD
+	user := &pb.UserData{Login: "login", Password: "password", PublicKey: clientPublicKey}
+	_, err := grpcClient.Register(context.Background(), user, grpc.Header(&header))
+	require.NoError(t, err)
+	_, err = grpcClient.Register(context.Background(), user)
+	require.Equal(t, codes.AlreadyExists, getStatusFromGrpcError(t, err))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "Authorization", header.Get("Authorization")[0])
+
+	fileKey := []byte("encrypt")
+	encryptionKey, err := encryption.EncryptFileEncryptionKey(fileKey, serverPublicKey)
+	require.NoError(t, err)
+	data := []byte("file data")
+	upload, err := grpcClient.UploadFile(ctx)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+		Filename: "name", EncryptionKey: encryptionKey, Size: uint64(len(data))}}}))
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: data}}))
+	resp, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+
+	// Declared size is not trusted by storage, server stays up.
+	upload, err = grpcClient.UploadFile(ctx)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+		Filename: "huge", EncryptionKey: encryptionKey, Size: math.MaxUint64}}}))
+	_, err = upload.CloseAndRecv()
+	require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err))
+
+	files, err := grpcClient.GetUserFiles(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1)
+	require.Equal(t, resp.GetId().GetId(), files.GetFiles()[0].GetId().GetId())
+	require.Equal(t, encryption.KeyID(serverPublicKey), files.GetFiles()[0].GetKeyId(), "file key should be stored with server key id")
+
+	download, err := grpcClient.DownloadFile(ctx, resp.GetId())
+	require.NoError(t, err)
+	info, err := download.Recv()
+	require.NoError(t, err)
+	decryptedKey, err := encryption.DecryptFileEncryptionKey(info.GetInfo().GetEncryptionKey(), clientPrivateKey)
+	require.NoError(t, err)
+	require.Equal(t, fileKey, decryptedKey)
+	downloaded := make([]byte, 0)
+	for {
+		chunk, err := download.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		downloaded = append(downloaded, chunk.GetChunkData()...)
+	}
+	require.Equal(t, data, downloaded)
+
+	_, err = grpcClient.DeleteFile(ctx, resp.GetId())
+	require.NoError(t, err)
+	files, err = grpcClient.GetUserFiles(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles())
+}
+
+func TestShortenerHandlerGrpc_RefreshToken(t *testing.T) {
+	useServerKeys(t)
+
+	auth := auth.NewAuthenticator(secretKey)
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), auth)
+
+	var header metadata.MD
+	user := &pb.UserData{Login: "login", Password: "password"}
+	_, err := grpcClient.Register(context.Background(), user, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get("Refresh-Token"), 1)
+	refresh := header.Get("Refresh-Token")[0]
+
+	tokens, err := grpcClient.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: refresh})
+	require.NoError(t, err)
+	require.NotEqual(t, refresh, tokens.GetRefreshToken())
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "Authorization", tokens.GetAccessToken())
+	_, err = grpcClient.GetUserFiles(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	_, err = grpcClient.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: refresh})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.RefreshToken(context.Background(), &pb.RefreshTokenRequest{RefreshToken: tokens.GetRefreshToken()})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+}
+
+func TestShortenerHandlerGrpc_Sessions(t *testing.T) {
+	useServerKeys(t)
+
+	auth := auth.NewAuthenticator(secretKey)
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), auth)
+
+	login := func(device string) context.Context {
+		var header metadata.MD
+		_, err := grpcClient.Login(context.Background(), &pb.UserData{Login: "login", Password: "password", Device: device}, grpc.Header(&header))
+		require.NoError(t, err)
+		return metadata.AppendToOutgoingContext(context.Background(), "Authorization", header.Get("Authorization")[0])
+	}
+	_, err := grpcClient.Register(context.Background(), &pb.UserData{Login: "login", Password: "password", Device: "laptop"})
+	require.NoError(t, err)
+	phone := login("phone")
+	tablet := login("tablet")
+
+	sessions, err := grpcClient.ListSessions(phone, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, sessions.GetSessions(), 3)
+	var tabletId string
+	for _, session := range sessions.GetSessions() {
+		require.Equal(t, session.GetDevice() == "phone", session.GetCurrent())
+		if session.GetDevice() == "tablet" {
+			tabletId = session.GetId()
+		}
+	}
+
+	_, err = grpcClient.RevokeSession(phone, &pb.SessionId{Id: "absent"})
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.RevokeSession(phone, &pb.SessionId{Id: tabletId})
+	require.NoError(t, err)
+	_, err = grpcClient.GetUserFiles(tablet, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+
+	revoked, err := grpcClient.RevokeOtherSessions(phone, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), revoked.GetCount())
+
+	_, err = grpcClient.Logout(phone, &emptypb.Empty{})
+	require.NoError(t, err)
+	_, err = grpcClient.GetUserFiles(phone, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+}
+
+func TestShortenerHandlerGrpc_SecondFactor(t *testing.T) {
+	useServerKeys(t)
+
+	// Wrong codes are checked against challenge limit, not login throttling.
+	authenticator := auth.NewAuthenticator(secretKey)
+	lenient := auth.ThrottleSettings{FreeAttempts: 100, LockoutAttempts: 100}
+	authenticator.SetThrottleSettings(lenient, lenient)
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), authenticator)
+
+	var header metadata.MD
+	user := &pb.UserData{Login: "login", Password: "password"}
+	_, err := grpcClient.Register(context.Background(), user, grpc.Header(&header))
+	require.NoError(t, err)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "Authorization", header.Get("Authorization")[0])
+
+	secret, err := grpcClient.EnableSecondFactor(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Contains(t, secret.GetUri(), secret.GetSecret())
+	_, err = grpcClient.ConfirmSecondFactor(ctx, &pb.SecondFactorCode{Code: "000000x"})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret.GetSecret(), step)
+	require.NoError(t, err)
+	recovery, err := grpcClient.ConfirmSecondFactor(ctx, &pb.SecondFactorCode{Code: code})
+	require.NoError(t, err)
+	require.Len(t, recovery.GetCodes(), 10)
+	_, err = grpcClient.EnableSecondFactor(ctx, &emptypb.Empty{})
+	require.Equal(t, codes.FailedPrecondition, getStatusFromGrpcError(t, err))
+
+	login := func() string {
+		_, err := grpcClient.Login(context.Background(), user)
+		require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+		for _, detail := range status.Convert(err).Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetReason() == SecondFactorRequiredReason {
+				return info.GetMetadata()[ChallengeIdKey]
+			}
+		}
+		t.Fatal("no second factor challenge")
+		return ""
+	}
+	verify := func(challenge string, code string) error {
+		header = metadata.MD{}
+		_, err := grpcClient.VerifySecondFactor(context.Background(),
+			&pb.SecondFactorRequest{ChallengeId: challenge, Code: code}, grpc.Header(&header))
+		return err
+	}
+
+	// Code used for confirmation can't be used again.
+	challenge := login()
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, verify(challenge, code)))
+	next, err := totp.Code(secret.GetSecret(), step+1)
+	require.NoError(t, err)
+	require.NoError(t, verify(challenge, next))
+	require.Len(t, header.Get("Authorization"), 1)
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, verify(challenge, next)))
+
+	challenge = login()
+	require.NoError(t, verify(challenge, strings.ToLower(recovery.GetCodes()[0])))
+	challenge = login()
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, verify(challenge, recovery.GetCodes()[0])))
+
+	// Challenge is dropped after too many wrong codes.
+	for i := 1; i < auth.MaxChallengeAttempts; i++ {
+		require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, verify(challenge, "000000")))
+	}
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, verify(challenge, recovery.GetCodes()[1])))
+
+	_, err = grpcClient.DisableSecondFactor(ctx, &pb.SecondFactorCode{Code: recovery.GetCodes()[1]})
+	require.NoError(t, err)
+	_, err = grpcClient.Login(context.Background(), user)
+	require.NoError(t, err)
+}
+
+func TestShortenerHandlerGrpc_Account(t *testing.T) {
+	serverPublicKey := useServerKeys(t)
+
+	metadataStorage := metadatastorage.NewMemoryStorage()
+	grpcClient := startMemoryServer(t, metadataStorage, auth.NewAuthenticator(secretKey))
+
+	login := func(password string) (context.Context, error) {
+		var header metadata.MD
+		_, err := grpcClient.Login(context.Background(), &pb.UserData{Login: "login", Password: password}, grpc.Header(&header))
+		if err != nil {
+			return nil, err
+		}
+		return metadata.AppendToOutgoingContext(context.Background(), "Authorization", header.Get("Authorization")[0]), nil
+	}
+	_, err := grpcClient.Register(context.Background(), &pb.UserData{Login: "login", Password: "password"})
+	require.NoError(t, err)
+	ctx, err := login("password")
+	require.NoError(t, err)
+	other, err := login("password")
+	require.NoError(t, err)
+
+	_, err = grpcClient.ChangePassword(ctx, &pb.ChangePasswordRequest{OldPassword: "wrong", NewPassword: "new"})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	revoked, err := grpcClient.ChangePassword(ctx, &pb.ChangePasswordRequest{OldPassword: "password", NewPassword: "new"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), revoked.GetCount())
+	_, err = grpcClient.GetUserFiles(other, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+	_, err = login("password")
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = login("new")
+	require.NoError(t, err)
+
+	fileKey, err := encryption.EncryptFileEncryptionKey([]byte("encrypt"), serverPublicKey)
+	require.NoError(t, err)
+	upload, err := grpcClient.UploadFile(ctx)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+		Filename: "name", EncryptionKey: fileKey, Size: 4}}}))
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: []byte("data")}}))
+	_, err = upload.CloseAndRecv()
+	require.NoError(t, err)
+
+	_, err = grpcClient.DeleteAccount(ctx, &pb.DeleteAccountRequest{Password: "password"})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	deleted, err := grpcClient.DeleteAccount(ctx, &pb.DeleteAccountRequest{Password: "new"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), deleted.GetFiles())
+	files, err := metadataStorage.GetAllFiles(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles())
+	_, err = grpcClient.GetUserFiles(ctx, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+	_, err = login("new")
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+}
+
+func TestShortenerHandlerGrpc_LoginThrottling(t *testing.T) {
+	useServerKeys(t)
+
+	authenticator := auth.NewAuthenticator(secretKey)
+	authenticator.SetThrottleSettings(
+		auth.ThrottleSettings{FreeAttempts: 1, BaseDelay: time.Hour, MaxDelay: time.Hour, LockoutAttempts: 5, LockoutDuration: time.Hour, Window: time.Hour},
+		auth.ThrottleSettings{FreeAttempts: 4, BaseDelay: time.Hour, MaxDelay: time.Hour, LockoutAttempts: 10, LockoutDuration: time.Hour, Window: time.Hour})
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), authenticator)
+
+	_, err := grpcClient.Register(context.Background(), &pb.UserData{Login: "login", Password: "password"})
+	require.NoError(t, err)
+	login := func(login, password string) error {
+		_, err := grpcClient.Login(context.Background(), &pb.UserData{Login: login, Password: password})
+		return err
+	}
+
+	// Unknown login and wrong password are indistinguishable.
+	unknown, wrong := login("absent", "password"), login("login", "wrong")
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, unknown))
+	require.Equal(t, status.Convert(unknown).Message(), status.Convert(wrong).Message())
+
+	// Login is throttled after free attempts even with right password.
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, login("login", "wrong")))
+	require.Equal(t, codes.ResourceExhausted, getStatusFromGrpcError(t, login("login", "password")))
+
+	// Address is throttled after its free attempts for any login.
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, login("other", "wrong")))
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, login("another", "wrong")))
+	require.Equal(t, codes.ResourceExhausted, getStatusFromGrpcError(t, login("third", "wrong")))
+}
+
+func TestShortenerHandlerGrpc_ParallelLoginThrottling(t *testing.T) {
+	useServerKeys(t)
+
+	authenticator := auth.NewAuthenticator(secretKey)
+	authenticator.SetThrottleSettings(
+		auth.ThrottleSettings{FreeAttempts: 1, BaseDelay: time.Hour, MaxDelay: time.Hour, LockoutAttempts: 5, LockoutDuration: time.Hour, Window: time.Hour},
+		auth.ThrottleSettings{FreeAttempts: 100, LockoutAttempts: 100})
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), authenticator)
+	_, err := grpcClient.Register(context.Background(), &pb.UserData{Login: "login", Password: "password"})
+	require.NoError(t, err)
+
+	// Parallel guesses can't pass before failures of previous ones are counted.
+	var wg sync.WaitGroup
+	results := make([]codes.Code, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := grpcClient.Login(context.Background(), &pb.UserData{Login: "login", Password: "wrong"})
+			results[i] = status.Code(err)
+		}()
+	}
+	wg.Wait()
+	checked := 0
+	for _, code := range results {
+		if code == codes.PermissionDenied {
+			checked++
+		}
+	}
+	require.LessOrEqual(t, checked, 2, "only free attempts and the first delayed one should check password")
+	require.Positive(t, checked)
+}
+
+func TestShortenerHandlerGrpc_RegisterPolicy(t *testing.T) {
+	useServerKeys(t)
+
+	users := userstorage.NewMemoryUserStorage()
+	service, _ := service.NewGophKeeperService(filestorage.NewMemoryFileStorage(), metadatastorage.NewMemoryStorage())
+	grpcSrv, lis := serveHandler(GophKeeperHandlerGrpc{service: *service, auth: *auth.NewAuthenticator(secretKey),
+		userStorage: users, LoginPolicy: policy.DefaultLoginPolicy, PasswordPolicy: policy.DefaultPasswordPolicy})
+	defer grpcSrv.Stop()
+	conn := getGrpcConn(t, lis)
+	defer conn.Close()
+	grpcClient := pb.NewGophKeeperServiceClient(conn)
+
+	violatedFields := func(err error) []string {
+		require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err))
+		var fields []string
+		for _, detail := range status.Convert(err).Details() {
+			for _, violation := range detail.(*errdetails.BadRequest).GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+		return fields
+	}
+	_, err := grpcClient.Register(context.Background(), &pb.UserData{Login: "a b", Password: "password"})
+	require.Equal(t, []string{"login", "password", "password", "password"}, violatedFields(err))
+	_, err = grpcClient.Register(context.Background(), &pb.UserData{Login: "alice", Password: "alicealice2024"})
+	require.Equal(t, []string{"password"}, violatedFields(err))
+
+	const password = "x7#kQ9!mZ2pL"
+	_, err = grpcClient.Register(context.Background(), &pb.UserData{Login: " Alice ", Password: password})
+	require.NoError(t, err)
+	_, err = users.GetUser(context.Background(), "alice")
+	require.NoError(t, err, "login should be stored normalized")
+	_, err = grpcClient.Register(context.Background(), &pb.UserData{Login: "ALICE", Password: password})
+	require.Equal(t, codes.AlreadyExists, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.Login(context.Background(), &pb.UserData{Login: "ALICE", Password: password})
+	require.NoError(t, err, "login should be case-insensitive")
+
+	// Users registered before normalization still log in with login as is.
+	hash, err := auth.HashPassword("legacy")
+	require.NoError(t, err)
+	require.NoError(t, users.AddUser(context.Background(), userstorage.User{Login: "Legacy", PasswordHash: hash}))
+	_, err = grpcClient.Login(context.Background(), &pb.UserData{Login: "Legacy", Password: "legacy"})
+	require.NoError(t, err)
+}
+
+func TestShortenerHandlerGrpc_ClientCertificate(t *testing.T) {
+	useServerKeys(t)
+
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	require.NoError(t, certs.Generate(certs.GenerateConfig{Dir: dir, Hosts: []string{"bufnet"}, Clients: []string{"alice"}, Validity: time.Hour}))
+	serverConfig, err := certs.ServerConfig{CertFile: path(certs.ServerFile), KeyFile: path(certs.ServerKeyFile),
+		ClientCAFile: path(certs.CAFile)}.TLSConfig()
+	require.NoError(t, err)
+	service, _ := service.NewGophKeeperService(filestorage.NewMemoryFileStorage(), metadatastorage.NewMemoryStorage())
+	grpcSrv, lis := serveHandler(GophKeeperHandlerGrpc{service: *service, auth: *auth.NewAuthenticator(secretKey),
+		userStorage: userstorage.NewMemoryUserStorage()}, grpc.Creds(credentials.NewTLS(serverConfig)))
+	defer grpcSrv.Stop()
+
+	connect := func(config certs.ClientConfig) pb.GophKeeperServiceClient {
+		clientConfig, err := config.TLSConfig()
+		require.NoError(t, err)
+		conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}), grpc.WithTransportCredentials(credentials.NewTLS(clientConfig)))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewGophKeeperServiceClient(conn)
+	}
+	certFile, keyFile := certs.ClientFiles("alice")
+	alice := connect(certs.ClientConfig{CAFile: path(certs.CAFile), CertFile: path(certFile), KeyFile: path(keyFile)})
+	anonymous := connect(certs.ClientConfig{CAFile: path(certs.CAFile)})
+
+	var bobHeader, aliceHeader metadata.MD
+	_, err = anonymous.Register(context.Background(), &pb.UserData{Login: "bob", Password: "password"}, grpc.Header(&bobHeader))
+	require.NoError(t, err, "client certificate should be optional")
+	_, err = alice.Register(context.Background(), &pb.UserData{Login: "alice", Password: "password"}, grpc.Header(&aliceHeader))
+	require.NoError(t, err)
+
+	// Certificate binds connection to its login.
+	_, err = alice.Login(context.Background(), &pb.UserData{Login: "bob", Password: "password"})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	bobCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("Authorization", bobHeader.Get("Authorization")[0]))
+	_, err = alice.ListSessions(bobCtx, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+	_, err = anonymous.ListSessions(bobCtx, &emptypb.Empty{})
+	require.NoError(t, err)
+	aliceCtx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("Authorization", aliceHeader.Get("Authorization")[0]))
+	_, err = alice.ListSessions(aliceCtx, &emptypb.Empty{})
+	require.NoError(t, err)
+}
+
+// Metadata storage failing to get file metainfo while fail is set.
+type lookupFailingStorage struct {
+	*metadatastorage.MemoryStorage
+	fail atomic.Bool
+}
+
+func (s *lookupFailingStorage) GetFileById(ctx context.Context, fileId string) (*pb.FileInfo, error) {
+	if s.fail.Load() {
+		return nil, errors.New("database is unavailable")
+	}
+	return s.MemoryStorage.GetFileById(ctx, fileId)
+}
+
+func TestShortenerHandlerGrpc_ApiTokens(t *testing.T) {
+	serverPublicKey := useServerKeys(t)
+	tokenPrivateKey, tokenPublicKey := generateRsaKeys(t)
+
+	metadataStorage := &lookupFailingStorage{MemoryStorage: metadatastorage.NewMemoryStorage()}
+	grpcClient := startMemoryServer(t, metadataStorage, auth.NewAuthenticator(secretKey))
+
+	ctx := registerUser(t, grpcClient, &pb.UserData{Login: "login", Password: "password"})
+
+	fileKey := []byte("encrypt")
+	upload := func(ctx context.Context, name string) (*pb.UploadResponse, error) {
+		encryptionKey, err := encryption.EncryptFileEncryptionKey(fileKey, serverPublicKey)
+		require.NoError(t, err)
+		upload, err := grpcClient.UploadFile(ctx)
+		require.NoError(t, err)
+		require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+			Filename: name, EncryptionKey: encryptionKey, Size: 4}}}))
+		upload.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: []byte("data")}})
+		return upload.CloseAndRecv()
+	}
+	inside, err := upload(ctx, "/ci/db/password")
+	require.NoError(t, err)
+	outside, err := upload(ctx, "personal/password")
+	require.NoError(t, err)
+
+	_, err = grpcClient.CreateApiToken(ctx, &pb.CreateApiTokenRequest{Name: "ci", Scope: "admin", ExpiresIn: 60, PublicKey: tokenPublicKey})
+	require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err))
+	created, err := grpcClient.CreateApiToken(ctx, &pb.CreateApiTokenRequest{Name: "ci", Scope: auth.ScopeRead,
+		Folders: []string{"/ci/"}, ExpiresIn: 60, PublicKey: tokenPublicKey})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ci"}, created.GetInfo().GetFolders())
+	tokenCtx := metadata.AppendToOutgoingContext(context.Background(), "Authorization", created.GetToken())
+
+	// Token sees only files inside its folders, file keys are wrapped for its key.
+	files, err := grpcClient.GetUserFiles(tokenCtx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1)
+	require.Equal(t, inside.GetId().GetId(), files.GetFiles()[0].GetId().GetId())
+	download, err := grpcClient.DownloadFile(tokenCtx, inside.GetId())
+	require.NoError(t, err)
+	info, err := download.Recv()
+	require.NoError(t, err)
+	decryptedKey, err := encryption.DecryptFileEncryptionKey(info.GetInfo().GetEncryptionKey(), tokenPrivateKey)
+	require.NoError(t, err)
+	require.Equal(t, fileKey, decryptedKey)
+	download, err = grpcClient.DownloadFile(tokenCtx, outside.GetId())
+	require.NoError(t, err)
+	_, err = download.Recv()
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+
+	// Read scope allows neither changes nor account management.
+	_, err = grpcClient.DeleteFile(tokenCtx, inside.GetId())
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = upload(tokenCtx, "ci/new")
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.ListApiTokens(tokenCtx, &emptypb.Empty{})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+
+	// Write scope allows changes inside folders only.
+	writer, err := grpcClient.CreateApiToken(ctx, &pb.CreateApiTokenRequest{Name: "deploy", Scope: auth.ScopeWrite,
+		Folders: []string{"ci"}, ExpiresIn: 60, PublicKey: tokenPublicKey})
+	require.NoError(t, err)
+	writerCtx := metadata.AppendToOutgoingContext(context.Background(), "Authorization", writer.GetToken())
+	_, err = upload(writerCtx, "ci/new")
+	require.NoError(t, err)
+	_, err = upload(writerCtx, "other")
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.DeleteFile(writerCtx, outside.GetId())
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.DeleteFile(writerCtx, &pb.FileId{Id: "absent"})
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+
+	// Request of folder-scoped token is rejected if file metainfo can't be checked.
+	metadataStorage.fail.Store(true)
+	_, err = grpcClient.DeleteFile(writerCtx, outside.GetId())
+	require.Equal(t, codes.Internal, getStatusFromGrpcError(t, err))
+	metadataStorage.fail.Store(false)
+	_, err = grpcClient.DeleteFile(ctx, outside.GetId())
+	require.NoError(t, err, "file should be left after rejected request")
+	_, err = grpcClient.DeleteFile(writerCtx, inside.GetId())
+	require.NoError(t, err)
+
+	tokens, err := grpcClient.ListApiTokens(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, tokens.GetTokens(), 2)
+	require.NotZero(t, tokens.GetTokens()[0].GetLastUsed())
+	_, err = grpcClient.RevokeApiToken(ctx, &pb.ApiTokenId{Id: created.GetInfo().GetId()})
+	require.NoError(t, err)
+	_, err = grpcClient.RevokeApiToken(ctx, &pb.ApiTokenId{Id: created.GetInfo().GetId()})
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.GetUserFiles(tokenCtx, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+	badSecret := metadata.AppendToOutgoingContext(context.Background(), "Authorization", writer.GetToken()+"x")
+	_, err = grpcClient.GetUserFiles(badSecret, &emptypb.Empty{})
+	require.Equal(t, codes.Unauthenticated, getStatusFromGrpcError(t, err))
+}
+
+func TestShortenerHandlerGrpc_Organizations(t *testing.T) {
+	serverPublicKey := useServerKeys(t)
+	bobPrivateKey, bobPublicKey := generateRsaKeys(t)
+
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), auth.NewAuthenticator(secretKey))
+
+	alice := registerUser(t, grpcClient, &pb.UserData{Login: "alice", Password: "password"})
+	bob := registerUser(t, grpcClient, &pb.UserData{Login: "bob", Password: "password", PublicKey: bobPublicKey})
+
+	fileKey := []byte("encrypt")
+	upload := func(ctx context.Context, org string) (*pb.UploadResponse, error) {
+		encryptionKey, err := encryption.EncryptFileEncryptionKey(fileKey, serverPublicKey)
+		require.NoError(t, err)
+		upload, err := grpcClient.UploadFile(ctx)
+		require.NoError(t, err)
+		require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+			Filename: "team/password", EncryptionKey: encryptionKey, Size: 4, Organization: org}}}))
+		upload.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: []byte("data")}})
+		return upload.CloseAndRecv()
+	}
+
+	_, err := grpcClient.CreateOrganization(alice, &pb.CreateOrganizationRequest{Name: " "})
+	require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err))
+	org, err := grpcClient.CreateOrganization(alice, &pb.CreateOrganizationRequest{Name: "team"})
+	require.NoError(t, err)
+	orgId := &pb.OrganizationId{Id: org.GetId()}
+	file, err := upload(alice, org.GetId())
+	require.NoError(t, err)
+
+	// Files of organization are inaccessible for non-members.
+	_, err = upload(bob, org.GetId())
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	files, err := grpcClient.GetUserFiles(bob, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles())
+	_, err = grpcClient.ListMembers(bob, orgId)
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+
+	_, err = grpcClient.SetMember(alice, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "nobody", Role: "reader"})
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.SetMember(alice, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "bob", Role: "guest"})
+	require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.SetMember(alice, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "bob", Role: "reader"})
+	require.NoError(t, err)
+
+	// Reader lists and downloads files of organization, file key is wrapped for his key.
+	files, err = grpcClient.GetUserFiles(bob, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1)
+	require.Equal(t, org.GetId(), files.GetFiles()[0].GetOrganization())
+	download, err := grpcClient.DownloadFile(bob, file.GetId())
+	require.NoError(t, err)
+	info, err := download.Recv()
+	require.NoError(t, err)
+	decryptedKey, err := encryption.DecryptFileEncryptionKey(info.GetInfo().GetEncryptionKey(), bobPrivateKey)
+	require.NoError(t, err)
+	require.Equal(t, fileKey, decryptedKey)
+
+	// Reader can't change files and members.
+	_, err = upload(bob, org.GetId())
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.DeleteFile(bob, file.GetId())
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.SetMember(bob, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "bob", Role: "writer"})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+
+	_, err = grpcClient.SetMember(alice, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "bob", Role: "writer"})
+	require.NoError(t, err)
+	_, err = upload(bob, org.GetId())
+	require.NoError(t, err)
+	_, err = grpcClient.DeleteFile(bob, file.GetId())
+	require.NoError(t, err)
+
+	members, err := grpcClient.ListMembers(bob, orgId)
+	require.NoError(t, err)
+	require.Len(t, members.GetMembers(), 2)
+	orgs, err := grpcClient.ListOrganizations(bob, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, orgs.GetOrganizations(), 1)
+	require.Equal(t, "writer", orgs.GetOrganizations()[0].GetRole())
+
+	// Organization keeps at least one owner, only owners delete it.
+	_, err = grpcClient.SetMember(alice, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "alice", Role: "admin"})
+	require.Equal(t, codes.FailedPrecondition, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.RemoveMember(alice, &pb.MemberRequest{OrganizationId: org.GetId()})
+	require.Equal(t, codes.FailedPrecondition, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.RemoveMember(alice, &pb.MemberRequest{OrganizationId: org.GetId(), Login: "Alice"})
+	require.Equal(t, codes.FailedPrecondition, getStatusFromGrpcError(t, err), "member login should be normalized")
+	_, err = grpcClient.DeleteOrganization(bob, orgId)
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	deleted, err := grpcClient.DeleteOrganization(alice, orgId)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), deleted.GetFiles())
+	files, err = grpcClient.GetUserFiles(bob, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles())
+}
+
+func TestShortenerHandlerGrpc_EmergencyAccess(t *testing.T) {
+	serverPublicKey := useServerKeys(t)
+	bobPrivateKey, bobPublicKey := generateRsaKeys(t)
+
+	grpcClient := startMemoryServer(t, metadatastorage.NewMemoryStorage(), auth.NewAuthenticator(secretKey))
+
+	alice := registerUser(t, grpcClient, &pb.UserData{Login: "alice", Password: "password"})
+	bob := registerUser(t, grpcClient, &pb.UserData{Login: "bob", Password: "password"})
+
+	fileKey := []byte("encrypt")
+	encryptionKey, err := encryption.EncryptFileEncryptionKey(fileKey, serverPublicKey)
+	require.NoError(t, err)
+	upload, err := grpcClient.UploadFile(alice)
+	require.NoError(t, err)
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+		Filename: "will", EncryptionKey: encryptionKey, Size: 4}}}))
+	require.NoError(t, upload.Send(&pb.FileStream{Data: &pb.FileStream_ChunkData{ChunkData: []byte("data")}}))
+	file, err := upload.CloseAndRecv()
+	require.NoError(t, err)
+
+	_, err = grpcClient.InviteEmergencyContact(alice, &pb.InviteRequest{Login: "alice", WaitPeriod: 3600})
+	require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.InviteEmergencyContact(alice, &pb.InviteRequest{Login: "bob", WaitPeriod: math.MaxUint64})
+	require.Equal(t, codes.InvalidArgument, getStatusFromGrpcError(t, err), "wait period should not overflow")
+	_, err = grpcClient.InviteEmergencyContact(alice, &pb.InviteRequest{Login: "nobody", WaitPeriod: 3600})
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+	grant, err := grpcClient.InviteEmergencyContact(alice, &pb.InviteRequest{Login: "bob", WaitPeriod: 3600})
+	require.NoError(t, err)
+	require.Equal(t, emergency.StatusInvited, grant.GetStatus())
+	_, err = grpcClient.InviteEmergencyContact(alice, &pb.InviteRequest{Login: "bob", WaitPeriod: 3600})
+	require.Equal(t, codes.AlreadyExists, getStatusFromGrpcError(t, err))
+	id := &pb.EmergencyId{Id: grant.GetId()}
+
+	// Contact requests access only after grantor wrapped file keys for his key.
+	_, err = grpcClient.RequestEmergencyAccess(bob, id)
+	require.Equal(t, codes.FailedPrecondition, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.AcceptEmergencyAccess(alice, &pb.AcceptRequest{Id: grant.GetId(), PublicKey: bobPublicKey})
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.AcceptEmergencyAccess(bob, &pb.AcceptRequest{Id: grant.GetId(), PublicKey: bobPublicKey})
+	require.NoError(t, err)
+	grants, err := grpcClient.ListEmergencyAccess(alice, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, grants.GetGrants(), 1)
+	wrappedKey, err := encryption.EncryptFileEncryptionKey(fileKey, grants.GetGrants()[0].GetPublicKey())
+	require.NoError(t, err)
+	_, err = grpcClient.ConfirmEmergencyAccess(alice, &pb.ConfirmRequest{Id: grant.GetId(),
+		Keys: []*pb.FileKey{{FileId: "other", Key: wrappedKey}}})
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	grant, err = grpcClient.ConfirmEmergencyAccess(alice, &pb.ConfirmRequest{Id: grant.GetId(),
+		Keys: []*pb.FileKey{{FileId: file.GetId().GetId(), Key: wrappedKey}}})
+	require.NoError(t, err)
+	require.Equal(t, emergency.StatusConfirmed, grant.GetStatus())
+
+	// Requested access is not granted until waiting period ends or grantor approves it.
+	grant, err = grpcClient.RequestEmergencyAccess(bob, id)
+	require.NoError(t, err)
+	require.Equal(t, emergency.StatusRequested, grant.GetStatus())
+	require.Equal(t, grant.GetRequested()+3600, grant.GetGranted())
+	_, err = grpcClient.GetEmergencyFiles(bob, id)
+	require.Equal(t, codes.PermissionDenied, getStatusFromGrpcError(t, err))
+	grant, err = grpcClient.RejectEmergencyAccess(alice, id)
+	require.NoError(t, err)
+	require.Equal(t, emergency.StatusConfirmed, grant.GetStatus())
+	_, err = grpcClient.ApproveEmergencyAccess(alice, id)
+	require.Equal(t, codes.FailedPrecondition, getStatusFromGrpcError(t, err))
+	_, err = grpcClient.RequestEmergencyAccess(bob, id)
+	require.NoError(t, err)
+	_, err = grpcClient.ApproveEmergencyAccess(bob, id)
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+	grant, err = grpcClient.ApproveEmergencyAccess(alice, id)
+	require.NoError(t, err)
+	require.Equal(t, emergency.StatusApproved, grant.GetStatus())
+
+	// Contact downloads files with keys wrapped by grantor.
+	files, err := grpcClient.GetEmergencyFiles(bob, id)
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1)
+	require.Equal(t, "will", files.GetFiles()[0].GetFilename())
+	download, err := grpcClient.DownloadEmergencyFile(bob, &pb.EmergencyFile{Id: grant.GetId(), FileId: file.GetId().GetId()})
+	require.NoError(t, err)
+	info, err := download.Recv()
+	require.NoError(t, err)
+	decryptedKey, err := encryption.DecryptFileEncryptionKey(info.GetInfo().GetEncryptionKey(), bobPrivateKey)
+	require.NoError(t, err)
+	require.Equal(t, fileKey, decryptedKey)
+	chunk, err := download.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []byte("data"), chunk.GetChunkData())
+	_, err = grpcClient.GetEmergencyFiles(alice, id)
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+
+	_, err = grpcClient.RevokeEmergencyAccess(alice, id)
+	require.NoError(t, err)
+	_, err = grpcClient.GetEmergencyFiles(bob, id)
+	require.Equal(t, codes.NotFound, getStatusFromGrpcError(t, err))
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/access"
+	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Converts error of organization request to grpc status.
+func organizationError(err error) error {
+	switch {
+	case errors.Is(err, access.ErrDenied):
+		return status.Errorf(codes.PermissionDenied, err.Error())
+	case errors.Is(err, orgstorage.ErrOrganizationNotFound), errors.Is(err, orgstorage.ErrMemberNotFound),
+		errors.Is(err, userstorage.ErrUserNotFound):
+		return status.Errorf(codes.NotFound, err.Error())
+	case errors.Is(err, access.ErrWrongRole), errors.Is(err, access.ErrEmptyName):
+		return status.Errorf(codes.InvalidArgument, err.Error())
+	case errors.Is(err, access.ErrLastOwner):
+		return status.Errorf(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, err.Error())
+	}
+}
+
+// Creates organization owned by user of request.
+func (h *GophKeeperHandlerGrpc) CreateOrganization(ctx context.Context, req *pb.CreateOrganizationRequest) (*pb.Organization, error) {
+	org, err := h.service.Access.CreateOrganization(ctx, auth.GetVarFromContext(ctx, "login"), req.GetName())
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	return &pb.Organization{Id: org.ID, Name: org.Name, Role: string(access.RoleOwner), Created: uint64(org.Created.Unix())}, nil
+}
+
+func (h *GophKeeperHandlerGrpc) ListOrganizations(ctx context.Context, _ *emptypb.Empty) (*pb.Organizations, error) {
+	memberships, err := h.service.Access.Memberships(ctx, auth.GetVarFromContext(ctx, "login"))
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	res := &pb.Organizations{}
+	for _, membership := range memberships {
+		res.Organizations = append(res.Organizations, &pb.Organization{
+			Id:      membership.Organization.ID,
+			Name:    membership.Organization.Name,
+			Role:    string(membership.Role),
+			Created: uint64(membership.Organization.Created.Unix()),
+		})
+	}
+	return res, nil
+}
+
+// Deletes organization with all its files, only owners may delete it.
+func (h *GophKeeperHandlerGrpc) DeleteOrganization(ctx context.Context, req *pb.OrganizationId) (*pb.DeletedOrganization, error) {
+	deleted, err := h.service.DeleteOrganization(ctx, auth.GetVarFromContext(ctx, "login"), req.GetId())
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	return &pb.DeletedOrganization{Files: uint64(deleted)}, nil
+}
+
+func (h *GophKeeperHandlerGrpc) ListMembers(ctx context.Context, req *pb.OrganizationId) (*pb.Members, error) {
+	members, err := h.service.Access.ListMembers(ctx, auth.GetVarFromContext(ctx, "login"), req.GetId())
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	res := &pb.Members{}
+	for _, member := range members {
+		res.Members = append(res.Members, &pb.Member{Login: member.Login, Role: member.Role, Added: uint64(member.Added.Unix())})
+	}
+	return res, nil
+}
+
+// Adds registered user to organization or changes role of member.
+func (h *GophKeeperHandlerGrpc) SetMember(ctx context.Context, req *pb.MemberRequest) (*emptypb.Empty, error) {
+	role, err := access.ParseRole(req.GetRole())
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	user, err := h.findUser(ctx, req.GetLogin())
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	err = h.service.Access.SetMember(ctx, auth.GetVarFromContext(ctx, "login"), req.GetOrganizationId(), user.Login, role)
+	if err != nil {
+		return nil, organizationError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Removes member from organization, members may remove themselves.
+func (h *GophKeeperHandlerGrpc) RemoveMember(ctx context.Context, req *pb.MemberRequest) (*emptypb.Empty, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	memberLogin := login
+	if req.GetLogin() != "" {
+		user, err := h.findUser(ctx, req.GetLogin())
+		if err != nil {
+			return nil, organizationError(err)
+		}
+		memberLogin = user.Login
+	}
+	if err := h.service.Access.RemoveMember(ctx, login, req.GetOrganizationId(), memberLogin); err != nil {
+		return nil, organizationError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/policy"
+	"github.com/valinurovdenis/gophkeeper/internal/app/totp"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// Reason of login error in case code is required, challenge id is in error metadata.
+	SecondFactorRequiredReason = "SECOND_FACTOR_REQUIRED"
+
+	// Error metadata key of challenge id.
+	ChallengeIdKey = "challenge_id"
+
+	// Issuer shown by authenticator apps.
+	totpIssuer = "gophkeeper"
+
+	recoveryCodesCount = 10
+)
+
+var errWrongCode = errors.New("wrong code")
+
+// Login error asking for second factor code.
+func secondFactorRequired(challengeId string) error {
+	st, err := status.New(codes.Unauthenticated, "second factor required").WithDetails(&errdetails.ErrorInfo{
+		Reason:   SecondFactorRequiredReason,
+		Metadata: map[string]string{ChallengeIdKey: challengeId},
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, err.Error())
+	}
+	return st.Err()
+}
+
+// Recovery code is compared ignoring case and separators.
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalized))
+	return hash[:]
+}
+
+// Generates recovery codes of 80 random bits, returns codes and their hashes.
+func generateRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([][]byte, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		random := make([]byte, 10)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+		code := base32.StdEncoding.EncodeToString(random)
+		code = strings.Join([]string{code[0:4], code[4:8], code[8:12], code[12:16]}, "-")
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// Decrypts totp secret of user.
+func totpSecret(factor userstorage.SecondFactor) (string, error) {
+	secret, err := encryption.DecryptServerKey(factor.Secret, "")
+	return string(secret), err
+}
+
+// Accepts totp code or unused recovery code of user, each code is accepted once.
+func (h *GophKeeperHandlerGrpc) checkCode(ctx context.Context, user *userstorage.User, code string) error {
+	secret, err := totpSecret(user.SecondFactor)
+	if err != nil {
+		return err
+	}
+	if step, ok := totp.Validate(secret, code, time.Now()); ok {
+		if err := h.userStorage.UseTotpStep(ctx, user.Login, step); err != nil {
+			return errWrongCode
+		}
+		return nil
+	}
+	if err := h.userStorage.UseRecoveryCode(ctx, user.Login, hashRecoveryCode(code)); err != nil {
+		return errWrongCode
+	}
+	return nil
+}
+
+// Completes login by second factor code.
+func (h *GophKeeperHandlerGrpc) VerifySecondFactor(ctx context.Context, req *pb.SecondFactorRequest) (*pb.ServicePublicKey, error) {
+	challenge, err := h.auth.GetChallenge(req.GetChallengeId())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
+	if err := h.reserveLoginAttempt(ctx, policy.NormalizeLogin(challenge.Login)); err != nil {
+		return nil, err
+	}
+	defer h.auth.FinishLoginAttempt(ctx, policy.NormalizeLogin(challenge.Login))
+	user, err := h.userStorage.GetUser(ctx, challenge.Login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	err = h.checkCode(ctx, user, req.GetCode())
+	if errors.Is(err, errWrongCode) {
+		h.auth.FailChallenge(req.GetChallengeId())
+		h.auth.LoginFailed(ctx, policy.NormalizeLogin(challenge.Login))
+		return nil, status.Errorf(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if err := h.auth.CompleteChallenge(req.GetChallengeId()); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, err.Error())
+	}
+	h.auth.LoginSucceeded(policy.NormalizeLogin(challenge.Login))
+	err = h.setTokens(ctx, &pb.UserData{Login: challenge.Login, PublicKey: challenge.PublicKey, Device: challenge.Device})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ServicePublicKey{PublicKey: encryption.ServerPublicKey()}, nil
+}
+
+// Generates totp secret waiting for confirmation by code.
+func (h *GophKeeperHandlerGrpc) EnableSecondFactor(ctx context.Context, _ *emptypb.Empty) (*pb.SecondFactorSecret, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	user, err := h.userStorage.GetUser(ctx, login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if user.SecondFactor.Enabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "second factor is already enabled")
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	encrypted, err := encryption.EncryptFileEncryptionKey([]byte(secret), encryption.ServerPublicKey())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if err := h.userStorage.SetSecondFactor(ctx, login, userstorage.SecondFactor{Secret: encrypted}); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.SecondFactorSecret{Secret: secret, Uri: totp.URI(totpIssuer, login, secret)}, nil
+}
+
+// Enables second factor confirmed by code of generated secret, returns recovery codes.
+func (h *GophKeeperHandlerGrpc) ConfirmSecondFactor(ctx context.Context, req *pb.SecondFactorCode) (*pb.RecoveryCodes, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	user, err := h.userStorage.GetUser(ctx, login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if user.SecondFactor.Enabled || len(user.SecondFactor.Secret) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "second factor is not being enabled")
+	}
+	secret, err := totpSecret(user.SecondFactor)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	step, ok := totp.Validate(secret, req.GetCode(), time.Now())
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, errWrongCode.Error())
+	}
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	err = h.userStorage.SetSecondFactor(ctx, login, userstorage.SecondFactor{
+		Secret: user.SecondFactor.Secret, Enabled: true, LastStep: step, RecoveryCodes: hashes})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &pb.RecoveryCodes{Codes: recoveryCodes}, nil
+}
+
+// Disables second factor, requires totp or recovery code.
+func (h *GophKeeperHandlerGrpc) DisableSecondFactor(ctx context.Context, req *pb.SecondFactorCode) (*emptypb.Empty, error) {
+	login := auth.GetVarFromContext(ctx, "login")
+	user, err := h.userStorage.GetUser(ctx, login)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if !user.SecondFactor.Enabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "second factor is not enabled")
+	}
+	err = h.checkCode(ctx, user, req.GetCode())
+	if errors.Is(err, errWrongCode) {
+		return nil, status.Errorf(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	if err := h.userStorage.SetSecondFactor(ctx, login, userstorage.SecondFactor{}); err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
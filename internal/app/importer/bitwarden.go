@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Bitwarden item types.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+)
+
+// Unencrypted Bitwarden json export.
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int     `json:"type"`
+	Name     string  `json:"name"`
+	Notes    string  `json:"notes"`
+	FolderId *string `json:"folderId"`
+	Fields   []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Totp     string `json:"totp"`
+		Uris     []struct {
+			Uri string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+
+	// Card and identity properties are stored as entry fields.
+	Card     map[string]any `json:"card"`
+	Identity map[string]any `json:"identity"`
+}
+
+// Parses unencrypted Bitwarden json export, attachments are not part of export.
+func ParseBitwardenJSON(reader io.Reader) (*Result, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(reader).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse bitwarden json: %w", err)
+	}
+	if export.Encrypted {
+		return nil, fmt.Errorf("encrypted bitwarden export is not supported, export vault as unencrypted json")
+	}
+	folders := make(map[string]string, len(export.Folders))
+	for _, folder := range export.Folders {
+		folders[folder.Id] = folder.Name
+	}
+	result := &Result{}
+	for _, item := range export.Items {
+		entry := Entry{Title: item.Name, Notes: item.Notes}
+		if item.FolderId != nil {
+			entry.Folder = folders[*item.FolderId]
+		}
+		for _, field := range item.Fields {
+			entry.Fields = setField(entry.Fields, field.Name, field.Value)
+		}
+		switch item.Type {
+		case bitwardenLogin:
+			entry.Kind = KindLogin
+			if item.Login != nil {
+				entry.Username = item.Login.Username
+				entry.Password = item.Login.Password
+				entry.Fields = setField(entry.Fields, "totp", item.Login.Totp)
+				for _, uri := range item.Login.Uris {
+					if uri.Uri != "" {
+						entry.URLs = append(entry.URLs, uri.Uri)
+					}
+				}
+			}
+		case bitwardenSecureNote:
+			entry.Kind = KindNote
+		case bitwardenCard:
+			entry.Kind = KindCard
+			entry.Fields = setProperties(entry.Fields, item.Card)
+		case bitwardenIdentity:
+			entry.Kind = KindIdentity
+			entry.Fields = setProperties(entry.Fields, item.Identity)
+		default:
+			result.skip(item.Name, fmt.Sprintf("unsupported item type %d", item.Type))
+			continue
+		}
+		result.add(entry)
+	}
+	return result, nil
+}
+
+// Sets non-empty string properties as fields.
+func setProperties(fields map[string]string, properties map[string]any) map[string]string {
+	for name, property := range properties {
+		if value, ok := property.(string); ok {
+			fields = setField(fields, name, value)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Column names used by Bitwarden, Chrome, Firefox, LastPass and 1Password csv exports.
+var csvColumns = map[string]string{
+	"name":           "title",
+	"title":          "title",
+	"account":        "title",
+	"username":       "username",
+	"user name":      "username",
+	"login":          "username",
+	"login_username": "username",
+	"password":       "password",
+	"login_password": "password",
+	"url":            "url",
+	"uri":            "url",
+	"website":        "url",
+	"login_uri":      "url",
+	"notes":          "notes",
+	"note":           "notes",
+	"extra":          "notes",
+	"folder":         "folder",
+	"group":          "folder",
+	"grouping":       "folder",
+}
+
+// Parses csv export with header row, unknown columns are stored as entry fields.
+func ParseCSV(reader io.Reader) (*Result, error) {
+	csvReader := csv.NewReader(reader)
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make([]string, len(header))
+	hasPassword := false
+	for i, name := range header {
+		name = strings.TrimPrefix(name, "\ufeff")
+		if column, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[i] = column
+			hasPassword = hasPassword || column == "password"
+			continue
+		}
+		header[i] = name
+	}
+	if !hasPassword {
+		return nil, fmt.Errorf("csv header has no password column: %v", header)
+	}
+	result := &Result{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse csv: %w", err)
+		}
+		entry := Entry{Kind: KindLogin}
+		for i, value := range record {
+			switch columns[i] {
+			case "title":
+				entry.Title = value
+			case "username":
+				entry.Username = value
+			case "password":
+				entry.Password = value
+			case "url":
+				if value != "" {
+					entry.URLs = append(entry.URLs, value)
+				}
+			case "notes":
+				entry.Notes = value
+			case "folder":
+				entry.Folder = value
+			default:
+				entry.Fields = setField(entry.Fields, header[i], value)
+			}
+		}
+		if entry.Username == "" && entry.Password == "" && len(entry.URLs) == 0 {
+			entry.Kind = KindNote
+		}
+		result.add(entry)
+	}
+	return result, nil
+}
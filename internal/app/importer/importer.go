@@ -0,0 +1,138 @@
+// Package importer parses exports of other password managers into entries to be stored as items.
+//
+// Every format is registered by name in parsers, entries which can't be mapped
+// are reported as skipped with reason instead of failing whole import.
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Error in case import format is not supported.
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// Entry kinds.
+const (
+	KindLogin    = "login"
+	KindNote     = "note"
+	KindCard     = "card"
+	KindIdentity = "identity"
+)
+
+// File attached to entry.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Entry of password manager, stored as json item.
+type Entry struct {
+	Kind     string            `json:"kind"`
+	Title    string            `json:"title"`
+	Folder   string            `json:"folder,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	URLs     []string          `json:"urls,omitempty"`
+	Notes    string            `json:"notes,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+
+	// Attachments are stored as separate items.
+	Attachments []Attachment `json:"-"`
+}
+
+// Entry not imported.
+type Skipped struct {
+	Title  string
+	Reason string
+}
+
+// Parsed export.
+type Result struct {
+	Entries []Entry
+	Skipped []Skipped
+}
+
+func (r *Result) skip(title, reason string) {
+	r.Skipped = append(r.Skipped, Skipped{Title: title, Reason: reason})
+}
+
+// Adds entry unless it has no data.
+func (r *Result) add(entry Entry) {
+	if entry.Username == "" && entry.Password == "" && len(entry.URLs) == 0 && entry.Notes == "" &&
+		len(entry.Fields) == 0 && len(entry.Attachments) == 0 {
+		r.skip(entry.Title, "empty entry")
+		return
+	}
+	if entry.Title == "" {
+		entry.Title = "untitled"
+	}
+	r.Entries = append(r.Entries, entry)
+}
+
+// Content of item storing entry.
+func (e Entry) Marshal() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// Non-secret description of entry for item comment.
+func (e Entry) Comment(format string) string {
+	comment := fmt.Sprintf("%s imported from %s", e.Kind, format)
+	if e.Folder != "" {
+		comment += ", folder " + e.Folder
+	}
+	return comment
+}
+
+// Filename of item storing entry or its attachment with given name, prefixed by folder of entry
+// so that entries of different folders differ and folder-scoped api tokens cover them.
+func (e Entry) Filename(name string) string {
+	if e.Folder == "" {
+		return name
+	}
+	return e.Folder + "/" + name
+}
+
+// Parses export of password manager.
+type Parser func(reader io.Reader) (*Result, error)
+
+var parsers = map[string]Parser{
+	"keepass":   ParseKeePassXML,
+	"bitwarden": ParseBitwardenJSON,
+	"csv":       ParseCSV,
+}
+
+// Names of supported formats.
+func Formats() []string {
+	formats := make([]string, 0, len(parsers))
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Parses export in given format.
+func Parse(format string, reader io.Reader) (*Result, error) {
+	parser, ok := parsers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, available: %v", ErrUnknownFormat, format, Formats())
+	}
+	return parser(reader)
+}
+
+// Sets field if value is not empty.
+func setField(fields map[string]string, name, value string) map[string]string {
+	if value == "" {
+		return fields
+	}
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	fields[name] = value
+	return fields
+}
@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBase64(t *testing.T, data string) string {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestParseKeePassXML(t *testing.T) {
+	export := `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<KeePassFile>
+	<Meta>
+		<RecycleBinUUID>bin</RecycleBinUUID>
+		<Binaries>
+			<Binary ID="0" Compressed="True">` + gzipBase64(t, "attached") + `</Binary>
+		</Binaries>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>root</UUID>
+			<Name>Database</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>mail</Value></String>
+				<String><Key>UserName</Key><Value>user</Value></String>
+				<String><Key>Password</Key><Value>secret</Value></String>
+				<String><Key>URL</Key><Value>https://mail.example</Value></String>
+				<String><Key>PIN</Key><Value>1234</Value></String>
+				<Binary><Key>key.txt</Key><Value Ref="0"/></Binary>
+				<Binary><Key>missing.txt</Key><Value Ref="5"/></Binary>
+				<History>
+					<Entry><String><Key>Title</Key><Value>old mail</Value></String></Entry>
+				</History>
+			</Entry>
+			<Group>
+				<UUID>work</UUID>
+				<Name>Work</Name>
+				<Group>
+					<UUID>servers</UUID>
+					<Name>Servers</Name>
+					<Entry>
+						<String><Key>Title</Key><Value>note</Value></String>
+						<String><Key>Notes</Key><Value>text</Value></String>
+						<Binary><Key>inline.txt</Key><Value>` + base64.StdEncoding.EncodeToString([]byte("inline")) + `</Value></Binary>
+					</Entry>
+					<Entry>
+						<String><Key>Title</Key><Value>empty</Value></String>
+					</Entry>
+				</Group>
+			</Group>
+			<Group>
+				<UUID>bin</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry><String><Key>Title</Key><Value>deleted</Value></String></Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+	result, err := Parse("keepass", strings.NewReader(export))
+	require.NoError(t, err)
+	require.Equal(t, []Entry{
+		{Kind: KindLogin, Title: "mail", Username: "user", Password: "secret", URLs: []string{"https://mail.example"},
+			Fields: map[string]string{"PIN": "1234"}, Attachments: []Attachment{{Name: "key.txt", Data: []byte("attached")}}},
+		{Kind: KindNote, Title: "note", Folder: "Work/Servers", Notes: "text",
+			Attachments: []Attachment{{Name: "inline.txt", Data: []byte("inline")}}},
+	}, result.Entries)
+	require.Equal(t, []Skipped{
+		{Title: "mail/missing.txt", Reason: "attachment: missing binary 5"},
+		{Title: "empty", Reason: "empty entry"},
+		{Title: "deleted", Reason: "in recycle bin"},
+	}, result.Skipped)
+
+	_, err = ParseKeePassXML(strings.NewReader("<KeePassFile>"))
+	require.Error(t, err)
+}
+
+func TestParseBitwardenJSON(t *testing.T) {
+	export := `{
+		"encrypted": false,
+		"folders": [{"id": "f1", "name": "Personal"}],
+		"items": [
+			{"type": 1, "name": "site", "folderId": "f1", "notes": null,
+			 "fields": [{"name": "question", "value": "answer", "type": 0}],
+			 "login": {"username": "user", "password": "secret", "totp": "otpauth://totp/x",
+			           "uris": [{"match": null, "uri": "https://site.example"}]}},
+			{"type": 2, "name": "memo", "folderId": null, "notes": "text", "secureNote": {"type": 0}},
+			{"type": 3, "name": "visa", "card": {"cardholderName": "John", "number": "4111", "expYear": "2030", "code": null}},
+			{"type": 4, "name": "me", "identity": {"firstName": "John", "email": "john@example.com"}},
+			{"type": 5, "name": "ssh key"}
+		]
+	}`
+	result, err := Parse("Bitwarden", strings.NewReader(export))
+	require.NoError(t, err)
+	require.Equal(t, []Entry{
+		{Kind: KindLogin, Title: "site", Folder: "Personal", Username: "user", Password: "secret",
+			URLs: []string{"https://site.example"}, Fields: map[string]string{"question": "answer", "totp": "otpauth://totp/x"}},
+		{Kind: KindNote, Title: "memo", Notes: "text"},
+		{Kind: KindCard, Title: "visa", Fields: map[string]string{"cardholderName": "John", "number": "4111", "expYear": "2030"}},
+		{Kind: KindIdentity, Title: "me", Fields: map[string]string{"firstName": "John", "email": "john@example.com"}},
+	}, result.Entries)
+	require.Equal(t, []Skipped{{Title: "ssh key", Reason: "unsupported item type 5"}}, result.Skipped)
+
+	_, err = ParseBitwardenJSON(strings.NewReader(`{"encrypted": true, "items": []}`))
+	require.Error(t, err)
+}
+
+func TestParseCSV(t *testing.T) {
+	export := "\ufeffname,url,username,password,note,otp\n" +
+		"site,https://site.example,user,secret,,123\n" +
+		"memo,,,,\"multi\nline\",\n" +
+		",,,,,\n"
+	result, err := Parse("csv", strings.NewReader(export))
+	require.NoError(t, err)
+	require.Equal(t, []Entry{
+		{Kind: KindLogin, Title: "site", Username: "user", Password: "secret", URLs: []string{"https://site.example"},
+			Fields: map[string]string{"otp": "123"}},
+		{Kind: KindNote, Title: "memo", Notes: "multi\nline"},
+	}, result.Entries)
+	require.Equal(t, []Skipped{{Title: "", Reason: "empty entry"}}, result.Skipped)
+
+	_, err = ParseCSV(strings.NewReader("name,url\nsite,https://site.example\n"))
+	require.Error(t, err)
+	_, err = ParseCSV(strings.NewReader("name,password\nsite\n"))
+	require.Error(t, err)
+}
+
+func TestParse_UnknownFormat(t *testing.T) {
+	_, err := Parse("lastpass", strings.NewReader(""))
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestEntry_Filename(t *testing.T) {
+	entry := Entry{Kind: KindNote, Title: "note", Folder: "Work/Servers"}
+	require.Equal(t, "Work/Servers/note", entry.Filename(entry.Title))
+	require.Equal(t, "Work/Servers/key.pem", entry.Filename("key.pem"))
+	entry.Folder = ""
+	require.Equal(t, "note", entry.Filename(entry.Title))
+}
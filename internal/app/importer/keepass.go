@@ -0,0 +1,156 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KeePass 2.x xml export, values are exported unprotected.
+type keePassFile struct {
+	Meta struct {
+		RecycleBinUUID string          `xml:"RecycleBinUUID"`
+		Binaries       []keePassBinary `xml:"Binaries>Binary"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// Attachment data shared by entries.
+type keePassBinary struct {
+	ID         string `xml:"ID,attr"`
+	Compressed bool   `xml:"Compressed,attr"`
+	Data       string `xml:",chardata"`
+}
+
+type keePassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Groups  []keePassGroup `xml:"Group"`
+	Entries []keePassEntry `xml:"Entry"`
+}
+
+// Entry strings and attachments, entry history is ignored.
+type keePassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+	Binaries []struct {
+		Key   string `xml:"Key"`
+		Value struct {
+			Ref  string `xml:"Ref,attr"`
+			Data string `xml:",chardata"`
+		} `xml:"Value"`
+	} `xml:"Binary"`
+}
+
+// Parses KeePass 2.x xml export.
+func ParseKeePassXML(reader io.Reader) (*Result, error) {
+	var file keePassFile
+	if err := xml.NewDecoder(reader).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse keepass xml: %w", err)
+	}
+	binaries := make(map[string]keePassBinary, len(file.Meta.Binaries))
+	for _, binary := range file.Meta.Binaries {
+		binaries[binary.ID] = binary
+	}
+	result := &Result{}
+	// Root group is the database itself, folders are counted from its children.
+	for _, root := range file.Root.Groups {
+		parseKeePassGroup(result, root, "", file.Meta.RecycleBinUUID, binaries)
+	}
+	return result, nil
+}
+
+func parseKeePassGroup(result *Result, group keePassGroup, folder string, recycleBin string, binaries map[string]keePassBinary) {
+	if recycleBin != "" && group.UUID == recycleBin {
+		skipKeePassGroup(result, group)
+		return
+	}
+	for _, keePassEntry := range group.Entries {
+		entry := Entry{Kind: KindLogin, Folder: folder}
+		for _, str := range keePassEntry.Strings {
+			switch str.Key {
+			case "Title":
+				entry.Title = str.Value
+			case "UserName":
+				entry.Username = str.Value
+			case "Password":
+				entry.Password = str.Value
+			case "URL":
+				if str.Value != "" {
+					entry.URLs = append(entry.URLs, str.Value)
+				}
+			case "Notes":
+				entry.Notes = str.Value
+			default:
+				entry.Fields = setField(entry.Fields, str.Key, str.Value)
+			}
+		}
+		for _, binary := range keePassEntry.Binaries {
+			data, err := keePassBinaryData(binary.Value.Ref, binary.Value.Data, binaries)
+			if err != nil {
+				result.skip(entry.Title+"/"+binary.Key, fmt.Sprintf("attachment: %s", err))
+				continue
+			}
+			entry.Attachments = append(entry.Attachments, Attachment{Name: binary.Key, Data: data})
+		}
+		if entry.Username == "" && entry.Password == "" && len(entry.URLs) == 0 {
+			entry.Kind = KindNote
+		}
+		result.add(entry)
+	}
+	for _, child := range group.Groups {
+		childFolder := child.Name
+		if folder != "" {
+			childFolder = folder + "/" + child.Name
+		}
+		parseKeePassGroup(result, child, childFolder, recycleBin, binaries)
+	}
+}
+
+// Reports entries of deleted group as skipped.
+func skipKeePassGroup(result *Result, group keePassGroup) {
+	for _, entry := range group.Entries {
+		title := ""
+		for _, str := range entry.Strings {
+			if str.Key == "Title" {
+				title = str.Value
+			}
+		}
+		result.skip(title, "in recycle bin")
+	}
+	for _, child := range group.Groups {
+		skipKeePassGroup(result, child)
+	}
+}
+
+// Decodes attachment referencing shared binary or stored inline.
+func keePassBinaryData(ref string, inline string, binaries map[string]keePassBinary) ([]byte, error) {
+	binary := keePassBinary{Data: inline}
+	if ref != "" {
+		var ok bool
+		if binary, ok = binaries[ref]; !ok {
+			return nil, fmt.Errorf("missing binary %s", ref)
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(binary.Data))
+	if err != nil {
+		return nil, fmt.Errorf("wrong binary data: %w", err)
+	}
+	if !binary.Compressed {
+		return data, nil
+	}
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("wrong compressed binary: %w", err)
+	}
+	defer gzipReader.Close()
+	return io.ReadAll(gzipReader)
+}
@@ -0,0 +1,248 @@
+// Package keyrotation re-wraps keys stored encrypted by retired server keys and removes those keys.
+//
+// Server key is rotated by generating new key while current one is kept as retired.
+// Keys of personal files are re-wrapped in batches by id of server key stored with them,
+// keys of organizations and totp secrets are few and are checked one by one.
+// Retired key is removed once nothing wrapped by it is left and grace period after rotation has passed,
+// so that uploads which wrapped their key just before rotation are stored and re-wrapped first.
+// Servers read new key on next use, see encryption.UseServerKeyFiles.
+package keyrotation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	"go.uber.org/zap"
+)
+
+// Error in case some keys are left wrapped by retired server keys.
+var ErrUnfinished = errors.New("some keys are left wrapped by retired server keys")
+
+// Number of file keys re-wrapped per batch by default.
+const DefaultBatchSize = 100
+
+// Time retired key is kept after rotation by default.
+const DefaultGrace = 10 * time.Minute
+
+// Result of re-wrapping pass.
+type Report struct {
+	Started       time.Time      `json:"started"`
+	KeyID         string         `json:"key_id"`
+	Files         int            `json:"files"`
+	Organizations int            `json:"organizations"`
+	Secrets       int            `json:"secrets"`
+	Remaining     map[string]int `json:"remaining"`
+	RetiredKeys   []string       `json:"retired_keys"`
+	PendingKeys   []string       `json:"pending_keys"`
+	Errors        []string       `json:"errors"`
+}
+
+// Whether some keys are left wrapped by retired server keys.
+func (r *Report) Unfinished() bool {
+	return len(r.Remaining) > 0 || len(r.Errors) > 0
+}
+
+// Re-wraps stored keys to current server key.
+type Rotator struct {
+	files metadatastorage.MetadataStorage
+	orgs  orgstorage.OrgStorage
+	users userstorage.UserStorage
+
+	// Number of file keys re-wrapped per batch.
+	BatchSize int
+	// Time retired key is kept after rotation, uploads started before it should be stored meanwhile.
+	Grace time.Duration
+}
+
+// New rotator, organizations and users may be nil if metadata backend has none.
+func NewRotator(files metadatastorage.MetadataStorage, orgs orgstorage.OrgStorage, users userstorage.UserStorage) *Rotator {
+	return &Rotator{files: files, orgs: orgs, users: users, BatchSize: DefaultBatchSize, Grace: DefaultGrace}
+}
+
+// Re-wraps keys to current server key and removes retired keys nothing is wrapped by.
+//
+// Nothing is done unless there are retired keys, files uploaded before key ids were stored
+// are re-wrapped on first rotation.
+func (r *Rotator) Run(ctx context.Context) (*Report, error) {
+	report := &Report{Started: time.Now(), KeyID: encryption.ServerKeyID(), Remaining: make(map[string]int)}
+	retired, err := encryption.RetiredServerKeyIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retired server keys: %w", err)
+	}
+	if len(retired) == 0 {
+		return report, nil
+	}
+	if err = r.rewrapFiles(ctx, report); err != nil {
+		return nil, err
+	}
+	if err = r.rewrapOrganizations(ctx, report); err != nil {
+		return nil, err
+	}
+	if err = r.rewrapSecrets(ctx, report); err != nil {
+		return nil, err
+	}
+	counts, err := r.files.CountFilesByKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count files: %w", err)
+	}
+	for _, id := range retired {
+		// Files without key id and failed keys may be wrapped by any retired key.
+		if counts[id] > 0 || counts[""] > 0 || len(report.Errors) > 0 {
+			report.Remaining[id] = counts[id] + counts[""]
+			continue
+		}
+		retiredAt, err := encryption.RetiredServerKeyTime(id)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			continue
+		}
+		// Server which has not read new key yet may still store file keys wrapped by this one.
+		if report.Started.Sub(retiredAt) < r.Grace {
+			report.PendingKeys = append(report.PendingKeys, id)
+			continue
+		}
+		if err := encryption.RetireServerKey(id); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			continue
+		}
+		logger.Audit("server_key_retired", zap.String("key_id", id))
+		report.RetiredKeys = append(report.RetiredKeys, id)
+	}
+	return report, nil
+}
+
+// Re-wraps keys of personal files in batches ordered by file id.
+func (r *Rotator) rewrapFiles(ctx context.Context, report *Report) error {
+	afterId := ""
+	for {
+		files, err := r.files.GetFilesToRewrap(ctx, report.KeyID, afterId, r.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to list files to re-wrap: %w", err)
+		}
+		for _, file := range files.GetFiles() {
+			id := file.GetId().GetId()
+			key, err := encryption.DecryptServerKey(file.GetEncryptionKey(), file.GetKeyId())
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("failed to decrypt key of file %s: %s", id, err))
+				continue
+			}
+			wrapped, keyId, err := encryption.WrapServerKey(key)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("failed to wrap key of file %s: %s", id, err))
+				continue
+			}
+			// Changed key means file has been deleted or re-wrapped concurrently.
+			err = r.files.UpdateFileKey(ctx, id, file.GetKeyId(), wrapped, keyId)
+			if errors.Is(err, metadatastorage.ErrKeyChanged) {
+				continue
+			}
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("failed to update key of file %s: %s", id, err))
+				continue
+			}
+			report.Files++
+		}
+		if len(files.GetFiles()) < r.BatchSize {
+			return nil
+		}
+		afterId = files.GetFiles()[len(files.GetFiles())-1].GetId().GetId()
+	}
+}
+
+// Returns key re-wrapped to current server key, nil if it is wrapped by current key already.
+func rewrap(wrapped []byte) ([]byte, error) {
+	if encryption.IsWrappedByServerKey(wrapped) {
+		return nil, nil
+	}
+	key, err := encryption.DecryptServerKey(wrapped, "")
+	if err != nil {
+		return nil, err
+	}
+	rewrapped, _, err := encryption.WrapServerKey(key)
+	return rewrapped, err
+}
+
+// Re-wraps keys of organizations wrapping keys of their files.
+func (r *Rotator) rewrapOrganizations(ctx context.Context, report *Report) error {
+	if r.orgs == nil {
+		return nil
+	}
+	orgs, err := r.orgs.ListOrganizations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list organizations: %w", err)
+	}
+	for _, org := range orgs {
+		key, err := rewrap(org.Key)
+		if err == nil && key != nil {
+			err = r.orgs.ReplaceKey(ctx, org.ID, org.Key, key)
+			if err == nil {
+				report.Organizations++
+			}
+		}
+		if err != nil && !errors.Is(err, orgstorage.ErrKeyChanged) {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to re-wrap key of organization %s: %s", org.ID, err))
+		}
+	}
+	return nil
+}
+
+// Re-wraps totp secrets of users with second factor.
+func (r *Rotator) rewrapSecrets(ctx context.Context, report *Report) error {
+	if r.users == nil {
+		return nil
+	}
+	users, err := r.users.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, user := range users {
+		if len(user.SecondFactor.Secret) == 0 {
+			continue
+		}
+		secret, err := rewrap(user.SecondFactor.Secret)
+		if err == nil && secret != nil {
+			err = r.users.ReplaceTotpSecret(ctx, user.Login, user.SecondFactor.Secret, secret)
+			if err == nil {
+				report.Secrets++
+			}
+		}
+		if err != nil && !errors.Is(err, userstorage.ErrSecretChanged) {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to re-wrap totp secret of %s: %s", user.Login, err))
+		}
+	}
+	return nil
+}
+
+// Runs re-wrapping pass with given interval until context is done.
+func (r *Rotator) RunPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := r.Run(ctx)
+			if err != nil {
+				logger.Log.Error("server key rotation failed", zap.Error(err))
+				continue
+			}
+			if len(report.Errors) > 0 {
+				data, _ := json.Marshal(report)
+				logger.Log.Warn("server key rotation failed for some keys", zap.ByteString("report", data))
+			} else if report.Files > 0 || report.Organizations > 0 || report.Secrets > 0 || len(report.RetiredKeys) > 0 {
+				logger.Log.Info("server keys re-wrapped", zap.Int("files", report.Files),
+					zap.Int("organizations", report.Organizations), zap.Int("secrets", report.Secrets),
+					zap.Strings("retired", report.RetiredKeys))
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package keyrotation
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Wraps new random key by current server key.
+func wrappedKey(t *testing.T) ([]byte, []byte, string) {
+	key, err := encryption.GenerateSymmetricFileEncryptionKey()
+	require.NoError(t, err)
+	wrapped, keyId, err := encryption.WrapServerKey(key)
+	require.NoError(t, err)
+	return key, wrapped, keyId
+}
+
+func TestRotator_Run(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	encryption.UseServerKeyFiles(filepath.Join(dir, "private"), filepath.Join(dir, "public"))
+	require.NoError(t, encryption.CreateKeysIfAbsent(true))
+
+	files := metadatastorage.NewMemoryStorage()
+	orgs := orgstorage.NewMemoryOrgStorage()
+	users := userstorage.NewMemoryUserStorage()
+	plainKeys := make(map[string][]byte)
+	for _, id := range []string{"a", "b", "c"} {
+		key, wrapped, keyId := wrappedKey(t)
+		if id == "a" {
+			// Files uploaded before key ids were stored.
+			keyId = ""
+		}
+		plainKeys[id] = key
+		require.NoError(t, files.AddFileInfo(ctx, &pb.FileInfo{Id: &pb.FileId{Id: id}, Login: "login", EncryptionKey: wrapped, KeyId: keyId}))
+	}
+	require.NoError(t, files.AddFileInfo(ctx, &pb.FileInfo{Id: &pb.FileId{Id: "team"}, Login: "login", Organization: "team",
+		EncryptionKey: []byte("wrapped by organization key")}))
+	orgKey, wrappedOrgKey, _ := wrappedKey(t)
+	require.NoError(t, orgs.AddOrganization(ctx, orgstorage.Organization{ID: "team", Name: "team", Key: wrappedOrgKey, Created: time.Now()}, nil))
+	secret, wrappedSecret, _ := wrappedKey(t)
+	require.NoError(t, users.AddUser(ctx, userstorage.User{Login: "login", SecondFactor: userstorage.SecondFactor{Secret: wrappedSecret, Enabled: true}}))
+	require.NoError(t, users.AddUser(ctx, userstorage.User{Login: "other"}))
+
+	oldId, newId, err := encryption.RotateServerKeys()
+	require.NoError(t, err)
+	require.NoError(t, files.AddFileInfo(ctx, &pb.FileInfo{Id: &pb.FileId{Id: "broken"}, Login: "login",
+		EncryptionKey: []byte("broken"), KeyId: oldId}))
+
+	rotator := NewRotator(files, orgs, users)
+	rotator.BatchSize = 2
+	report, err := rotator.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, newId, report.KeyID)
+	require.Equal(t, 3, report.Files)
+	require.Equal(t, 1, report.Organizations)
+	require.Equal(t, 1, report.Secrets)
+	require.Len(t, report.Errors, 1)
+	require.Equal(t, map[string]int{oldId: 1}, report.Remaining)
+	require.Empty(t, report.RetiredKeys, "key should not be retired while something is wrapped by it")
+	require.True(t, report.Unfinished())
+
+	for id, key := range plainKeys {
+		file, err := files.GetFileById(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, newId, file.GetKeyId())
+		unwrapped, err := encryption.DecryptServerKey(file.GetEncryptionKey(), newId)
+		require.NoError(t, err)
+		require.Equal(t, key, unwrapped)
+	}
+	team, err := files.GetFileById(ctx, "team")
+	require.NoError(t, err)
+	require.Empty(t, team.GetKeyId(), "organization file keys are not wrapped by server key")
+	org, err := orgs.GetOrganization(ctx, "team")
+	require.NoError(t, err)
+	require.True(t, encryption.IsWrappedByServerKey(org.Key))
+	unwrapped, err := encryption.DecryptServerKey(org.Key, newId)
+	require.NoError(t, err)
+	require.Equal(t, orgKey, unwrapped)
+	user, err := users.GetUser(ctx, "login")
+	require.NoError(t, err)
+	unwrapped, err = encryption.DecryptServerKey(user.SecondFactor.Secret, newId)
+	require.NoError(t, err)
+	require.Equal(t, secret, unwrapped)
+
+	require.NoError(t, files.DeleteFileInfo(ctx, "broken"))
+	report, err = rotator.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{oldId}, report.PendingKeys, "key should be kept during grace period")
+	require.Empty(t, report.RetiredKeys)
+	require.False(t, report.Unfinished())
+
+	rotator.Grace = 0
+	report, err = rotator.Run(ctx)
+	require.NoError(t, err)
+	require.Zero(t, report.Files+report.Organizations+report.Secrets)
+	require.Empty(t, report.PendingKeys)
+	require.Equal(t, []string{oldId}, report.RetiredKeys)
+	require.False(t, report.Unfinished())
+	retired, err := encryption.RetiredServerKeyIDs()
+	require.NoError(t, err)
+	require.Empty(t, retired)
+}
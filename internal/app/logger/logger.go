@@ -71,3 +71,8 @@ func RequestStreamLoggerInterceptor() grpc.StreamServerInterceptor {
 		return err
 	}
 }
+
+// Writes security audit event, audit events are marked by audit field.
+func Audit(event string, fields ...zap.Field) {
+	Log.Warn("audit event", append([]zap.Field{zap.Bool("audit", true), zap.String("event", event)}, fields...)...)
+}
@@ -0,0 +1,158 @@
+package metadatastorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// Error in case file with given id is absent.
+var ErrFileNotFound = errors.New("file not found")
+
+// Storage contains file metainfo in memory, all files are lost on restart.
+//
+// Stored infos are copied on every access so callers can't modify them.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	files map[string]*pb.FileInfo
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string]*pb.FileInfo)}
+}
+
+func (s *MemoryStorage) GetFileById(_ context.Context, fileId string) (*pb.FileInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[fileId]
+	if !ok {
+		return nil, fmt.Errorf("failed to get file %s: %w", fileId, ErrFileNotFound)
+	}
+	return proto.Clone(file).(*pb.FileInfo), nil
+}
+
+func (s *MemoryStorage) GetFilesByLogin(_ context.Context, login string) (*pb.ListFiles, error) {
+	return s.listFiles(func(file *pb.FileInfo) bool {
+		return file.GetLogin() == login && file.GetOrganization() == "" && file.GetState() == pb.FileState_COMMITTED
+	}), nil
+}
+
+func (s *MemoryStorage) GetFilesByOrganization(_ context.Context, orgId string) (*pb.ListFiles, error) {
+	return s.listFiles(func(file *pb.FileInfo) bool {
+		return file.GetOrganization() == orgId && file.GetState() == pb.FileState_COMMITTED
+	}), nil
+}
+
+func (s *MemoryStorage) GetAllFiles(_ context.Context) (*pb.ListFiles, error) {
+	return s.listFiles(func(*pb.FileInfo) bool { return true }), nil
+}
+
+// Copies files matching filter ordered by creation time.
+func (s *MemoryStorage) listFiles(filter func(file *pb.FileInfo) bool) *pb.ListFiles {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	files := make([]*pb.FileInfo, 0)
+	for _, file := range s.files {
+		if filter(file) {
+			files = append(files, proto.Clone(file).(*pb.FileInfo))
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].GetCreated() != files[j].GetCreated() {
+			return files[i].GetCreated() < files[j].GetCreated()
+		}
+		return files[i].GetId().GetId() < files[j].GetId().GetId()
+	})
+	return &pb.ListFiles{Files: files}
+}
+
+func (s *MemoryStorage) AddFileInfo(_ context.Context, fileInfo *pb.FileInfo) error {
+	if fileInfo.GetLogin() == "" {
+		return fmt.Errorf("failed to add file info: empty login")
+	}
+	file := proto.Clone(fileInfo).(*pb.FileInfo)
+	file.Modified = uint64(time.Now().Unix())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[file.GetId().GetId()]; ok {
+		return ErrConflictMetaId
+	}
+	s.files[file.GetId().GetId()] = file
+	return nil
+}
+
+func (s *MemoryStorage) CommitFileInfo(_ context.Context, fileId string, checksum []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[fileId]
+	if !ok || file.GetState() != pb.FileState_PENDING {
+		return ErrNotPending
+	}
+	file.State = pb.FileState_COMMITTED
+	file.Checksum = append([]byte(nil), checksum...)
+	file.Modified = uint64(time.Now().Unix())
+	return nil
+}
+
+func (s *MemoryStorage) UpdateFileState(_ context.Context, fileId string, state pb.FileState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if file, ok := s.files[fileId]; ok {
+		file.State = state
+		file.Modified = uint64(time.Now().Unix())
+	}
+	return nil
+}
+
+// Copies personal files with key wrapped by other server key ordered by id.
+func (s *MemoryStorage) GetFilesToRewrap(_ context.Context, keyId string, afterId string, limit int) (*pb.ListFiles, error) {
+	files := s.listFiles(func(file *pb.FileInfo) bool {
+		return file.GetOrganization() == "" && file.GetKeyId() != keyId && file.GetId().GetId() > afterId
+	}).GetFiles()
+	sort.Slice(files, func(i, j int) bool { return files[i].GetId().GetId() < files[j].GetId().GetId() })
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return &pb.ListFiles{Files: files}, nil
+}
+
+func (s *MemoryStorage) UpdateFileKey(_ context.Context, fileId string, oldKeyId string, key []byte, keyId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[fileId]
+	if !ok || file.GetKeyId() != oldKeyId {
+		return ErrKeyChanged
+	}
+	file.EncryptionKey = append([]byte(nil), key...)
+	file.KeyId = keyId
+	return nil
+}
+
+func (s *MemoryStorage) CountFilesByKey(_ context.Context) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, file := range s.files {
+		if file.GetOrganization() == "" {
+			counts[file.GetKeyId()]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *MemoryStorage) DeleteFileInfo(_ context.Context, fileId string) error {
+	s.mu.Lock()
+	delete(s.files, fileId)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStorage) Ping() error {
+	return nil
+}
@@ -14,12 +14,33 @@ type MetadataStorage interface {
 	// Get file info by id.
 	GetFileById(context context.Context, fileId string) (*pb.FileInfo, error)
 
-	// Get files info by login.
+	// Get personal files info by login, files of organizations uploaded by user are not included.
 	GetFilesByLogin(context context.Context, login string) (*pb.ListFiles, error)
 
+	// Get files info of organization.
+	GetFilesByOrganization(context context.Context, orgId string) (*pb.ListFiles, error)
+
+	// Get all files info in any state.
+	GetAllFiles(context context.Context) (*pb.ListFiles, error)
+
 	// Add file metainfo.
 	AddFileInfo(context context.Context, fileInfo *pb.FileInfo) error
 
+	// Mark pending file as committed with given ciphertext checksum in one transaction.
+	CommitFileInfo(context context.Context, fileId string, checksum []byte) error
+
+	// Set file state.
+	UpdateFileState(context context.Context, fileId string, state pb.FileState) error
+
+	// Get personal files with encryption key wrapped by other server key than given one, ordered by id after given id.
+	GetFilesToRewrap(context context.Context, keyId string, afterId string, limit int) (*pb.ListFiles, error)
+
+	// Replace encryption key of file if it is still wrapped by server key with given id.
+	UpdateFileKey(context context.Context, fileId string, oldKeyId string, key []byte, keyId string) error
+
+	// Count personal files by id of server key wrapping their encryption keys.
+	CountFilesByKey(context context.Context) (map[string]int, error)
+
 	// Delete file metainfo.
 	DeleteFileInfo(context context.Context, fileId string) error
 
@@ -7,44 +7,41 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/jackc/pgconn"
-	"github.com/jackc/pgerrcode"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dberrors"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
 )
 
 // Error in case file with given id already has been saved.
 var ErrConflictMetaId = errors.New("conflicting id")
 
-type PostgresqlStorage struct {
-	DB *sql.DB
-}
+// Error in case file to commit is not pending.
+var ErrNotPending = errors.New("file is not pending")
 
-func NewPostgresqlStorageStorage(db *sql.DB) *PostgresqlStorage {
-	ret := &PostgresqlStorage{DB: db}
-	ret.init()
-	return ret
+// Error in case file key to replace has been wrapped by other server key or file is absent.
+var ErrKeyChanged = errors.New("file key has been changed")
+
+type PostgresqlStorage struct {
+	DB dbtx.DB
 }
 
-func (s *PostgresqlStorage) init() error {
-	tx, err := s.DB.BeginTx(context.Background(), nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-	tx.Exec(`CREATE TABLE fileinfo("id" TEXT PRIMARY KEY, "login" TEXT NOT NULL CHECK ("login" <> ''), "filename" TEXT, "comment" TEXT, "created" TIMESTAMP, "modified" TIMESTAMP, "size" INT, "encryption_key" bytea)`)
-	tx.Exec(`CREATE INDEX login_index ON fileinfo USING btree(login)`)
-	return tx.Commit()
+func NewPostgresqlStorageStorage(db dbtx.DB) *PostgresqlStorage {
+	return &PostgresqlStorage{DB: db}
 }
 
 func (s *PostgresqlStorage) GetFileById(ctx context.Context, fileId string) (*pb.FileInfo, error) {
 	row := s.DB.QueryRowContext(ctx,
-		"SELECT id, login, filename, comment, created, size, encryption_key FROM fileinfo WHERE id = $1", fileId)
+		"SELECT id, login, filename, comment, created, COALESCE(modified, created), size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE id = $1", fileId)
 	file := pb.FileInfo{}
-	var created time.Time
+	var created, modified time.Time
 	var id string
-	err := row.Scan(&id, &file.Login, &file.Filename, &file.Comment, &created, &file.Size, &file.EncryptionKey)
+	err := row.Scan(&id, &file.Login, &file.Filename, &file.Comment, &created, &modified, &file.Size, &file.EncryptionKey, &file.ContentHash, &file.Checksum, &file.State, &file.Organization, &file.KeyId)
 	file.Id = &pb.FileId{Id: id}
 	file.Created = uint64(created.Unix())
+	file.Modified = uint64(modified.Unix())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get file %s: %w", fileId, ErrFileNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan rows: %w", err)
 	}
@@ -52,23 +49,49 @@ func (s *PostgresqlStorage) GetFileById(ctx context.Context, fileId string) (*pb
 }
 
 func (s *PostgresqlStorage) GetFilesByLogin(ctx context.Context, login string) (*pb.ListFiles, error) {
-	var files []*pb.FileInfo
 	rows, err := s.DB.QueryContext(ctx,
-		"SELECT id, login, filename, comment, created, size, encryption_key FROM fileinfo WHERE login = $1", login)
+		"SELECT id, login, filename, comment, created, COALESCE(modified, created), size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE login = $1 AND state = $2 AND organization_id = ''",
+		login, pb.FileState_COMMITTED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *PostgresqlStorage) GetFilesByOrganization(ctx context.Context, orgId string) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, COALESCE(modified, created), size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE organization_id = $1 AND state = $2",
+		orgId, pb.FileState_COMMITTED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *PostgresqlStorage) GetAllFiles(ctx context.Context) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, COALESCE(modified, created), size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo")
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin select query: %w", err)
 	}
+	return scanFiles(rows)
+}
+
+// Read files info from selected rows.
+func scanFiles(rows *sql.Rows) (*pb.ListFiles, error) {
+	var files []*pb.FileInfo
 	if rows.Err() != nil {
 		return nil, fmt.Errorf("failed to get rows: %w", rows.Err())
 	}
 	defer rows.Close()
 	for rows.Next() {
 		file := pb.FileInfo{}
-		var created time.Time
+		var created, modified time.Time
 		var id string
-		err = rows.Scan(&id, &file.Login, &file.Filename, &file.Comment, &created, &file.Size, &file.EncryptionKey)
+		err := rows.Scan(&id, &file.Login, &file.Filename, &file.Comment, &created, &modified, &file.Size, &file.EncryptionKey, &file.ContentHash, &file.Checksum, &file.State, &file.Organization, &file.KeyId)
 		file.Id = &pb.FileId{Id: id}
 		file.Created = uint64(created.Unix())
+		file.Modified = uint64(modified.Unix())
 		if err != nil {
 			return nil, err
 		}
@@ -80,15 +103,90 @@ func (s *PostgresqlStorage) GetFilesByLogin(ctx context.Context, login string) (
 
 func (s *PostgresqlStorage) AddFileInfo(ctx context.Context, fileInfo *pb.FileInfo) error {
 	_, err := s.DB.ExecContext(ctx,
-		"INSERT into fileinfo (id, login, filename, comment, created, size, encryption_key) VALUES($1, $2, $3, $4, $5, $6, $7)",
+		"INSERT into fileinfo (id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
 		fileInfo.GetId().GetId(), fileInfo.GetLogin(), fileInfo.GetFilename(), fileInfo.GetComment(),
-		time.Unix(int64(fileInfo.GetCreated()), 0), fileInfo.GetSize(), fileInfo.GetEncryptionKey())
-	if e, ok := err.(*pgconn.PgError); ok && e.Code == pgerrcode.UniqueViolation {
+		time.Unix(int64(fileInfo.GetCreated()), 0), time.Now(), fileInfo.GetSize(), fileInfo.GetEncryptionKey(),
+		fileInfo.GetContentHash(), fileInfo.GetChecksum(), fileInfo.GetState(), fileInfo.GetOrganization(), fileInfo.GetKeyId())
+	if dberrors.IsPostgresqlUniqueViolation(err) {
 		err = ErrConflictMetaId
 	}
 	return err
 }
 
+func (s *PostgresqlStorage) CommitFileInfo(ctx context.Context, fileId string, checksum []byte) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		"UPDATE fileinfo SET state = $1, checksum = $2, modified = $3 WHERE id = $4 AND state = $5",
+		pb.FileState_COMMITTED, checksum, time.Now(), fileId, pb.FileState_PENDING)
+	if err != nil {
+		return fmt.Errorf("failed to commit file info: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 1 {
+		return ErrNotPending
+	}
+	return tx.Commit()
+}
+
+func (s *PostgresqlStorage) UpdateFileState(ctx context.Context, fileId string, state pb.FileState) error {
+	_, err := s.DB.ExecContext(ctx,
+		"UPDATE fileinfo SET state = $1, modified = $2 WHERE id = $3", state, time.Now(), fileId)
+	return err
+}
+
+func (s *PostgresqlStorage) GetFilesToRewrap(ctx context.Context, keyId string, afterId string, limit int) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, COALESCE(modified, created), size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE organization_id = '' AND key_id <> $1 AND id > $2 ORDER BY id LIMIT $3",
+		keyId, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *PostgresqlStorage) UpdateFileKey(ctx context.Context, fileId string, oldKeyId string, key []byte, keyId string) error {
+	res, err := s.DB.ExecContext(ctx,
+		"UPDATE fileinfo SET encryption_key = $1, key_id = $2 WHERE id = $3 AND key_id = $4", key, keyId, fileId, oldKeyId)
+	return checkKeyUpdated(res, err)
+}
+
+func (s *PostgresqlStorage) CountFilesByKey(ctx context.Context) (map[string]int, error) {
+	return countFilesByKey(ctx, s.DB)
+}
+
+// Returns ErrKeyChanged if file key has not been replaced.
+func checkKeyUpdated(res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to update file key: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 1 {
+		return ErrKeyChanged
+	}
+	return nil
+}
+
+// Counts personal files by server key id, the query is the same for both databases.
+func countFilesByKey(ctx context.Context, db dbtx.DB) (map[string]int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT key_id, COUNT(*) FROM fileinfo WHERE organization_id = '' GROUP BY key_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count files: %w", err)
+	}
+	defer rows.Close()
+	counts := make(map[string]int)
+	for rows.Next() {
+		var keyId string
+		var count int
+		if err := rows.Scan(&keyId, &count); err != nil {
+			return nil, fmt.Errorf("failed to count files: %w", err)
+		}
+		counts[keyId] = count
+	}
+	return counts, rows.Err()
+}
+
 func (s *PostgresqlStorage) DeleteFileInfo(ctx context.Context, fileId string) error {
 	query := `DELETE from fileinfo where id = $1`
 	_, err := s.DB.ExecContext(ctx, query, fileId)
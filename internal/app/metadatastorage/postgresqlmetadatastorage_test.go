@@ -24,7 +24,9 @@ func TestPostgresqlStorage_GetFileById(t *testing.T) {
 	created := time.Now()
 	fileId := pb.FileId{Id: "id"}
 	key := []byte("key")
-	fileInfo := pb.FileInfo{Id: &fileId, Login: "login", Filename: "name", Comment: "comment", Created: uint64(created.Unix()), Size: 1, EncryptionKey: key}
+	hash := []byte("hash")
+	checksum := []byte("checksum")
+	fileInfo := pb.FileInfo{Id: &fileId, Login: "login", Filename: "name", Comment: "comment", Created: uint64(created.Unix()), Modified: uint64(created.Unix()), Size: 1, EncryptionKey: key, ContentHash: hash, Checksum: checksum}
 
 	storage := NewPostgresqlStorageStorage(db)
 	tests := []struct {
@@ -42,8 +44,8 @@ func TestPostgresqlStorage_GetFileById(t *testing.T) {
 				mock.ExpectQuery("SELECT").WillReturnError(&pgconn.PgError{})
 			} else if tt.isFound {
 				mock.ExpectQuery("SELECT").WillReturnRows(
-					sqlmock.NewRows([]string{"id", "login", "filename", "comment", "created", "size", "enctyprion_key"}).AddRow(
-						"id", "login", "name", "comment", created, 1, key))
+					sqlmock.NewRows([]string{"id", "login", "filename", "comment", "created", "modified", "size", "enctyprion_key", "content_hash", "checksum", "state", "organization_id", "key_id"}).AddRow(
+						"id", "login", "name", "comment", created, created, 1, key, hash, checksum, 0, "", ""))
 			} else {
 				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{}))
 			}
@@ -68,10 +70,12 @@ func TestPostgresqlStorage_GetFilesByLogin(t *testing.T) {
 	created := time.Now()
 
 	key := []byte("key")
+	hash := []byte("hash")
+	checksum := []byte("checksum")
 	listFiles := pb.ListFiles{}
 	for _, id := range []string{"id1", "id2"} {
 		fileId := pb.FileId{Id: id}
-		fileInfo := pb.FileInfo{Id: &fileId, Login: "login", Filename: "name", Comment: "comment", Created: uint64(created.Unix()), Size: 1, EncryptionKey: key}
+		fileInfo := pb.FileInfo{Id: &fileId, Login: "login", Filename: "name", Comment: "comment", Created: uint64(created.Unix()), Modified: uint64(created.Unix()), Size: 1, EncryptionKey: key, ContentHash: hash, Checksum: checksum}
 		listFiles.Files = append(listFiles.Files, &fileInfo)
 	}
 
@@ -91,8 +95,8 @@ func TestPostgresqlStorage_GetFilesByLogin(t *testing.T) {
 				mock.ExpectQuery("SELECT").WillReturnError(&pgconn.PgError{})
 			} else if tt.isFound {
 				mock.ExpectQuery("SELECT").WillReturnRows(
-					sqlmock.NewRows([]string{"id", "login", "filename", "comment", "created", "size", "enctyprion_key"}).AddRows(
-						[]driver.Value{"id1", "login", "name", "comment", created, 1, key}, []driver.Value{"id2", "login", "name", "comment", created, 1, key}))
+					sqlmock.NewRows([]string{"id", "login", "filename", "comment", "created", "modified", "size", "enctyprion_key", "content_hash", "checksum", "state", "organization_id", "key_id"}).AddRows(
+						[]driver.Value{"id1", "login", "name", "comment", created, created, 1, key, hash, checksum, 0, "", ""}, []driver.Value{"id2", "login", "name", "comment", created, created, 1, key, hash, checksum, 0, "", ""}))
 			} else {
 				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{}))
 			}
@@ -111,6 +115,25 @@ func TestPostgresqlStorage_GetFilesByLogin(t *testing.T) {
 	}
 }
 
+func TestPostgresqlStorage_GetAllFiles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	created := time.Now()
+	storage := NewPostgresqlStorageStorage(db)
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "login", "filename", "comment", "created", "modified", "size", "enctyprion_key", "content_hash", "checksum", "state", "organization_id", "key_id"}).AddRows(
+			[]driver.Value{"id1", "login1", "name", "comment", created, created, 1, nil, nil, nil, 0, "", ""},
+			[]driver.Value{"id2", "login2", "name", "comment", created, created, 1, nil, nil, nil, 1, "", ""}))
+	got, err := storage.GetAllFiles(context.Background())
+	require.NoError(t, err)
+	require.Len(t, got.Files, 2)
+	assert.Equal(t, pb.FileState_PENDING, got.Files[1].State)
+}
+
 func TestPostgresqlStorage_AddFileInfo(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -121,7 +144,9 @@ func TestPostgresqlStorage_AddFileInfo(t *testing.T) {
 	created := time.Now()
 	fileId := pb.FileId{Id: "id"}
 	key := []byte("key")
-	fileInfo := pb.FileInfo{Id: &fileId, Login: "login", Filename: "name", Comment: "comment", Created: uint64(created.Unix()), Size: 1, EncryptionKey: key}
+	hash := []byte("hash")
+	checksum := []byte("checksum")
+	fileInfo := pb.FileInfo{Id: &fileId, Login: "login", Filename: "name", Comment: "comment", Created: uint64(created.Unix()), Modified: uint64(created.Unix()), Size: 1, EncryptionKey: key, ContentHash: hash, Checksum: checksum}
 
 	storage := NewPostgresqlStorageStorage(db)
 	tests := []struct {
@@ -154,7 +179,7 @@ func TestPostgresqlStorage_AddFileInfo(t *testing.T) {
 	}
 }
 
-func TestPostgresqlStorage_DeleteFileInfo(t *testing.T) {
+func TestPostgresqlStorage_CommitFileInfo(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -163,22 +188,32 @@ func TestPostgresqlStorage_DeleteFileInfo(t *testing.T) {
 
 	storage := NewPostgresqlStorageStorage(db)
 	tests := []struct {
-		name    string
-		wantErr bool
+		name       string
+		wantErr    bool
+		notPending bool
 	}{
-		{name: "delete_error", wantErr: true},
-		{name: "delete_good", wantErr: false},
+		{name: "commit_error", wantErr: true, notPending: false},
+		{name: "commit_not_pending", wantErr: false, notPending: true},
+		{name: "commit_good", wantErr: false, notPending: false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectBegin()
 			if tt.wantErr {
-				mock.ExpectExec("DELETE").WillReturnError(&pgconn.PgError{})
+				mock.ExpectExec("UPDATE fileinfo SET state").WillReturnError(&pgconn.PgError{})
+				mock.ExpectRollback()
+			} else if tt.notPending {
+				mock.ExpectExec("UPDATE fileinfo SET state").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
 			} else {
-				mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE fileinfo SET state").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
 			}
-			err := storage.DeleteFileInfo(context.Background(), "id")
+			err := storage.CommitFileInfo(context.Background(), "id", []byte("checksum"))
 			if tt.wantErr {
 				assert.NotEqual(t, err, nil)
+			} else if tt.notPending {
+				assert.ErrorIs(t, err, ErrNotPending)
 			} else {
 				assert.Equal(t, err, nil)
 			}
@@ -186,32 +221,59 @@ func TestPostgresqlStorage_DeleteFileInfo(t *testing.T) {
 	}
 }
 
-func TestPostgresqlStorage_Ping(t *testing.T) {
+func TestPostgresqlStorage_UpdateFileState(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
 
-	mock.ExpectBegin()
-	mock.ExpectCommit()
-
 	storage := NewPostgresqlStorageStorage(db)
-	err = storage.Ping()
+	mock.ExpectExec("UPDATE fileinfo SET state").WillReturnResult(sqlmock.NewResult(0, 1))
+	err = storage.UpdateFileState(context.Background(), "id", pb.FileState_DELETING)
 	assert.Equal(t, err, nil)
 }
 
-func TestPostgresqlStorage_init(t *testing.T) {
+func TestPostgresqlStorage_DeleteFileInfo(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
-	mock.ExpectBegin()
-	mock.ExpectExec("CREATE TABLE fileinfo").WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectExec("CREATE INDEX login_index").WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
 
 	storage := NewPostgresqlStorageStorage(db)
-	storage.init()
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "delete_error", wantErr: true},
+		{name: "delete_good", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr {
+				mock.ExpectExec("DELETE").WillReturnError(&pgconn.PgError{})
+			} else {
+				mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+			err := storage.DeleteFileInfo(context.Background(), "id")
+			if tt.wantErr {
+				assert.NotEqual(t, err, nil)
+			} else {
+				assert.Equal(t, err, nil)
+			}
+		})
+	}
+}
+
+func TestPostgresqlStorage_Ping(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	storage := NewPostgresqlStorageStorage(db)
+	err = storage.Ping()
+	assert.Equal(t, err, nil)
 }
@@ -0,0 +1,137 @@
+package metadatastorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dberrors"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+)
+
+// Storage contains file metainfo in embedded sqlite database.
+type SqliteStorage struct {
+	DB dbtx.DB
+}
+
+func NewSqliteStorage(db dbtx.DB) *SqliteStorage {
+	return &SqliteStorage{DB: db}
+}
+
+func (s *SqliteStorage) GetFileById(ctx context.Context, fileId string) (*pb.FileInfo, error) {
+	row := s.DB.QueryRowContext(ctx,
+		"SELECT id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE id = $1", fileId)
+	file := pb.FileInfo{}
+	var created, modified time.Time
+	var id string
+	err := row.Scan(&id, &file.Login, &file.Filename, &file.Comment, &created, &modified, &file.Size, &file.EncryptionKey, &file.ContentHash, &file.Checksum, &file.State, &file.Organization, &file.KeyId)
+	file.Id = &pb.FileId{Id: id}
+	file.Created = uint64(created.Unix())
+	file.Modified = uint64(modified.Unix())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get file %s: %w", fileId, ErrFileNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan rows: %w", err)
+	}
+	return &file, nil
+}
+
+func (s *SqliteStorage) GetFilesByLogin(ctx context.Context, login string) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE login = $1 AND state = $2 AND organization_id = ''",
+		login, pb.FileState_COMMITTED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *SqliteStorage) GetFilesByOrganization(ctx context.Context, orgId string) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE organization_id = $1 AND state = $2",
+		orgId, pb.FileState_COMMITTED)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *SqliteStorage) GetAllFiles(ctx context.Context) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *SqliteStorage) AddFileInfo(ctx context.Context, fileInfo *pb.FileInfo) error {
+	_, err := s.DB.ExecContext(ctx,
+		"INSERT into fileinfo (id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		fileInfo.GetId().GetId(), fileInfo.GetLogin(), fileInfo.GetFilename(), fileInfo.GetComment(),
+		time.Unix(int64(fileInfo.GetCreated()), 0), time.Now(), fileInfo.GetSize(), fileInfo.GetEncryptionKey(),
+		fileInfo.GetContentHash(), fileInfo.GetChecksum(), fileInfo.GetState(), fileInfo.GetOrganization(), fileInfo.GetKeyId())
+	if dberrors.IsSqliteUniqueViolation(err) {
+		err = ErrConflictMetaId
+	}
+	return err
+}
+
+func (s *SqliteStorage) CommitFileInfo(ctx context.Context, fileId string, checksum []byte) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx,
+		"UPDATE fileinfo SET state = $1, checksum = $2, modified = $3 WHERE id = $4 AND state = $5",
+		pb.FileState_COMMITTED, checksum, time.Now(), fileId, pb.FileState_PENDING)
+	if err != nil {
+		return fmt.Errorf("failed to commit file info: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 1 {
+		return ErrNotPending
+	}
+	return tx.Commit()
+}
+
+func (s *SqliteStorage) UpdateFileState(ctx context.Context, fileId string, state pb.FileState) error {
+	_, err := s.DB.ExecContext(ctx,
+		"UPDATE fileinfo SET state = $1, modified = $2 WHERE id = $3", state, time.Now(), fileId)
+	return err
+}
+
+func (s *SqliteStorage) GetFilesToRewrap(ctx context.Context, keyId string, afterId string, limit int) (*pb.ListFiles, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, filename, comment, created, modified, size, encryption_key, content_hash, checksum, state, organization_id, key_id FROM fileinfo WHERE organization_id = '' AND key_id <> $1 AND id > $2 ORDER BY id LIMIT $3",
+		keyId, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin select query: %w", err)
+	}
+	return scanFiles(rows)
+}
+
+func (s *SqliteStorage) UpdateFileKey(ctx context.Context, fileId string, oldKeyId string, key []byte, keyId string) error {
+	res, err := s.DB.ExecContext(ctx,
+		"UPDATE fileinfo SET encryption_key = $1, key_id = $2 WHERE id = $3 AND key_id = $4", key, keyId, fileId, oldKeyId)
+	return checkKeyUpdated(res, err)
+}
+
+func (s *SqliteStorage) CountFilesByKey(ctx context.Context) (map[string]int, error) {
+	return countFilesByKey(ctx, s.DB)
+}
+
+func (s *SqliteStorage) DeleteFileInfo(ctx context.Context, fileId string) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE from fileinfo where id = $1`, fileId)
+	return err
+}
+
+func (s *SqliteStorage) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.DB.PingContext(ctx)
+}
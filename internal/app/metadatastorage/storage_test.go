@@ -0,0 +1,157 @@
+package metadatastorage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every metadata storage implementation must follow.
+func testMetadataStorage(t *testing.T, storage MetadataStorage) {
+	ctx := context.Background()
+	login := uuid.NewString()
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	newFile := func() *pb.FileInfo {
+		return &pb.FileInfo{Id: &pb.FileId{Id: uuid.NewString()}, Login: login, Filename: "name", Comment: "comment",
+			Created: uint64(created.Unix()), Size: 10, EncryptionKey: []byte("key"), ContentHash: []byte("hash"),
+			State: pb.FileState_PENDING}
+	}
+	fileIds := func(files *pb.ListFiles) []string {
+		var ids []string
+		for _, file := range files.GetFiles() {
+			ids = append(ids, file.GetId().GetId())
+		}
+		return ids
+	}
+
+	require.NoError(t, storage.Ping())
+
+	pending := newFile()
+	require.NoError(t, storage.AddFileInfo(ctx, pending))
+	require.ErrorIs(t, storage.AddFileInfo(ctx, pending), ErrConflictMetaId)
+	got, err := storage.GetFileById(ctx, pending.GetId().GetId())
+	require.NoError(t, err)
+	require.Equal(t, pending.GetLogin(), got.GetLogin())
+	require.Equal(t, pending.GetFilename(), got.GetFilename())
+	require.Equal(t, pending.GetComment(), got.GetComment())
+	require.Equal(t, pending.GetCreated(), got.GetCreated())
+	require.Equal(t, pending.GetSize(), got.GetSize())
+	require.Equal(t, pending.GetEncryptionKey(), got.GetEncryptionKey())
+	require.Equal(t, pending.GetContentHash(), got.GetContentHash())
+	require.Equal(t, pb.FileState_PENDING, got.GetState())
+
+	files, err := storage.GetFilesByLogin(ctx, login)
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles(), "pending files should not be listed")
+
+	require.NoError(t, storage.CommitFileInfo(ctx, pending.GetId().GetId(), []byte("checksum")))
+	require.ErrorIs(t, storage.CommitFileInfo(ctx, pending.GetId().GetId(), []byte("checksum")), ErrNotPending)
+	got, err = storage.GetFileById(ctx, pending.GetId().GetId())
+	require.NoError(t, err)
+	require.Equal(t, pb.FileState_COMMITTED, got.GetState())
+	require.Equal(t, []byte("checksum"), got.GetChecksum())
+
+	other := newFile()
+	require.NoError(t, storage.AddFileInfo(ctx, other))
+	files, err = storage.GetFilesByLogin(ctx, login)
+	require.NoError(t, err)
+	require.Equal(t, []string{pending.GetId().GetId()}, fileIds(files))
+	files, err = storage.GetAllFiles(ctx)
+	require.NoError(t, err)
+	require.Subset(t, fileIds(files), []string{pending.GetId().GetId(), other.GetId().GetId()})
+
+	team := newFile()
+	team.Organization = uuid.NewString()
+	team.State = pb.FileState_COMMITTED
+	require.NoError(t, storage.AddFileInfo(ctx, team))
+	got, err = storage.GetFileById(ctx, team.GetId().GetId())
+	require.NoError(t, err)
+	require.Equal(t, team.GetOrganization(), got.GetOrganization())
+	files, err = storage.GetFilesByLogin(ctx, login)
+	require.NoError(t, err)
+	require.Equal(t, []string{pending.GetId().GetId()}, fileIds(files), "organization files should not be listed as personal")
+	files, err = storage.GetFilesByOrganization(ctx, team.GetOrganization())
+	require.NoError(t, err)
+	require.Equal(t, []string{team.GetId().GetId()}, fileIds(files))
+
+	require.NoError(t, storage.UpdateFileState(ctx, pending.GetId().GetId(), pb.FileState_DELETING))
+	files, err = storage.GetFilesByLogin(ctx, login)
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles(), "deleting files should not be listed")
+
+	oldKey, newKey := uuid.NewString(), uuid.NewString()
+	rewrapped := newFile()
+	rewrapped.KeyId = oldKey
+	require.NoError(t, storage.AddFileInfo(ctx, rewrapped))
+	got, err = storage.GetFileById(ctx, rewrapped.GetId().GetId())
+	require.NoError(t, err)
+	require.Equal(t, oldKey, got.GetKeyId())
+	files, err = storage.GetFilesToRewrap(ctx, newKey, "", 1000)
+	require.NoError(t, err)
+	require.Subset(t, fileIds(files), []string{other.GetId().GetId(), rewrapped.GetId().GetId()})
+	require.NotContains(t, fileIds(files), team.GetId().GetId(), "organization file keys are not wrapped by server key")
+	files, err = storage.GetFilesToRewrap(ctx, newKey, rewrapped.GetId().GetId(), 1)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(files.GetFiles()), 1)
+	for _, file := range files.GetFiles() {
+		require.Greater(t, file.GetId().GetId(), rewrapped.GetId().GetId())
+	}
+	counts, err := storage.CountFilesByKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, counts[oldKey])
+	require.ErrorIs(t, storage.UpdateFileKey(ctx, rewrapped.GetId().GetId(), newKey, []byte("new key"), newKey), ErrKeyChanged)
+	require.NoError(t, storage.UpdateFileKey(ctx, rewrapped.GetId().GetId(), oldKey, []byte("new key"), newKey))
+	got, err = storage.GetFileById(ctx, rewrapped.GetId().GetId())
+	require.NoError(t, err)
+	require.Equal(t, []byte("new key"), got.GetEncryptionKey())
+	require.Equal(t, newKey, got.GetKeyId())
+	counts, err = storage.CountFilesByKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, counts[oldKey])
+	require.Equal(t, 1, counts[newKey])
+
+	for _, file := range []*pb.FileInfo{pending, other, team, rewrapped} {
+		require.NoError(t, storage.DeleteFileInfo(ctx, file.GetId().GetId()))
+		_, err = storage.GetFileById(ctx, file.GetId().GetId())
+		require.ErrorIs(t, err, ErrFileNotFound)
+	}
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteStorage(t *testing.T) {
+	testMetadataStorage(t, NewSqliteStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db := openTestDB(t, "pgx", dsn, migrations.Postgres)
+	testMetadataStorage(t, NewPostgresqlStorageStorage(db))
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testMetadataStorage(t, NewMemoryStorage())
+}
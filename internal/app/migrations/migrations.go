@@ -0,0 +1,375 @@
+// Package migrations applies versioned database schema migrations.
+//
+// Migrations are embedded sql files {version}_{name}.up.sql and
+// {version}_{name}.down.sql in directory of database dialect.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Database dialect with its own set of migrations.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	Sqlite   Dialect = "sqlite"
+)
+
+// Error in case there are no applied migrations to roll back.
+var ErrNoApplied = errors.New("no applied migrations")
+
+// Error in case database has only some of tables created before migrations.
+var ErrPartialLegacySchema = errors.New("database has partial schema created before migrations, drop or complete it")
+
+// Error in case user logins differ only in case, so they can't be made case insensitive.
+var ErrLoginCaseConflict = errors.New("logins differ only in case, rename or delete duplicate accounts before upgrade")
+
+// Postgresql advisory lock key guarding migrations from concurrent server starts.
+const lockKey = 4_770_318_650
+
+// Tables created by storages before migrations were introduced.
+var legacyTables = []string{"fileinfo", "userinfo"}
+
+// Version of first migration which is equal to schema created before migrations.
+const baselineVersion = 1
+
+// Checks of data run before migration with given name, they explain how to fix data migration would fail on.
+var preconditions = map[string]func(ctx context.Context, tx *sql.Tx, dialect Dialect) error{
+	"login_case": checkLoginCase,
+}
+
+// Schema change with its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migration state in database.
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Applies migrations of given dialect to database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Reads embedded migrations of dialect ordered by version.
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, string(dialect))
+	if err != nil {
+		return nil, fmt.Errorf("unknown dialect %q: %w", dialect, err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		versionStr, name, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || !found || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		data, err := files.ReadFile(path.Join(string(dialect), entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migrations %q and %q have same version", migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Applies all pending migrations, returns applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Rolls back last applied migration.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var done *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, applied map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s can't be rolled back", migration.Version, migration.Name)
+			}
+			done = &migration
+			return m.apply(ctx, conn, migration, false)
+		}
+		return ErrNoApplied
+	})
+	return done, err
+}
+
+// Lists all known migrations with their state, database is not changed.
+//
+// Baseline of database created by storages before migrations is listed as applied
+// without apply time since it is adopted on first up.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	applied := make(map[int]time.Time)
+	tracked, err := m.tableExists(ctx, conn, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if tracked {
+		if applied, err = m.applied(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	adopted := false
+	if len(applied) == 0 {
+		if adopted, err = m.isLegacy(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		if adopted && migration.Version == baselineVersion {
+			status.Applied = true
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Runs f on single connection holding migrations lock with applied versions.
+//
+// Sqlite serializes writers itself so lock is taken only for postgresql.
+func (m *Migrator) withLock(ctx context.Context, f func(conn *sql.Conn, applied map[int]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+	if m.dialect == Postgres {
+		if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+	}
+	if _, err = conn.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations("version" INTEGER PRIMARY KEY, "name" TEXT NOT NULL, "applied_at" TIMESTAMP NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	if err = m.adoptBaseline(ctx, conn); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return f(conn, applied)
+}
+
+// Marks first migration applied for database created by storages before migrations.
+func (m *Migrator) adoptBaseline(ctx context.Context, conn *sql.Conn) error {
+	var count int
+	if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM schema_migrations").Scan(&count); err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	legacy, err := m.isLegacy(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if !legacy {
+		return nil
+	}
+	_, err = conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES($1, $2, $3)",
+		baselineVersion, m.migrations[0].Name, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to adopt baseline: %w", err)
+	}
+	return nil
+}
+
+// Whether database has schema created by storages before migrations.
+//
+// Schema with only some of legacy tables can't be adopted, ErrPartialLegacySchema is returned.
+func (m *Migrator) isLegacy(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var present, missing []string
+	for _, table := range legacyTables {
+		exists, err := m.tableExists(ctx, conn, table)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			present = append(present, table)
+		} else {
+			missing = append(missing, table)
+		}
+	}
+	if len(present) > 0 && len(missing) > 0 {
+		return false, fmt.Errorf("%w: tables %s exist but %s are missing", ErrPartialLegacySchema,
+			strings.Join(present, ", "), strings.Join(missing, ", "))
+	}
+	return len(missing) == 0, nil
+}
+
+func (m *Migrator) tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	var query string
+	switch m.dialect {
+	case Postgres:
+		query = "SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1)"
+	case Sqlite:
+		query = "SELECT count(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = $1"
+	}
+	var exists bool
+	if err := conn.QueryRowContext(ctx, query, table).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check table %s: %w", table, err)
+	}
+	return exists, nil
+}
+
+// Get applied versions with their apply time.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Applies or rolls back migration with its version record in one transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+	if check, ok := preconditions[migration.Name]; ok && up {
+		if err = check(ctx, tx, m.dialect); err != nil {
+			return fmt.Errorf("failed to migrate %s %d_%s: %w", direction, migration.Version, migration.Name, err)
+		}
+	}
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to migrate %s %d_%s: %w", direction, migration.Version, migration.Name, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES($1, $2, $3)",
+			migration.Version, migration.Name, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return tx.Commit()
+}
+
+// Lists groups of logins differing only in case which unique index on lower(login) would reject.
+func checkLoginCase(ctx context.Context, tx *sql.Tx, dialect Dialect) error {
+	aggregate := "string_agg(login, ', ' ORDER BY login)"
+	if dialect == Sqlite {
+		aggregate = "group_concat(login, ', ')"
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT "+aggregate+" FROM userinfo GROUP BY lower(login) HAVING count(*) > 1 ORDER BY 1")
+	if err != nil {
+		return fmt.Errorf("failed to check logins: %w", err)
+	}
+	defer rows.Close()
+	var conflicts []string
+	for rows.Next() {
+		var logins string
+		if err = rows.Scan(&logins); err != nil {
+			return fmt.Errorf("failed to scan rows: %w", err)
+		}
+		conflicts = append(conflicts, logins)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrLoginCaseConflict, strings.Join(conflicts, "; "))
+	}
+	return nil
+}
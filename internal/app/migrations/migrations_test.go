@@ -0,0 +1,142 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openTestSqlite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []Dialect{Postgres, Sqlite} {
+		migrations, err := loadMigrations(dialect)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		for i, migration := range migrations {
+			require.Equal(t, i+1, migration.Version, "versions should be consecutive")
+			require.NotEmpty(t, migration.Up)
+			require.NotEmpty(t, migration.Down)
+		}
+	}
+	_, err := loadMigrations("mysql")
+	require.Error(t, err)
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	db := openTestSqlite(t)
+	migrator, err := NewMigrator(db, Sqlite)
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, len(migrator.migrations))
+	require.False(t, statuses[0].Applied)
+	_, err = db.Exec("SELECT * FROM schema_migrations")
+	require.Error(t, err, "status should not change database")
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, migrator.migrations, applied)
+	_, err = db.Exec("INSERT INTO userinfo (login, password_hash) VALUES('login', 'hash')")
+	require.NoError(t, err)
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	require.True(t, statuses[0].Applied)
+	require.NotNil(t, statuses[0].AppliedAt)
+
+	for range migrator.migrations {
+		_, err = migrator.Down(ctx)
+		require.NoError(t, err)
+	}
+	_, err = migrator.Down(ctx)
+	require.ErrorIs(t, err, ErrNoApplied)
+	_, err = db.Exec("SELECT * FROM userinfo")
+	require.Error(t, err, "tables should be dropped")
+}
+
+func TestMigrator_AdoptBaseline(t *testing.T) {
+	ctx := context.Background()
+	db := openTestSqlite(t)
+	_, err := db.Exec(`CREATE TABLE userinfo("login" TEXT PRIMARY KEY, "password_hash" BLOB)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE fileinfo("id" TEXT PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db, Sqlite)
+	require.NoError(t, err)
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.True(t, statuses[0].Applied, "baseline should be listed as adopted")
+	require.Nil(t, statuses[0].AppliedAt)
+	require.False(t, statuses[1].Applied)
+	_, err = db.Exec("SELECT * FROM schema_migrations")
+	require.Error(t, err, "status should not change database")
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	for _, migration := range applied {
+		require.Greater(t, migration.Version, baselineVersion, "baseline should not be applied again")
+	}
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		require.True(t, status.Applied)
+	}
+}
+
+func TestMigrator_PartialLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	db := openTestSqlite(t)
+	_, err := db.Exec(`CREATE TABLE fileinfo("id" TEXT PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(db, Sqlite)
+	require.NoError(t, err)
+	_, err = migrator.Status(ctx)
+	require.ErrorIs(t, err, ErrPartialLegacySchema)
+	_, err = migrator.Up(ctx)
+	require.ErrorIs(t, err, ErrPartialLegacySchema)
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM schema_migrations").Scan(&count))
+	require.Zero(t, count, "baseline should not be adopted")
+}
+
+func TestMigrator_LoginCaseConflict(t *testing.T) {
+	ctx := context.Background()
+	db := openTestSqlite(t)
+	migrator, err := NewMigrator(db, Sqlite)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	for i := len(migrator.migrations) - 1; migrator.migrations[i].Name != "second_factor"; i-- {
+		_, err = migrator.Down(ctx)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec("INSERT INTO userinfo (login, password_hash) VALUES('Alice', 'hash'), ('alice', 'hash'), ('bob', 'hash')")
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.ErrorIs(t, err, ErrLoginCaseConflict)
+	require.ErrorContains(t, err, "Alice")
+	require.ErrorContains(t, err, "alice")
+	require.NotContains(t, err.Error(), "bob")
+
+	_, err = db.Exec("DELETE FROM userinfo WHERE login = 'Alice'")
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+}
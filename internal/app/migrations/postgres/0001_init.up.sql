@@ -0,0 +1,3 @@
+CREATE TABLE fileinfo("id" TEXT PRIMARY KEY, "login" TEXT NOT NULL CHECK ("login" <> ''), "filename" TEXT, "comment" TEXT, "created" TIMESTAMP, "modified" TIMESTAMP, "size" INT, "encryption_key" bytea);
+CREATE INDEX login_index ON fileinfo USING btree(login);
+CREATE TABLE userinfo("login" TEXT PRIMARY KEY, "password_hash" BYTEA);
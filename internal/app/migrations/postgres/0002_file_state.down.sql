@@ -0,0 +1,3 @@
+ALTER TABLE fileinfo DROP COLUMN "state";
+ALTER TABLE fileinfo DROP COLUMN "checksum";
+ALTER TABLE fileinfo DROP COLUMN "content_hash";
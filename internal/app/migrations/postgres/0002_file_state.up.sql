@@ -0,0 +1,4 @@
+-- Columns may already exist in databases created by storages before migrations.
+ALTER TABLE fileinfo ADD COLUMN IF NOT EXISTS "content_hash" bytea;
+ALTER TABLE fileinfo ADD COLUMN IF NOT EXISTS "checksum" bytea;
+ALTER TABLE fileinfo ADD COLUMN IF NOT EXISTS "state" SMALLINT NOT NULL DEFAULT 0;
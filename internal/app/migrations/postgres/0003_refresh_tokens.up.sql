@@ -0,0 +1,3 @@
+CREATE TABLE refresh_tokens("hash" BYTEA PRIMARY KEY, "family" TEXT NOT NULL, "login" TEXT NOT NULL, "public_key" BYTEA, "created" TIMESTAMP NOT NULL, "expires" TIMESTAMP NOT NULL, "used" BOOLEAN NOT NULL DEFAULT FALSE);
+CREATE INDEX refresh_tokens_family_index ON refresh_tokens(family);
+CREATE INDEX refresh_tokens_expires_index ON refresh_tokens(expires);
@@ -0,0 +1,3 @@
+CREATE TABLE sessions("id" TEXT PRIMARY KEY, "login" TEXT NOT NULL, "device" TEXT NOT NULL, "ip" TEXT NOT NULL, "created" TIMESTAMP NOT NULL, "last_seen" TIMESTAMP NOT NULL);
+CREATE INDEX sessions_login_index ON sessions(login);
+CREATE INDEX sessions_last_seen_index ON sessions(last_seen);
@@ -0,0 +1,4 @@
+ALTER TABLE userinfo ADD COLUMN "totp_secret" BYTEA;
+ALTER TABLE userinfo ADD COLUMN "totp_enabled" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE userinfo ADD COLUMN "totp_last_step" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE userinfo ADD COLUMN "recovery_codes" BYTEA;
@@ -0,0 +1 @@
+DROP INDEX userinfo_login_lower_index;
@@ -0,0 +1,3 @@
+CREATE TABLE api_tokens("id" TEXT PRIMARY KEY, "login" TEXT NOT NULL, "name" TEXT NOT NULL, "hash" BYTEA NOT NULL, "scope" TEXT NOT NULL, "folders" TEXT NOT NULL, "public_key" BYTEA NOT NULL, "created" TIMESTAMP NOT NULL, "expires" TIMESTAMP NOT NULL, "last_used" TIMESTAMP);
+CREATE INDEX api_tokens_login_index ON api_tokens(login);
+CREATE INDEX api_tokens_expires_index ON api_tokens(expires);
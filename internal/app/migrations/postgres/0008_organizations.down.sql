@@ -0,0 +1,4 @@
+DROP INDEX organization_index;
+ALTER TABLE fileinfo DROP COLUMN "organization_id";
+DROP TABLE organization_members;
+DROP TABLE organizations;
@@ -0,0 +1,5 @@
+CREATE TABLE organizations("id" TEXT PRIMARY KEY, "name" TEXT NOT NULL, "key" BYTEA NOT NULL, "created" TIMESTAMP NOT NULL);
+CREATE TABLE organization_members("organization_id" TEXT NOT NULL, "login" TEXT NOT NULL, "role" TEXT NOT NULL, "added" TIMESTAMP NOT NULL, PRIMARY KEY ("organization_id", "login"));
+CREATE INDEX organization_members_login_index ON organization_members(login);
+ALTER TABLE fileinfo ADD COLUMN "organization_id" TEXT NOT NULL DEFAULT '';
+CREATE INDEX organization_index ON fileinfo(organization_id);
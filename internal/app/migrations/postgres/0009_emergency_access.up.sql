@@ -0,0 +1,3 @@
+CREATE TABLE emergency_grants("id" TEXT PRIMARY KEY, "grantor" TEXT NOT NULL, "grantee" TEXT NOT NULL, "wait_period" BIGINT NOT NULL, "status" TEXT NOT NULL, "public_key" BYTEA NOT NULL, "created" TIMESTAMP NOT NULL, "requested" TIMESTAMP, UNIQUE ("grantor", "grantee"));
+CREATE INDEX emergency_grants_grantee_index ON emergency_grants(grantee);
+CREATE TABLE emergency_keys("grant_id" TEXT NOT NULL, "file_id" TEXT NOT NULL, "key" BYTEA NOT NULL, PRIMARY KEY ("grant_id", "file_id"));
@@ -0,0 +1,2 @@
+ALTER TABLE fileinfo ADD COLUMN "key_id" TEXT NOT NULL DEFAULT '';
+CREATE INDEX key_id_index ON fileinfo(key_id);
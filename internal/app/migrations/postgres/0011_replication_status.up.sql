@@ -0,0 +1 @@
+CREATE TABLE replication_status("id" TEXT PRIMARY KEY, "missing" TEXT NOT NULL, "error" TEXT NOT NULL, "attempts" INTEGER NOT NULL, "updated" TIMESTAMP NOT NULL);
@@ -0,0 +1,2 @@
+DROP TABLE userinfo;
+DROP TABLE fileinfo;
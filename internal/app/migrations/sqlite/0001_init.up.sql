@@ -0,0 +1,3 @@
+CREATE TABLE fileinfo("id" TEXT PRIMARY KEY, "login" TEXT NOT NULL CHECK ("login" <> ''), "filename" TEXT, "comment" TEXT, "created" TIMESTAMP, "modified" TIMESTAMP, "size" INTEGER, "encryption_key" BLOB, "content_hash" BLOB, "checksum" BLOB, "state" INTEGER NOT NULL DEFAULT 0);
+CREATE INDEX login_index ON fileinfo(login);
+CREATE TABLE userinfo("login" TEXT PRIMARY KEY, "password_hash" BLOB);
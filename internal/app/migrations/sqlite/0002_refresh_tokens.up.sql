@@ -0,0 +1,3 @@
+CREATE TABLE refresh_tokens("hash" BLOB PRIMARY KEY, "family" TEXT NOT NULL, "login" TEXT NOT NULL, "public_key" BLOB, "created" INTEGER NOT NULL, "expires" INTEGER NOT NULL, "used" INTEGER NOT NULL DEFAULT 0);
+CREATE INDEX refresh_tokens_family_index ON refresh_tokens(family);
+CREATE INDEX refresh_tokens_expires_index ON refresh_tokens(expires);
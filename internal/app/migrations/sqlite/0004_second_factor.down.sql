@@ -0,0 +1,4 @@
+ALTER TABLE userinfo DROP COLUMN "recovery_codes";
+ALTER TABLE userinfo DROP COLUMN "totp_last_step";
+ALTER TABLE userinfo DROP COLUMN "totp_enabled";
+ALTER TABLE userinfo DROP COLUMN "totp_secret";
@@ -0,0 +1,4 @@
+ALTER TABLE userinfo ADD COLUMN "totp_secret" BLOB;
+ALTER TABLE userinfo ADD COLUMN "totp_enabled" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE userinfo ADD COLUMN "totp_last_step" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE userinfo ADD COLUMN "recovery_codes" BLOB;
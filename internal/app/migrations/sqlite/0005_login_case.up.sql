@@ -0,0 +1 @@
+CREATE UNIQUE INDEX userinfo_login_lower_index ON userinfo (lower(login));
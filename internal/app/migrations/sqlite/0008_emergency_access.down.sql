@@ -0,0 +1,3 @@
+DROP TABLE emergency_keys;
+DROP INDEX emergency_grants_grantee_index;
+DROP TABLE emergency_grants;
@@ -0,0 +1,3 @@
+CREATE TABLE emergency_grants("id" TEXT PRIMARY KEY, "grantor" TEXT NOT NULL, "grantee" TEXT NOT NULL, "wait_period" INTEGER NOT NULL, "status" TEXT NOT NULL, "public_key" BLOB NOT NULL, "created" INTEGER NOT NULL, "requested" INTEGER, UNIQUE ("grantor", "grantee"));
+CREATE INDEX emergency_grants_grantee_index ON emergency_grants(grantee);
+CREATE TABLE emergency_keys("grant_id" TEXT NOT NULL, "file_id" TEXT NOT NULL, "key" BLOB NOT NULL, PRIMARY KEY ("grant_id", "file_id"));
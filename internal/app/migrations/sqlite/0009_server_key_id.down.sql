@@ -0,0 +1,2 @@
+DROP INDEX key_id_index;
+ALTER TABLE fileinfo DROP COLUMN "key_id";
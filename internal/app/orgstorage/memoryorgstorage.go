@@ -0,0 +1,186 @@
+package orgstorage
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Stores organizations in memory, all organizations are lost on restart.
+type MemoryOrgStorage struct {
+	mu      sync.Mutex
+	orgs    map[string]Organization
+	members map[string]map[string]Member
+}
+
+// New in-memory organization storage.
+func NewMemoryOrgStorage() *MemoryOrgStorage {
+	return &MemoryOrgStorage{orgs: make(map[string]Organization), members: make(map[string]map[string]Member)}
+}
+
+func copyOrganization(org Organization) Organization {
+	org.Key = slices.Clone(org.Key)
+	return org
+}
+
+func sortMembers(members []Member) {
+	slices.SortFunc(members, func(a, b Member) int {
+		if c := a.Added.Compare(b.Added); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.OrganizationID, b.OrganizationID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Login, b.Login)
+	})
+}
+
+// Add organization with members.
+func (s *MemoryOrgStorage) AddOrganization(_ context.Context, org Organization, members []Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orgs[org.ID] = copyOrganization(org)
+	s.members[org.ID] = make(map[string]Member, len(members))
+	for _, member := range members {
+		s.members[org.ID][member.Login] = member
+	}
+	return nil
+}
+
+// Get organization by id.
+func (s *MemoryOrgStorage) GetOrganization(_ context.Context, id string) (*Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[id]
+	if !ok {
+		return nil, ErrOrganizationNotFound
+	}
+	org = copyOrganization(org)
+	return &org, nil
+}
+
+// Get all organizations.
+func (s *MemoryOrgStorage) ListOrganizations(_ context.Context) ([]Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	orgs := make([]Organization, 0, len(s.orgs))
+	for _, org := range s.orgs {
+		orgs = append(orgs, copyOrganization(org))
+	}
+	slices.SortFunc(orgs, func(a, b Organization) int {
+		if c := a.Created.Compare(b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return orgs, nil
+}
+
+// Replace encrypted key of organization.
+func (s *MemoryOrgStorage) ReplaceKey(_ context.Context, id string, oldKey []byte, key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[id]
+	if !ok || !bytes.Equal(org.Key, oldKey) {
+		return ErrKeyChanged
+	}
+	org.Key = slices.Clone(key)
+	s.orgs[id] = org
+	return nil
+}
+
+// Delete organization with members.
+func (s *MemoryOrgStorage) DeleteOrganization(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[id]; !ok {
+		return ErrOrganizationNotFound
+	}
+	delete(s.orgs, id)
+	delete(s.members, id)
+	return nil
+}
+
+// Get member of organization.
+func (s *MemoryOrgStorage) GetMember(_ context.Context, orgID string, login string) (*Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	member, ok := s.members[orgID][login]
+	if !ok {
+		return nil, ErrMemberNotFound
+	}
+	return &member, nil
+}
+
+// Get members of organization.
+func (s *MemoryOrgStorage) ListMembers(_ context.Context, orgID string) ([]Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	members := make([]Member, 0, len(s.members[orgID]))
+	for _, member := range s.members[orgID] {
+		members = append(members, member)
+	}
+	sortMembers(members)
+	return members, nil
+}
+
+// Get memberships of user.
+func (s *MemoryOrgStorage) ListMemberships(_ context.Context, login string) ([]Member, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	members := make([]Member, 0)
+	for _, orgMembers := range s.members {
+		if member, ok := orgMembers[login]; ok {
+			members = append(members, member)
+		}
+	}
+	sortMembers(members)
+	return members, nil
+}
+
+// Add member or change role, adding time of existing member is kept.
+func (s *MemoryOrgStorage) SetMember(_ context.Context, member Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	members, ok := s.members[member.OrganizationID]
+	if !ok {
+		return ErrOrganizationNotFound
+	}
+	if member.Role != OwnerRole && s.lastOwner(member.OrganizationID, member.Login) {
+		return ErrLastOwner
+	}
+	if existing, ok := members[member.Login]; ok {
+		member.Added = existing.Added
+	}
+	members[member.Login] = member
+	return nil
+}
+
+// Whether member is the only owner of organization.
+func (s *MemoryOrgStorage) lastOwner(orgID string, login string) bool {
+	if s.members[orgID][login].Role != OwnerRole {
+		return false
+	}
+	for _, member := range s.members[orgID] {
+		if member.Login != login && member.Role == OwnerRole {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove member from organization.
+func (s *MemoryOrgStorage) DeleteMember(_ context.Context, orgID string, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.members[orgID][login]; !ok {
+		return ErrMemberNotFound
+	}
+	if s.lastOwner(orgID, login) {
+		return ErrLastOwner
+	}
+	delete(s.members[orgID], login)
+	return nil
+}
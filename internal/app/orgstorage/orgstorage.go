@@ -0,0 +1,78 @@
+// Package orgstorage for storing organizations and their members.
+package orgstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// Error in case organization is absent.
+	ErrOrganizationNotFound = errors.New("organization not found")
+
+	// Error in case user is not member of organization.
+	ErrMemberNotFound = errors.New("member not found")
+
+	// Error in case organization key to replace has been changed or organization is absent.
+	ErrKeyChanged = errors.New("organization key has been changed")
+
+	// Error in case change would leave organization without owner.
+	ErrLastOwner = errors.New("organization should have at least one owner")
+)
+
+// Role of members managing organization, organization always keeps at least one of them.
+const OwnerRole = "owner"
+
+// Organization owning team files.
+//
+// Key wraps encryption keys of team files, it is stored encrypted by server key.
+type Organization struct {
+	ID      string
+	Name    string
+	Key     []byte
+	Created time.Time
+}
+
+// Membership of user in organization.
+type Member struct {
+	OrganizationID string
+	Login          string
+	Role           string
+	Added          time.Time
+}
+
+// Storage of organizations and their members.
+//
+//go:generate mockery --name OrgStorage
+type OrgStorage interface {
+	// Method for adding organization with its members in one transaction.
+	AddOrganization(ctx context.Context, org Organization, members []Member) error
+
+	// Method for getting organization by id.
+	GetOrganization(ctx context.Context, id string) (*Organization, error)
+
+	// Method for getting all organizations ordered by creation time.
+	ListOrganizations(ctx context.Context) ([]Organization, error)
+
+	// Method for replacing encrypted key of organization if it is unchanged, e.g. when it is encrypted by new server key.
+	ReplaceKey(ctx context.Context, id string, oldKey []byte, key []byte) error
+
+	// Method for deleting organization with its members.
+	DeleteOrganization(ctx context.Context, id string) error
+
+	// Method for getting member of organization.
+	GetMember(ctx context.Context, orgID string, login string) (*Member, error)
+
+	// Method for getting members of organization ordered by adding time.
+	ListMembers(ctx context.Context, orgID string) ([]Member, error)
+
+	// Method for getting memberships of user ordered by adding time.
+	ListMemberships(ctx context.Context, login string) ([]Member, error)
+
+	// Method for adding member or changing role of existing one, returns ErrLastOwner if last owner is demoted.
+	SetMember(ctx context.Context, member Member) error
+
+	// Method for removing member from organization, returns ErrLastOwner if member is last owner.
+	DeleteMember(ctx context.Context, orgID string, login string) error
+}
@@ -0,0 +1,243 @@
+package orgstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores organizations in postgresql.
+type PostgresqlOrgStorage struct {
+	DB dbtx.DB
+}
+
+// New postgresql organization storage.
+func NewPostgresqlOrgStorage(db dbtx.DB) *PostgresqlOrgStorage {
+	return &PostgresqlOrgStorage{DB: db}
+}
+
+const (
+	organizationColumns = "id, name, key, created"
+	memberColumns       = "organization_id, login, role, added"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Add organization with members.
+func (s *PostgresqlOrgStorage) AddOrganization(ctx context.Context, org Organization, members []Member) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, "INSERT into organizations ("+organizationColumns+") VALUES($1, $2, $3, $4)",
+		org.ID, org.Name, org.Key, org.Created.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add organization: %w", err)
+	}
+	for _, member := range members {
+		_, err = tx.ExecContext(ctx, "INSERT into organization_members ("+memberColumns+") VALUES($1, $2, $3, $4)",
+			org.ID, member.Login, member.Role, member.Added.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Get organization by id.
+func (s *PostgresqlOrgStorage) GetOrganization(ctx context.Context, id string) (*Organization, error) {
+	var org Organization
+	err := s.DB.QueryRowContext(ctx, "SELECT "+organizationColumns+" FROM organizations WHERE id = $1", id).
+		Scan(&org.ID, &org.Name, &org.Key, &org.Created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return &org, nil
+}
+
+// Get all organizations.
+func (s *PostgresqlOrgStorage) ListOrganizations(ctx context.Context) ([]Organization, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+organizationColumns+" FROM organizations ORDER BY created, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+	orgs := make([]Organization, 0)
+	for rows.Next() {
+		var org Organization
+		if err := rows.Scan(&org.ID, &org.Name, &org.Key, &org.Created); err != nil {
+			return nil, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+// Replace encrypted key of organization.
+func (s *PostgresqlOrgStorage) ReplaceKey(ctx context.Context, id string, oldKey []byte, key []byte) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE organizations SET key = $1 WHERE id = $2 AND key = $3", key, id, oldKey)
+	return checkAffected(res, err, ErrKeyChanged)
+}
+
+// Delete organization with members.
+func (s *PostgresqlOrgStorage) DeleteOrganization(ctx context.Context, id string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete members: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM organizations WHERE id = $1", id)
+	if err = checkAffected(res, err, ErrOrganizationNotFound); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanPostgresqlMember(row scanner) (*Member, error) {
+	var member Member
+	if err := row.Scan(&member.OrganizationID, &member.Login, &member.Role, &member.Added); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// Get member of organization.
+func (s *PostgresqlOrgStorage) GetMember(ctx context.Context, orgID string, login string) (*Member, error) {
+	member, err := scanPostgresqlMember(s.DB.QueryRowContext(ctx,
+		"SELECT "+memberColumns+" FROM organization_members WHERE organization_id = $1 AND login = $2", orgID, login))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	return member, nil
+}
+
+func (s *PostgresqlOrgStorage) listMembers(ctx context.Context, query string, arg string) ([]Member, error) {
+	rows, err := s.DB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+	members := make([]Member, 0)
+	for rows.Next() {
+		member, err := scanPostgresqlMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members: %w", err)
+		}
+		members = append(members, *member)
+	}
+	return members, rows.Err()
+}
+
+// Get members of organization.
+func (s *PostgresqlOrgStorage) ListMembers(ctx context.Context, orgID string) ([]Member, error) {
+	return s.listMembers(ctx,
+		"SELECT "+memberColumns+" FROM organization_members WHERE organization_id = $1 ORDER BY added, login", orgID)
+}
+
+// Get memberships of user.
+func (s *PostgresqlOrgStorage) ListMemberships(ctx context.Context, login string) ([]Member, error) {
+	return s.listMembers(ctx,
+		"SELECT "+memberColumns+" FROM organization_members WHERE login = $1 ORDER BY added, organization_id", login)
+}
+
+// Add member or change role, adding time of existing member is kept.
+func (s *PostgresqlOrgStorage) SetMember(ctx context.Context, member Member) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err = lockOrganization(ctx, tx, member.OrganizationID); err != nil {
+		return err
+	}
+	if member.Role != OwnerRole {
+		if err = checkNotLastOwner(ctx, tx, member.OrganizationID, member.Login); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "INSERT into organization_members ("+memberColumns+") VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (organization_id, login) DO UPDATE SET role = excluded.role",
+		member.OrganizationID, member.Login, member.Role, member.Added.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to set member: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Remove member from organization.
+func (s *PostgresqlOrgStorage) DeleteMember(ctx context.Context, orgID string, login string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err = lockOrganization(ctx, tx, orgID); errors.Is(err, ErrOrganizationNotFound) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err = checkNotLastOwner(ctx, tx, orgID, login); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1 AND login = $2", orgID, login)
+	if err = checkAffected(res, err, ErrMemberNotFound); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Locks organization row so that concurrent changes of its members are serialized.
+func lockOrganization(ctx context.Context, tx *sql.Tx, orgID string) error {
+	err := tx.QueryRowContext(ctx, "SELECT id FROM organizations WHERE id = $1 FOR UPDATE", orgID).Scan(&orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrOrganizationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock organization: %w", err)
+	}
+	return nil
+}
+
+// Returns ErrLastOwner if member is the only owner of organization.
+//
+// Postgresql transaction has to lock organization first, sqlite transactions are serialized by single connection.
+func checkNotLastOwner(ctx context.Context, tx *sql.Tx, orgID string, login string) error {
+	var last bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = $1 AND login = $2 AND role = $3) "+
+		"AND NOT EXISTS(SELECT 1 FROM organization_members WHERE organization_id = $1 AND login <> $2 AND role = $3)",
+		orgID, login, OwnerRole).Scan(&last)
+	if err != nil {
+		return fmt.Errorf("failed to check owners: %w", err)
+	}
+	if last {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// Returns notFound if statement changed no rows.
+func checkAffected(res sql.Result, err error, notFound error) error {
+	if err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package orgstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores organizations in embedded sqlite database, times are stored as unix seconds.
+type SqliteOrgStorage struct {
+	DB dbtx.DB
+}
+
+// New sqlite organization storage.
+func NewSqliteOrgStorage(db dbtx.DB) *SqliteOrgStorage {
+	return &SqliteOrgStorage{DB: db}
+}
+
+func scanSqliteOrganization(row scanner) (*Organization, error) {
+	var org Organization
+	var created int64
+	if err := row.Scan(&org.ID, &org.Name, &org.Key, &created); err != nil {
+		return nil, err
+	}
+	org.Created = time.Unix(created, 0).UTC()
+	return &org, nil
+}
+
+// Add organization with members.
+func (s *SqliteOrgStorage) AddOrganization(ctx context.Context, org Organization, members []Member) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, "INSERT into organizations ("+organizationColumns+") VALUES($1, $2, $3, $4)",
+		org.ID, org.Name, org.Key, org.Created.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to add organization: %w", err)
+	}
+	for _, member := range members {
+		_, err = tx.ExecContext(ctx, "INSERT into organization_members ("+memberColumns+") VALUES($1, $2, $3, $4)",
+			org.ID, member.Login, member.Role, member.Added.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Get organization by id.
+func (s *SqliteOrgStorage) GetOrganization(ctx context.Context, id string) (*Organization, error) {
+	org, err := scanSqliteOrganization(s.DB.QueryRowContext(ctx,
+		"SELECT "+organizationColumns+" FROM organizations WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// Get all organizations.
+func (s *SqliteOrgStorage) ListOrganizations(ctx context.Context) ([]Organization, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+organizationColumns+" FROM organizations ORDER BY created, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+	orgs := make([]Organization, 0)
+	for rows.Next() {
+		org, err := scanSqliteOrganization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		orgs = append(orgs, *org)
+	}
+	return orgs, rows.Err()
+}
+
+// Replace encrypted key of organization.
+func (s *SqliteOrgStorage) ReplaceKey(ctx context.Context, id string, oldKey []byte, key []byte) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE organizations SET key = $1 WHERE id = $2 AND key = $3", key, id, oldKey)
+	return checkAffected(res, err, ErrKeyChanged)
+}
+
+// Delete organization with members.
+func (s *SqliteOrgStorage) DeleteOrganization(ctx context.Context, id string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete members: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM organizations WHERE id = $1", id)
+	if err = checkAffected(res, err, ErrOrganizationNotFound); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanSqliteMember(row scanner) (*Member, error) {
+	var member Member
+	var added int64
+	if err := row.Scan(&member.OrganizationID, &member.Login, &member.Role, &added); err != nil {
+		return nil, err
+	}
+	member.Added = time.Unix(added, 0).UTC()
+	return &member, nil
+}
+
+// Get member of organization.
+func (s *SqliteOrgStorage) GetMember(ctx context.Context, orgID string, login string) (*Member, error) {
+	member, err := scanSqliteMember(s.DB.QueryRowContext(ctx,
+		"SELECT "+memberColumns+" FROM organization_members WHERE organization_id = $1 AND login = $2", orgID, login))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %w", err)
+	}
+	return member, nil
+}
+
+func (s *SqliteOrgStorage) listMembers(ctx context.Context, query string, arg string) ([]Member, error) {
+	rows, err := s.DB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+	members := make([]Member, 0)
+	for rows.Next() {
+		member, err := scanSqliteMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members: %w", err)
+		}
+		members = append(members, *member)
+	}
+	return members, rows.Err()
+}
+
+// Get members of organization.
+func (s *SqliteOrgStorage) ListMembers(ctx context.Context, orgID string) ([]Member, error) {
+	return s.listMembers(ctx,
+		"SELECT "+memberColumns+" FROM organization_members WHERE organization_id = $1 ORDER BY added, login", orgID)
+}
+
+// Get memberships of user.
+func (s *SqliteOrgStorage) ListMemberships(ctx context.Context, login string) ([]Member, error) {
+	return s.listMembers(ctx,
+		"SELECT "+memberColumns+" FROM organization_members WHERE login = $1 ORDER BY added, organization_id", login)
+}
+
+// Add member or change role, adding time of existing member is kept.
+func (s *SqliteOrgStorage) SetMember(ctx context.Context, member Member) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM organizations WHERE id = $1)", member.OrganizationID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to set member: %w", err)
+	}
+	if !exists {
+		return ErrOrganizationNotFound
+	}
+	if member.Role != OwnerRole {
+		if err = checkNotLastOwner(ctx, tx, member.OrganizationID, member.Login); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "INSERT into organization_members ("+memberColumns+") VALUES($1, $2, $3, $4) "+
+		"ON CONFLICT (organization_id, login) DO UPDATE SET role = excluded.role",
+		member.OrganizationID, member.Login, member.Role, member.Added.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to set member: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Remove member from organization.
+func (s *SqliteOrgStorage) DeleteMember(ctx context.Context, orgID string, login string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err = checkNotLastOwner(ctx, tx, orgID, login); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1 AND login = $2", orgID, login)
+	if err = checkAffected(res, err, ErrMemberNotFound); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,111 @@
+package orgstorage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every organization storage implementation must follow.
+func testOrgStorage(t *testing.T, storage OrgStorage) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	alice, bob := uuid.NewString(), uuid.NewString()
+	team := Organization{ID: uuid.NewString(), Name: "team", Key: []byte("key"), Created: now}
+	other := Organization{ID: uuid.NewString(), Name: "other", Key: []byte("other key"), Created: now.Add(time.Second)}
+	owner := Member{OrganizationID: team.ID, Login: alice, Role: "owner", Added: now}
+	require.NoError(t, storage.AddOrganization(ctx, team, []Member{owner}))
+	require.NoError(t, storage.AddOrganization(ctx, other, []Member{{OrganizationID: other.ID, Login: bob, Role: "owner", Added: now}}))
+
+	got, err := storage.GetOrganization(ctx, team.ID)
+	require.NoError(t, err)
+	require.Equal(t, team, *got)
+	_, err = storage.GetOrganization(ctx, uuid.NewString())
+	require.ErrorIs(t, err, ErrOrganizationNotFound)
+	orgs, err := storage.ListOrganizations(ctx)
+	require.NoError(t, err)
+	require.Subset(t, orgs, []Organization{team, other})
+
+	require.NoError(t, storage.ReplaceKey(ctx, team.ID, team.Key, []byte("new key")))
+	require.ErrorIs(t, storage.ReplaceKey(ctx, team.ID, team.Key, []byte("other key")), ErrKeyChanged)
+	require.ErrorIs(t, storage.ReplaceKey(ctx, uuid.NewString(), team.Key, []byte("other key")), ErrKeyChanged)
+	got, err = storage.GetOrganization(ctx, team.ID)
+	require.NoError(t, err)
+	require.Equal(t, []byte("new key"), got.Key)
+
+	reader := Member{OrganizationID: team.ID, Login: bob, Role: "reader", Added: now.Add(time.Minute)}
+	require.NoError(t, storage.SetMember(ctx, reader))
+	member, err := storage.GetMember(ctx, team.ID, bob)
+	require.NoError(t, err)
+	require.Equal(t, reader, *member)
+	require.NoError(t, storage.SetMember(ctx, Member{OrganizationID: team.ID, Login: bob, Role: "writer", Added: now.Add(time.Hour)}))
+	member, err = storage.GetMember(ctx, team.ID, bob)
+	require.NoError(t, err)
+	require.Equal(t, "writer", member.Role)
+	require.True(t, reader.Added.Equal(member.Added), "adding time should be kept on role change")
+	require.ErrorIs(t, storage.SetMember(ctx, Member{OrganizationID: uuid.NewString(), Login: bob, Role: "reader", Added: now}),
+		ErrOrganizationNotFound)
+
+	members, err := storage.ListMembers(ctx, team.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{alice, bob}, []string{members[0].Login, members[1].Login})
+	memberships, err := storage.ListMemberships(ctx, bob)
+	require.NoError(t, err)
+	require.Equal(t, []string{other.ID, team.ID}, []string{memberships[0].OrganizationID, memberships[1].OrganizationID})
+
+	require.ErrorIs(t, storage.SetMember(ctx, Member{OrganizationID: team.ID, Login: alice, Role: "admin", Added: now}), ErrLastOwner)
+	require.ErrorIs(t, storage.DeleteMember(ctx, team.ID, alice), ErrLastOwner)
+	require.NoError(t, storage.SetMember(ctx, Member{OrganizationID: team.ID, Login: bob, Role: OwnerRole, Added: now}))
+	require.NoError(t, storage.SetMember(ctx, Member{OrganizationID: team.ID, Login: alice, Role: "admin", Added: now}),
+		"owner may be demoted while other owner is left")
+	require.ErrorIs(t, storage.DeleteMember(ctx, team.ID, bob), ErrLastOwner)
+	require.NoError(t, storage.SetMember(ctx, owner))
+
+	require.NoError(t, storage.DeleteMember(ctx, team.ID, bob))
+	require.ErrorIs(t, storage.DeleteMember(ctx, team.ID, bob), ErrMemberNotFound)
+	_, err = storage.GetMember(ctx, team.ID, bob)
+	require.ErrorIs(t, err, ErrMemberNotFound)
+
+	require.NoError(t, storage.DeleteOrganization(ctx, team.ID))
+	require.ErrorIs(t, storage.DeleteOrganization(ctx, team.ID), ErrOrganizationNotFound)
+	memberships, err = storage.ListMemberships(ctx, alice)
+	require.NoError(t, err)
+	require.Empty(t, memberships, "members should be deleted with organization")
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteOrgStorage(t *testing.T) {
+	testOrgStorage(t, NewSqliteOrgStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlOrgStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	testOrgStorage(t, NewPostgresqlOrgStorage(openTestDB(t, "pgx", dsn, migrations.Postgres)))
+}
+
+func TestMemoryOrgStorage(t *testing.T) {
+	testOrgStorage(t, NewMemoryOrgStorage())
+}
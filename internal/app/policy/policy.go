@@ -0,0 +1,107 @@
+// Package policy contains rules for logins and passwords of new users.
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule broken by login or password.
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// Names of checked fields, the same as in UserData message.
+const (
+	LoginField    = "login"
+	PasswordField = "password"
+)
+
+// Rules of user logins.
+type LoginPolicy struct {
+	MinLength int
+	MaxLength int
+}
+
+// Default login rules.
+var DefaultLoginPolicy = LoginPolicy{MinLength: 3, MaxLength: 64}
+
+// Returns login in form it is stored and compared in.
+//
+// Logins are case-insensitive, so they are lowercased with surrounding spaces removed.
+func NormalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+// Allowed login characters besides latin letters and digits, login should not start with them.
+const loginPunctuation = "._-@"
+
+// Checks normalized login, returns broken rules.
+func (p LoginPolicy) Check(login string) []Violation {
+	var violations []Violation
+	violate := func(format string, args ...any) {
+		violations = append(violations, Violation{Field: LoginField, Description: fmt.Sprintf(format, args...)})
+	}
+	if length := utf8.RuneCountInString(login); length < p.MinLength {
+		violate("should be at least %d characters long", p.MinLength)
+	} else if p.MaxLength > 0 && length > p.MaxLength {
+		violate("should be at most %d characters long", p.MaxLength)
+	}
+	for _, r := range login {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && !strings.ContainsRune(loginPunctuation, r) {
+			violate("should contain only latin letters, digits and %q", loginPunctuation)
+			break
+		}
+	}
+	if login != "" && strings.ContainsRune(loginPunctuation, rune(login[0])) {
+		violate("should start with letter or digit")
+	}
+	return violations
+}
+
+// Rules of user passwords.
+type PasswordPolicy struct {
+	MinLength int
+
+	// Maximal length in bytes, bcrypt ignores bytes after 72.
+	MaxLength int
+
+	// Minimal score of EstimateStrength.
+	MinStrength int
+
+	// Whether passwords from bundled list of common passwords are rejected.
+	RejectCommon bool
+}
+
+// Maximal password length supported by bcrypt.
+const MaxBcryptLength = 72
+
+// Default password rules.
+var DefaultPasswordPolicy = PasswordPolicy{MinLength: 10, MaxLength: MaxBcryptLength,
+	MinStrength: ScoreSafelyUnguessable, RejectCommon: true}
+
+// Checks password of user with given login, returns broken rules.
+func (p PasswordPolicy) Check(password string, login string) []Violation {
+	var violations []Violation
+	violate := func(format string, args ...any) {
+		violations = append(violations, Violation{Field: PasswordField, Description: fmt.Sprintf(format, args...)})
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violate("should be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violate("should be at most %d bytes long", p.MaxLength)
+	}
+	if p.RejectCommon && IsCommonPassword(password) {
+		violate("is in list of common passwords")
+	}
+	if p.MinStrength > 0 {
+		if strength := EstimateStrength(password, login); strength.Score < p.MinStrength {
+			violate("is too easy to guess: strength %d of %d required, add more words or random characters",
+				strength.Score, p.MinStrength)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		maxScore int
+		minScore int
+	}{
+		{password: "password", maxScore: ScoreTooGuessable},
+		{password: "P@ssw0rd", maxScore: ScoreTooGuessable},
+		{password: "drowssap", maxScore: ScoreTooGuessable},
+		{password: "aaaaaaaaaaaa", maxScore: ScoreTooGuessable},
+		{password: "abcdefghijkl", maxScore: ScoreTooGuessable},
+		{password: "qwerty123456", maxScore: ScoreVeryGuessable},
+		{password: "alicealice2024", maxScore: ScoreSomewhatGuessable},
+		{password: "x7#kQ9!mZ2pL", minScore: ScoreVeryUnguessable, maxScore: ScoreVeryUnguessable},
+		{password: "correct horse battery staple", minScore: ScoreVeryUnguessable, maxScore: ScoreVeryUnguessable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			score := EstimateStrength(tt.password, "alice").Score
+			assert.LessOrEqual(t, score, tt.maxScore)
+			assert.GreaterOrEqual(t, score, tt.minScore)
+		})
+	}
+	assert.Less(t, EstimateStrength("alice-secret", "alice").Guesses, EstimateStrength("alice-secret").Guesses,
+		"user inputs should make password weaker")
+}
+
+func TestLoginPolicy(t *testing.T) {
+	assert.Equal(t, "alice@example.com", NormalizeLogin("  Alice@Example.COM "))
+	tests := []struct {
+		login      string
+		violations int
+	}{
+		{login: "alice", violations: 0},
+		{login: "alice.smith-1_2@example.com", violations: 0},
+		{login: "al", violations: 1},
+		{login: strings.Repeat("a", 65), violations: 1},
+		{login: "alice smith", violations: 1},
+		{login: "алиса", violations: 1},
+		{login: ".alice", violations: 1},
+		{login: "", violations: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.login, func(t *testing.T) {
+			violations := DefaultLoginPolicy.Check(tt.login)
+			assert.Len(t, violations, tt.violations)
+			for _, violation := range violations {
+				assert.Equal(t, LoginField, violation.Field)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		password   string
+		violations int
+	}{
+		{password: "x7#kQ9!mZ2pL", violations: 0},
+		{password: "short", violations: 2},
+		{password: "password123", violations: 2},
+		{password: "alicealice2024", violations: 1},
+		{password: strings.Repeat("x7#kQ9!mZ2pL", 7), violations: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			violations := DefaultPasswordPolicy.Check(tt.password, "alice")
+			assert.Len(t, violations, tt.violations, violations)
+			for _, violation := range violations {
+				assert.Equal(t, PasswordField, violation.Field)
+			}
+		})
+	}
+	assert.Empty(t, PasswordPolicy{}.Check("password", "alice"), "zero policy should allow any password")
+	assert.True(t, IsCommonPassword("QWERTY"))
+	assert.False(t, IsCommonPassword("x7#kQ9!mZ2pL"))
+}
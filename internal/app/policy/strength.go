@@ -0,0 +1,221 @@
+package policy
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Bundled list of common passwords ordered by frequency.
+//
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+// Rank of common password by its lowercase form, the most common has rank 1.
+var commonPasswords = sync.OnceValue(func() map[string]int {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordsList))
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if _, ok := ranks[word]; word != "" && !ok {
+			ranks[word] = len(ranks) + 1
+		}
+	}
+	return ranks
+})
+
+// Whether password is in bundled list of common passwords, case is ignored.
+func IsCommonPassword(password string) bool {
+	_, ok := commonPasswords()[strings.ToLower(password)]
+	return ok
+}
+
+// Scores of password strength, the same as in zxcvbn.
+const (
+	// Guessed by online attack with no throttling.
+	ScoreTooGuessable = iota
+	// Guessed by throttled online attack.
+	ScoreVeryGuessable
+	// Guessed by offline attack against slow hash.
+	ScoreSomewhatGuessable
+	// Hard to guess by offline attack against slow hash.
+	ScoreSafelyUnguessable
+	// Very hard to guess by offline attack against slow hash.
+	ScoreVeryUnguessable
+)
+
+// Lower bounds of log10 of guesses for scores starting from ScoreVeryGuessable.
+var scoreThresholds = []float64{3, 6, 8, 10}
+
+// Estimated strength of password.
+type Strength struct {
+	// Log10 of estimated number of guesses needed to find password.
+	Guesses float64
+	Score   int
+}
+
+// Keyboard rows walked by common patterns like qwerty or asdf.
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./", "qazwsxedcrfvtgbyhnujmikolp"}
+
+// Common character substitutions like p@ssw0rd.
+var leetSubstitutions = map[rune]rune{'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+	'|': 'l', '0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z'}
+
+// Guesses of single character not covered by any pattern.
+const bruteforceGuesses = 10
+
+// Minimal guesses of pattern covering several characters, so that short patterns are not cheaper than bruteforce.
+const minPatternGuesses = 50
+
+// Estimates strength of password in the manner of zxcvbn.
+//
+// Password is split into dictionary words, repeats, sequences and keyboard walks
+// so that total number of guesses is minimal, characters not covered by patterns are bruteforced.
+// Words of user inputs like login are treated as the most common dictionary words.
+func EstimateStrength(password string, userInputs ...string) Strength {
+	dictionary := commonPasswords()
+	inputs := make(map[string]int)
+	for _, input := range userInputs {
+		if input = strings.ToLower(input); len(input) >= 3 {
+			inputs[input] = 1
+		}
+	}
+	chars := []rune(password)
+	best := make([]float64, len(chars)+1)
+	for end := 1; end <= len(chars); end++ {
+		best[end] = best[end-1] + math.Log10(bruteforceGuesses)
+		for start := 0; start+1 < end; start++ {
+			if guesses := patternGuesses(chars[start:end], dictionary, inputs); guesses > 0 {
+				best[end] = min(best[end], best[start]+math.Log10(max(guesses, minPatternGuesses)))
+			}
+		}
+	}
+	strength := Strength{Guesses: best[len(chars)]}
+	for _, threshold := range scoreThresholds {
+		if strength.Guesses >= threshold {
+			strength.Score++
+		}
+	}
+	return strength
+}
+
+// Guesses of the cheapest pattern matching whole token, zero if token matches no pattern.
+func patternGuesses(token []rune, dictionaries ...map[string]int) float64 {
+	guesses := math.Inf(1)
+	for _, dictionary := range dictionaries {
+		if g := dictionaryGuesses(token, dictionary); g > 0 {
+			guesses = min(guesses, g)
+		}
+	}
+	if len(token) >= 3 {
+		if g := repeatGuesses(token); g > 0 {
+			guesses = min(guesses, g)
+		}
+		if g := sequenceGuesses(token); g > 0 {
+			guesses = min(guesses, g)
+		}
+	}
+	if len(token) >= 4 {
+		if g := keyboardGuesses(token); g > 0 {
+			guesses = min(guesses, g)
+		}
+	}
+	if math.IsInf(guesses, 1) {
+		return 0
+	}
+	return guesses
+}
+
+// Guesses of dictionary word optionally capitalized, reversed or with substitutions.
+func dictionaryGuesses(token []rune, dictionary map[string]int) float64 {
+	lower := []rune(strings.ToLower(string(token)))
+	variations := 1.0
+	if string(lower) != string(token) {
+		variations *= 2
+	}
+	unleet := make([]rune, len(lower))
+	for i, r := range lower {
+		unleet[i] = r
+		if sub, ok := leetSubstitutions[r]; ok {
+			unleet[i] = sub
+		}
+	}
+	guesses := math.Inf(1)
+	for _, candidate := range []struct {
+		word   string
+		factor float64
+	}{{string(lower), 1}, {string(unleet), 2}, {reverse(lower), 2}, {reverse(unleet), 4}} {
+		if rank, ok := dictionary[candidate.word]; ok {
+			guesses = min(guesses, float64(rank)*candidate.factor*variations)
+		}
+	}
+	if math.IsInf(guesses, 1) {
+		return 0
+	}
+	return guesses
+}
+
+// Guesses of one character repeated.
+func repeatGuesses(token []rune) float64 {
+	for _, r := range token[1:] {
+		if r != token[0] {
+			return 0
+		}
+	}
+	return cardinality(token[0]) * float64(len(token))
+}
+
+// Guesses of characters with constant step of 1, like abcd or 9876.
+func sequenceGuesses(token []rune) float64 {
+	step := token[1] - token[0]
+	if step != 1 && step != -1 {
+		return 0
+	}
+	for i := 2; i < len(token); i++ {
+		if token[i]-token[i-1] != step {
+			return 0
+		}
+	}
+	base := cardinality(token[0])
+	if strings.ContainsRune("aAzZ019", token[0]) {
+		base = 4
+	}
+	if step < 0 {
+		base *= 2
+	}
+	return base * float64(len(token))
+}
+
+// Guesses of walk along keyboard row in any direction.
+func keyboardGuesses(token []rune) float64 {
+	lower := strings.ToLower(string(token))
+	for _, row := range keyboardRows {
+		if strings.Contains(row, lower) || strings.Contains(row, reverse([]rune(lower))) {
+			return float64(len(row)) * float64(len(token)) * 2
+		}
+	}
+	return 0
+}
+
+// Size of character class of rune.
+func cardinality(r rune) float64 {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r) || unicode.IsUpper(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+func reverse(runes []rune) string {
+	reversed := make([]rune, len(runes))
+	for i, r := range runes {
+		reversed[len(runes)-1-i] = r
+	}
+	return string(reversed)
+}
@@ -0,0 +1,58 @@
+package replicationstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+)
+
+// Stores replication status in postgresql.
+type PostgresqlReplicationStorage struct {
+	DB *sql.DB
+}
+
+// New postgresql replication storage.
+func NewPostgresqlReplicationStorage(db *sql.DB) *PostgresqlReplicationStorage {
+	return &PostgresqlReplicationStorage{DB: db}
+}
+
+// Records that object is missing on given side, increments attempts if already recorded.
+func (s *PostgresqlReplicationStorage) MarkMissing(ctx context.Context, fileId string, side filestorage.MirrorSide, cause error) error {
+	_, err := s.DB.ExecContext(ctx,
+		`INSERT into replication_status (id, missing, error, attempts, updated) VALUES($1, $2, $3, 1, $4)
+		ON CONFLICT (id) DO UPDATE SET missing = $2, error = $3, attempts = replication_status.attempts + 1, updated = $4`,
+		fileId, string(side), causeMessage(cause), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record replication status: %w", err)
+	}
+	return nil
+}
+
+// Removes record of object present on both sides or deleted.
+func (s *PostgresqlReplicationStorage) MarkReplicated(ctx context.Context, fileId string) error {
+	if _, err := s.DB.ExecContext(ctx, "DELETE FROM replication_status WHERE id = $1", fileId); err != nil {
+		return fmt.Errorf("failed to record replication status: %w", err)
+	}
+	return nil
+}
+
+// List objects missing on some side.
+func (s *PostgresqlReplicationStorage) ListMissing(ctx context.Context) ([]filestorage.ReplicationStatus, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT id, missing, error, attempts, updated FROM replication_status ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replication status: %w", err)
+	}
+	defer rows.Close()
+	statuses := make([]filestorage.ReplicationStatus, 0)
+	for rows.Next() {
+		var status filestorage.ReplicationStatus
+		if err := rows.Scan(&status.Id, &status.Missing, &status.Error, &status.Attempts, &status.Updated); err != nil {
+			return nil, fmt.Errorf("failed to list replication status: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
@@ -0,0 +1,13 @@
+// Package replicationstorage stores replication status of mirror blob backend in metadata database.
+//
+// Status is shared by server and maintenance commands running as separate processes,
+// so that replication status and repair see uploads failed on one side in running server.
+package replicationstorage
+
+// Returns error message stored for cause of failed replication.
+func causeMessage(cause error) string {
+	if cause == nil {
+		return ""
+	}
+	return cause.Error()
+}
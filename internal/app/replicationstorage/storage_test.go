@@ -0,0 +1,71 @@
+package replicationstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every replication status storage implementation must follow.
+func testReplicationStorage(t *testing.T, storage filestorage.ReplicationStatusStore) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Second)
+	broken := errors.New("broken")
+	require.NoError(t, storage.MarkMissing(ctx, "b", filestorage.Secondary, nil))
+	require.NoError(t, storage.MarkMissing(ctx, "a", filestorage.Secondary, broken))
+	require.NoError(t, storage.MarkMissing(ctx, "a", filestorage.Primary, broken))
+	require.NoError(t, storage.MarkMissing(ctx, "c", filestorage.Primary, broken))
+	require.NoError(t, storage.MarkReplicated(ctx, "c"))
+	require.NoError(t, storage.MarkReplicated(ctx, "absent"))
+
+	statuses, err := storage.ListMissing(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.Equal(t, "a", statuses[0].Id)
+	require.Equal(t, filestorage.Primary, statuses[0].Missing)
+	require.Equal(t, broken.Error(), statuses[0].Error)
+	require.Equal(t, 2, statuses[0].Attempts)
+	require.True(t, statuses[0].Updated.After(start))
+	require.Equal(t, "b", statuses[1].Id)
+	require.Equal(t, filestorage.Secondary, statuses[1].Missing)
+	require.Empty(t, statuses[1].Error)
+	require.Equal(t, 1, statuses[1].Attempts)
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteReplicationStorage(t *testing.T) {
+	testReplicationStorage(t, NewSqliteReplicationStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlReplicationStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	testReplicationStorage(t, NewPostgresqlReplicationStorage(openTestDB(t, "pgx", dsn, migrations.Postgres)))
+}
+
+func TestMemoryReplicationStorage(t *testing.T) {
+	testReplicationStorage(t, filestorage.NewMemoryReplicationStatusStore())
+}
@@ -5,34 +5,91 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/valinurovdenis/gophkeeper/internal/app/access"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergency"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
 	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
 	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
 	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
+	"go.uber.org/zap"
 )
 
-// Error in case when user with given login doesnt own file with given id.
+// Error in case when user with given login has no access to file with given id.
 var ErrNotOwn = errors.New("file not owned")
 
+// Error in case when file upload is not finished or file is being deleted.
+var ErrNotCommitted = errors.New("file is not available")
+
+// Error in case when declared file size can't be stored.
+var ErrFileTooLarge = errors.New("file size is too large")
+
 type GophKeeperService struct {
 	fileStorage     filestorage.StreamingFileStorage
 	metaDataStorage metadatastorage.MetadataStorage
+
+	// Policy authorizing every file and organization request.
+	Access *access.Policy
+
+	// Emergency access of trusted contacts to personal files.
+	Emergency *emergency.Manager
 }
 
 func NewGophKeeperService(s3Storage filestorage.StreamingFileStorage, metaDataStorage metadatastorage.MetadataStorage) (*GophKeeperService, error) {
-	return &GophKeeperService{fileStorage: s3Storage, metaDataStorage: metaDataStorage}, nil
+	return &GophKeeperService{fileStorage: s3Storage, metaDataStorage: metaDataStorage,
+		Access:    access.NewPolicy(orgstorage.NewMemoryOrgStorage()),
+		Emergency: emergency.NewManager(emergencystorage.NewMemoryEmergencyStorage())}, nil
 }
 
+// Checks whether user may perform action on file, returns ErrNotOwn if not.
+func (h *GophKeeperService) authorize(ctx context.Context, login string, info *pb.FileInfo, action access.Action) error {
+	err := h.Access.AuthorizeFile(ctx, login, info, action)
+	if errors.Is(err, access.ErrDenied) {
+		return ErrNotOwn
+	}
+	if err != nil {
+		return fmt.Errorf("error checking access: %w", err)
+	}
+	return nil
+}
+
+// Returns personal files of user and files of organizations user is member of.
 func (h *GophKeeperService) GetUserFiles(ctx context.Context, login string) (*pb.ListFiles, error) {
 	files, err := h.metaDataStorage.GetFilesByLogin(ctx, login)
 	if err != nil {
 		return nil, fmt.Errorf("error getting file metainfo: %w", err)
 	}
+	memberships, err := h.Access.Memberships(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("error getting organizations: %w", err)
+	}
+	for _, membership := range memberships {
+		orgFiles, err := h.metaDataStorage.GetFilesByOrganization(ctx, membership.Organization.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting file metainfo: %w", err)
+		}
+		files.Files = append(files.Files, orgFiles.GetFiles()...)
+	}
 	return files, nil
 }
 
+// Returns metainfo of file accessible by user.
+func (h *GophKeeperService) GetFileInfo(ctx context.Context, fileId *pb.FileId, login string) (*pb.FileInfo, error) {
+	info, err := h.metaDataStorage.GetFileById(ctx, fileId.GetId())
+	if err != nil {
+		return nil, fmt.Errorf("error getting file metainfo: %w", err)
+	}
+	if err = h.authorize(ctx, login, info, access.ActionRead); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
 func (h *GophKeeperService) UploadFile(stream pb.GophKeeperService_UploadFileServer, login string) error {
 	res, err := stream.Recv()
 	if err != nil {
@@ -42,24 +99,78 @@ func (h *GophKeeperService) UploadFile(stream pb.GophKeeperService_UploadFileSer
 	if info == nil {
 		return fmt.Errorf("no upload file info")
 	}
-	_, err = encryption.DecryptFileEncryptionKey(info.GetEncryptionKey(), encryption.ServerPrivateKey())
+	if info.GetSize() > math.MaxInt64 {
+		return ErrFileTooLarge
+	}
+	// Clients may wrap key by retired server key until they login again.
+	fileKey, err := encryption.DecryptServerKey(info.GetEncryptionKey(), "")
 	if err != nil {
-		return fmt.Errorf("wrong encryption key %w", err)
+		return fmt.Errorf("wrong encryption key, relogin may required: %w", err)
 	}
 
 	info.Login = login
+	if info.GetOrganization() != "" {
+		if err = h.authorize(stream.Context(), login, info, access.ActionWrite); err != nil {
+			return err
+		}
+	}
+	if info.EncryptionKey, info.KeyId, err = h.Access.WrapFileKey(stream.Context(), info.GetOrganization(), fileKey); err != nil {
+		return fmt.Errorf("cannot wrap file encryption key: %w", err)
+	}
 	if info.GetCreated() == 0 {
 		info.Created = uint64(time.Now().Unix())
 	}
 	info.Id = &pb.FileId{Id: filestorage.CreateFileId(info)}
 
+	info.State = pb.FileState_PENDING
+	fileId := info.GetId().Id
+	if err = h.metaDataStorage.AddFileInfo(stream.Context(), info); err != nil {
+		return fmt.Errorf("failed to add file metainfo: %w", err)
+	}
+
 	fileSize := int64(info.GetSize())
-	err = h.fileStorage.Upload(stream, fileSize, info.GetId().Id)
+	reader := filestorage.NewFileStreamReader(stream)
+	err = h.fileStorage.Upload(stream.Context(), reader, fileSize, fileId)
+	if verifyErr := reader.Verify(fileSize); verifyErr != nil {
+		h.discardUpload(stream.Context(), fileId)
+		return fmt.Errorf("failed to upload: %w", verifyErr)
+	}
 	if err != nil {
+		h.discardUpload(stream.Context(), fileId)
 		return fmt.Errorf("failed to upload: %w", err)
 	}
-	stream.SendAndClose(&pb.UploadResponse{Id: &pb.FileId{Id: info.GetId().Id}})
-	return h.metaDataStorage.AddFileInfo(stream.Context(), info)
+	info.Checksum = reader.Checksum()
+	if err = h.metaDataStorage.CommitFileInfo(stream.Context(), fileId, info.Checksum); err != nil {
+		h.discardUpload(stream.Context(), fileId)
+		return fmt.Errorf("failed to commit file metainfo: %w", err)
+	}
+	// Organization deleted during upload has not seen file while it was pending.
+	if info.GetOrganization() != "" {
+		if _, err = h.Access.Orgs.GetOrganization(stream.Context(), info.GetOrganization()); err != nil {
+			h.discardUpload(stream.Context(), fileId)
+			return fmt.Errorf("failed to upload to organization: %w", err)
+		}
+	}
+	info.State = pb.FileState_COMMITTED
+	return stream.SendAndClose(&pb.UploadResponse{Id: &pb.FileId{Id: fileId}, Checksum: info.Checksum, Size: info.Size})
+}
+
+// Time given to remove failed upload after stream is closed.
+const discardTimeout = 10 * time.Second
+
+// Removes blob and pending metainfo of failed upload.
+//
+// Upload mostly fails on client disconnect, so stream context is detached from cancellation.
+// Whatever is left is removed by consistency check after grace period.
+func (h *GophKeeperService) discardUpload(streamCtx context.Context, fileId string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(streamCtx), discardTimeout)
+	defer cancel()
+	if err := h.fileStorage.Delete(ctx, fileId); err != nil {
+		logger.Log.Error("failed to delete blob of failed upload", zap.String("file_id", fileId), zap.Error(err))
+	}
+	if err := h.metaDataStorage.DeleteFileInfo(ctx, fileId); err != nil {
+		logger.Log.Error("failed to delete metainfo of failed upload", zap.String("file_id", fileId), zap.Error(err))
+	}
 }
 
 func (h *GophKeeperService) DownloadFile(fileId *pb.FileId, stream pb.GophKeeperService_DownloadFileServer, login string, clientPublicKey []byte) error {
@@ -67,10 +178,16 @@ func (h *GophKeeperService) DownloadFile(fileId *pb.FileId, stream pb.GophKeeper
 	if err != nil {
 		return fmt.Errorf("error getting file metainfo: %w", err)
 	}
-	if info.Login != login {
-		return ErrNotOwn
+	if err = h.authorize(stream.Context(), login, info, access.ActionRead); err != nil {
+		return err
+	}
+	if info.State != pb.FileState_COMMITTED {
+		return ErrNotCommitted
+	}
+	key, err := h.Access.UnwrapFileKey(stream.Context(), info)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt file encryption key: %w", err)
 	}
-	key, _ := encryption.DecryptFileEncryptionKey(info.EncryptionKey, encryption.ServerPrivateKey())
 	encryptedKey, err := encryption.EncryptFileEncryptionKey(key, clientPublicKey)
 	if err != nil {
 		return fmt.Errorf("cannot encrypt file encryption key: %w", err)
@@ -79,10 +196,12 @@ func (h *GophKeeperService) DownloadFile(fileId *pb.FileId, stream pb.GophKeeper
 		Id:            info.Id,
 		Filename:      info.Filename,
 		Login:         info.Login,
+		Organization:  info.Organization,
 		Comment:       info.Comment,
 		Created:       info.Created,
 		Size:          info.Size,
-		EncryptionKey: encryptedKey}}})
+		EncryptionKey: encryptedKey,
+		ContentHash:   info.ContentHash}}})
 	return h.fileStorage.Download(stream, fileId.GetId())
 }
 
@@ -91,8 +210,198 @@ func (h *GophKeeperService) DeleteFile(ctx context.Context, fileId *pb.FileId, l
 	if err != nil {
 		return fmt.Errorf("error getting file metainfo: %w", err)
 	}
-	if info.Login != login {
+	if err = h.authorize(ctx, login, info, access.ActionWrite); err != nil {
+		return err
+	}
+	if info.State == pb.FileState_PENDING {
+		return ErrNotCommitted
+	}
+	return h.removeFile(ctx, fileId.GetId())
+}
+
+// Removes blob and metainfo of file without authorization.
+func (h *GophKeeperService) removeFile(ctx context.Context, fileId string) error {
+	// Hide file first so that metainfo never points to removed blob.
+	if err := h.metaDataStorage.UpdateFileState(ctx, fileId, pb.FileState_DELETING); err != nil {
+		return fmt.Errorf("error updating file metainfo: %w", err)
+	}
+	if err := h.fileStorage.Delete(ctx, fileId); err != nil {
+		return fmt.Errorf("error deleting file: %w", err)
+	}
+	return h.metaDataStorage.DeleteFileInfo(ctx, fileId)
+}
+
+// Deletes all committed files of user, returns number of deleted files.
+//
+// Pending uploads are left to consistency check which removes them after grace period.
+func (h *GophKeeperService) DeleteUserFiles(ctx context.Context, login string) (int, error) {
+	files, err := h.metaDataStorage.GetFilesByLogin(ctx, login)
+	if err != nil {
+		return 0, fmt.Errorf("error getting file metainfo: %w", err)
+	}
+	deleted := 0
+	for _, file := range files.GetFiles() {
+		if err := h.DeleteFile(ctx, file.GetId(), login); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Deletes organization with all its files, returns number of deleted files.
+//
+// Organization is deleted first so that new uploads to it are rejected, uploads committed
+// meanwhile are discarded by UploadFile. Pending uploads are left to consistency check.
+// Files which failed to be removed are reported in error, others are removed anyway.
+func (h *GophKeeperService) DeleteOrganization(ctx context.Context, login string, orgId string) (int, error) {
+	if err := h.Access.Authorize(ctx, login, "", orgId, access.ActionDeleteOrganization); err != nil {
+		return 0, err
+	}
+	if err := h.Access.Orgs.DeleteOrganization(ctx, orgId); err != nil {
+		return 0, err
+	}
+	files, err := h.metaDataStorage.GetFilesByOrganization(ctx, orgId)
+	if err != nil {
+		return 0, fmt.Errorf("error getting file metainfo: %w", err)
+	}
+	deleted := 0
+	var errs []error
+	for _, file := range files.GetFiles() {
+		if err := h.removeFile(ctx, file.GetId().GetId()); err != nil {
+			errs = append(errs, fmt.Errorf("file %s: %w", file.GetId().GetId(), err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// Removes user from all organizations, returns number of deleted files.
+//
+// Organizations where user is the only member are deleted with their files. Nothing is changed
+// if user is the last owner of organization with other members, access.ErrLastOwner is returned.
+func (h *GophKeeperService) LeaveOrganizations(ctx context.Context, login string) (int, error) {
+	memberships, err := h.Access.Memberships(ctx, login)
+	if err != nil {
+		return 0, fmt.Errorf("error getting organizations: %w", err)
+	}
+	alone := make(map[string]bool)
+	for _, membership := range memberships {
+		members, err := h.Access.ListMembers(ctx, login, membership.Organization.ID)
+		if err != nil {
+			return 0, fmt.Errorf("error getting members: %w", err)
+		}
+		owners := 0
+		for _, member := range members {
+			if access.Role(member.Role) == access.RoleOwner {
+				owners++
+			}
+		}
+		switch {
+		case len(members) == 1:
+			alone[membership.Organization.ID] = true
+		case membership.Role == access.RoleOwner && owners == 1:
+			return 0, fmt.Errorf("%w: %s", access.ErrLastOwner, membership.Organization.Name)
+		}
+	}
+	deleted := 0
+	for _, membership := range memberships {
+		orgId := membership.Organization.ID
+		if alone[orgId] {
+			orgDeleted, err := h.DeleteOrganization(ctx, login, orgId)
+			deleted += orgDeleted
+			if err != nil {
+				return deleted, err
+			}
+			continue
+		}
+		if err := h.Access.RemoveMember(ctx, login, orgId, login); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// Replaces file keys of emergency access, keys are wrapped by grantor for public key of contact.
+//
+// Only committed personal files of grantor may be given to contact.
+func (h *GophKeeperService) ConfirmEmergencyAccess(ctx context.Context, login string, id string, keys []emergencystorage.Key) (*emergencystorage.Grant, error) {
+	if _, err := h.Emergency.ConfirmableGrant(ctx, login, id); err != nil {
+		return nil, err
+	}
+	files, err := h.metaDataStorage.GetFilesByLogin(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file metainfo: %w", err)
+	}
+	personal := make(map[string]bool, len(files.GetFiles()))
+	for _, file := range files.GetFiles() {
+		personal[file.GetId().GetId()] = file.GetState() == pb.FileState_COMMITTED
+	}
+	for _, key := range keys {
+		if !personal[key.FileID] {
+			return nil, ErrNotOwn
+		}
+	}
+	return h.Emergency.Confirm(ctx, login, id, keys)
+}
+
+// Returns personal files of grantor available to contact by approved emergency access.
+func (h *GophKeeperService) GetEmergencyFiles(ctx context.Context, login string, id string) (*pb.ListFiles, error) {
+	grant, err := h.Emergency.ApprovedGrant(ctx, login, id)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := h.Emergency.Grants.ListKeys(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wrapped[key.FileID] = true
+	}
+	files, err := h.metaDataStorage.GetFilesByLogin(ctx, grant.Grantor)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file metainfo: %w", err)
+	}
+	res := &pb.ListFiles{}
+	for _, file := range files.GetFiles() {
+		if wrapped[file.GetId().GetId()] && file.GetState() == pb.FileState_COMMITTED {
+			file.EncryptionKey = nil
+			res.Files = append(res.Files, file)
+		}
+	}
+	return res, nil
+}
+
+// Downloads file of grantor by approved emergency access, file key is sent as wrapped by grantor for contact.
+func (h *GophKeeperService) DownloadEmergencyFile(req *pb.EmergencyFile, stream pb.GophKeeperService_DownloadEmergencyFileServer, login string) error {
+	grant, err := h.Emergency.ApprovedGrant(stream.Context(), login, req.GetId())
+	if err != nil {
+		return err
+	}
+	key, err := h.Emergency.Grants.GetKey(stream.Context(), req.GetId(), req.GetFileId())
+	if err != nil {
+		return err
+	}
+	info, err := h.metaDataStorage.GetFileById(stream.Context(), req.GetFileId())
+	if err != nil {
+		return fmt.Errorf("error getting file metainfo: %w", err)
+	}
+	if info.GetLogin() != grant.Grantor || info.GetOrganization() != "" {
 		return ErrNotOwn
 	}
-	return h.fileStorage.Delete(ctx, fileId.GetId())
+	if info.State != pb.FileState_COMMITTED {
+		return ErrNotCommitted
+	}
+	stream.Send(&pb.FileStream{Data: &pb.FileStream_Info{Info: &pb.FileInfo{
+		Id:            info.Id,
+		Filename:      info.Filename,
+		Login:         info.Login,
+		Comment:       info.Comment,
+		Created:       info.Created,
+		Size:          info.Size,
+		EncryptionKey: key,
+		ContentHash:   info.ContentHash}}})
+	return h.fileStorage.Download(stream, req.GetFileId())
 }
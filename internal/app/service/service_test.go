@@ -3,11 +3,16 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
 	"github.com/valinurovdenis/gophkeeper/internal/mocks"
 	pb "github.com/valinurovdenis/gophkeeper/internal/proto"
 	"google.golang.org/grpc"
@@ -19,10 +24,19 @@ type serverStreamMock struct {
 	t        *testing.T
 	fileInfo *pb.FileInfo
 	file     *[]byte
+	recv     *[]*pb.FileStream
 }
 
 func (s serverStreamMock) Recv() (*pb.FileStream, error) {
-	return &pb.FileStream{Data: &pb.FileStream_Info{Info: s.fileInfo}}, nil
+	if s.recv == nil {
+		return &pb.FileStream{Data: &pb.FileStream_Info{Info: s.fileInfo}}, nil
+	}
+	if len(*s.recv) == 0 {
+		return nil, io.EOF
+	}
+	res := (*s.recv)[0]
+	*s.recv = (*s.recv)[1:]
+	return res, nil
 }
 
 func (s serverStreamMock) Send(filePart *pb.FileStream) error {
@@ -106,21 +120,64 @@ func setMockEncryption() {
 
 func TestGophKeeperService_UploadFile(t *testing.T) {
 	setMockEncryption()
-	mockMetadataStorage := mocks.NewMetadataStorage(t)
-	mockStreamingFileStorage := mocks.NewStreamingFileStorage(t)
-
-	service, err := NewGophKeeperService(mockStreamingFileStorage, mockMetadataStorage)
-	require.NoError(t, err)
 	encryptionKey, _ := encryption.EncryptFileEncryptionKey([]byte("encrypt"), encryption.ServerPublicKey())
-	fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 1}
-	stream := serverStreamMock{ctx: context.Background(), t: t, fileInfo: &fileInfo}
 	login := "kulebaka"
+	tests := []struct {
+		name         string
+		chunk        []byte
+		wantErr      bool
+		disconnected bool
+	}{
+		{name: "good", chunk: []byte("abc"), wantErr: false},
+		{name: "too_short", chunk: []byte("ab"), wantErr: true},
+		{name: "too_long", chunk: []byte("abcd"), wantErr: true},
+		{name: "disconnected", chunk: []byte("ab"), wantErr: true, disconnected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetadataStorage := mocks.NewMetadataStorage(t)
+			mockStreamingFileStorage := mocks.NewStreamingFileStorage(t)
+			service, err := NewGophKeeperService(mockStreamingFileStorage, mockMetadataStorage)
+			require.NoError(t, err)
 
-	mockStreamingFileStorage.On("Upload", stream, int64(1), mock.Anything).Return(nil).Once()
-	mockMetadataStorage.On("AddFileInfo", stream.Context(), &fileInfo).Return(nil).Once()
+			fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 3}
+			recv := []*pb.FileStream{
+				{Data: &pb.FileStream_Info{Info: &fileInfo}},
+				{Data: &pb.FileStream_ChunkData{ChunkData: tt.chunk}},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.disconnected {
+				cancel()
+			}
+			stream := serverStreamMock{ctx: ctx, t: t, fileInfo: &fileInfo, recv: &recv}
 
-	err = service.UploadFile(stream, login)
-	require.NoError(t, err)
+			var uploadErr error
+			mockStreamingFileStorage.On("Upload", stream.Context(), mock.Anything, int64(3), mock.Anything).Run(func(args mock.Arguments) {
+				_, uploadErr = io.CopyN(io.Discard, args.Get(1).(io.Reader), args.Get(2).(int64))
+			}).Return(func(context.Context, io.Reader, int64, string) error { return uploadErr }).Once()
+			mockMetadataStorage.On("AddFileInfo", stream.Context(), &fileInfo).Return(nil).Once()
+			if tt.wantErr {
+				// Failed upload is removed even if client has gone.
+				alive := mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil })
+				mockStreamingFileStorage.On("Delete", alive, mock.Anything).Return(nil).Once()
+				mockMetadataStorage.On("DeleteFileInfo", alive, mock.Anything).Return(nil).Once()
+			} else {
+				mockMetadataStorage.On("CommitFileInfo", stream.Context(), mock.Anything, mock.Anything).Return(nil).Once()
+			}
+
+			err = service.UploadFile(stream, login)
+			if tt.wantErr {
+				require.ErrorIs(t, err, filestorage.ErrSizeMismatch)
+				return
+			}
+			require.NoError(t, err)
+			checksum := sha256.Sum256(tt.chunk)
+			require.Equal(t, checksum[:], fileInfo.Checksum)
+			require.Equal(t, pb.FileState_COMMITTED, fileInfo.State)
+			require.NotNil(t, fileInfo.Id)
+		})
+	}
 }
 
 func TestGophKeeperService_DownloadFile(t *testing.T) {
@@ -141,7 +198,8 @@ func TestGophKeeperService_DownloadFile(t *testing.T) {
 
 	mockStreamingFileStorage.On("Download", stream, fileId.GetId()).Return(nil).Once()
 	encryptionKey, _ := encryption.EncryptFileEncryptionKey(key, encryption.ServerPublicKey())
-	fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 1, Login: login, Id: &fileId}
+	contentHash := []byte("hash")
+	fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 1, Login: login, Id: &fileId, ContentHash: contentHash}
 	mockMetadataStorage.On("GetFileById", stream.Context(), fileId.GetId()).Return(&fileInfo, nil).Once()
 
 	err = service.DownloadFile(&fileId, stream, login, encryption.ClientPublicKey())
@@ -151,4 +209,162 @@ func TestGophKeeperService_DownloadFile(t *testing.T) {
 	require.Equal(t, filename, stream.fileInfo.Filename)
 	require.Equal(t, login, stream.fileInfo.Login)
 	require.Equal(t, &fileId, stream.fileInfo.Id)
+	require.Equal(t, contentHash, stream.fileInfo.ContentHash)
+
+	// File key wrapped by removed server key is reported instead of sending garbage key.
+	unknown := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 1, Login: login, Id: &fileId, KeyId: "removed"}
+	mockMetadataStorage.On("GetFileById", stream.Context(), fileId.GetId()).Return(&unknown, nil).Once()
+	err = service.DownloadFile(&fileId, stream, login, encryption.ClientPublicKey())
+	require.ErrorIs(t, err, encryption.ErrUnknownServerKey)
+}
+
+func TestGophKeeperService_DeleteFile(t *testing.T) {
+	login := "kulebaka"
+	fileId := pb.FileId{Id: "12345"}
+	tests := []struct {
+		name      string
+		fileInfo  *pb.FileInfo
+		deleteErr error
+		wantErr   error
+	}{
+		{name: "good", fileInfo: &pb.FileInfo{Login: login, Id: &fileId}},
+		{name: "not_own", fileInfo: &pb.FileInfo{Login: "other", Id: &fileId}, wantErr: ErrNotOwn},
+		{name: "pending", fileInfo: &pb.FileInfo{Login: login, Id: &fileId, State: pb.FileState_PENDING}, wantErr: ErrNotCommitted},
+		{name: "blob_delete_error", fileInfo: &pb.FileInfo{Login: login, Id: &fileId}, deleteErr: io.ErrUnexpectedEOF, wantErr: io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetadataStorage := mocks.NewMetadataStorage(t)
+			mockStreamingFileStorage := mocks.NewStreamingFileStorage(t)
+			service, err := NewGophKeeperService(mockStreamingFileStorage, mockMetadataStorage)
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			mockMetadataStorage.On("GetFileById", ctx, fileId.GetId()).Return(tt.fileInfo, nil).Once()
+			if tt.wantErr == nil || tt.deleteErr != nil {
+				mockMetadataStorage.On("UpdateFileState", ctx, fileId.GetId(), pb.FileState_DELETING).Return(nil).Once()
+				mockStreamingFileStorage.On("Delete", ctx, fileId.GetId()).Return(tt.deleteErr).Once()
+			}
+			if tt.wantErr == nil {
+				mockMetadataStorage.On("DeleteFileInfo", ctx, fileId.GetId()).Return(nil).Once()
+			}
+
+			err = service.DeleteFile(ctx, &fileId, login)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGophKeeperService_MemoryStorages(t *testing.T) {
+	setMockEncryption()
+	ctx := context.Background()
+	login := "kulebaka"
+	metaDataStorage := metadatastorage.NewMemoryStorage()
+	service, err := NewGophKeeperService(filestorage.NewMemoryFileStorage(), metaDataStorage)
+	require.NoError(t, err)
+
+	encryptionKey, _ := encryption.EncryptFileEncryptionKey([]byte("encrypt"), encryption.ServerPublicKey())
+	fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 3}
+	recv := []*pb.FileStream{
+		{Data: &pb.FileStream_Info{Info: &fileInfo}},
+		{Data: &pb.FileStream_ChunkData{ChunkData: []byte("abc")}},
+	}
+	require.NoError(t, service.UploadFile(serverStreamMock{ctx: ctx, t: t, fileInfo: &fileInfo, recv: &recv}, login))
+
+	files, err := service.GetUserFiles(ctx, login)
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1)
+	fileId := files.GetFiles()[0].GetId()
+
+	fileBytes := make([]byte, 0)
+	stream := serverStreamMock{ctx: ctx, t: t, fileInfo: &pb.FileInfo{}, file: &fileBytes}
+	require.NoError(t, service.DownloadFile(fileId, stream, login, encryption.ClientPublicKey()))
+	require.Equal(t, []byte("abc"), fileBytes)
+
+	require.ErrorIs(t, service.DeleteFile(ctx, fileId, "other"), ErrNotOwn)
+	require.NoError(t, service.DeleteFile(ctx, fileId, login))
+	files, err = metaDataStorage.GetAllFiles(ctx)
+	require.NoError(t, err)
+	require.Empty(t, files.GetFiles())
+}
+
+func TestGophKeeperService_DeleteUserFiles(t *testing.T) {
+	setMockEncryption()
+	ctx := context.Background()
+	metaDataStorage := metadatastorage.NewMemoryStorage()
+	service, err := NewGophKeeperService(filestorage.NewMemoryFileStorage(), metaDataStorage)
+	require.NoError(t, err)
+
+	encryptionKey, _ := encryption.EncryptFileEncryptionKey([]byte("encrypt"), encryption.ServerPublicKey())
+	for _, login := range []string{"kulebaka", "kulebaka", "other"} {
+		fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 3}
+		recv := []*pb.FileStream{
+			{Data: &pb.FileStream_Info{Info: &fileInfo}},
+			{Data: &pb.FileStream_ChunkData{ChunkData: []byte("abc")}},
+		}
+		require.NoError(t, service.UploadFile(serverStreamMock{ctx: ctx, t: t, fileInfo: &fileInfo, recv: &recv}, login))
+	}
+
+	deleted, err := service.DeleteUserFiles(ctx, "kulebaka")
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	files, err := metaDataStorage.GetAllFiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1)
+	require.Equal(t, "other", files.GetFiles()[0].GetLogin())
+}
+
+// Upload stream calling hook before chunk is received.
+type hookedStreamMock struct {
+	serverStreamMock
+	beforeChunk func()
+}
+
+func (s hookedStreamMock) Recv() (*pb.FileStream, error) {
+	if len(*s.recv) == 1 {
+		s.beforeChunk()
+	}
+	return s.serverStreamMock.Recv()
+}
+
+func TestGophKeeperService_DeleteOrganization(t *testing.T) {
+	setMockEncryption()
+	ctx := context.Background()
+	login := "kulebaka"
+	metaDataStorage := metadatastorage.NewMemoryStorage()
+	service, err := NewGophKeeperService(filestorage.NewMemoryFileStorage(), metaDataStorage)
+	require.NoError(t, err)
+	org, err := service.Access.CreateOrganization(ctx, login, "team")
+	require.NoError(t, err)
+
+	encryptionKey, _ := encryption.EncryptFileEncryptionKey([]byte("encrypt"), encryption.ServerPublicKey())
+	upload := func(beforeChunk func()) error {
+		fileInfo := pb.FileInfo{Filename: "asdf", EncryptionKey: encryptionKey, Size: 3, Organization: org.ID}
+		recv := []*pb.FileStream{
+			{Data: &pb.FileStream_Info{Info: &fileInfo}},
+			{Data: &pb.FileStream_ChunkData{ChunkData: []byte("abc")}},
+		}
+		return service.UploadFile(hookedStreamMock{serverStreamMock: serverStreamMock{ctx: ctx, t: t, fileInfo: &fileInfo, recv: &recv},
+			beforeChunk: beforeChunk}, login)
+	}
+	require.NoError(t, upload(func() {}))
+	pending := &pb.FileInfo{Id: &pb.FileId{Id: "pending"}, Login: login, Organization: org.ID, State: pb.FileState_PENDING}
+	require.NoError(t, metaDataStorage.AddFileInfo(ctx, pending))
+
+	// Upload running while organization is deleted is discarded.
+	err = upload(func() {
+		deleted, err := service.DeleteOrganization(ctx, login, org.ID)
+		require.NoError(t, err, "pending files should not stop deletion")
+		require.Equal(t, 1, deleted)
+	})
+	require.ErrorIs(t, err, orgstorage.ErrOrganizationNotFound)
+	files, err := metaDataStorage.GetAllFiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, files.GetFiles(), 1, "only abandoned pending file should be left to consistency check")
+	require.Equal(t, "pending", files.GetFiles()[0].GetId().GetId())
+	require.Error(t, upload(func() {}), "upload to deleted organization should be rejected")
 }
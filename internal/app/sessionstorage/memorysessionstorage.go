@@ -0,0 +1,97 @@
+package sessionstorage
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stores sessions in memory, all sessions are lost on restart.
+type MemorySessionStorage struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// New in-memory session storage.
+func NewMemorySessionStorage() *MemorySessionStorage {
+	return &MemorySessionStorage{sessions: make(map[string]Session)}
+}
+
+// Add new session.
+func (s *MemorySessionStorage) AddSession(_ context.Context, session Session) error {
+	s.mu.Lock()
+	s.sessions[session.ID] = session
+	s.mu.Unlock()
+	return nil
+}
+
+// Get session by id.
+func (s *MemorySessionStorage) GetSession(_ context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// Get user sessions.
+func (s *MemorySessionStorage) ListSessions(_ context.Context, login string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]Session, 0)
+	for _, session := range s.sessions {
+		if session.Login == login {
+			sessions = append(sessions, session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b Session) int {
+		if c := a.Created.Compare(b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return sessions, nil
+}
+
+// Update session activity.
+func (s *MemorySessionStorage) TouchSession(_ context.Context, id string, ip string, lastSeen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	session.IP = ip
+	session.LastSeen = lastSeen
+	s.sessions[id] = session
+	return nil
+}
+
+// Delete user session.
+func (s *MemorySessionStorage) DeleteSession(_ context.Context, login string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session, ok := s.sessions[id]; !ok || session.Login != login {
+		return ErrSessionNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+// Delete inactive sessions.
+func (s *MemorySessionStorage) DeleteInactive(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := int64(0)
+	for id, session := range s.sessions {
+		if session.LastSeen.Before(before) {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
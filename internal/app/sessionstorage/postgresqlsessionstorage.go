@@ -0,0 +1,96 @@
+package sessionstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Stores sessions in postgresql.
+type PostgresqlSessionStorage struct {
+	DB *sql.DB
+}
+
+// New postgresql session storage.
+func NewPostgresqlSessionStorage(db *sql.DB) *PostgresqlSessionStorage {
+	return &PostgresqlSessionStorage{DB: db}
+}
+
+// Add new session.
+func (s *PostgresqlSessionStorage) AddSession(ctx context.Context, session Session) error {
+	_, err := s.DB.ExecContext(ctx,
+		"INSERT into sessions (id, login, device, ip, created, last_seen) VALUES($1, $2, $3, $4, $5, $6)",
+		session.ID, session.Login, session.Device, session.IP, session.Created.UTC(), session.LastSeen.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add session: %w", err)
+	}
+	return nil
+}
+
+// Get session by id.
+func (s *PostgresqlSessionStorage) GetSession(ctx context.Context, id string) (*Session, error) {
+	row := s.DB.QueryRowContext(ctx,
+		"SELECT id, login, device, ip, created, last_seen FROM sessions WHERE id = $1", id)
+	var session Session
+	err := row.Scan(&session.ID, &session.Login, &session.Device, &session.IP, &session.Created, &session.LastSeen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+// Get user sessions.
+func (s *PostgresqlSessionStorage) ListSessions(ctx context.Context, login string) ([]Session, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, device, ip, created, last_seen FROM sessions WHERE login = $1 ORDER BY created, id", login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		var session Session
+		if err := rows.Scan(&session.ID, &session.Login, &session.Device, &session.IP, &session.Created, &session.LastSeen); err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Update session activity.
+func (s *PostgresqlSessionStorage) TouchSession(ctx context.Context, id string, ip string, lastSeen time.Time) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE sessions SET ip = $2, last_seen = $3 WHERE id = $1", id, ip, lastSeen.UTC())
+	return checkAffected(res, err)
+}
+
+// Delete user session.
+func (s *PostgresqlSessionStorage) DeleteSession(ctx context.Context, login string, id string) error {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM sessions WHERE id = $1 AND login = $2", id, login)
+	return checkAffected(res, err)
+}
+
+// Delete inactive sessions.
+func (s *PostgresqlSessionStorage) DeleteInactive(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM sessions WHERE last_seen < $1", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete inactive sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Returns ErrSessionNotFound if statement changed no session.
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Package sessionstorage for storing user login sessions.
+package sessionstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Error in case session is absent, revoked or belongs to other user.
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session started by login, lasts while its refresh tokens are exchanged.
+//
+// Session id is shared by refresh token family and jti claim of access tokens.
+type Session struct {
+	ID       string
+	Login    string
+	Device   string
+	IP       string
+	Created  time.Time
+	LastSeen time.Time
+}
+
+// Storage of active sessions, revoked session is deleted.
+//
+//go:generate mockery --name SessionStorage
+type SessionStorage interface {
+	// Method for adding new session.
+	AddSession(ctx context.Context, session Session) error
+
+	// Method for getting session by id.
+	GetSession(ctx context.Context, id string) (*Session, error)
+
+	// Method for getting user sessions ordered by creation time.
+	ListSessions(ctx context.Context, login string) ([]Session, error)
+
+	// Method for updating address and last activity time of session.
+	TouchSession(ctx context.Context, id string, ip string, lastSeen time.Time) error
+
+	// Method for deleting user session.
+	DeleteSession(ctx context.Context, login string, id string) error
+
+	// Method for deleting sessions inactive since given time, returns number of deleted sessions.
+	DeleteInactive(ctx context.Context, before time.Time) (int64, error)
+}
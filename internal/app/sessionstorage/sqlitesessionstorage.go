@@ -0,0 +1,98 @@
+package sessionstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Stores sessions in embedded sqlite database, times are stored as unix seconds.
+type SqliteSessionStorage struct {
+	DB *sql.DB
+}
+
+// New sqlite session storage.
+func NewSqliteSessionStorage(db *sql.DB) *SqliteSessionStorage {
+	return &SqliteSessionStorage{DB: db}
+}
+
+type sqliteScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSqliteSession(row sqliteScanner) (*Session, error) {
+	var session Session
+	var created, lastSeen int64
+	if err := row.Scan(&session.ID, &session.Login, &session.Device, &session.IP, &created, &lastSeen); err != nil {
+		return nil, err
+	}
+	session.Created = time.Unix(created, 0).UTC()
+	session.LastSeen = time.Unix(lastSeen, 0).UTC()
+	return &session, nil
+}
+
+// Add new session.
+func (s *SqliteSessionStorage) AddSession(ctx context.Context, session Session) error {
+	_, err := s.DB.ExecContext(ctx,
+		"INSERT into sessions (id, login, device, ip, created, last_seen) VALUES($1, $2, $3, $4, $5, $6)",
+		session.ID, session.Login, session.Device, session.IP, session.Created.Unix(), session.LastSeen.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to add session: %w", err)
+	}
+	return nil
+}
+
+// Get session by id.
+func (s *SqliteSessionStorage) GetSession(ctx context.Context, id string) (*Session, error) {
+	session, err := scanSqliteSession(s.DB.QueryRowContext(ctx,
+		"SELECT id, login, device, ip, created, last_seen FROM sessions WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// Get user sessions.
+func (s *SqliteSessionStorage) ListSessions(ctx context.Context, login string) ([]Session, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		"SELECT id, login, device, ip, created, last_seen FROM sessions WHERE login = $1 ORDER BY created, id", login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+	sessions := make([]Session, 0)
+	for rows.Next() {
+		session, err := scanSqliteSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// Update session activity.
+func (s *SqliteSessionStorage) TouchSession(ctx context.Context, id string, ip string, lastSeen time.Time) error {
+	res, err := s.DB.ExecContext(ctx, "UPDATE sessions SET ip = $2, last_seen = $3 WHERE id = $1", id, ip, lastSeen.Unix())
+	return checkAffected(res, err)
+}
+
+// Delete user session.
+func (s *SqliteSessionStorage) DeleteSession(ctx context.Context, login string, id string) error {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM sessions WHERE id = $1 AND login = $2", id, login)
+	return checkAffected(res, err)
+}
+
+// Delete inactive sessions.
+func (s *SqliteSessionStorage) DeleteInactive(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM sessions WHERE last_seen < $1", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete inactive sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
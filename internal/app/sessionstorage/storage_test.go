@@ -0,0 +1,86 @@
+package sessionstorage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every session storage implementation must follow.
+func testSessionStorage(t *testing.T, storage SessionStorage) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	first := Session{ID: "first", Login: "login", Device: "laptop", IP: "127.0.0.1", Created: now, LastSeen: now}
+	second := Session{ID: "second", Login: "login", Device: "phone", IP: "127.0.0.2",
+		Created: now.Add(time.Second), LastSeen: now.Add(-time.Hour)}
+	other := Session{ID: "other", Login: "other", Device: "laptop", IP: "127.0.0.3", Created: now, LastSeen: now}
+	for _, session := range []Session{second, first, other} {
+		require.NoError(t, storage.AddSession(ctx, session))
+	}
+
+	got, err := storage.GetSession(ctx, "first")
+	require.NoError(t, err)
+	require.Equal(t, first, *got)
+	_, err = storage.GetSession(ctx, "absent")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	sessions, err := storage.ListSessions(ctx, "login")
+	require.NoError(t, err)
+	require.Equal(t, []Session{first, second}, sessions)
+
+	require.NoError(t, storage.TouchSession(ctx, "first", "10.0.0.1", now.Add(time.Minute)))
+	got, err = storage.GetSession(ctx, "first")
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", got.IP)
+	require.True(t, now.Add(time.Minute).Equal(got.LastSeen))
+	require.ErrorIs(t, storage.TouchSession(ctx, "absent", "", now), ErrSessionNotFound)
+
+	require.ErrorIs(t, storage.DeleteSession(ctx, "login", "other"), ErrSessionNotFound)
+	require.NoError(t, storage.DeleteSession(ctx, "other", "other"))
+	_, err = storage.GetSession(ctx, "other")
+	require.ErrorIs(t, err, ErrSessionNotFound)
+
+	deleted, err := storage.DeleteInactive(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	sessions, err = storage.ListSessions(ctx, "login")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, "first", sessions[0].ID)
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteSessionStorage(t *testing.T) {
+	testSessionStorage(t, NewSqliteSessionStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlSessionStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	testSessionStorage(t, NewPostgresqlSessionStorage(openTestDB(t, "pgx", dsn, migrations.Postgres)))
+}
+
+func TestMemorySessionStorage(t *testing.T) {
+	testSessionStorage(t, NewMemorySessionStorage())
+}
@@ -0,0 +1,129 @@
+// Package shamir splits secrets into shares by Shamir secret sharing over GF(256).
+//
+// Any threshold of shares reconstructs the secret, fewer shares reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var (
+	// Error in case number of shares or threshold is out of range.
+	ErrWrongParameters = errors.New("threshold should be between 2 and number of shares, shares should be at most 255")
+
+	// Error in case there are fewer shares than threshold.
+	ErrNotEnoughShares = errors.New("not enough shares")
+
+	// Error in case the same share is given twice.
+	ErrDuplicateShare = errors.New("duplicate share")
+
+	// Error in case shares belong to different secrets.
+	ErrMismatchedShares = errors.New("shares belong to different secrets")
+)
+
+// Share of secret, index is the point polynomials are evaluated at.
+type Share struct {
+	Index byte
+	Data  []byte
+}
+
+// Exponents and logarithms of generator 3 in GF(256) with polynomial x^8 + x^4 + x^3 + x + 1.
+var (
+	exp [510]byte
+	log [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = x, x
+		log[x] = byte(i)
+		// Multiply by generator 3, i.e. x*2 + x.
+		double := x << 1
+		if x&0x80 != 0 {
+			double ^= 0x1b
+		}
+		x ^= double
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return exp[int(log[a])+int(log[b])]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return exp[int(log[a])+255-int(log[b])]
+}
+
+// Evaluates polynomial with given coefficients, the first one is constant term.
+func evaluate(coefficients []byte, x byte) byte {
+	y := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// Splits secret into shares, any threshold of them reconstructs it.
+func Split(secret []byte, shares int, threshold int) ([]Share, error) {
+	if threshold < 2 || threshold > shares || shares > 255 {
+		return nil, ErrWrongParameters
+	}
+	res := make([]Share, shares)
+	for i := range res {
+		res[i] = Share{Index: byte(i + 1), Data: make([]byte, len(secret))}
+	}
+	coefficients := make([]byte, threshold)
+	for pos, b := range secret {
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("cannot generate polynomial: %w", err)
+		}
+		coefficients[0] = b
+		for i := range res {
+			res[i].Data[pos] = evaluate(coefficients, res[i].Index)
+		}
+	}
+	return res, nil
+}
+
+// Reconstructs secret from at least threshold shares of it.
+//
+// Shares of other secret or too few shares give wrong secret, so result should be verified by caller.
+func Combine(shares []Share, threshold int) ([]byte, error) {
+	if len(shares) < threshold || len(shares) < 2 {
+		return nil, fmt.Errorf("%w: %d of %d", ErrNotEnoughShares, len(shares), threshold)
+	}
+	shares = shares[:threshold]
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share.Data) != len(shares[0].Data) {
+			return nil, ErrMismatchedShares
+		}
+		if share.Index == 0 || seen[share.Index] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateShare, share.Index)
+		}
+		seen[share.Index] = true
+	}
+	secret := make([]byte, len(shares[0].Data))
+	for i, share := range shares {
+		// Lagrange basis polynomial of share at zero, subtraction is xor in GF(256).
+		basis := byte(1)
+		for j, other := range shares {
+			if i != j {
+				basis = mul(basis, div(other.Index, other.Index^share.Index))
+			}
+		}
+		for pos := range secret {
+			secret[pos] ^= mul(share.Data[pos], basis)
+		}
+	}
+	return secret, nil
+}
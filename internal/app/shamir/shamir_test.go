@@ -0,0 +1,70 @@
+package shamir
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("client private key")
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	// Any threshold of shares in any order reconstructs secret.
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		picked := make([]Share, 0, len(subset))
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		combined, err := Combine(picked, 3)
+		require.NoError(t, err)
+		require.Equal(t, secret, combined)
+	}
+	combined, err := Combine(shares[:2], 2)
+	require.NoError(t, err)
+	require.NotEqual(t, secret, combined, "fewer shares than threshold should not reveal secret")
+
+	_, err = Combine(shares[:2], 3)
+	require.ErrorIs(t, err, ErrNotEnoughShares)
+	_, err = Combine([]Share{shares[0], shares[0], shares[1]}, 3)
+	require.ErrorIs(t, err, ErrDuplicateShare)
+
+	for _, params := range [][2]int{{5, 1}, {3, 4}, {256, 3}} {
+		_, err = Split(secret, params[0], params[1])
+		require.ErrorIs(t, err, ErrWrongParameters)
+	}
+}
+
+func TestSplitCombinePrintable(t *testing.T) {
+	secret := []byte("client private key")
+	shares, err := SplitPrintable(secret, 5, 3)
+	require.NoError(t, err)
+	for _, share := range shares {
+		require.True(t, strings.HasPrefix(share, sharePrefix))
+		require.Regexp(t, "^[A-Z0-9-]+$", share, "share should be qr alphanumeric")
+	}
+
+	combined, err := CombinePrintable([]string{shares[4], " " + strings.ToLower(shares[1]) + "\n", shares[2]})
+	require.NoError(t, err)
+	require.Equal(t, secret, combined)
+
+	_, err = CombinePrintable(shares[:2])
+	require.ErrorIs(t, err, ErrNotEnoughShares)
+
+	// Mistyped character is detected by checksum of share.
+	damaged := []byte(shares[1])
+	damaged[len(damaged)/2] = map[bool]byte{true: 'B', false: 'A'}[damaged[len(damaged)/2] == 'A']
+	_, err = CombinePrintable([]string{shares[0], string(damaged), shares[2]})
+	require.ErrorIs(t, err, ErrCorruptedShare)
+	require.ErrorContains(t, err, "share 2")
+	_, err = CombinePrintable([]string{"not a share", shares[1], shares[2]})
+	require.ErrorIs(t, err, ErrCorruptedShare)
+
+	other, err := SplitPrintable([]byte("other private key!"), 5, 3)
+	require.NoError(t, err)
+	_, err = CombinePrintable([]string{shares[0], other[1], shares[2]})
+	require.ErrorIs(t, err, ErrMismatchedShares)
+}
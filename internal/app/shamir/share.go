@@ -0,0 +1,117 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix of printable shares, the number is version of share format.
+const sharePrefix = "GKS1-"
+
+// Lengths of printable share parts.
+const (
+	headerSize   = 2
+	digestSize   = 8
+	checksumSize = 4
+)
+
+var (
+	// Error in case printable share is damaged or mistyped.
+	ErrCorruptedShare = errors.New("share is corrupted")
+
+	// Error in case reconstructed secret doesn't match shares.
+	ErrWrongSecret = errors.New("reconstructed secret doesn't match shares")
+)
+
+// Upper case base32 without padding, so shares consist only of characters of qr alphanumeric mode.
+var shareEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Returns digest of secret stored in shares to detect shares of different secrets.
+func secretDigest(secret []byte) []byte {
+	sum := sha256.Sum256(secret)
+	return sum[:digestSize]
+}
+
+// Splits secret into printable shares, any threshold of them reconstructs it.
+//
+// Share holds threshold, its index, digest of secret and checksum of itself, so damaged shares,
+// shares of other secrets and wrong reconstruction are detected.
+func SplitPrintable(secret []byte, shares int, threshold int) ([]string, error) {
+	parts, err := Split(secret, shares, threshold)
+	if err != nil {
+		return nil, err
+	}
+	digest := secretDigest(secret)
+	res := make([]string, 0, len(parts))
+	for _, part := range parts {
+		payload := append([]byte{byte(threshold), part.Index}, digest...)
+		payload = append(payload, part.Data...)
+		checksum := sha256.Sum256(payload)
+		res = append(res, sharePrefix+shareEncoding.EncodeToString(append(payload, checksum[:checksumSize]...)))
+	}
+	return res, nil
+}
+
+// Printable share with its threshold and digest of secret.
+type printableShare struct {
+	Share
+	threshold int
+	digest    []byte
+}
+
+// Parses printable share, spaces, line breaks and case are ignored.
+func parseShare(text string) (*printableShare, error) {
+	text = strings.ToUpper(strings.Join(strings.Fields(text), ""))
+	encoded, ok := strings.CutPrefix(text, sharePrefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: should start with %s", ErrCorruptedShare, sharePrefix)
+	}
+	data, err := shareEncoding.DecodeString(encoded)
+	if err != nil || len(data) < headerSize+digestSize+checksumSize+1 {
+		return nil, ErrCorruptedShare
+	}
+	payload, checksum := data[:len(data)-checksumSize], data[len(data)-checksumSize:]
+	expected := sha256.Sum256(payload)
+	if !bytes.Equal(checksum, expected[:checksumSize]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptedShare)
+	}
+	return &printableShare{
+		Share:     Share{Index: payload[1], Data: payload[headerSize+digestSize:]},
+		threshold: int(payload[0]),
+		digest:    payload[headerSize : headerSize+digestSize],
+	}, nil
+}
+
+// Reconstructs secret from printable shares and verifies it against digest stored in them.
+func CombinePrintable(texts []string) ([]byte, error) {
+	shares := make([]Share, 0, len(texts))
+	var first *printableShare
+	for i, text := range texts {
+		share, err := parseShare(text)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		if first == nil {
+			first = share
+		}
+		if share.threshold != first.threshold || !bytes.Equal(share.digest, first.digest) {
+			return nil, fmt.Errorf("share %d: %w", i+1, ErrMismatchedShares)
+		}
+		shares = append(shares, share.Share)
+	}
+	if first == nil {
+		return nil, ErrNotEnoughShares
+	}
+	secret, err := Combine(shares, first.threshold)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(secretDigest(secret), first.digest) {
+		return nil, ErrWrongSecret
+	}
+	return secret, nil
+}
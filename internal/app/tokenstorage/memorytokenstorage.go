@@ -0,0 +1,78 @@
+package tokenstorage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryToken struct {
+	token RefreshToken
+	used  bool
+}
+
+// Stores refresh tokens in memory, all tokens are lost on restart.
+type MemoryTokenStorage struct {
+	mu     sync.Mutex
+	tokens map[string]*memoryToken
+}
+
+// New in-memory token storage.
+func NewMemoryTokenStorage() *MemoryTokenStorage {
+	return &MemoryTokenStorage{tokens: make(map[string]*memoryToken)}
+}
+
+// Add new token.
+func (s *MemoryTokenStorage) AddToken(_ context.Context, token RefreshToken) error {
+	token.Hash = append([]byte(nil), token.Hash...)
+	token.PublicKey = append([]byte(nil), token.PublicKey...)
+	s.mu.Lock()
+	s.tokens[string(token.Hash)] = &memoryToken{token: token}
+	s.mu.Unlock()
+	return nil
+}
+
+// Mark token used.
+func (s *MemoryTokenStorage) UseToken(_ context.Context, hash []byte, now time.Time) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.tokens[string(hash)]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	token := stored.token
+	if stored.used {
+		return &token, ErrTokenReused
+	}
+	if !now.Before(token.Expires) {
+		return nil, ErrTokenNotFound
+	}
+	stored.used = true
+	return &token, nil
+}
+
+// Delete tokens of family.
+func (s *MemoryTokenStorage) DeleteFamily(_ context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, stored := range s.tokens {
+		if stored.token.Family == family {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// Delete expired tokens.
+func (s *MemoryTokenStorage) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := int64(0)
+	for hash, stored := range s.tokens {
+		if stored.token.Expires.Before(before) {
+			delete(s.tokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,83 @@
+package tokenstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Stores refresh tokens in postgresql.
+type PostgresqlTokenStorage struct {
+	DB *sql.DB
+}
+
+// New postgresql token storage.
+func NewPostgresqlTokenStorage(db *sql.DB) *PostgresqlTokenStorage {
+	return &PostgresqlTokenStorage{DB: db}
+}
+
+// Add new token.
+func (s *PostgresqlTokenStorage) AddToken(ctx context.Context, token RefreshToken) error {
+	_, err := s.DB.ExecContext(ctx,
+		"INSERT into refresh_tokens (hash, family, login, public_key, created, expires) VALUES($1, $2, $3, $4, $5, $6)",
+		token.Hash, token.Family, token.Login, token.PublicKey, token.Created.UTC(), token.Expires.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to add refresh token: %w", err)
+	}
+	return nil
+}
+
+// Mark token used.
+func (s *PostgresqlTokenStorage) UseToken(ctx context.Context, hash []byte, now time.Time) (*RefreshToken, error) {
+	row := s.DB.QueryRowContext(ctx,
+		"SELECT hash, family, login, public_key, created, expires, used FROM refresh_tokens WHERE hash = $1", hash)
+	var token RefreshToken
+	var used bool
+	err := row.Scan(&token.Hash, &token.Family, &token.Login, &token.PublicKey, &token.Created, &token.Expires, &used)
+	return useToken(ctx, s.DB, &token, used, now, err)
+}
+
+// Delete tokens of family.
+func (s *PostgresqlTokenStorage) DeleteFamily(ctx context.Context, family string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE family = $1", family)
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// Delete expired tokens.
+func (s *PostgresqlTokenStorage) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires < $1", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// Marks selected token used unless it is already used or expired.
+// Update is conditional so that concurrent exchange of the same token succeeds only once.
+func useToken(ctx context.Context, db *sql.DB, token *RefreshToken, used bool, now time.Time, selectErr error) (*RefreshToken, error) {
+	if errors.Is(selectErr, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	if selectErr != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", selectErr)
+	}
+	if used {
+		return token, ErrTokenReused
+	}
+	if !now.Before(token.Expires) {
+		return nil, ErrTokenNotFound
+	}
+	res, err := db.ExecContext(ctx, "UPDATE refresh_tokens SET used = TRUE WHERE hash = $1 AND NOT used", token.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected != 1 {
+		return token, ErrTokenReused
+	}
+	return token, nil
+}
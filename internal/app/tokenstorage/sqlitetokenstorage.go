@@ -0,0 +1,60 @@
+package tokenstorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Stores refresh tokens in embedded sqlite database, times are stored as unix seconds.
+type SqliteTokenStorage struct {
+	DB *sql.DB
+}
+
+// New sqlite token storage.
+func NewSqliteTokenStorage(db *sql.DB) *SqliteTokenStorage {
+	return &SqliteTokenStorage{DB: db}
+}
+
+// Add new token.
+func (s *SqliteTokenStorage) AddToken(ctx context.Context, token RefreshToken) error {
+	_, err := s.DB.ExecContext(ctx,
+		"INSERT into refresh_tokens (hash, family, login, public_key, created, expires) VALUES($1, $2, $3, $4, $5, $6)",
+		token.Hash, token.Family, token.Login, token.PublicKey, token.Created.Unix(), token.Expires.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to add refresh token: %w", err)
+	}
+	return nil
+}
+
+// Mark token used.
+func (s *SqliteTokenStorage) UseToken(ctx context.Context, hash []byte, now time.Time) (*RefreshToken, error) {
+	row := s.DB.QueryRowContext(ctx,
+		"SELECT hash, family, login, public_key, created, expires, used FROM refresh_tokens WHERE hash = $1", hash)
+	var token RefreshToken
+	var created, expires int64
+	var used bool
+	err := row.Scan(&token.Hash, &token.Family, &token.Login, &token.PublicKey, &created, &expires, &used)
+	token.Created = time.Unix(created, 0).UTC()
+	token.Expires = time.Unix(expires, 0).UTC()
+	return useToken(ctx, s.DB, &token, used, now, err)
+}
+
+// Delete tokens of family.
+func (s *SqliteTokenStorage) DeleteFamily(ctx context.Context, family string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE family = $1", family)
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// Delete expired tokens.
+func (s *SqliteTokenStorage) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.DB.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires < $1", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+	return res.RowsAffected()
+}
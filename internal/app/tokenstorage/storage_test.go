@@ -0,0 +1,84 @@
+package tokenstorage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every token storage implementation must follow.
+func testTokenStorage(t *testing.T, storage TokenStorage) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	family := uuid.NewString()
+	token := RefreshToken{Hash: []byte(uuid.NewString()), Family: family, Login: "login", PublicKey: []byte("key"),
+		Created: now, Expires: now.Add(time.Hour)}
+	expired := RefreshToken{Hash: []byte(uuid.NewString()), Family: family, Login: "login", PublicKey: []byte("key"),
+		Created: now.Add(-2 * time.Hour), Expires: now.Add(-time.Hour)}
+	other := RefreshToken{Hash: []byte(uuid.NewString()), Family: uuid.NewString(), Login: "login",
+		Created: now, Expires: now.Add(time.Hour)}
+	for _, token := range []RefreshToken{token, expired, other} {
+		require.NoError(t, storage.AddToken(ctx, token))
+	}
+
+	_, err := storage.UseToken(ctx, []byte("absent"), now)
+	require.ErrorIs(t, err, ErrTokenNotFound)
+	_, err = storage.UseToken(ctx, expired.Hash, now)
+	require.ErrorIs(t, err, ErrTokenNotFound)
+
+	got, err := storage.UseToken(ctx, token.Hash, now)
+	require.NoError(t, err)
+	require.Equal(t, token.Family, got.Family)
+	require.Equal(t, token.Login, got.Login)
+	require.Equal(t, token.PublicKey, got.PublicKey)
+	require.True(t, token.Expires.Equal(got.Expires))
+	got, err = storage.UseToken(ctx, token.Hash, now)
+	require.ErrorIs(t, err, ErrTokenReused)
+	require.Equal(t, family, got.Family)
+
+	deleted, err := storage.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+	require.NoError(t, storage.DeleteFamily(ctx, family))
+	_, err = storage.UseToken(ctx, token.Hash, now)
+	require.ErrorIs(t, err, ErrTokenNotFound)
+	_, err = storage.UseToken(ctx, other.Hash, now)
+	require.NoError(t, err)
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteTokenStorage(t *testing.T) {
+	testTokenStorage(t, NewSqliteTokenStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlTokenStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	testTokenStorage(t, NewPostgresqlTokenStorage(openTestDB(t, "pgx", dsn, migrations.Postgres)))
+}
+
+func TestMemoryTokenStorage(t *testing.T) {
+	testTokenStorage(t, NewMemoryTokenStorage())
+}
@@ -0,0 +1,45 @@
+// Package tokenstorage for storing refresh tokens.
+package tokenstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// Error in case token is absent or expired.
+	ErrTokenNotFound = errors.New("refresh token not found")
+
+	// Error in case token has already been exchanged, token is returned along with it.
+	ErrTokenReused = errors.New("refresh token reused")
+)
+
+// Refresh token stored by its hash.
+//
+// Tokens issued by rotation of the same login token share family.
+type RefreshToken struct {
+	Hash      []byte
+	Family    string
+	Login     string
+	PublicKey []byte
+	Created   time.Time
+	Expires   time.Time
+}
+
+// Storage of refresh tokens, each token can be exchanged once.
+//
+//go:generate mockery --name TokenStorage
+type TokenStorage interface {
+	// Method for adding new unused token.
+	AddToken(ctx context.Context, token RefreshToken) error
+
+	// Method for marking token used, returns ErrTokenReused with token if it was already used.
+	UseToken(ctx context.Context, hash []byte, now time.Time) (*RefreshToken, error)
+
+	// Method for deleting all tokens of family.
+	DeleteFamily(ctx context.Context, family string) error
+
+	// Method for deleting tokens expired before given time, returns number of deleted tokens.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,159 @@
+package userstorage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Error in case user with given login is absent.
+var ErrUserNotFound = errors.New("user not found")
+
+// Stores users in memory, all users are lost on restart.
+type MemoryUserStorage struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+// New in-memory user storage.
+func NewMemoryUserStorage() *MemoryUserStorage {
+	return &MemoryUserStorage{users: make(map[string]User)}
+}
+
+// Add new user.
+func (s *MemoryUserStorage) AddUser(_ context.Context, user User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for login := range s.users {
+		if strings.EqualFold(login, user.Login) {
+			return ErrConflictUserLogin
+		}
+	}
+	s.users[user.Login] = copyUser(user)
+	return nil
+}
+
+// Get user.
+func (s *MemoryUserStorage) GetUser(_ context.Context, login string) (*User, error) {
+	s.mu.RLock()
+	user, ok := s.users[login]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("failed to get user: %w", ErrUserNotFound)
+	}
+	user = copyUser(user)
+	return &user, nil
+}
+
+// List all users.
+func (s *MemoryUserStorage) ListUsers(_ context.Context) ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	users := make([]User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, copyUser(user))
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Login < users[j].Login })
+	return users, nil
+}
+
+// Copies user so that stored user is not changed by caller.
+func copyUser(user User) User {
+	user.PasswordHash = append([]byte(nil), user.PasswordHash...)
+	user.SecondFactor = copySecondFactor(user.SecondFactor)
+	return user
+}
+
+func copySecondFactor(factor SecondFactor) SecondFactor {
+	factor.Secret = append([]byte(nil), factor.Secret...)
+	var codes [][]byte
+	for _, code := range factor.RecoveryCodes {
+		codes = append(codes, append([]byte(nil), code...))
+	}
+	factor.RecoveryCodes = codes
+	return factor
+}
+
+// Replace password hash.
+func (s *MemoryUserStorage) ChangePassword(_ context.Context, login string, passwordHash []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[login]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.PasswordHash = append([]byte(nil), passwordHash...)
+	s.users[login] = user
+	return nil
+}
+
+// Delete user.
+func (s *MemoryUserStorage) DeleteUser(_ context.Context, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[login]; !ok {
+		return ErrUserNotFound
+	}
+	delete(s.users, login)
+	return nil
+}
+
+// Replace second factor.
+func (s *MemoryUserStorage) SetSecondFactor(_ context.Context, login string, factor SecondFactor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[login]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.SecondFactor = copySecondFactor(factor)
+	s.users[login] = user
+	return nil
+}
+
+// Accept totp time step.
+func (s *MemoryUserStorage) UseTotpStep(_ context.Context, login string, step int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[login]
+	if !ok || user.SecondFactor.LastStep >= step {
+		return ErrCodeUsed
+	}
+	user.SecondFactor.LastStep = step
+	s.users[login] = user
+	return nil
+}
+
+// Remove recovery code.
+func (s *MemoryUserStorage) UseRecoveryCode(_ context.Context, login string, hash []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[login]
+	if !ok {
+		return ErrCodeUsed
+	}
+	codes, ok := removeCode(user.SecondFactor.RecoveryCodes, hash)
+	if !ok {
+		return ErrCodeUsed
+	}
+	user.SecondFactor.RecoveryCodes = codes
+	s.users[login] = user
+	return nil
+}
+
+// Replace encrypted totp secret.
+func (s *MemoryUserStorage) ReplaceTotpSecret(_ context.Context, login string, oldSecret []byte, secret []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[login]
+	if !ok || !bytes.Equal(user.SecondFactor.Secret, oldSecret) {
+		return ErrSecretChanged
+	}
+	user.SecondFactor.Secret = append([]byte(nil), secret...)
+	s.users[login] = user
+	return nil
+}
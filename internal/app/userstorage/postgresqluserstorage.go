@@ -6,8 +6,8 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/jackc/pgconn"
-	"github.com/jackc/pgerrcode"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dberrors"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
 )
 
 // Error in case user already has been saved.
@@ -15,32 +15,18 @@ var ErrConflictUserLogin = errors.New("conflicting login")
 
 // Generates new user id by autoincrement in postgresql.
 type PostgresqlUserStorage struct {
-	DB *sql.DB
+	DB dbtx.DB
 }
 
 // New postgresql user storage.
-func NewPostgresqlUserStorage(db *sql.DB) *PostgresqlUserStorage {
-	ret := &PostgresqlUserStorage{DB: db}
-	ret.init()
-	return ret
-}
-
-// Create all tables if needed.
-func (s *PostgresqlUserStorage) init() error {
-	tx, err := s.DB.BeginTx(context.Background(), nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-	tx.Exec(`CREATE TABLE userinfo("login" TEXT PRIMARY KEY, "password_hash" BYTEA)`)
-	return tx.Commit()
+func NewPostgresqlUserStorage(db dbtx.DB) *PostgresqlUserStorage {
+	return &PostgresqlUserStorage{DB: db}
 }
 
 // Add new user.
 func (s *PostgresqlUserStorage) AddUser(ctx context.Context, user User) error {
-	_, err := s.DB.ExecContext(ctx,
-		"INSERT into userinfo (login, password_hash) VALUES($1, $2)", user.Login, user.PasswordHash)
-	if e, ok := err.(*pgconn.PgError); ok && e.Code == pgerrcode.UniqueViolation {
+	err := addUser(ctx, s.DB, user)
+	if dberrors.IsPostgresqlUniqueViolation(err) {
 		err = ErrConflictUserLogin
 	}
 	return err
@@ -48,12 +34,176 @@ func (s *PostgresqlUserStorage) AddUser(ctx context.Context, user User) error {
 
 // Get user.
 func (s *PostgresqlUserStorage) GetUser(ctx context.Context, login string) (*User, error) {
-	row := s.DB.QueryRowContext(ctx,
-		"SELECT login, password_hash FROM userinfo WHERE login = $1", login)
+	return getUser(ctx, s.DB, login)
+}
+
+// List all users.
+func (s *PostgresqlUserStorage) ListUsers(ctx context.Context) ([]User, error) {
+	return listUsers(ctx, s.DB)
+}
+
+// Replace password hash.
+func (s *PostgresqlUserStorage) ChangePassword(ctx context.Context, login string, passwordHash []byte) error {
+	return changePassword(ctx, s.DB, login, passwordHash)
+}
+
+// Delete user.
+func (s *PostgresqlUserStorage) DeleteUser(ctx context.Context, login string) error {
+	return deleteUser(ctx, s.DB, login)
+}
+
+// Replace second factor.
+func (s *PostgresqlUserStorage) SetSecondFactor(ctx context.Context, login string, factor SecondFactor) error {
+	return setSecondFactor(ctx, s.DB, login, factor)
+}
+
+// Accept totp time step.
+func (s *PostgresqlUserStorage) UseTotpStep(ctx context.Context, login string, step int64) error {
+	return useTotpStep(ctx, s.DB, login, step)
+}
+
+// Remove recovery code.
+func (s *PostgresqlUserStorage) UseRecoveryCode(ctx context.Context, login string, hash []byte) error {
+	return useRecoveryCode(ctx, s.DB, login, hash)
+}
+
+// Replace encrypted totp secret.
+func (s *PostgresqlUserStorage) ReplaceTotpSecret(ctx context.Context, login string, oldSecret []byte, secret []byte) error {
+	return replaceTotpSecret(ctx, s.DB, login, oldSecret, secret)
+}
+
+// Queries below are shared by postgresql and sqlite storages.
+
+const userColumns = "login, password_hash, totp_secret, totp_enabled, totp_last_step, recovery_codes"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
 	var user User
-	err := row.Scan(&user.Login, &user.PasswordHash)
+	var codes []byte
+	err := row.Scan(&user.Login, &user.PasswordHash, &user.SecondFactor.Secret, &user.SecondFactor.Enabled,
+		&user.SecondFactor.LastStep, &codes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, err
 	}
+	user.SecondFactor.RecoveryCodes = splitCodes(codes)
 	return &user, nil
 }
+
+func addUser(ctx context.Context, db dbtx.DB, user User) error {
+	factor := user.SecondFactor
+	_, err := db.ExecContext(ctx, "INSERT into userinfo ("+userColumns+") VALUES($1, $2, $3, $4, $5, $6)",
+		user.Login, user.PasswordHash, factor.Secret, factor.Enabled, factor.LastStep, joinCodes(factor.RecoveryCodes))
+	return err
+}
+
+func getUser(ctx context.Context, db dbtx.DB, login string) (*User, error) {
+	user, err := scanUser(db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM userinfo WHERE login = $1", login))
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func listUsers(ctx context.Context, db dbtx.DB) ([]User, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+userColumns+" FROM userinfo ORDER BY login")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+	users := make([]User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %w", err)
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+func changePassword(ctx context.Context, db dbtx.DB, login string, passwordHash []byte) error {
+	res, err := db.ExecContext(ctx, "UPDATE userinfo SET password_hash = $2 WHERE login = $1", login, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func deleteUser(ctx context.Context, db dbtx.DB, login string) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM userinfo WHERE login = $1", login)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func setSecondFactor(ctx context.Context, db dbtx.DB, login string, factor SecondFactor) error {
+	res, err := db.ExecContext(ctx,
+		"UPDATE userinfo SET totp_secret = $2, totp_enabled = $3, totp_last_step = $4, recovery_codes = $5 WHERE login = $1",
+		login, factor.Secret, factor.Enabled, factor.LastStep, joinCodes(factor.RecoveryCodes))
+	if err != nil {
+		return fmt.Errorf("failed to set second factor: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func useTotpStep(ctx context.Context, db dbtx.DB, login string, step int64) error {
+	res, err := db.ExecContext(ctx,
+		"UPDATE userinfo SET totp_last_step = $2 WHERE login = $1 AND totp_last_step < $2", login, step)
+	if err != nil {
+		return fmt.Errorf("failed to use totp step: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// Update is conditional on unchanged codes so that concurrent use of the same code succeeds only once.
+func useRecoveryCode(ctx context.Context, db dbtx.DB, login string, hash []byte) error {
+	var joined []byte
+	err := db.QueryRowContext(ctx, "SELECT recovery_codes FROM userinfo WHERE login = $1", login).Scan(&joined)
+	if err != nil {
+		return fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+	codes, ok := removeCode(splitCodes(joined), hash)
+	if !ok {
+		return ErrCodeUsed
+	}
+	res, err := db.ExecContext(ctx,
+		"UPDATE userinfo SET recovery_codes = $2 WHERE login = $1 AND recovery_codes = $3", login, joinCodes(codes), joined)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+func replaceTotpSecret(ctx context.Context, db dbtx.DB, login string, oldSecret []byte, secret []byte) error {
+	res, err := db.ExecContext(ctx,
+		"UPDATE userinfo SET totp_secret = $2 WHERE login = $1 AND totp_secret = $3", login, secret, oldSecret)
+	if err != nil {
+		return fmt.Errorf("failed to replace totp secret: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return ErrSecretChanged
+	}
+	return nil
+}
@@ -11,20 +11,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestPostgresqlUserStorage_init(t *testing.T) {
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer db.Close()
-	mock.ExpectBegin()
-	mock.ExpectExec("CREATE TABLE userinfo").WillReturnResult(sqlmock.NewResult(1, 1))
-	mock.ExpectCommit()
-
-	storage := NewPostgresqlUserStorage(db)
-	storage.init()
-}
-
 func TestPostgresqlUserStorage_AddUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -88,8 +74,8 @@ func TestPostgresqlUserStorage_GetUser(t *testing.T) {
 				mock.ExpectQuery("SELECT").WillReturnError(&pgconn.PgError{})
 			} else if tt.isFound {
 				mock.ExpectQuery("SELECT").WillReturnRows(
-					sqlmock.NewRows([]string{"login", "password_hash"}).AddRow(
-						userInfo.Login, userInfo.PasswordHash))
+					sqlmock.NewRows([]string{"login", "password_hash", "totp_secret", "totp_enabled", "totp_last_step", "recovery_codes"}).AddRow(
+						userInfo.Login, userInfo.PasswordHash, nil, false, 0, nil))
 			} else {
 				mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{}))
 			}
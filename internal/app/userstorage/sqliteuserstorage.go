@@ -0,0 +1,67 @@
+package userstorage
+
+import (
+	"context"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/dberrors"
+	"github.com/valinurovdenis/gophkeeper/internal/app/dbtx"
+)
+
+// Stores users in embedded sqlite database.
+type SqliteUserStorage struct {
+	DB dbtx.DB
+}
+
+// New sqlite user storage.
+func NewSqliteUserStorage(db dbtx.DB) *SqliteUserStorage {
+	return &SqliteUserStorage{DB: db}
+}
+
+// Add new user.
+func (s *SqliteUserStorage) AddUser(ctx context.Context, user User) error {
+	err := addUser(ctx, s.DB, user)
+	if dberrors.IsSqliteUniqueViolation(err) {
+		err = ErrConflictUserLogin
+	}
+	return err
+}
+
+// Get user.
+func (s *SqliteUserStorage) GetUser(ctx context.Context, login string) (*User, error) {
+	return getUser(ctx, s.DB, login)
+}
+
+// List all users.
+func (s *SqliteUserStorage) ListUsers(ctx context.Context) ([]User, error) {
+	return listUsers(ctx, s.DB)
+}
+
+// Replace password hash.
+func (s *SqliteUserStorage) ChangePassword(ctx context.Context, login string, passwordHash []byte) error {
+	return changePassword(ctx, s.DB, login, passwordHash)
+}
+
+// Delete user.
+func (s *SqliteUserStorage) DeleteUser(ctx context.Context, login string) error {
+	return deleteUser(ctx, s.DB, login)
+}
+
+// Replace second factor.
+func (s *SqliteUserStorage) SetSecondFactor(ctx context.Context, login string, factor SecondFactor) error {
+	return setSecondFactor(ctx, s.DB, login, factor)
+}
+
+// Accept totp time step.
+func (s *SqliteUserStorage) UseTotpStep(ctx context.Context, login string, step int64) error {
+	return useTotpStep(ctx, s.DB, login, step)
+}
+
+// Remove recovery code.
+func (s *SqliteUserStorage) UseRecoveryCode(ctx context.Context, login string, hash []byte) error {
+	return useRecoveryCode(ctx, s.DB, login, hash)
+}
+
+// Replace encrypted totp secret.
+func (s *SqliteUserStorage) ReplaceTotpSecret(ctx context.Context, login string, oldSecret []byte, secret []byte) error {
+	return replaceTotpSecret(ctx, s.DB, login, oldSecret, secret)
+}
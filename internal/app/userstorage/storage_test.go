@@ -0,0 +1,113 @@
+package userstorage
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/valinurovdenis/gophkeeper/internal/app/migrations"
+	_ "modernc.org/sqlite"
+)
+
+// Checks behaviour every user storage implementation must follow.
+func testUserStorage(t *testing.T, storage UserStorage) {
+	ctx := context.Background()
+	user := User{Login: uuid.NewString(), PasswordHash: []byte("hash")}
+
+	_, err := storage.GetUser(ctx, user.Login)
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	require.NoError(t, storage.AddUser(ctx, user))
+	require.ErrorIs(t, storage.AddUser(ctx, user), ErrConflictUserLogin)
+	require.ErrorIs(t, storage.AddUser(ctx, User{Login: strings.ToUpper(user.Login)}), ErrConflictUserLogin,
+		"logins should be unique case-insensitively")
+
+	got, err := storage.GetUser(ctx, user.Login)
+	require.NoError(t, err)
+	require.Equal(t, &user, got)
+
+	users, err := storage.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Contains(t, users, user)
+
+	first, second := sha256.Sum256([]byte("first")), sha256.Sum256([]byte("second"))
+	factor := SecondFactor{Secret: []byte("secret"), Enabled: true, LastStep: 10, RecoveryCodes: [][]byte{first[:], second[:]}}
+	require.NoError(t, storage.SetSecondFactor(ctx, user.Login, factor))
+	require.Error(t, storage.SetSecondFactor(ctx, "absent", factor))
+	got, err = storage.GetUser(ctx, user.Login)
+	require.NoError(t, err)
+	require.Equal(t, factor, got.SecondFactor)
+
+	require.ErrorIs(t, storage.UseTotpStep(ctx, user.Login, 10), ErrCodeUsed)
+	require.NoError(t, storage.UseTotpStep(ctx, user.Login, 11))
+	require.ErrorIs(t, storage.UseTotpStep(ctx, user.Login, 11), ErrCodeUsed)
+
+	require.NoError(t, storage.UseRecoveryCode(ctx, user.Login, first[:]))
+	require.ErrorIs(t, storage.UseRecoveryCode(ctx, user.Login, first[:]), ErrCodeUsed)
+	got, err = storage.GetUser(ctx, user.Login)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{second[:]}, got.SecondFactor.RecoveryCodes)
+	require.Equal(t, int64(11), got.SecondFactor.LastStep)
+
+	require.NoError(t, storage.ReplaceTotpSecret(ctx, user.Login, []byte("secret"), []byte("rewrapped")))
+	require.ErrorIs(t, storage.ReplaceTotpSecret(ctx, user.Login, []byte("secret"), []byte("other")), ErrSecretChanged)
+	require.ErrorIs(t, storage.ReplaceTotpSecret(ctx, "absent", []byte("secret"), []byte("other")), ErrSecretChanged)
+	got, err = storage.GetUser(ctx, user.Login)
+	require.NoError(t, err)
+	require.Equal(t, []byte("rewrapped"), got.SecondFactor.Secret)
+	require.True(t, got.SecondFactor.Enabled, "only secret should be replaced")
+
+	// User is added along with second factor, e.g. on restore from backup.
+	restored := User{Login: uuid.NewString(), PasswordHash: []byte("hash"), SecondFactor: factor}
+	require.NoError(t, storage.AddUser(ctx, restored))
+	got, err = storage.GetUser(ctx, restored.Login)
+	require.NoError(t, err)
+	require.Equal(t, &restored, got)
+
+	require.NoError(t, storage.ChangePassword(ctx, user.Login, []byte("new hash")))
+	require.ErrorIs(t, storage.ChangePassword(ctx, "absent", []byte("new hash")), ErrUserNotFound)
+	got, err = storage.GetUser(ctx, user.Login)
+	require.NoError(t, err)
+	require.Equal(t, []byte("new hash"), got.PasswordHash)
+
+	require.NoError(t, storage.DeleteUser(ctx, user.Login))
+	require.ErrorIs(t, storage.DeleteUser(ctx, user.Login), ErrUserNotFound)
+	_, err = storage.GetUser(ctx, user.Login)
+	require.Error(t, err)
+}
+
+// Opens migrated database.
+func openTestDB(t *testing.T, driver, dsn string, dialect migrations.Dialect) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewMigrator(db, dialect)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestSqliteUserStorage(t *testing.T) {
+	testUserStorage(t, NewSqliteUserStorage(openTestDB(t, "sqlite", ":memory:", migrations.Sqlite)))
+}
+
+func TestPostgresqlUserStorage(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db := openTestDB(t, "pgx", dsn, migrations.Postgres)
+	testUserStorage(t, NewPostgresqlUserStorage(db))
+}
+
+func TestMemoryUserStorage(t *testing.T) {
+	testUserStorage(t, NewMemoryUserStorage())
+}
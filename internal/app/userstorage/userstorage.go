@@ -1,11 +1,38 @@
 // Package userstorage for storing and generating user ids.
 package userstorage
 
-import "context"
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+)
+
+// Error in case one-time code or recovery code has already been used.
+var ErrCodeUsed = errors.New("code already used")
+
+// Error in case totp secret to replace has been changed or user is absent.
+var ErrSecretChanged = errors.New("totp secret has been changed")
+
+// Second authentication factor of user.
+type SecondFactor struct {
+	// Totp secret encrypted by server key, empty if second factor is not set up.
+	Secret []byte
+
+	// Whether code is required on login, secret is waiting for confirmation otherwise.
+	Enabled bool
+
+	// Last accepted totp time step, codes of the same and earlier steps are rejected.
+	LastStep int64
+
+	// Sha256 hashes of unused recovery codes.
+	RecoveryCodes [][]byte
+}
 
 type User struct {
 	Login        string
 	PasswordHash []byte
+	SecondFactor SecondFactor
 }
 
 // Storage can generate uuid for new user with no collision.
@@ -17,4 +44,49 @@ type UserStorage interface {
 
 	// Method for adding new user.
 	GetUser(ctx context.Context, login string) (*User, error)
+
+	// Method for getting all users ordered by login.
+	ListUsers(ctx context.Context) ([]User, error)
+
+	// Method for replacing password hash of user.
+	ChangePassword(ctx context.Context, login string, passwordHash []byte) error
+
+	// Method for deleting user.
+	DeleteUser(ctx context.Context, login string) error
+
+	// Method for replacing second factor of user.
+	SetSecondFactor(ctx context.Context, login string, factor SecondFactor) error
+
+	// Method for accepting totp time step, returns ErrCodeUsed unless step is later than last accepted.
+	UseTotpStep(ctx context.Context, login string, step int64) error
+
+	// Method for removing recovery code with given hash, returns ErrCodeUsed if it is absent.
+	UseRecoveryCode(ctx context.Context, login string, hash []byte) error
+
+	// Method for replacing encrypted totp secret if it is unchanged, e.g. when it is encrypted by new server key.
+	ReplaceTotpSecret(ctx context.Context, login string, oldSecret []byte, secret []byte) error
+}
+
+// Recovery code hashes are stored concatenated in one column.
+func joinCodes(codes [][]byte) []byte {
+	return bytes.Join(codes, nil)
+}
+
+func splitCodes(joined []byte) [][]byte {
+	var codes [][]byte
+	for len(joined) >= sha256.Size {
+		codes = append(codes, joined[:sha256.Size:sha256.Size])
+		joined = joined[sha256.Size:]
+	}
+	return codes
+}
+
+// Returns codes without given one, false if it is absent.
+func removeCode(codes [][]byte, hash []byte) ([][]byte, bool) {
+	for i, code := range codes {
+		if bytes.Equal(code, hash) {
+			return append(codes[:i:i], codes[i+1:]...), true
+		}
+	}
+	return codes, false
 }
@@ -0,0 +1,258 @@
+// Package vaultarchive contains portable archive of decrypted user items.
+//
+// Archive is tar stream compressed with zstd and encrypted with passphrase,
+// so it can be imported to any server with any client keys. Every item is
+// stored as items/{n}.json description followed by items/{n} content, and
+// manifest.json with all descriptions closes the archive.
+package vaultarchive
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+)
+
+// Archive format version.
+const FormatVersion = 1
+
+const manifestEntry = "manifest.json"
+
+var (
+	// Error in case archive content doesn't match its descriptions.
+	ErrCorrupted = errors.New("vault archive is corrupted")
+
+	// Error in case item content differs from its checksum.
+	ErrChecksumMismatch = errors.New("item checksum mismatch")
+)
+
+// Description of archived item.
+type Item struct {
+	Filename string    `json:"filename"`
+	Comment  string    `json:"comment"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Size     int64     `json:"size"`
+
+	// Hex sha256 of item plaintext.
+	Sha256 string `json:"sha256"`
+}
+
+// Archive description written after all items.
+type Manifest struct {
+	Version  int       `json:"version"`
+	Exported time.Time `json:"exported"`
+	Items    []Item    `json:"items"`
+}
+
+func itemEntry(n int) string {
+	return fmt.Sprintf("items/%d", n)
+}
+
+// Archive writer.
+type Writer struct {
+	encrypted io.WriteCloser
+	zstd      *zstd.Encoder
+	tar       *tar.Writer
+	manifest  Manifest
+}
+
+// Creates archive writer encrypting archive with passphrase.
+// Close must be called to finish archive, it doesn't close underlying writer.
+func NewWriter(writer io.Writer, passphrase []byte) (*Writer, error) {
+	encrypted, err := encryption.NewPassphraseWriter(writer, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	zstdWriter, err := zstd.NewWriter(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		encrypted: encrypted,
+		zstd:      zstdWriter,
+		tar:       tar.NewWriter(zstdWriter),
+		manifest:  Manifest{Version: FormatVersion, Exported: time.Now()},
+	}, nil
+}
+
+// Writes item with content of item.Size bytes from reader.
+// Sha256 is required beforehand so that importer can skip duplicates without reading content.
+func (w *Writer) WriteItem(item Item, reader io.Reader) error {
+	if item.Sha256 == "" {
+		return fmt.Errorf("item %s has no sha256", item.Filename)
+	}
+	entry := itemEntry(len(w.manifest.Items))
+	description, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err = w.writeEntry(entry+".json", description, item.Modified); err != nil {
+		return err
+	}
+	err = w.tar.WriteHeader(&tar.Header{Name: entry, Mode: 0600, Size: item.Size, ModTime: item.Modified})
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	hash := sha256.New()
+	if _, err = io.CopyN(io.MultiWriter(w.tar, hash), reader, item.Size); err != nil {
+		return fmt.Errorf("failed to write item %s: %w", item.Filename, err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != item.Sha256 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, item.Filename)
+	}
+	w.manifest.Items = append(w.manifest.Items, item)
+	return nil
+}
+
+func (w *Writer) writeEntry(name string, data []byte, modTime time.Time) error {
+	err := w.tar.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime})
+	if err == nil {
+		_, err = w.tar.Write(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return nil
+}
+
+// Writes manifest and finishes archive.
+func (w *Writer) Close() error {
+	manifest, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = w.writeEntry(manifestEntry, manifest, w.manifest.Exported); err != nil {
+		return err
+	}
+	if err = w.tar.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err = w.zstd.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return w.encrypted.Close()
+}
+
+// Archive reader.
+type Reader struct {
+	zstd  *zstd.Decoder
+	tar   *tar.Reader
+	items []Item
+
+	// Content reader of last returned item.
+	current *itemReader
+}
+
+// Creates reader of archive encrypted with passphrase.
+func NewReader(reader io.Reader, passphrase []byte) (*Reader, error) {
+	decrypted, err := encryption.NewPassphraseReader(reader, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	zstdReader, err := zstd.NewReader(decrypted)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{zstd: zstdReader, tar: tar.NewReader(zstdReader)}, nil
+}
+
+// Returns next item and its content reader, which fails on checksum mismatch at the end.
+// Returns io.EOF after the last item once archive is checked against manifest.
+// Content of previous item is verified even if it was not read.
+func (r *Reader) Next() (*Item, io.Reader, error) {
+	if err := r.finishCurrent(); err != nil {
+		return nil, nil, err
+	}
+	header, err := r.tar.Next()
+	if err != nil {
+		return nil, nil, r.readError(err)
+	}
+	if header.Name == manifestEntry {
+		return nil, nil, r.checkManifest()
+	}
+	entry := itemEntry(len(r.items))
+	if header.Name != entry+".json" {
+		return nil, nil, fmt.Errorf("%w: unexpected entry %q", ErrCorrupted, header.Name)
+	}
+	var item Item
+	if err = json.NewDecoder(r.tar).Decode(&item); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	if header, err = r.tar.Next(); err != nil {
+		return nil, nil, r.readError(err)
+	}
+	if header.Name != entry || header.Size != item.Size {
+		return nil, nil, fmt.Errorf("%w: unexpected entry %q", ErrCorrupted, header.Name)
+	}
+	r.items = append(r.items, item)
+	r.current = &itemReader{reader: r.tar, hash: sha256.New(), item: item}
+	return &item, r.current, nil
+}
+
+func (r *Reader) finishCurrent() error {
+	if r.current == nil {
+		return nil
+	}
+	_, err := io.Copy(io.Discard, r.current)
+	r.current = nil
+	return err
+}
+
+func (r *Reader) readError(err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("%w: no manifest", ErrCorrupted)
+	}
+	return fmt.Errorf("failed to read archive: %w", err)
+}
+
+func (r *Reader) checkManifest() error {
+	var manifest Manifest
+	if err := json.NewDecoder(r.tar).Decode(&manifest); err != nil {
+		return fmt.Errorf("%w: %w", ErrCorrupted, err)
+	}
+	if manifest.Version != FormatVersion {
+		return fmt.Errorf("%w: unsupported format version %d", ErrCorrupted, manifest.Version)
+	}
+	if len(manifest.Items) != len(r.items) {
+		return fmt.Errorf("%w: %d items, manifest has %d", ErrCorrupted, len(r.items), len(manifest.Items))
+	}
+	for i, item := range manifest.Items {
+		if item.Sha256 != r.items[i].Sha256 || item.Size != r.items[i].Size {
+			return fmt.Errorf("%w: item %s differs from manifest", ErrCorrupted, item.Filename)
+		}
+	}
+	if _, err := r.tar.Next(); err != io.EOF {
+		return fmt.Errorf("%w: entries after manifest", ErrCorrupted)
+	}
+	return io.EOF
+}
+
+// Releases decompressor resources.
+func (r *Reader) Close() {
+	r.zstd.Close()
+}
+
+// Item content reader verifying sha256 at the end.
+type itemReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	item   Item
+}
+
+func (r *itemReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.hash.Sum(nil)) != r.item.Sha256 {
+		return n, fmt.Errorf("%w: %s", ErrChecksumMismatch, r.item.Filename)
+	}
+	return n, err
+}
@@ -0,0 +1,82 @@
+package vaultarchive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPassphrase = []byte("passphrase")
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeArchive(t *testing.T, contents [][]byte) []byte {
+	var archive bytes.Buffer
+	writer, err := NewWriter(&archive, testPassphrase)
+	require.NoError(t, err)
+	for i, content := range contents {
+		item := Item{Filename: string(rune('a' + i)), Comment: "comment", Created: time.Unix(100, 0).UTC(),
+			Size: int64(len(content)), Sha256: sha256Hex(content)}
+		require.NoError(t, writer.WriteItem(item, bytes.NewReader(content)))
+	}
+	require.NoError(t, writer.Close())
+	return archive.Bytes()
+}
+
+func TestArchive(t *testing.T) {
+	contents := [][]byte{[]byte("first"), {}, bytes.Repeat([]byte("third"), 100000)}
+	archive := writeArchive(t, contents)
+
+	reader, err := NewReader(bytes.NewReader(archive), testPassphrase)
+	require.NoError(t, err)
+	defer reader.Close()
+	for i, content := range contents {
+		item, itemReader, err := reader.Next()
+		require.NoError(t, err)
+		require.Equal(t, &Item{Filename: string(rune('a' + i)), Comment: "comment", Created: time.Unix(100, 0).UTC(),
+			Size: int64(len(content)), Sha256: sha256Hex(content)}, item)
+		// Content of second item is skipped.
+		if i == 1 {
+			continue
+		}
+		data, err := io.ReadAll(itemReader)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	}
+	_, _, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestArchive_Failures(t *testing.T) {
+	archive := writeArchive(t, [][]byte{[]byte("first")})
+
+	reader, err := NewReader(bytes.NewReader(archive), []byte("wrong"))
+	require.NoError(t, err)
+	_, _, err = reader.Next()
+	require.Error(t, err)
+
+	reader, err = NewReader(bytes.NewReader(archive[:len(archive)-1]), testPassphrase)
+	require.NoError(t, err)
+	for err == nil {
+		_, _, err = reader.Next()
+	}
+	require.NotErrorIs(t, err, io.EOF)
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, testPassphrase)
+	require.NoError(t, err)
+	err = writer.WriteItem(Item{Filename: "a", Size: 4, Sha256: "wrong"}, bytes.NewReader([]byte("data")))
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	err = writer.WriteItem(Item{Filename: "b", Size: 4}, bytes.NewReader([]byte("data")))
+	require.Error(t, err)
+	err = writer.WriteItem(Item{Filename: "c", Size: 5, Sha256: sha256Hex([]byte("data"))}, bytes.NewReader([]byte("data")))
+	require.Error(t, err)
+}
@@ -0,0 +1,173 @@
+// Package mocks contains mocks for storages.
+package mocks
+
+import (
+	context "context"
+
+	apitokenstorage "github.com/valinurovdenis/gophkeeper/internal/app/apitokenstorage"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// ApiTokenStorage is an autogenerated mock type for the ApiTokenStorage type
+type ApiTokenStorage struct {
+	mock.Mock
+}
+
+// AddToken provides a mock function with given fields: ctx, token
+func (_m *ApiTokenStorage) AddToken(ctx context.Context, token apitokenstorage.ApiToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, apitokenstorage.ApiToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx, before
+func (_m *ApiTokenStorage) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpired")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteToken provides a mock function with given fields: ctx, login, id
+func (_m *ApiTokenStorage) DeleteToken(ctx context.Context, login string, id string) error {
+	ret := _m.Called(ctx, login, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, login, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetToken provides a mock function with given fields: ctx, id
+func (_m *ApiTokenStorage) GetToken(ctx context.Context, id string) (*apitokenstorage.ApiToken, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetToken")
+	}
+
+	var r0 *apitokenstorage.ApiToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*apitokenstorage.ApiToken, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *apitokenstorage.ApiToken); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apitokenstorage.ApiToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTokens provides a mock function with given fields: ctx, login
+func (_m *ApiTokenStorage) ListTokens(ctx context.Context, login string) ([]apitokenstorage.ApiToken, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTokens")
+	}
+
+	var r0 []apitokenstorage.ApiToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]apitokenstorage.ApiToken, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []apitokenstorage.ApiToken); ok {
+		r0 = rf(ctx, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]apitokenstorage.ApiToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchToken provides a mock function with given fields: ctx, id, lastUsed
+func (_m *ApiTokenStorage) TouchToken(ctx context.Context, id string, lastUsed time.Time) error {
+	ret := _m.Called(ctx, id, lastUsed)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, id, lastUsed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewApiTokenStorage creates a new instance of ApiTokenStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewApiTokenStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ApiTokenStorage {
+	mock := &ApiTokenStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
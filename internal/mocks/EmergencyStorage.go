@@ -0,0 +1,266 @@
+// Package mocks contains mocks for storages.
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	emergencystorage "github.com/valinurovdenis/gophkeeper/internal/app/emergencystorage"
+)
+
+// EmergencyStorage is an autogenerated mock type for the EmergencyStorage type
+type EmergencyStorage struct {
+	mock.Mock
+}
+
+// AddGrant provides a mock function with given fields: ctx, grant
+func (_m *EmergencyStorage) AddGrant(ctx context.Context, grant emergencystorage.Grant) error {
+	ret := _m.Called(ctx, grant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddGrant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, emergencystorage.Grant) error); ok {
+		r0 = rf(ctx, grant)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmGrant provides a mock function with given fields: ctx, grant, status, keys
+func (_m *EmergencyStorage) ConfirmGrant(ctx context.Context, grant emergencystorage.Grant, status string, keys []emergencystorage.Key) error {
+	ret := _m.Called(ctx, grant, status, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ConfirmGrant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, emergencystorage.Grant, string, []emergencystorage.Key) error); ok {
+		r0 = rf(ctx, grant, status, keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteGrant provides a mock function with given fields: ctx, id
+func (_m *EmergencyStorage) DeleteGrant(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteGrant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUserGrants provides a mock function with given fields: ctx, login
+func (_m *EmergencyStorage) DeleteUserGrants(ctx context.Context, login string) (int64, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUserGrants")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, login)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGrant provides a mock function with given fields: ctx, id
+func (_m *EmergencyStorage) GetGrant(ctx context.Context, id string) (*emergencystorage.Grant, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGrant")
+	}
+
+	var r0 *emergencystorage.Grant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*emergencystorage.Grant, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *emergencystorage.Grant); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*emergencystorage.Grant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetKey provides a mock function with given fields: ctx, grantID, fileID
+func (_m *EmergencyStorage) GetKey(ctx context.Context, grantID string, fileID string) ([]byte, error) {
+	ret := _m.Called(ctx, grantID, fileID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKey")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]byte, error)); ok {
+		return rf(ctx, grantID, fileID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, grantID, fileID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, grantID, fileID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListGrants provides a mock function with given fields: ctx, login
+func (_m *EmergencyStorage) ListGrants(ctx context.Context, login string) ([]emergencystorage.Grant, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListGrants")
+	}
+
+	var r0 []emergencystorage.Grant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]emergencystorage.Grant, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []emergencystorage.Grant); ok {
+		r0 = rf(ctx, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]emergencystorage.Grant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListKeys provides a mock function with given fields: ctx, grantID
+func (_m *EmergencyStorage) ListKeys(ctx context.Context, grantID string) ([]emergencystorage.Key, error) {
+	ret := _m.Called(ctx, grantID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListKeys")
+	}
+
+	var r0 []emergencystorage.Key
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]emergencystorage.Key, error)); ok {
+		return rf(ctx, grantID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []emergencystorage.Key); ok {
+		r0 = rf(ctx, grantID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]emergencystorage.Key)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, grantID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetKeys provides a mock function with given fields: ctx, grantID, keys
+func (_m *EmergencyStorage) SetKeys(ctx context.Context, grantID string, keys []emergencystorage.Key) error {
+	ret := _m.Called(ctx, grantID, keys)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetKeys")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []emergencystorage.Key) error); ok {
+		r0 = rf(ctx, grantID, keys)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateGrant provides a mock function with given fields: ctx, grant, status
+func (_m *EmergencyStorage) UpdateGrant(ctx context.Context, grant emergencystorage.Grant, status string) error {
+	ret := _m.Called(ctx, grant, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateGrant")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, emergencystorage.Grant, string) error); ok {
+		r0 = rf(ctx, grant, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewEmergencyStorage creates a new instance of EmergencyStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewEmergencyStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *EmergencyStorage {
+	mock := &EmergencyStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
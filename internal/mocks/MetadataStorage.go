@@ -32,6 +32,54 @@ func (_m *MetadataStorage) AddFileInfo(_a0 context.Context, fileInfo *proto.File
 	return r0
 }
 
+// CommitFileInfo provides a mock function with given fields: _a0, fileId, checksum
+func (_m *MetadataStorage) CommitFileInfo(_a0 context.Context, fileId string, checksum []byte) error {
+	ret := _m.Called(_a0, fileId, checksum)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CommitFileInfo")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(_a0, fileId, checksum)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountFilesByKey provides a mock function with given fields: _a0
+func (_m *MetadataStorage) CountFilesByKey(_a0 context.Context) (map[string]int, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountFilesByKey")
+	}
+
+	var r0 map[string]int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (map[string]int, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteFileInfo provides a mock function with given fields: _a0, fileId
 func (_m *MetadataStorage) DeleteFileInfo(_a0 context.Context, fileId string) error {
 	ret := _m.Called(_a0, fileId)
@@ -50,6 +98,36 @@ func (_m *MetadataStorage) DeleteFileInfo(_a0 context.Context, fileId string) er
 	return r0
 }
 
+// GetAllFiles provides a mock function with given fields: _a0
+func (_m *MetadataStorage) GetAllFiles(_a0 context.Context) (*proto.ListFiles, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllFiles")
+	}
+
+	var r0 *proto.ListFiles
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*proto.ListFiles, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *proto.ListFiles); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.ListFiles)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFileById provides a mock function with given fields: _a0, fileId
 func (_m *MetadataStorage) GetFileById(_a0 context.Context, fileId string) (*proto.FileInfo, error) {
 	ret := _m.Called(_a0, fileId)
@@ -110,6 +188,66 @@ func (_m *MetadataStorage) GetFilesByLogin(_a0 context.Context, login string) (*
 	return r0, r1
 }
 
+// GetFilesByOrganization provides a mock function with given fields: _a0, orgId
+func (_m *MetadataStorage) GetFilesByOrganization(_a0 context.Context, orgId string) (*proto.ListFiles, error) {
+	ret := _m.Called(_a0, orgId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFilesByOrganization")
+	}
+
+	var r0 *proto.ListFiles
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*proto.ListFiles, error)); ok {
+		return rf(_a0, orgId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *proto.ListFiles); ok {
+		r0 = rf(_a0, orgId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.ListFiles)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, orgId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFilesToRewrap provides a mock function with given fields: _a0, keyId, afterId, limit
+func (_m *MetadataStorage) GetFilesToRewrap(_a0 context.Context, keyId string, afterId string, limit int) (*proto.ListFiles, error) {
+	ret := _m.Called(_a0, keyId, afterId, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFilesToRewrap")
+	}
+
+	var r0 *proto.ListFiles
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) (*proto.ListFiles, error)); ok {
+		return rf(_a0, keyId, afterId, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) *proto.ListFiles); ok {
+		r0 = rf(_a0, keyId, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*proto.ListFiles)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(_a0, keyId, afterId, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Ping provides a mock function with given fields:
 func (_m *MetadataStorage) Ping() error {
 	ret := _m.Called()
@@ -128,6 +266,42 @@ func (_m *MetadataStorage) Ping() error {
 	return r0
 }
 
+// UpdateFileKey provides a mock function with given fields: _a0, fileId, oldKeyId, key, keyId
+func (_m *MetadataStorage) UpdateFileKey(_a0 context.Context, fileId string, oldKeyId string, key []byte, keyId string) error {
+	ret := _m.Called(_a0, fileId, oldKeyId, key, keyId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFileKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, []byte, string) error); ok {
+		r0 = rf(_a0, fileId, oldKeyId, key, keyId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateFileState provides a mock function with given fields: _a0, fileId, state
+func (_m *MetadataStorage) UpdateFileState(_a0 context.Context, fileId string, state proto.FileState) error {
+	ret := _m.Called(_a0, fileId, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateFileState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, proto.FileState) error); ok {
+		r0 = rf(_a0, fileId, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewMetadataStorage creates a new instance of MetadataStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMetadataStorage(t interface {
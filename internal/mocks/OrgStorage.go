@@ -0,0 +1,268 @@
+// Package mocks contains mocks for storages.
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	orgstorage "github.com/valinurovdenis/gophkeeper/internal/app/orgstorage"
+)
+
+// OrgStorage is an autogenerated mock type for the OrgStorage type
+type OrgStorage struct {
+	mock.Mock
+}
+
+// AddOrganization provides a mock function with given fields: ctx, org, members
+func (_m *OrgStorage) AddOrganization(ctx context.Context, org orgstorage.Organization, members []orgstorage.Member) error {
+	ret := _m.Called(ctx, org, members)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddOrganization")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, orgstorage.Organization, []orgstorage.Member) error); ok {
+		r0 = rf(ctx, org, members)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteMember provides a mock function with given fields: ctx, orgID, login
+func (_m *OrgStorage) DeleteMember(ctx context.Context, orgID string, login string) error {
+	ret := _m.Called(ctx, orgID, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, orgID, login)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteOrganization provides a mock function with given fields: ctx, id
+func (_m *OrgStorage) DeleteOrganization(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOrganization")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetMember provides a mock function with given fields: ctx, orgID, login
+func (_m *OrgStorage) GetMember(ctx context.Context, orgID string, login string) (*orgstorage.Member, error) {
+	ret := _m.Called(ctx, orgID, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMember")
+	}
+
+	var r0 *orgstorage.Member
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*orgstorage.Member, error)); ok {
+		return rf(ctx, orgID, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *orgstorage.Member); ok {
+		r0 = rf(ctx, orgID, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*orgstorage.Member)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, orgID, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrganization provides a mock function with given fields: ctx, id
+func (_m *OrgStorage) GetOrganization(ctx context.Context, id string) (*orgstorage.Organization, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrganization")
+	}
+
+	var r0 *orgstorage.Organization
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*orgstorage.Organization, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *orgstorage.Organization); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*orgstorage.Organization)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListMembers provides a mock function with given fields: ctx, orgID
+func (_m *OrgStorage) ListMembers(ctx context.Context, orgID string) ([]orgstorage.Member, error) {
+	ret := _m.Called(ctx, orgID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMembers")
+	}
+
+	var r0 []orgstorage.Member
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]orgstorage.Member, error)); ok {
+		return rf(ctx, orgID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []orgstorage.Member); ok {
+		r0 = rf(ctx, orgID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]orgstorage.Member)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orgID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListMemberships provides a mock function with given fields: ctx, login
+func (_m *OrgStorage) ListMemberships(ctx context.Context, login string) ([]orgstorage.Member, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListMemberships")
+	}
+
+	var r0 []orgstorage.Member
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]orgstorage.Member, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []orgstorage.Member); ok {
+		r0 = rf(ctx, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]orgstorage.Member)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOrganizations provides a mock function with given fields: ctx
+func (_m *OrgStorage) ListOrganizations(ctx context.Context) ([]orgstorage.Organization, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOrganizations")
+	}
+
+	var r0 []orgstorage.Organization
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]orgstorage.Organization, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []orgstorage.Organization); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]orgstorage.Organization)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceKey provides a mock function with given fields: ctx, id, oldKey, key
+func (_m *OrgStorage) ReplaceKey(ctx context.Context, id string, oldKey []byte, key []byte) error {
+	ret := _m.Called(ctx, id, oldKey, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte) error); ok {
+		r0 = rf(ctx, id, oldKey, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetMember provides a mock function with given fields: ctx, member
+func (_m *OrgStorage) SetMember(ctx context.Context, member orgstorage.Member) error {
+	ret := _m.Called(ctx, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, orgstorage.Member) error); ok {
+		r0 = rf(ctx, member)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewOrgStorage creates a new instance of OrgStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOrgStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OrgStorage {
+	mock := &OrgStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,172 @@
+// Package mocks contains mocks for storages.
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	sessionstorage "github.com/valinurovdenis/gophkeeper/internal/app/sessionstorage"
+
+	time "time"
+)
+
+// SessionStorage is an autogenerated mock type for the SessionStorage type
+type SessionStorage struct {
+	mock.Mock
+}
+
+// AddSession provides a mock function with given fields: ctx, session
+func (_m *SessionStorage) AddSession(ctx context.Context, session sessionstorage.Session) error {
+	ret := _m.Called(ctx, session)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, sessionstorage.Session) error); ok {
+		r0 = rf(ctx, session)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteInactive provides a mock function with given fields: ctx, before
+func (_m *SessionStorage) DeleteInactive(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteInactive")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteSession provides a mock function with given fields: ctx, login, id
+func (_m *SessionStorage) DeleteSession(ctx context.Context, login string, id string) error {
+	ret := _m.Called(ctx, login, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, login, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetSession provides a mock function with given fields: ctx, id
+func (_m *SessionStorage) GetSession(ctx context.Context, id string) (*sessionstorage.Session, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSession")
+	}
+
+	var r0 *sessionstorage.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*sessionstorage.Session, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *sessionstorage.Session); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*sessionstorage.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSessions provides a mock function with given fields: ctx, login
+func (_m *SessionStorage) ListSessions(ctx context.Context, login string) ([]sessionstorage.Session, error) {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSessions")
+	}
+
+	var r0 []sessionstorage.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]sessionstorage.Session, error)); ok {
+		return rf(ctx, login)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []sessionstorage.Session); ok {
+		r0 = rf(ctx, login)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]sessionstorage.Session)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, login)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchSession provides a mock function with given fields: ctx, id, ip, lastSeen
+func (_m *SessionStorage) TouchSession(ctx context.Context, id string, ip string, lastSeen time.Time) error {
+	ret := _m.Called(ctx, id, ip, lastSeen)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchSession")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = rf(ctx, id, ip, lastSeen)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewSessionStorage creates a new instance of SessionStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSessionStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SessionStorage {
+	mock := &SessionStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,6 +4,10 @@ package mocks
 import (
 	context "context"
 
+	filestorage "github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+
+	io "io"
+
 	mock "github.com/stretchr/testify/mock"
 
 	proto "github.com/valinurovdenis/gophkeeper/internal/proto"
@@ -50,17 +54,47 @@ func (_m *StreamingFileStorage) Download(stream proto.GophKeeperService_Download
 	return r0
 }
 
-// Upload provides a mock function with given fields: stream, fileSize, fileId
-func (_m *StreamingFileStorage) Upload(stream proto.GophKeeperService_UploadFileServer, fileSize int64, fileId string) error {
-	ret := _m.Called(stream, fileSize, fileId)
+// List provides a mock function with given fields: ctx
+func (_m *StreamingFileStorage) List(ctx context.Context) ([]filestorage.FileObject, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []filestorage.FileObject
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]filestorage.FileObject, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []filestorage.FileObject); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]filestorage.FileObject)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Upload provides a mock function with given fields: ctx, reader, fileSize, fileId
+func (_m *StreamingFileStorage) Upload(ctx context.Context, reader io.Reader, fileSize int64, fileId string) error {
+	ret := _m.Called(ctx, reader, fileSize, fileId)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Upload")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(proto.GophKeeperService_UploadFileServer, int64, string) error); ok {
-		r0 = rf(stream, fileSize, fileId)
+	if rf, ok := ret.Get(0).(func(context.Context, io.Reader, int64, string) error); ok {
+		r0 = rf(ctx, reader, fileSize, fileId)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,124 @@
+// Package mocks contains mocks for storages.
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	tokenstorage "github.com/valinurovdenis/gophkeeper/internal/app/tokenstorage"
+)
+
+// TokenStorage is an autogenerated mock type for the TokenStorage type
+type TokenStorage struct {
+	mock.Mock
+}
+
+// AddToken provides a mock function with given fields: ctx, token
+func (_m *TokenStorage) AddToken(ctx context.Context, token tokenstorage.RefreshToken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, tokenstorage.RefreshToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx, before
+func (_m *TokenStorage) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpired")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteFamily provides a mock function with given fields: ctx, family
+func (_m *TokenStorage) DeleteFamily(ctx context.Context, family string) error {
+	ret := _m.Called(ctx, family)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteFamily")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, family)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseToken provides a mock function with given fields: ctx, hash, now
+func (_m *TokenStorage) UseToken(ctx context.Context, hash []byte, now time.Time) (*tokenstorage.RefreshToken, error) {
+	ret := _m.Called(ctx, hash, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseToken")
+	}
+
+	var r0 *tokenstorage.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, time.Time) (*tokenstorage.RefreshToken, error)); ok {
+		return rf(ctx, hash, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, time.Time) *tokenstorage.RefreshToken); ok {
+		r0 = rf(ctx, hash, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*tokenstorage.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, time.Time) error); ok {
+		r1 = rf(ctx, hash, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTokenStorage creates a new instance of TokenStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTokenStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TokenStorage {
+	mock := &TokenStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -31,6 +31,42 @@ func (_m *UserStorage) AddUser(ctx context.Context, user userstorage.User) error
 	return r0
 }
 
+// ChangePassword provides a mock function with given fields: ctx, login, passwordHash
+func (_m *UserStorage) ChangePassword(ctx context.Context, login string, passwordHash []byte) error {
+	ret := _m.Called(ctx, login, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, login, passwordHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: ctx, login
+func (_m *UserStorage) DeleteUser(ctx context.Context, login string) error {
+	ret := _m.Called(ctx, login)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, login)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetUser provides a mock function with given fields: ctx, login
 func (_m *UserStorage) GetUser(ctx context.Context, login string) (*userstorage.User, error) {
 	ret := _m.Called(ctx, login)
@@ -61,6 +97,108 @@ func (_m *UserStorage) GetUser(ctx context.Context, login string) (*userstorage.
 	return r0, r1
 }
 
+// ListUsers provides a mock function with given fields: ctx
+func (_m *UserStorage) ListUsers(ctx context.Context) ([]userstorage.User, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []userstorage.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]userstorage.User, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []userstorage.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]userstorage.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReplaceTotpSecret provides a mock function with given fields: ctx, login, oldSecret, secret
+func (_m *UserStorage) ReplaceTotpSecret(ctx context.Context, login string, oldSecret []byte, secret []byte) error {
+	ret := _m.Called(ctx, login, oldSecret, secret)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceTotpSecret")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte, []byte) error); ok {
+		r0 = rf(ctx, login, oldSecret, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetSecondFactor provides a mock function with given fields: ctx, login, factor
+func (_m *UserStorage) SetSecondFactor(ctx context.Context, login string, factor userstorage.SecondFactor) error {
+	ret := _m.Called(ctx, login, factor)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSecondFactor")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, userstorage.SecondFactor) error); ok {
+		r0 = rf(ctx, login, factor)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, login, hash
+func (_m *UserStorage) UseRecoveryCode(ctx context.Context, login string, hash []byte) error {
+	ret := _m.Called(ctx, login, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseRecoveryCode")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []byte) error); ok {
+		r0 = rf(ctx, login, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseTotpStep provides a mock function with given fields: ctx, login, step
+func (_m *UserStorage) UseTotpStep(ctx context.Context, login string, step int64) error {
+	ret := _m.Called(ctx, login, step)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseTotpStep")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, login, step)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewUserStorage creates a new instance of UserStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserStorage(t interface {
@@ -21,6 +21,55 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type FileState int32
+
+const (
+	FileState_COMMITTED FileState = 0
+	FileState_PENDING   FileState = 1
+	FileState_DELETING  FileState = 2
+)
+
+// Enum value maps for FileState.
+var (
+	FileState_name = map[int32]string{
+		0: "COMMITTED",
+		1: "PENDING",
+		2: "DELETING",
+	}
+	FileState_value = map[string]int32{
+		"COMMITTED": 0,
+		"PENDING":   1,
+		"DELETING":  2,
+	}
+)
+
+func (x FileState) Enum() *FileState {
+	p := new(FileState)
+	*p = x
+	return p
+}
+
+func (x FileState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FileState) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_proto_file_proto_enumTypes[0].Descriptor()
+}
+
+func (FileState) Type() protoreflect.EnumType {
+	return &file_internal_proto_file_proto_enumTypes[0]
+}
+
+func (x FileState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FileState.Descriptor instead.
+func (FileState) EnumDescriptor() ([]byte, []int) {
+	return file_internal_proto_file_proto_rawDescGZIP(), []int{0}
+}
+
 type FileId struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -75,6 +124,11 @@ type FileInfo struct {
 	Size          uint64                 `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
 	Comment       string                 `protobuf:"bytes,7,opt,name=comment,proto3" json:"comment,omitempty"`
 	EncryptionKey []byte                 `protobuf:"bytes,8,opt,name=encryption_key,json=encryptionKey,proto3" json:"encryption_key,omitempty"`
+	ContentHash   []byte                 `protobuf:"bytes,9,opt,name=content_hash,json=contentHash,proto3" json:"content_hash,omitempty"`
+	Checksum      []byte                 `protobuf:"bytes,10,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	State         FileState              `protobuf:"varint,11,opt,name=state,proto3,enum=file.FileState" json:"state,omitempty"`
+	Organization  string                 `protobuf:"bytes,12,opt,name=organization,proto3" json:"organization,omitempty"`
+	KeyId         string                 `protobuf:"bytes,13,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -165,6 +219,41 @@ func (x *FileInfo) GetEncryptionKey() []byte {
 	return nil
 }
 
+func (x *FileInfo) GetContentHash() []byte {
+	if x != nil {
+		return x.ContentHash
+	}
+	return nil
+}
+
+func (x *FileInfo) GetChecksum() []byte {
+	if x != nil {
+		return x.Checksum
+	}
+	return nil
+}
+
+func (x *FileInfo) GetState() FileState {
+	if x != nil {
+		return x.State
+	}
+	return FileState_COMMITTED
+}
+
+func (x *FileInfo) GetOrganization() string {
+	if x != nil {
+		return x.Organization
+	}
+	return ""
+}
+
+func (x *FileInfo) GetKeyId() string {
+	if x != nil {
+		return x.KeyId
+	}
+	return ""
+}
+
 type FileStream struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Data:
@@ -250,6 +339,8 @@ func (*FileStream_ChunkData) isFileStream_Data() {}
 type UploadResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            *FileId                `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Checksum      []byte                 `protobuf:"bytes,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Size          uint64                 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -291,6 +382,20 @@ func (x *UploadResponse) GetId() *FileId {
 	return nil
 }
 
+func (x *UploadResponse) GetChecksum() []byte {
+	if x != nil {
+		return x.Checksum
+	}
+	return nil
+}
+
+func (x *UploadResponse) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
 type ListFiles struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Files         []*FileInfo            `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
@@ -341,7 +446,7 @@ const file_internal_proto_file_proto_rawDesc = "" +
 	"\n" +
 	"\x19internal/proto/file.proto\x12\x04file\"\x18\n" +
 	"\x06FileId\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"\xe5\x01\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x86\x03\n" +
 	"\bFileInfo\x12\x1c\n" +
 	"\x02id\x18\x01 \x01(\v2\f.file.FileIdR\x02id\x12\x1a\n" +
 	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x14\n" +
@@ -350,17 +455,29 @@ const file_internal_proto_file_proto_rawDesc = "" +
 	"\bmodified\x18\x05 \x01(\x04R\bmodified\x12\x12\n" +
 	"\x04size\x18\x06 \x01(\x04R\x04size\x12\x18\n" +
 	"\acomment\x18\a \x01(\tR\acomment\x12%\n" +
-	"\x0eencryption_key\x18\b \x01(\fR\rencryptionKey\"[\n" +
+	"\x0eencryption_key\x18\b \x01(\fR\rencryptionKey\x12!\n" +
+	"\fcontent_hash\x18\t \x01(\fR\vcontentHash\x12\x1a\n" +
+	"\bchecksum\x18\n" +
+	" \x01(\fR\bchecksum\x12%\n" +
+	"\x05state\x18\v \x01(\x0e2\x0f.file.FileStateR\x05state\x12\"\n" +
+	"\forganization\x18\f \x01(\tR\forganization\x12\x15\n" +
+	"\x06key_id\x18\r \x01(\tR\x05keyId\"[\n" +
 	"\n" +
 	"FileStream\x12$\n" +
 	"\x04info\x18\x01 \x01(\v2\x0e.file.FileInfoH\x00R\x04info\x12\x1f\n" +
 	"\n" +
 	"chunk_data\x18\x02 \x01(\fH\x00R\tchunkDataB\x06\n" +
-	"\x04data\".\n" +
+	"\x04data\"^\n" +
 	"\x0eUploadResponse\x12\x1c\n" +
-	"\x02id\x18\x01 \x01(\v2\f.file.FileIdR\x02id\"1\n" +
+	"\x02id\x18\x01 \x01(\v2\f.file.FileIdR\x02id\x12\x1a\n" +
+	"\bchecksum\x18\x02 \x01(\fR\bchecksum\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x04R\x04size\"1\n" +
 	"\tListFiles\x12$\n" +
-	"\x05files\x18\x01 \x03(\v2\x0e.file.FileInfoR\x05filesB\n" +
+	"\x05files\x18\x01 \x03(\v2\x0e.file.FileInfoR\x05files*5\n" +
+	"\tFileState\x12\r\n" +
+	"\tCOMMITTED\x10\x00\x12\v\n" +
+	"\aPENDING\x10\x01\x12\f\n" +
+	"\bDELETING\x10\x02B\n" +
 	"Z\b./;protob\x06proto3"
 
 var (
@@ -375,24 +492,27 @@ func file_internal_proto_file_proto_rawDescGZIP() []byte {
 	return file_internal_proto_file_proto_rawDescData
 }
 
+var file_internal_proto_file_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
 var file_internal_proto_file_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_internal_proto_file_proto_goTypes = []any{
-	(*FileId)(nil),         // 0: file.FileId
-	(*FileInfo)(nil),       // 1: file.FileInfo
-	(*FileStream)(nil),     // 2: file.FileStream
-	(*UploadResponse)(nil), // 3: file.UploadResponse
-	(*ListFiles)(nil),      // 4: file.ListFiles
+	(FileState)(0),         // 0: file.FileState
+	(*FileId)(nil),         // 1: file.FileId
+	(*FileInfo)(nil),       // 2: file.FileInfo
+	(*FileStream)(nil),     // 3: file.FileStream
+	(*UploadResponse)(nil), // 4: file.UploadResponse
+	(*ListFiles)(nil),      // 5: file.ListFiles
 }
 var file_internal_proto_file_proto_depIdxs = []int32{
-	0, // 0: file.FileInfo.id:type_name -> file.FileId
-	1, // 1: file.FileStream.info:type_name -> file.FileInfo
-	0, // 2: file.UploadResponse.id:type_name -> file.FileId
-	1, // 3: file.ListFiles.files:type_name -> file.FileInfo
-	4, // [4:4] is the sub-list for method output_type
-	4, // [4:4] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	1, // 0: file.FileInfo.id:type_name -> file.FileId
+	0, // 1: file.FileInfo.state:type_name -> file.FileState
+	2, // 2: file.FileStream.info:type_name -> file.FileInfo
+	1, // 3: file.UploadResponse.id:type_name -> file.FileId
+	2, // 4: file.ListFiles.files:type_name -> file.FileInfo
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_file_proto_init() }
@@ -409,13 +529,14 @@ func file_internal_proto_file_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_proto_file_proto_rawDesc), len(file_internal_proto_file_proto_rawDesc)),
-			NumEnums:      0,
+			NumEnums:      1,
 			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_internal_proto_file_proto_goTypes,
 		DependencyIndexes: file_internal_proto_file_proto_depIdxs,
+		EnumInfos:         file_internal_proto_file_proto_enumTypes,
 		MessageInfos:      file_internal_proto_file_proto_msgTypes,
 	}.Build()
 	File_internal_proto_file_proto = out.File
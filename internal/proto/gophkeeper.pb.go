@@ -72,17 +72,47 @@ var File_internal_proto_gophkeeper_proto protoreflect.FileDescriptor
 const file_internal_proto_gophkeeper_proto_rawDesc = "" +
 	"\n" +
 	"\x1finternal/proto/gophkeeper.proto\x12\n" +
-	"gophkeeper\x1a\x19internal/proto/user.proto\x1a\x19internal/proto/file.proto\x1a\x1bgoogle/protobuf/empty.proto\"1\n" +
+	"gophkeeper\x1a\x19internal/proto/user.proto\x1a\x19internal/proto/file.proto\x1a!internal/proto/organization.proto\x1a\x1einternal/proto/emergency.proto\x1a\x1bgoogle/protobuf/empty.proto\"1\n" +
 	"\x10ServicePublicKey\x12\x1d\n" +
 	"\n" +
-	"public_key\x18\x01 \x01(\fR\tpublicKey2\xdb\x02\n" +
+	"public_key\x18\x01 \x01(\fR\tpublicKey2\xda\x12\n" +
 	"\x11GophKeeperService\x128\n" +
 	"\bRegister\x12\x0e.user.UserData\x1a\x1c.gophkeeper.ServicePublicKey\x125\n" +
-	"\x05Login\x12\x0e.user.UserData\x1a\x1c.gophkeeper.ServicePublicKey\x127\n" +
+	"\x05Login\x12\x0e.user.UserData\x1a\x1c.gophkeeper.ServicePublicKey\x12M\n" +
+	"\x12VerifySecondFactor\x12\x19.user.SecondFactorRequest\x1a\x1c.gophkeeper.ServicePublicKey\x127\n" +
+	"\fRefreshToken\x12\x19.user.RefreshTokenRequest\x1a\f.user.Tokens\x128\n" +
+	"\x06Logout\x12\x16.google.protobuf.Empty\x1a\x16.google.protobuf.Empty\x126\n" +
+	"\fListSessions\x12\x16.google.protobuf.Empty\x1a\x0e.user.Sessions\x128\n" +
+	"\rRevokeSession\x12\x0f.user.SessionId\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\x13RevokeOtherSessions\x12\x16.google.protobuf.Empty\x1a\x15.user.RevokedSessions\x12F\n" +
+	"\x12EnableSecondFactor\x12\x16.google.protobuf.Empty\x1a\x18.user.SecondFactorSecret\x12B\n" +
+	"\x13ConfirmSecondFactor\x12\x16.user.SecondFactorCode\x1a\x13.user.RecoveryCodes\x12E\n" +
+	"\x13DisableSecondFactor\x12\x16.user.SecondFactorCode\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\x0eChangePassword\x12\x1b.user.ChangePasswordRequest\x1a\x15.user.RevokedSessions\x12A\n" +
+	"\rDeleteAccount\x12\x1a.user.DeleteAccountRequest\x1a\x14.user.DeletedAccount\x12=\n" +
+	"\x0eCreateApiToken\x12\x1b.user.CreateApiTokenRequest\x1a\x0e.user.ApiToken\x128\n" +
+	"\rListApiTokens\x12\x16.google.protobuf.Empty\x1a\x0f.user.ApiTokens\x12:\n" +
+	"\x0eRevokeApiToken\x12\x10.user.ApiTokenId\x1a\x16.google.protobuf.Empty\x12Y\n" +
+	"\x12CreateOrganization\x12'.organization.CreateOrganizationRequest\x1a\x1a.organization.Organization\x12H\n" +
+	"\x11ListOrganizations\x12\x16.google.protobuf.Empty\x1a\x1b.organization.Organizations\x12U\n" +
+	"\x12DeleteOrganization\x12\x1c.organization.OrganizationId\x1a!.organization.DeletedOrganization\x12B\n" +
+	"\vListMembers\x12\x1c.organization.OrganizationId\x1a\x15.organization.Members\x12@\n" +
+	"\tSetMember\x12\x1b.organization.MemberRequest\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\fRemoveMember\x12\x1b.organization.MemberRequest\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\x16InviteEmergencyContact\x12\x18.emergency.InviteRequest\x1a\x10.emergency.Grant\x12@\n" +
+	"\x13ListEmergencyAccess\x12\x16.google.protobuf.Empty\x1a\x11.emergency.Grants\x12C\n" +
+	"\x15AcceptEmergencyAccess\x12\x18.emergency.AcceptRequest\x1a\x10.emergency.Grant\x12E\n" +
+	"\x16ConfirmEmergencyAccess\x12\x19.emergency.ConfirmRequest\x1a\x10.emergency.Grant\x12B\n" +
+	"\x16RequestEmergencyAccess\x12\x16.emergency.EmergencyId\x1a\x10.emergency.Grant\x12B\n" +
+	"\x16ApproveEmergencyAccess\x12\x16.emergency.EmergencyId\x1a\x10.emergency.Grant\x12A\n" +
+	"\x15RejectEmergencyAccess\x12\x16.emergency.EmergencyId\x1a\x10.emergency.Grant\x12G\n" +
+	"\x15RevokeEmergencyAccess\x12\x16.emergency.EmergencyId\x1a\x16.google.protobuf.Empty\x12<\n" +
+	"\x11GetEmergencyFiles\x12\x16.emergency.EmergencyId\x1a\x0f.file.ListFiles\x127\n" +
 	"\fGetUserFiles\x12\x16.google.protobuf.Empty\x1a\x0f.file.ListFiles\x126\n" +
 	"\n" +
 	"UploadFile\x12\x10.file.FileStream\x1a\x14.file.UploadResponse(\x01\x120\n" +
-	"\fDownloadFile\x12\f.file.FileId\x1a\x10.file.FileStream0\x01\x122\n" +
+	"\fDownloadFile\x12\f.file.FileId\x1a\x10.file.FileStream0\x01\x12E\n" +
+	"\x15DownloadEmergencyFile\x12\x18.emergency.EmergencyFile\x1a\x10.file.FileStream0\x01\x122\n" +
 	"\n" +
 	"DeleteFile\x12\f.file.FileId\x1a\x16.google.protobuf.EmptyB\n" +
 	"Z\b./;protob\x06proto3"
@@ -101,32 +131,122 @@ func file_internal_proto_gophkeeper_proto_rawDescGZIP() []byte {
 
 var file_internal_proto_gophkeeper_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_internal_proto_gophkeeper_proto_goTypes = []any{
-	(*ServicePublicKey)(nil), // 0: gophkeeper.ServicePublicKey
-	(*UserData)(nil),         // 1: user.UserData
-	(*empty.Empty)(nil),      // 2: google.protobuf.Empty
-	(*FileStream)(nil),       // 3: file.FileStream
-	(*FileId)(nil),           // 4: file.FileId
-	(*ListFiles)(nil),        // 5: file.ListFiles
-	(*UploadResponse)(nil),   // 6: file.UploadResponse
+	(*ServicePublicKey)(nil),          // 0: gophkeeper.ServicePublicKey
+	(*UserData)(nil),                  // 1: user.UserData
+	(*SecondFactorRequest)(nil),       // 2: user.SecondFactorRequest
+	(*RefreshTokenRequest)(nil),       // 3: user.RefreshTokenRequest
+	(*empty.Empty)(nil),               // 4: google.protobuf.Empty
+	(*SessionId)(nil),                 // 5: user.SessionId
+	(*SecondFactorCode)(nil),          // 6: user.SecondFactorCode
+	(*ChangePasswordRequest)(nil),     // 7: user.ChangePasswordRequest
+	(*DeleteAccountRequest)(nil),      // 8: user.DeleteAccountRequest
+	(*CreateApiTokenRequest)(nil),     // 9: user.CreateApiTokenRequest
+	(*ApiTokenId)(nil),                // 10: user.ApiTokenId
+	(*CreateOrganizationRequest)(nil), // 11: organization.CreateOrganizationRequest
+	(*OrganizationId)(nil),            // 12: organization.OrganizationId
+	(*MemberRequest)(nil),             // 13: organization.MemberRequest
+	(*InviteRequest)(nil),             // 14: emergency.InviteRequest
+	(*AcceptRequest)(nil),             // 15: emergency.AcceptRequest
+	(*ConfirmRequest)(nil),            // 16: emergency.ConfirmRequest
+	(*EmergencyId)(nil),               // 17: emergency.EmergencyId
+	(*FileStream)(nil),                // 18: file.FileStream
+	(*FileId)(nil),                    // 19: file.FileId
+	(*EmergencyFile)(nil),             // 20: emergency.EmergencyFile
+	(*Tokens)(nil),                    // 21: user.Tokens
+	(*Sessions)(nil),                  // 22: user.Sessions
+	(*RevokedSessions)(nil),           // 23: user.RevokedSessions
+	(*SecondFactorSecret)(nil),        // 24: user.SecondFactorSecret
+	(*RecoveryCodes)(nil),             // 25: user.RecoveryCodes
+	(*DeletedAccount)(nil),            // 26: user.DeletedAccount
+	(*ApiToken)(nil),                  // 27: user.ApiToken
+	(*ApiTokens)(nil),                 // 28: user.ApiTokens
+	(*Organization)(nil),              // 29: organization.Organization
+	(*Organizations)(nil),             // 30: organization.Organizations
+	(*DeletedOrganization)(nil),       // 31: organization.DeletedOrganization
+	(*Members)(nil),                   // 32: organization.Members
+	(*Grant)(nil),                     // 33: emergency.Grant
+	(*Grants)(nil),                    // 34: emergency.Grants
+	(*ListFiles)(nil),                 // 35: file.ListFiles
+	(*UploadResponse)(nil),            // 36: file.UploadResponse
 }
 var file_internal_proto_gophkeeper_proto_depIdxs = []int32{
-	1, // 0: gophkeeper.GophKeeperService.Register:input_type -> user.UserData
-	1, // 1: gophkeeper.GophKeeperService.Login:input_type -> user.UserData
-	2, // 2: gophkeeper.GophKeeperService.GetUserFiles:input_type -> google.protobuf.Empty
-	3, // 3: gophkeeper.GophKeeperService.UploadFile:input_type -> file.FileStream
-	4, // 4: gophkeeper.GophKeeperService.DownloadFile:input_type -> file.FileId
-	4, // 5: gophkeeper.GophKeeperService.DeleteFile:input_type -> file.FileId
-	0, // 6: gophkeeper.GophKeeperService.Register:output_type -> gophkeeper.ServicePublicKey
-	0, // 7: gophkeeper.GophKeeperService.Login:output_type -> gophkeeper.ServicePublicKey
-	5, // 8: gophkeeper.GophKeeperService.GetUserFiles:output_type -> file.ListFiles
-	6, // 9: gophkeeper.GophKeeperService.UploadFile:output_type -> file.UploadResponse
-	3, // 10: gophkeeper.GophKeeperService.DownloadFile:output_type -> file.FileStream
-	2, // 11: gophkeeper.GophKeeperService.DeleteFile:output_type -> google.protobuf.Empty
-	6, // [6:12] is the sub-list for method output_type
-	0, // [0:6] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1,  // 0: gophkeeper.GophKeeperService.Register:input_type -> user.UserData
+	1,  // 1: gophkeeper.GophKeeperService.Login:input_type -> user.UserData
+	2,  // 2: gophkeeper.GophKeeperService.VerifySecondFactor:input_type -> user.SecondFactorRequest
+	3,  // 3: gophkeeper.GophKeeperService.RefreshToken:input_type -> user.RefreshTokenRequest
+	4,  // 4: gophkeeper.GophKeeperService.Logout:input_type -> google.protobuf.Empty
+	4,  // 5: gophkeeper.GophKeeperService.ListSessions:input_type -> google.protobuf.Empty
+	5,  // 6: gophkeeper.GophKeeperService.RevokeSession:input_type -> user.SessionId
+	4,  // 7: gophkeeper.GophKeeperService.RevokeOtherSessions:input_type -> google.protobuf.Empty
+	4,  // 8: gophkeeper.GophKeeperService.EnableSecondFactor:input_type -> google.protobuf.Empty
+	6,  // 9: gophkeeper.GophKeeperService.ConfirmSecondFactor:input_type -> user.SecondFactorCode
+	6,  // 10: gophkeeper.GophKeeperService.DisableSecondFactor:input_type -> user.SecondFactorCode
+	7,  // 11: gophkeeper.GophKeeperService.ChangePassword:input_type -> user.ChangePasswordRequest
+	8,  // 12: gophkeeper.GophKeeperService.DeleteAccount:input_type -> user.DeleteAccountRequest
+	9,  // 13: gophkeeper.GophKeeperService.CreateApiToken:input_type -> user.CreateApiTokenRequest
+	4,  // 14: gophkeeper.GophKeeperService.ListApiTokens:input_type -> google.protobuf.Empty
+	10, // 15: gophkeeper.GophKeeperService.RevokeApiToken:input_type -> user.ApiTokenId
+	11, // 16: gophkeeper.GophKeeperService.CreateOrganization:input_type -> organization.CreateOrganizationRequest
+	4,  // 17: gophkeeper.GophKeeperService.ListOrganizations:input_type -> google.protobuf.Empty
+	12, // 18: gophkeeper.GophKeeperService.DeleteOrganization:input_type -> organization.OrganizationId
+	12, // 19: gophkeeper.GophKeeperService.ListMembers:input_type -> organization.OrganizationId
+	13, // 20: gophkeeper.GophKeeperService.SetMember:input_type -> organization.MemberRequest
+	13, // 21: gophkeeper.GophKeeperService.RemoveMember:input_type -> organization.MemberRequest
+	14, // 22: gophkeeper.GophKeeperService.InviteEmergencyContact:input_type -> emergency.InviteRequest
+	4,  // 23: gophkeeper.GophKeeperService.ListEmergencyAccess:input_type -> google.protobuf.Empty
+	15, // 24: gophkeeper.GophKeeperService.AcceptEmergencyAccess:input_type -> emergency.AcceptRequest
+	16, // 25: gophkeeper.GophKeeperService.ConfirmEmergencyAccess:input_type -> emergency.ConfirmRequest
+	17, // 26: gophkeeper.GophKeeperService.RequestEmergencyAccess:input_type -> emergency.EmergencyId
+	17, // 27: gophkeeper.GophKeeperService.ApproveEmergencyAccess:input_type -> emergency.EmergencyId
+	17, // 28: gophkeeper.GophKeeperService.RejectEmergencyAccess:input_type -> emergency.EmergencyId
+	17, // 29: gophkeeper.GophKeeperService.RevokeEmergencyAccess:input_type -> emergency.EmergencyId
+	17, // 30: gophkeeper.GophKeeperService.GetEmergencyFiles:input_type -> emergency.EmergencyId
+	4,  // 31: gophkeeper.GophKeeperService.GetUserFiles:input_type -> google.protobuf.Empty
+	18, // 32: gophkeeper.GophKeeperService.UploadFile:input_type -> file.FileStream
+	19, // 33: gophkeeper.GophKeeperService.DownloadFile:input_type -> file.FileId
+	20, // 34: gophkeeper.GophKeeperService.DownloadEmergencyFile:input_type -> emergency.EmergencyFile
+	19, // 35: gophkeeper.GophKeeperService.DeleteFile:input_type -> file.FileId
+	0,  // 36: gophkeeper.GophKeeperService.Register:output_type -> gophkeeper.ServicePublicKey
+	0,  // 37: gophkeeper.GophKeeperService.Login:output_type -> gophkeeper.ServicePublicKey
+	0,  // 38: gophkeeper.GophKeeperService.VerifySecondFactor:output_type -> gophkeeper.ServicePublicKey
+	21, // 39: gophkeeper.GophKeeperService.RefreshToken:output_type -> user.Tokens
+	4,  // 40: gophkeeper.GophKeeperService.Logout:output_type -> google.protobuf.Empty
+	22, // 41: gophkeeper.GophKeeperService.ListSessions:output_type -> user.Sessions
+	4,  // 42: gophkeeper.GophKeeperService.RevokeSession:output_type -> google.protobuf.Empty
+	23, // 43: gophkeeper.GophKeeperService.RevokeOtherSessions:output_type -> user.RevokedSessions
+	24, // 44: gophkeeper.GophKeeperService.EnableSecondFactor:output_type -> user.SecondFactorSecret
+	25, // 45: gophkeeper.GophKeeperService.ConfirmSecondFactor:output_type -> user.RecoveryCodes
+	4,  // 46: gophkeeper.GophKeeperService.DisableSecondFactor:output_type -> google.protobuf.Empty
+	23, // 47: gophkeeper.GophKeeperService.ChangePassword:output_type -> user.RevokedSessions
+	26, // 48: gophkeeper.GophKeeperService.DeleteAccount:output_type -> user.DeletedAccount
+	27, // 49: gophkeeper.GophKeeperService.CreateApiToken:output_type -> user.ApiToken
+	28, // 50: gophkeeper.GophKeeperService.ListApiTokens:output_type -> user.ApiTokens
+	4,  // 51: gophkeeper.GophKeeperService.RevokeApiToken:output_type -> google.protobuf.Empty
+	29, // 52: gophkeeper.GophKeeperService.CreateOrganization:output_type -> organization.Organization
+	30, // 53: gophkeeper.GophKeeperService.ListOrganizations:output_type -> organization.Organizations
+	31, // 54: gophkeeper.GophKeeperService.DeleteOrganization:output_type -> organization.DeletedOrganization
+	32, // 55: gophkeeper.GophKeeperService.ListMembers:output_type -> organization.Members
+	4,  // 56: gophkeeper.GophKeeperService.SetMember:output_type -> google.protobuf.Empty
+	4,  // 57: gophkeeper.GophKeeperService.RemoveMember:output_type -> google.protobuf.Empty
+	33, // 58: gophkeeper.GophKeeperService.InviteEmergencyContact:output_type -> emergency.Grant
+	34, // 59: gophkeeper.GophKeeperService.ListEmergencyAccess:output_type -> emergency.Grants
+	33, // 60: gophkeeper.GophKeeperService.AcceptEmergencyAccess:output_type -> emergency.Grant
+	33, // 61: gophkeeper.GophKeeperService.ConfirmEmergencyAccess:output_type -> emergency.Grant
+	33, // 62: gophkeeper.GophKeeperService.RequestEmergencyAccess:output_type -> emergency.Grant
+	33, // 63: gophkeeper.GophKeeperService.ApproveEmergencyAccess:output_type -> emergency.Grant
+	33, // 64: gophkeeper.GophKeeperService.RejectEmergencyAccess:output_type -> emergency.Grant
+	4,  // 65: gophkeeper.GophKeeperService.RevokeEmergencyAccess:output_type -> google.protobuf.Empty
+	35, // 66: gophkeeper.GophKeeperService.GetEmergencyFiles:output_type -> file.ListFiles
+	35, // 67: gophkeeper.GophKeeperService.GetUserFiles:output_type -> file.ListFiles
+	36, // 68: gophkeeper.GophKeeperService.UploadFile:output_type -> file.UploadResponse
+	18, // 69: gophkeeper.GophKeeperService.DownloadFile:output_type -> file.FileStream
+	18, // 70: gophkeeper.GophKeeperService.DownloadEmergencyFile:output_type -> file.FileStream
+	4,  // 71: gophkeeper.GophKeeperService.DeleteFile:output_type -> google.protobuf.Empty
+	36, // [36:72] is the sub-list for method output_type
+	0,  // [0:36] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_gophkeeper_proto_init() }
@@ -136,6 +256,8 @@ func file_internal_proto_gophkeeper_proto_init() {
 	}
 	file_internal_proto_user_proto_init()
 	file_internal_proto_file_proto_init()
+	file_internal_proto_organization_proto_init()
+	file_internal_proto_emergency_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
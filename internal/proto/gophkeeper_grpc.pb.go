@@ -20,12 +20,42 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	GophKeeperService_Register_FullMethodName     = "/gophkeeper.GophKeeperService/Register"
-	GophKeeperService_Login_FullMethodName        = "/gophkeeper.GophKeeperService/Login"
-	GophKeeperService_GetUserFiles_FullMethodName = "/gophkeeper.GophKeeperService/GetUserFiles"
-	GophKeeperService_UploadFile_FullMethodName   = "/gophkeeper.GophKeeperService/UploadFile"
-	GophKeeperService_DownloadFile_FullMethodName = "/gophkeeper.GophKeeperService/DownloadFile"
-	GophKeeperService_DeleteFile_FullMethodName   = "/gophkeeper.GophKeeperService/DeleteFile"
+	GophKeeperService_Register_FullMethodName               = "/gophkeeper.GophKeeperService/Register"
+	GophKeeperService_Login_FullMethodName                  = "/gophkeeper.GophKeeperService/Login"
+	GophKeeperService_VerifySecondFactor_FullMethodName     = "/gophkeeper.GophKeeperService/VerifySecondFactor"
+	GophKeeperService_RefreshToken_FullMethodName           = "/gophkeeper.GophKeeperService/RefreshToken"
+	GophKeeperService_Logout_FullMethodName                 = "/gophkeeper.GophKeeperService/Logout"
+	GophKeeperService_ListSessions_FullMethodName           = "/gophkeeper.GophKeeperService/ListSessions"
+	GophKeeperService_RevokeSession_FullMethodName          = "/gophkeeper.GophKeeperService/RevokeSession"
+	GophKeeperService_RevokeOtherSessions_FullMethodName    = "/gophkeeper.GophKeeperService/RevokeOtherSessions"
+	GophKeeperService_EnableSecondFactor_FullMethodName     = "/gophkeeper.GophKeeperService/EnableSecondFactor"
+	GophKeeperService_ConfirmSecondFactor_FullMethodName    = "/gophkeeper.GophKeeperService/ConfirmSecondFactor"
+	GophKeeperService_DisableSecondFactor_FullMethodName    = "/gophkeeper.GophKeeperService/DisableSecondFactor"
+	GophKeeperService_ChangePassword_FullMethodName         = "/gophkeeper.GophKeeperService/ChangePassword"
+	GophKeeperService_DeleteAccount_FullMethodName          = "/gophkeeper.GophKeeperService/DeleteAccount"
+	GophKeeperService_CreateApiToken_FullMethodName         = "/gophkeeper.GophKeeperService/CreateApiToken"
+	GophKeeperService_ListApiTokens_FullMethodName          = "/gophkeeper.GophKeeperService/ListApiTokens"
+	GophKeeperService_RevokeApiToken_FullMethodName         = "/gophkeeper.GophKeeperService/RevokeApiToken"
+	GophKeeperService_CreateOrganization_FullMethodName     = "/gophkeeper.GophKeeperService/CreateOrganization"
+	GophKeeperService_ListOrganizations_FullMethodName      = "/gophkeeper.GophKeeperService/ListOrganizations"
+	GophKeeperService_DeleteOrganization_FullMethodName     = "/gophkeeper.GophKeeperService/DeleteOrganization"
+	GophKeeperService_ListMembers_FullMethodName            = "/gophkeeper.GophKeeperService/ListMembers"
+	GophKeeperService_SetMember_FullMethodName              = "/gophkeeper.GophKeeperService/SetMember"
+	GophKeeperService_RemoveMember_FullMethodName           = "/gophkeeper.GophKeeperService/RemoveMember"
+	GophKeeperService_InviteEmergencyContact_FullMethodName = "/gophkeeper.GophKeeperService/InviteEmergencyContact"
+	GophKeeperService_ListEmergencyAccess_FullMethodName    = "/gophkeeper.GophKeeperService/ListEmergencyAccess"
+	GophKeeperService_AcceptEmergencyAccess_FullMethodName  = "/gophkeeper.GophKeeperService/AcceptEmergencyAccess"
+	GophKeeperService_ConfirmEmergencyAccess_FullMethodName = "/gophkeeper.GophKeeperService/ConfirmEmergencyAccess"
+	GophKeeperService_RequestEmergencyAccess_FullMethodName = "/gophkeeper.GophKeeperService/RequestEmergencyAccess"
+	GophKeeperService_ApproveEmergencyAccess_FullMethodName = "/gophkeeper.GophKeeperService/ApproveEmergencyAccess"
+	GophKeeperService_RejectEmergencyAccess_FullMethodName  = "/gophkeeper.GophKeeperService/RejectEmergencyAccess"
+	GophKeeperService_RevokeEmergencyAccess_FullMethodName  = "/gophkeeper.GophKeeperService/RevokeEmergencyAccess"
+	GophKeeperService_GetEmergencyFiles_FullMethodName      = "/gophkeeper.GophKeeperService/GetEmergencyFiles"
+	GophKeeperService_GetUserFiles_FullMethodName           = "/gophkeeper.GophKeeperService/GetUserFiles"
+	GophKeeperService_UploadFile_FullMethodName             = "/gophkeeper.GophKeeperService/UploadFile"
+	GophKeeperService_DownloadFile_FullMethodName           = "/gophkeeper.GophKeeperService/DownloadFile"
+	GophKeeperService_DownloadEmergencyFile_FullMethodName  = "/gophkeeper.GophKeeperService/DownloadEmergencyFile"
+	GophKeeperService_DeleteFile_FullMethodName             = "/gophkeeper.GophKeeperService/DeleteFile"
 )
 
 // GophKeeperServiceClient is the client API for GophKeeperService service.
@@ -34,9 +64,39 @@ const (
 type GophKeeperServiceClient interface {
 	Register(ctx context.Context, in *UserData, opts ...grpc.CallOption) (*ServicePublicKey, error)
 	Login(ctx context.Context, in *UserData, opts ...grpc.CallOption) (*ServicePublicKey, error)
+	VerifySecondFactor(ctx context.Context, in *SecondFactorRequest, opts ...grpc.CallOption) (*ServicePublicKey, error)
+	RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*Tokens, error)
+	Logout(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error)
+	ListSessions(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Sessions, error)
+	RevokeSession(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*empty.Empty, error)
+	RevokeOtherSessions(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RevokedSessions, error)
+	EnableSecondFactor(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SecondFactorSecret, error)
+	ConfirmSecondFactor(ctx context.Context, in *SecondFactorCode, opts ...grpc.CallOption) (*RecoveryCodes, error)
+	DisableSecondFactor(ctx context.Context, in *SecondFactorCode, opts ...grpc.CallOption) (*empty.Empty, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*RevokedSessions, error)
+	DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeletedAccount, error)
+	CreateApiToken(ctx context.Context, in *CreateApiTokenRequest, opts ...grpc.CallOption) (*ApiToken, error)
+	ListApiTokens(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ApiTokens, error)
+	RevokeApiToken(ctx context.Context, in *ApiTokenId, opts ...grpc.CallOption) (*empty.Empty, error)
+	CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error)
+	ListOrganizations(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Organizations, error)
+	DeleteOrganization(ctx context.Context, in *OrganizationId, opts ...grpc.CallOption) (*DeletedOrganization, error)
+	ListMembers(ctx context.Context, in *OrganizationId, opts ...grpc.CallOption) (*Members, error)
+	SetMember(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	RemoveMember(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	InviteEmergencyContact(ctx context.Context, in *InviteRequest, opts ...grpc.CallOption) (*Grant, error)
+	ListEmergencyAccess(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Grants, error)
+	AcceptEmergencyAccess(ctx context.Context, in *AcceptRequest, opts ...grpc.CallOption) (*Grant, error)
+	ConfirmEmergencyAccess(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*Grant, error)
+	RequestEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*Grant, error)
+	ApproveEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*Grant, error)
+	RejectEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*Grant, error)
+	RevokeEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetEmergencyFiles(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*ListFiles, error)
 	GetUserFiles(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ListFiles, error)
 	UploadFile(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileStream, UploadResponse], error)
 	DownloadFile(ctx context.Context, in *FileId, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileStream], error)
+	DownloadEmergencyFile(ctx context.Context, in *EmergencyFile, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileStream], error)
 	DeleteFile(ctx context.Context, in *FileId, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
@@ -68,6 +128,296 @@ func (c *gophKeeperServiceClient) Login(ctx context.Context, in *UserData, opts
 	return out, nil
 }
 
+func (c *gophKeeperServiceClient) VerifySecondFactor(ctx context.Context, in *SecondFactorRequest, opts ...grpc.CallOption) (*ServicePublicKey, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ServicePublicKey)
+	err := c.cc.Invoke(ctx, GophKeeperService_VerifySecondFactor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*Tokens, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, GophKeeperService_RefreshToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) Logout(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ListSessions(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Sessions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Sessions)
+	err := c.cc.Invoke(ctx, GophKeeperService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RevokeSession(ctx context.Context, in *SessionId, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_RevokeSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RevokeOtherSessions(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*RevokedSessions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokedSessions)
+	err := c.cc.Invoke(ctx, GophKeeperService_RevokeOtherSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) EnableSecondFactor(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*SecondFactorSecret, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SecondFactorSecret)
+	err := c.cc.Invoke(ctx, GophKeeperService_EnableSecondFactor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ConfirmSecondFactor(ctx context.Context, in *SecondFactorCode, opts ...grpc.CallOption) (*RecoveryCodes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecoveryCodes)
+	err := c.cc.Invoke(ctx, GophKeeperService_ConfirmSecondFactor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) DisableSecondFactor(ctx context.Context, in *SecondFactorCode, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_DisableSecondFactor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*RevokedSessions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokedSessions)
+	err := c.cc.Invoke(ctx, GophKeeperService_ChangePassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) DeleteAccount(ctx context.Context, in *DeleteAccountRequest, opts ...grpc.CallOption) (*DeletedAccount, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletedAccount)
+	err := c.cc.Invoke(ctx, GophKeeperService_DeleteAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) CreateApiToken(ctx context.Context, in *CreateApiTokenRequest, opts ...grpc.CallOption) (*ApiToken, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApiToken)
+	err := c.cc.Invoke(ctx, GophKeeperService_CreateApiToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ListApiTokens(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ApiTokens, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApiTokens)
+	err := c.cc.Invoke(ctx, GophKeeperService_ListApiTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RevokeApiToken(ctx context.Context, in *ApiTokenId, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_RevokeApiToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) CreateOrganization(ctx context.Context, in *CreateOrganizationRequest, opts ...grpc.CallOption) (*Organization, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Organization)
+	err := c.cc.Invoke(ctx, GophKeeperService_CreateOrganization_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ListOrganizations(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Organizations, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Organizations)
+	err := c.cc.Invoke(ctx, GophKeeperService_ListOrganizations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) DeleteOrganization(ctx context.Context, in *OrganizationId, opts ...grpc.CallOption) (*DeletedOrganization, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletedOrganization)
+	err := c.cc.Invoke(ctx, GophKeeperService_DeleteOrganization_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ListMembers(ctx context.Context, in *OrganizationId, opts ...grpc.CallOption) (*Members, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Members)
+	err := c.cc.Invoke(ctx, GophKeeperService_ListMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) SetMember(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_SetMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RemoveMember(ctx context.Context, in *MemberRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_RemoveMember_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) InviteEmergencyContact(ctx context.Context, in *InviteRequest, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, GophKeeperService_InviteEmergencyContact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ListEmergencyAccess(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*Grants, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grants)
+	err := c.cc.Invoke(ctx, GophKeeperService_ListEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) AcceptEmergencyAccess(ctx context.Context, in *AcceptRequest, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, GophKeeperService_AcceptEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ConfirmEmergencyAccess(ctx context.Context, in *ConfirmRequest, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, GophKeeperService_ConfirmEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RequestEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, GophKeeperService_RequestEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) ApproveEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, GophKeeperService_ApproveEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RejectEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*Grant, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Grant)
+	err := c.cc.Invoke(ctx, GophKeeperService_RejectEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) RevokeEmergencyAccess(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_RevokeEmergencyAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) GetEmergencyFiles(ctx context.Context, in *EmergencyId, opts ...grpc.CallOption) (*ListFiles, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListFiles)
+	err := c.cc.Invoke(ctx, GophKeeperService_GetEmergencyFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *gophKeeperServiceClient) GetUserFiles(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*ListFiles, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListFiles)
@@ -75,142 +425,803 @@ func (c *gophKeeperServiceClient) GetUserFiles(ctx context.Context, in *empty.Em
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	return out, nil
+}
+
+func (c *gophKeeperServiceClient) UploadFile(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileStream, UploadResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GophKeeperService_ServiceDesc.Streams[0], GophKeeperService_UploadFile_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileStream, UploadResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GophKeeperService_UploadFileClient = grpc.ClientStreamingClient[FileStream, UploadResponse]
+
+func (c *gophKeeperServiceClient) DownloadFile(ctx context.Context, in *FileId, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileStream], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GophKeeperService_ServiceDesc.Streams[1], GophKeeperService_DownloadFile_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileId, FileStream]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GophKeeperService_DownloadFileClient = grpc.ServerStreamingClient[FileStream]
+
+func (c *gophKeeperServiceClient) DownloadEmergencyFile(ctx context.Context, in *EmergencyFile, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileStream], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GophKeeperService_ServiceDesc.Streams[2], GophKeeperService_DownloadEmergencyFile_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EmergencyFile, FileStream]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GophKeeperService_DownloadEmergencyFileClient = grpc.ServerStreamingClient[FileStream]
+
+func (c *gophKeeperServiceClient) DeleteFile(ctx context.Context, in *FileId, opts ...grpc.CallOption) (*empty.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, GophKeeperService_DeleteFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GophKeeperServiceServer is the server API for GophKeeperService service.
+// All implementations must embed UnimplementedGophKeeperServiceServer
+// for forward compatibility.
+type GophKeeperServiceServer interface {
+	Register(context.Context, *UserData) (*ServicePublicKey, error)
+	Login(context.Context, *UserData) (*ServicePublicKey, error)
+	VerifySecondFactor(context.Context, *SecondFactorRequest) (*ServicePublicKey, error)
+	RefreshToken(context.Context, *RefreshTokenRequest) (*Tokens, error)
+	Logout(context.Context, *empty.Empty) (*empty.Empty, error)
+	ListSessions(context.Context, *empty.Empty) (*Sessions, error)
+	RevokeSession(context.Context, *SessionId) (*empty.Empty, error)
+	RevokeOtherSessions(context.Context, *empty.Empty) (*RevokedSessions, error)
+	EnableSecondFactor(context.Context, *empty.Empty) (*SecondFactorSecret, error)
+	ConfirmSecondFactor(context.Context, *SecondFactorCode) (*RecoveryCodes, error)
+	DisableSecondFactor(context.Context, *SecondFactorCode) (*empty.Empty, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*RevokedSessions, error)
+	DeleteAccount(context.Context, *DeleteAccountRequest) (*DeletedAccount, error)
+	CreateApiToken(context.Context, *CreateApiTokenRequest) (*ApiToken, error)
+	ListApiTokens(context.Context, *empty.Empty) (*ApiTokens, error)
+	RevokeApiToken(context.Context, *ApiTokenId) (*empty.Empty, error)
+	CreateOrganization(context.Context, *CreateOrganizationRequest) (*Organization, error)
+	ListOrganizations(context.Context, *empty.Empty) (*Organizations, error)
+	DeleteOrganization(context.Context, *OrganizationId) (*DeletedOrganization, error)
+	ListMembers(context.Context, *OrganizationId) (*Members, error)
+	SetMember(context.Context, *MemberRequest) (*empty.Empty, error)
+	RemoveMember(context.Context, *MemberRequest) (*empty.Empty, error)
+	InviteEmergencyContact(context.Context, *InviteRequest) (*Grant, error)
+	ListEmergencyAccess(context.Context, *empty.Empty) (*Grants, error)
+	AcceptEmergencyAccess(context.Context, *AcceptRequest) (*Grant, error)
+	ConfirmEmergencyAccess(context.Context, *ConfirmRequest) (*Grant, error)
+	RequestEmergencyAccess(context.Context, *EmergencyId) (*Grant, error)
+	ApproveEmergencyAccess(context.Context, *EmergencyId) (*Grant, error)
+	RejectEmergencyAccess(context.Context, *EmergencyId) (*Grant, error)
+	RevokeEmergencyAccess(context.Context, *EmergencyId) (*empty.Empty, error)
+	GetEmergencyFiles(context.Context, *EmergencyId) (*ListFiles, error)
+	GetUserFiles(context.Context, *empty.Empty) (*ListFiles, error)
+	UploadFile(grpc.ClientStreamingServer[FileStream, UploadResponse]) error
+	DownloadFile(*FileId, grpc.ServerStreamingServer[FileStream]) error
+	DownloadEmergencyFile(*EmergencyFile, grpc.ServerStreamingServer[FileStream]) error
+	DeleteFile(context.Context, *FileId) (*empty.Empty, error)
+	mustEmbedUnimplementedGophKeeperServiceServer()
+}
+
+// UnimplementedGophKeeperServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGophKeeperServiceServer struct{}
+
+func (UnimplementedGophKeeperServiceServer) Register(context.Context, *UserData) (*ServicePublicKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) Login(context.Context, *UserData) (*ServicePublicKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) VerifySecondFactor(context.Context, *SecondFactorRequest) (*ServicePublicKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifySecondFactor not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RefreshToken(context.Context, *RefreshTokenRequest) (*Tokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshToken not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) Logout(context.Context, *empty.Empty) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ListSessions(context.Context, *empty.Empty) (*Sessions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RevokeSession(context.Context, *SessionId) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RevokeOtherSessions(context.Context, *empty.Empty) (*RevokedSessions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeOtherSessions not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) EnableSecondFactor(context.Context, *empty.Empty) (*SecondFactorSecret, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableSecondFactor not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ConfirmSecondFactor(context.Context, *SecondFactorCode) (*RecoveryCodes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmSecondFactor not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) DisableSecondFactor(context.Context, *SecondFactorCode) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableSecondFactor not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ChangePassword(context.Context, *ChangePasswordRequest) (*RevokedSessions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) DeleteAccount(context.Context, *DeleteAccountRequest) (*DeletedAccount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAccount not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) CreateApiToken(context.Context, *CreateApiTokenRequest) (*ApiToken, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateApiToken not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ListApiTokens(context.Context, *empty.Empty) (*ApiTokens, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListApiTokens not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RevokeApiToken(context.Context, *ApiTokenId) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeApiToken not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) CreateOrganization(context.Context, *CreateOrganizationRequest) (*Organization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateOrganization not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ListOrganizations(context.Context, *empty.Empty) (*Organizations, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrganizations not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) DeleteOrganization(context.Context, *OrganizationId) (*DeletedOrganization, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteOrganization not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ListMembers(context.Context, *OrganizationId) (*Members, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMembers not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) SetMember(context.Context, *MemberRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMember not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RemoveMember(context.Context, *MemberRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveMember not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) InviteEmergencyContact(context.Context, *InviteRequest) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InviteEmergencyContact not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ListEmergencyAccess(context.Context, *empty.Empty) (*Grants, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) AcceptEmergencyAccess(context.Context, *AcceptRequest) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ConfirmEmergencyAccess(context.Context, *ConfirmRequest) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RequestEmergencyAccess(context.Context, *EmergencyId) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) ApproveEmergencyAccess(context.Context, *EmergencyId) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RejectEmergencyAccess(context.Context, *EmergencyId) (*Grant, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) RevokeEmergencyAccess(context.Context, *EmergencyId) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeEmergencyAccess not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) GetEmergencyFiles(context.Context, *EmergencyId) (*ListFiles, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEmergencyFiles not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) GetUserFiles(context.Context, *empty.Empty) (*ListFiles, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserFiles not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) UploadFile(grpc.ClientStreamingServer[FileStream, UploadResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method UploadFile not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) DownloadFile(*FileId, grpc.ServerStreamingServer[FileStream]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadFile not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) DownloadEmergencyFile(*EmergencyFile, grpc.ServerStreamingServer[FileStream]) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadEmergencyFile not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) DeleteFile(context.Context, *FileId) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+}
+func (UnimplementedGophKeeperServiceServer) mustEmbedUnimplementedGophKeeperServiceServer() {}
+func (UnimplementedGophKeeperServiceServer) testEmbeddedByValue()                           {}
+
+// UnsafeGophKeeperServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GophKeeperServiceServer will
+// result in compilation errors.
+type UnsafeGophKeeperServiceServer interface {
+	mustEmbedUnimplementedGophKeeperServiceServer()
+}
+
+func RegisterGophKeeperServiceServer(s grpc.ServiceRegistrar, srv GophKeeperServiceServer) {
+	// If the following call pancis, it indicates UnimplementedGophKeeperServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GophKeeperService_ServiceDesc, srv)
+}
+
+func _GophKeeperService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).Register(ctx, req.(*UserData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).Login(ctx, req.(*UserData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_VerifySecondFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecondFactorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).VerifySecondFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_VerifySecondFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).VerifySecondFactor(ctx, req.(*SecondFactorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RefreshToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RefreshToken(ctx, req.(*RefreshTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).Logout(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ListSessions(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RevokeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RevokeSession(ctx, req.(*SessionId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_RevokeOtherSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RevokeOtherSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RevokeOtherSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RevokeOtherSessions(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_EnableSecondFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).EnableSecondFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_EnableSecondFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).EnableSecondFactor(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_ConfirmSecondFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecondFactorCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ConfirmSecondFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ConfirmSecondFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ConfirmSecondFactor(ctx, req.(*SecondFactorCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_DisableSecondFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecondFactorCode)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).DisableSecondFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_DisableSecondFactor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).DisableSecondFactor(ctx, req.(*SecondFactorCode))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_DeleteAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).DeleteAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_DeleteAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).DeleteAccount(ctx, req.(*DeleteAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_CreateApiToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateApiTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).CreateApiToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_CreateApiToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).CreateApiToken(ctx, req.(*CreateApiTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_ListApiTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ListApiTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ListApiTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ListApiTokens(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_RevokeApiToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApiTokenId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RevokeApiToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RevokeApiToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RevokeApiToken(ctx, req.(*ApiTokenId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_CreateOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateOrganizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).CreateOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_CreateOrganization_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).CreateOrganization(ctx, req.(*CreateOrganizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_ListOrganizations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ListOrganizations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ListOrganizations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ListOrganizations(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_DeleteOrganization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrganizationId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).DeleteOrganization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_DeleteOrganization_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).DeleteOrganization(ctx, req.(*OrganizationId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeperService_ListMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OrganizationId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ListMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ListMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ListMembers(ctx, req.(*OrganizationId))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *gophKeeperServiceClient) UploadFile(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileStream, UploadResponse], error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &GophKeeperService_ServiceDesc.Streams[0], GophKeeperService_UploadFile_FullMethodName, cOpts...)
-	if err != nil {
+func _GophKeeperService_SetMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MemberRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[FileStream, UploadResponse]{ClientStream: stream}
-	return x, nil
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).SetMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_SetMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).SetMember(ctx, req.(*MemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type GophKeeperService_UploadFileClient = grpc.ClientStreamingClient[FileStream, UploadResponse]
-
-func (c *gophKeeperServiceClient) DownloadFile(ctx context.Context, in *FileId, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileStream], error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &GophKeeperService_ServiceDesc.Streams[1], GophKeeperService_DownloadFile_FullMethodName, cOpts...)
-	if err != nil {
+func _GophKeeperService_RemoveMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MemberRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[FileId, FileStream]{ClientStream: stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RemoveMember(ctx, in)
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RemoveMember_FullMethodName,
 	}
-	return x, nil
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RemoveMember(ctx, req.(*MemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type GophKeeperService_DownloadFileClient = grpc.ServerStreamingClient[FileStream]
-
-func (c *gophKeeperServiceClient) DeleteFile(ctx context.Context, in *FileId, opts ...grpc.CallOption) (*empty.Empty, error) {
-	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, GophKeeperService_DeleteFile_FullMethodName, in, out, cOpts...)
-	if err != nil {
+func _GophKeeperService_InviteEmergencyContact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).InviteEmergencyContact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_InviteEmergencyContact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).InviteEmergencyContact(ctx, req.(*InviteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// GophKeeperServiceServer is the server API for GophKeeperService service.
-// All implementations must embed UnimplementedGophKeeperServiceServer
-// for forward compatibility.
-type GophKeeperServiceServer interface {
-	Register(context.Context, *UserData) (*ServicePublicKey, error)
-	Login(context.Context, *UserData) (*ServicePublicKey, error)
-	GetUserFiles(context.Context, *empty.Empty) (*ListFiles, error)
-	UploadFile(grpc.ClientStreamingServer[FileStream, UploadResponse]) error
-	DownloadFile(*FileId, grpc.ServerStreamingServer[FileStream]) error
-	DeleteFile(context.Context, *FileId) (*empty.Empty, error)
-	mustEmbedUnimplementedGophKeeperServiceServer()
+func _GophKeeperService_ListEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ListEmergencyAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ListEmergencyAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ListEmergencyAccess(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// UnimplementedGophKeeperServiceServer must be embedded to have
-// forward compatible implementations.
-//
-// NOTE: this should be embedded by value instead of pointer to avoid a nil
-// pointer dereference when methods are called.
-type UnimplementedGophKeeperServiceServer struct{}
-
-func (UnimplementedGophKeeperServiceServer) Register(context.Context, *UserData) (*ServicePublicKey, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
-}
-func (UnimplementedGophKeeperServiceServer) Login(context.Context, *UserData) (*ServicePublicKey, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
-}
-func (UnimplementedGophKeeperServiceServer) GetUserFiles(context.Context, *empty.Empty) (*ListFiles, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetUserFiles not implemented")
-}
-func (UnimplementedGophKeeperServiceServer) UploadFile(grpc.ClientStreamingServer[FileStream, UploadResponse]) error {
-	return status.Errorf(codes.Unimplemented, "method UploadFile not implemented")
+func _GophKeeperService_AcceptEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).AcceptEmergencyAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_AcceptEmergencyAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).AcceptEmergencyAccess(ctx, req.(*AcceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServiceServer) DownloadFile(*FileId, grpc.ServerStreamingServer[FileStream]) error {
-	return status.Errorf(codes.Unimplemented, "method DownloadFile not implemented")
+
+func _GophKeeperService_ConfirmEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ConfirmEmergencyAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ConfirmEmergencyAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ConfirmEmergencyAccess(ctx, req.(*ConfirmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServiceServer) DeleteFile(context.Context, *FileId) (*empty.Empty, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteFile not implemented")
+
+func _GophKeeperService_RequestEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmergencyId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RequestEmergencyAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RequestEmergencyAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RequestEmergencyAccess(ctx, req.(*EmergencyId))
+	}
+	return interceptor(ctx, in, info, handler)
 }
-func (UnimplementedGophKeeperServiceServer) mustEmbedUnimplementedGophKeeperServiceServer() {}
-func (UnimplementedGophKeeperServiceServer) testEmbeddedByValue()                           {}
 
-// UnsafeGophKeeperServiceServer may be embedded to opt out of forward compatibility for this service.
-// Use of this interface is not recommended, as added methods to GophKeeperServiceServer will
-// result in compilation errors.
-type UnsafeGophKeeperServiceServer interface {
-	mustEmbedUnimplementedGophKeeperServiceServer()
+func _GophKeeperService_ApproveEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmergencyId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).ApproveEmergencyAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_ApproveEmergencyAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).ApproveEmergencyAccess(ctx, req.(*EmergencyId))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterGophKeeperServiceServer(s grpc.ServiceRegistrar, srv GophKeeperServiceServer) {
-	// If the following call pancis, it indicates UnimplementedGophKeeperServiceServer was
-	// embedded by pointer and is nil.  This will cause panics if an
-	// unimplemented method is ever invoked, so we test this at initialization
-	// time to prevent it from happening at runtime later due to I/O.
-	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
-		t.testEmbeddedByValue()
+func _GophKeeperService_RejectEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmergencyId)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	s.RegisterService(&GophKeeperService_ServiceDesc, srv)
+	if interceptor == nil {
+		return srv.(GophKeeperServiceServer).RejectEmergencyAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeperService_RejectEmergencyAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServiceServer).RejectEmergencyAccess(ctx, req.(*EmergencyId))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserData)
+func _GophKeeperService_RevokeEmergencyAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmergencyId)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServiceServer).Register(ctx, in)
+		return srv.(GophKeeperServiceServer).RevokeEmergencyAccess(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: GophKeeperService_Register_FullMethodName,
+		FullMethod: GophKeeperService_RevokeEmergencyAccess_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServiceServer).Register(ctx, req.(*UserData))
+		return srv.(GophKeeperServiceServer).RevokeEmergencyAccess(ctx, req.(*EmergencyId))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _GophKeeperService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserData)
+func _GophKeeperService_GetEmergencyFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmergencyId)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(GophKeeperServiceServer).Login(ctx, in)
+		return srv.(GophKeeperServiceServer).GetEmergencyFiles(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: GophKeeperService_Login_FullMethodName,
+		FullMethod: GophKeeperService_GetEmergencyFiles_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(GophKeeperServiceServer).Login(ctx, req.(*UserData))
+		return srv.(GophKeeperServiceServer).GetEmergencyFiles(ctx, req.(*EmergencyId))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -251,6 +1262,17 @@ func _GophKeeperService_DownloadFile_Handler(srv interface{}, stream grpc.Server
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type GophKeeperService_DownloadFileServer = grpc.ServerStreamingServer[FileStream]
 
+func _GophKeeperService_DownloadEmergencyFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EmergencyFile)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServiceServer).DownloadEmergencyFile(m, &grpc.GenericServerStream[EmergencyFile, FileStream]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GophKeeperService_DownloadEmergencyFileServer = grpc.ServerStreamingServer[FileStream]
+
 func _GophKeeperService_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(FileId)
 	if err := dec(in); err != nil {
@@ -284,6 +1306,122 @@ var GophKeeperService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Login",
 			Handler:    _GophKeeperService_Login_Handler,
 		},
+		{
+			MethodName: "VerifySecondFactor",
+			Handler:    _GophKeeperService_VerifySecondFactor_Handler,
+		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _GophKeeperService_RefreshToken_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _GophKeeperService_Logout_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _GophKeeperService_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _GophKeeperService_RevokeSession_Handler,
+		},
+		{
+			MethodName: "RevokeOtherSessions",
+			Handler:    _GophKeeperService_RevokeOtherSessions_Handler,
+		},
+		{
+			MethodName: "EnableSecondFactor",
+			Handler:    _GophKeeperService_EnableSecondFactor_Handler,
+		},
+		{
+			MethodName: "ConfirmSecondFactor",
+			Handler:    _GophKeeperService_ConfirmSecondFactor_Handler,
+		},
+		{
+			MethodName: "DisableSecondFactor",
+			Handler:    _GophKeeperService_DisableSecondFactor_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _GophKeeperService_ChangePassword_Handler,
+		},
+		{
+			MethodName: "DeleteAccount",
+			Handler:    _GophKeeperService_DeleteAccount_Handler,
+		},
+		{
+			MethodName: "CreateApiToken",
+			Handler:    _GophKeeperService_CreateApiToken_Handler,
+		},
+		{
+			MethodName: "ListApiTokens",
+			Handler:    _GophKeeperService_ListApiTokens_Handler,
+		},
+		{
+			MethodName: "RevokeApiToken",
+			Handler:    _GophKeeperService_RevokeApiToken_Handler,
+		},
+		{
+			MethodName: "CreateOrganization",
+			Handler:    _GophKeeperService_CreateOrganization_Handler,
+		},
+		{
+			MethodName: "ListOrganizations",
+			Handler:    _GophKeeperService_ListOrganizations_Handler,
+		},
+		{
+			MethodName: "DeleteOrganization",
+			Handler:    _GophKeeperService_DeleteOrganization_Handler,
+		},
+		{
+			MethodName: "ListMembers",
+			Handler:    _GophKeeperService_ListMembers_Handler,
+		},
+		{
+			MethodName: "SetMember",
+			Handler:    _GophKeeperService_SetMember_Handler,
+		},
+		{
+			MethodName: "RemoveMember",
+			Handler:    _GophKeeperService_RemoveMember_Handler,
+		},
+		{
+			MethodName: "InviteEmergencyContact",
+			Handler:    _GophKeeperService_InviteEmergencyContact_Handler,
+		},
+		{
+			MethodName: "ListEmergencyAccess",
+			Handler:    _GophKeeperService_ListEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "AcceptEmergencyAccess",
+			Handler:    _GophKeeperService_AcceptEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "ConfirmEmergencyAccess",
+			Handler:    _GophKeeperService_ConfirmEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "RequestEmergencyAccess",
+			Handler:    _GophKeeperService_RequestEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "ApproveEmergencyAccess",
+			Handler:    _GophKeeperService_ApproveEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "RejectEmergencyAccess",
+			Handler:    _GophKeeperService_RejectEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "RevokeEmergencyAccess",
+			Handler:    _GophKeeperService_RevokeEmergencyAccess_Handler,
+		},
+		{
+			MethodName: "GetEmergencyFiles",
+			Handler:    _GophKeeperService_GetEmergencyFiles_Handler,
+		},
 		{
 			MethodName: "GetUserFiles",
 			Handler:    _GophKeeperService_GetUserFiles_Handler,
@@ -304,6 +1442,11 @@ var GophKeeperService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _GophKeeperService_DownloadFile_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "DownloadEmergencyFile",
+			Handler:       _GophKeeperService_DownloadEmergencyFile_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "internal/proto/gophkeeper.proto",
 }
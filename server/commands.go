@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/backends"
+	"github.com/valinurovdenis/gophkeeper/internal/app/backup"
+	"github.com/valinurovdenis/gophkeeper/internal/app/certs"
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
+	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/fsck"
+	"github.com/valinurovdenis/gophkeeper/internal/app/keyrotation"
+)
+
+// Server maintenance subcommand.
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+// Subcommands given after server flags, e.g. ./server -x {dsn} fsck --repair.
+var commands = map[string]command{
+	"fsck":        {usage: "check consistency of blobs and files metainfo", run: runFsck},
+	"replication": {usage: "status|repair list or re-copy objects missing on one side of mirror blob backend", run: runReplication},
+	"migrate":     {usage: "up|down|status apply, roll back last or list database schema migrations", run: runMigrate},
+	"backup":      {usage: "--out {file} write encrypted archive of users, files, blobs and server keys", run: runBackup},
+	"restore":     {usage: "--in {file} restore archive into empty database and blob backend", run: runRestore},
+	"rotate-keys": {usage: "[--rewrap] generate new server key, stored keys are re-wrapped to it by server in background", run: runRotateKeys},
+	"rewrap-keys": {usage: "[--grace 10m] re-wrap keys wrapped by retired server keys and remove retired keys nothing is wrapped by", run: runRewrapKeys},
+	"gen-certs":   {usage: "[--dir certs] [--hosts localhost] [--client {login}] create self-signed CA, server and client certificates", run: runGenCerts},
+}
+
+// Runs subcommand with its arguments, exits with non zero code on failure.
+func ExecuteCommand(args []string) {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Printf("Unknown command %q, available commands:\n", args[0])
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s\t%s\n", name, commands[name].usage)
+		}
+		os.Exit(2)
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Prints value as indented json to stdout.
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// Checks storage consistency and prints json report.
+// Returns error if inconsistencies are left so that cron can alert.
+func runFsck(args []string) error {
+	grace, err := fsckGracePeriod()
+	if err != nil {
+		return err
+	}
+	flags := flag.NewFlagSet("fsck", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "delete orphaned blobs and stale files older than grace period")
+	flags.DurationVar(&grace, "grace", grace, "grace period for repair")
+	flags.Parse(args)
+
+	metadata := GetMetadata()
+	defer metadata.Close()
+	checker := fsck.NewChecker(GetFileStorage(metadata), metadata.Files)
+	report, err := checker.Check(context.Background(), *repair, grace)
+	if err != nil {
+		return err
+	}
+	if err = printJSON(report); err != nil {
+		return err
+	}
+	if report.Unrepaired() {
+		return fsck.ErrInconsistent
+	}
+	return nil
+}
+
+// Applies, rolls back or lists database schema migrations.
+func runMigrate(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: migrate up|down|status")
+	}
+	metadata := GetMetadata()
+	defer metadata.Close()
+	migrator := metadata.Migrator
+	if migrator == nil {
+		return fmt.Errorf("%s metadata backend has no schema migrations", metadataBackend())
+	}
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %04d_%s\n", migration.Version, migration.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(statuses)
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", args[0])
+	}
+	return nil
+}
+
+// Lists or repairs objects missing on one side of mirror blob backend.
+// Returns error if some objects are left unrepaired so that cron can alert.
+func runReplication(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: replication status|repair")
+	}
+	metadata := GetMetadata()
+	defer metadata.Close()
+	mirror, ok := GetFileStorage(metadata).(*filestorage.MirrorFileStorage)
+	if !ok {
+		return fmt.Errorf("%s blob backend is not mirror", blobBackend())
+	}
+	ctx := context.Background()
+	switch args[0] {
+	case "status":
+		statuses, err := mirror.ReplicationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(statuses)
+	case "repair":
+		report, err := mirror.Repair(ctx)
+		if err != nil {
+			return err
+		}
+		if err = printJSON(report); err != nil {
+			return err
+		}
+		if len(report.Failed) > 0 {
+			return fmt.Errorf("failed to repair %d objects", len(report.Failed))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown replication command %q, expected status or repair", args[0])
+	}
+}
+
+// Env variable with backup passphrase, used if passphrase file is not given.
+const backupPassphraseEnv = "BACKUP_PASSPHRASE"
+
+// Reads operator passphrase protecting server keys in backup archive.
+func readPassphrase(path string) ([]byte, error) {
+	var passphrase string
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	} else {
+		passphrase = os.Getenv(backupPassphraseEnv)
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("backup passphrase should be given with --passphrase-file or %s", backupPassphraseEnv)
+	}
+	return []byte(passphrase), nil
+}
+
+// Writes encrypted archive of the whole vault and prints json report.
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := flags.String("out", "", "archive path, e.g. vault.tar.zst")
+	passphraseFile := flags.String("passphrase-file", "", "file with passphrase encrypting server keys")
+	flags.Parse(args)
+	if *out == "" {
+		return fmt.Errorf("usage: backup --out {file} [--passphrase-file {file}]")
+	}
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	var keys backup.Keys
+	if keys.PrivateKey, err = os.ReadFile(config.GetConfig().ServerPrivateKeyPath); err != nil {
+		return fmt.Errorf("failed to read server private key: %w", err)
+	}
+	if keys.PublicKey, err = os.ReadFile(config.GetConfig().ServerPublicKeyPath); err != nil {
+		return fmt.Errorf("failed to read server public key: %w", err)
+	}
+	encryption.InitData()
+	retired, err := encryption.RetiredServerKeyIDs()
+	if err != nil {
+		return fmt.Errorf("failed to list retired server keys: %w", err)
+	}
+	for _, id := range retired {
+		if keys.Retired == nil {
+			keys.Retired = make(map[string][]byte)
+		}
+		if keys.Retired[id], err = os.ReadFile(encryption.RetiredServerKeyPath(id)); err != nil {
+			return fmt.Errorf("failed to read retired server key: %w", err)
+		}
+	}
+
+	metadata := GetMetadata()
+	defer metadata.Close()
+	vault := newVault(metadata, GetFileStorage(metadata))
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer file.Close()
+	report, err := backup.Backup(context.Background(), vault, keys, passphrase, file)
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(*out)
+		return err
+	}
+	return printJSON(report)
+}
+
+// Restores archive into empty vault, writes server keys and prints json report.
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := flags.String("in", "", "archive path")
+	passphraseFile := flags.String("passphrase-file", "", "file with passphrase encrypting server keys")
+	flags.Parse(args)
+	if *in == "" {
+		return fmt.Errorf("usage: restore --in {file} [--passphrase-file {file}]")
+	}
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(*in)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	ctx := context.Background()
+	metadata := GetMetadata()
+	defer metadata.Close()
+	if metadata.Migrator != nil {
+		if _, err = metadata.Migrator.Up(ctx); err != nil {
+			return err
+		}
+	}
+	vault := newVault(metadata, GetFileStorage(metadata))
+	report, keys, err := backup.Restore(ctx, vault, passphrase, file)
+	if err != nil {
+		return err
+	}
+	if err = writeKey(config.GetConfig().ServerPrivateKeyPath, keys.PrivateKey); err != nil {
+		return err
+	}
+	if err = writeKey(config.GetConfig().ServerPublicKeyPath, keys.PublicKey); err != nil {
+		return err
+	}
+	encryption.InitData()
+	for id, key := range keys.Retired {
+		if err = writeKey(encryption.RetiredServerKeyPath(id), key); err != nil {
+			return err
+		}
+	}
+	return printJSON(report)
+}
+
+// Vault of metadata backend storages and blobs, metainfo is read in snapshot if backend has them.
+func newVault(metadata *backends.Metadata, blobs filestorage.StreamingFileStorage) backup.Vault {
+	vault := backup.Vault{Files: metadata.Files, Users: metadata.Users, Blobs: blobs, Orgs: metadata.Orgs,
+		ApiTokens: metadata.ApiTokens, Emergency: metadata.Emergency}
+	if metadata.Snapshot != nil {
+		vault.Snapshot = func(ctx context.Context, read func(snapshot backup.Vault) error) error {
+			return metadata.Snapshot(ctx, func(snapshot *backends.Metadata) error {
+				return read(newVault(snapshot, blobs))
+			})
+		}
+	}
+	return vault
+}
+
+// Generates new server key and prints ids of retired and new keys.
+//
+// Running server re-wraps stored keys in background, with --rewrap they are re-wrapped at once, e.g. when server is stopped,
+// so old key is removed without grace period.
+func runRotateKeys(args []string) error {
+	flags := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	rewrap := flags.Bool("rewrap", false, "re-wrap stored keys at once and remove old key")
+	flags.Parse(args)
+
+	encryption.InitData()
+	retired, current, err := encryption.RotateServerKeys()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Server key %s is retired, new server key is %s\n", retired, current)
+	if !*rewrap {
+		return nil
+	}
+	return runRewrapKeys([]string{"--grace", "0"})
+}
+
+// Re-wraps keys wrapped by retired server keys and prints json report.
+// Returns error if some keys are left wrapped by retired keys so that cron can alert.
+func runRewrapKeys(args []string) error {
+	flags := flag.NewFlagSet("rewrap-keys", flag.ExitOnError)
+	grace := flags.Duration("grace", keyrotation.DefaultGrace, "time retired key is kept after rotation")
+	flags.Parse(args)
+
+	encryption.InitData()
+	metadata := GetMetadata()
+	defer metadata.Close()
+	rotator := newRotator(metadata)
+	rotator.Grace = *grace
+	report, err := rotator.Run(context.Background())
+	if err != nil {
+		return err
+	}
+	if err = printJSON(report); err != nil {
+		return err
+	}
+	if report.Unfinished() {
+		return keyrotation.ErrUnfinished
+	}
+	return nil
+}
+
+// Writes restored key, different existing key is kept and restored one is written next to it.
+func writeKey(path string, key []byte) error {
+	existing, err := os.ReadFile(path)
+	if err == nil {
+		if bytes.Equal(existing, key) {
+			return nil
+		}
+		if err = os.WriteFile(path+".restored", key, 0600); err != nil {
+			return fmt.Errorf("failed to write server key: %w", err)
+		}
+		return fmt.Errorf("different server key exists at %s, restored key is written to %s.restored, "+
+			"replace it before starting server", path, path)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to check server key: %w", err)
+	}
+	if err = os.WriteFile(path, key, 0600); err != nil {
+		return fmt.Errorf("failed to write server key: %w", err)
+	}
+	return nil
+}
+
+// Repeated string flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// Creates self-signed CA with server and client certificates for local deployments.
+func runGenCerts(args []string) error {
+	flags := flag.NewFlagSet("gen-certs", flag.ExitOnError)
+	dir := flags.String("dir", "certs", "directory of certificates, existing CA in it is reused")
+	hosts := flags.String("hosts", "localhost,127.0.0.1", "comma separated dns names and ip addresses of server")
+	validity := flags.Duration("validity", 365*24*time.Hour, "lifetime of certificates")
+	var clients stringsFlag
+	flags.Var(&clients, "client", "login of client certificate, may be repeated")
+	flags.Parse(args)
+
+	err := certs.Generate(certs.GenerateConfig{Dir: *dir, Hosts: strings.Split(*hosts, ","), Clients: clients, Validity: *validity})
+	if err != nil {
+		return err
+	}
+	report := map[string]string{
+		"ca":         filepath.Join(*dir, certs.CAFile),
+		"server":     filepath.Join(*dir, certs.ServerFile),
+		"server_key": filepath.Join(*dir, certs.ServerKeyFile),
+	}
+	for _, login := range clients {
+		certFile, keyFile := certs.ClientFiles(login)
+		report["client_"+login] = filepath.Join(*dir, certFile)
+		report["client_"+login+"_key"] = filepath.Join(*dir, keyFile)
+	}
+	return printJSON(report)
+}
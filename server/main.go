@@ -1,8 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net/http"
+
+	"github.com/valinurovdenis/gophkeeper/internal/app/config"
 )
 
 var (
@@ -12,6 +15,12 @@ var (
 )
 
 func main() {
+	config.GetConfig()
+	if args := flag.Args(); len(args) > 0 {
+		ExecuteCommand(args)
+		return
+	}
+
 	fmt.Printf("Build version: %s\n", buildVersion)
 	fmt.Printf("Build date: %s\n", buildDate)
 	fmt.Printf("Build commit: %s\n", buildCommit)
@@ -2,38 +2,148 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"fmt"
 	"net"
+	"strconv"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/valinurovdenis/gophkeeper/internal/app/access"
 	"github.com/valinurovdenis/gophkeeper/internal/app/auth"
+	"github.com/valinurovdenis/gophkeeper/internal/app/backends"
+	"github.com/valinurovdenis/gophkeeper/internal/app/certs"
 	"github.com/valinurovdenis/gophkeeper/internal/app/config"
+	"github.com/valinurovdenis/gophkeeper/internal/app/emergency"
 	"github.com/valinurovdenis/gophkeeper/internal/app/encryption"
 	"github.com/valinurovdenis/gophkeeper/internal/app/filestorage"
+	"github.com/valinurovdenis/gophkeeper/internal/app/fsck"
 	"github.com/valinurovdenis/gophkeeper/internal/app/handlers"
+	"github.com/valinurovdenis/gophkeeper/internal/app/keyrotation"
 	"github.com/valinurovdenis/gophkeeper/internal/app/logger"
-	"github.com/valinurovdenis/gophkeeper/internal/app/metadatastorage"
 	"github.com/valinurovdenis/gophkeeper/internal/app/service"
-	"github.com/valinurovdenis/gophkeeper/internal/app/userstorage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-// Initialize db connection.
-func GetDB() *sql.DB {
+// Whether server runs with in-memory storages.
+func isDev() bool {
+	dev, _ := strconv.ParseBool(config.GetConfig().Dev)
+	return dev
+}
+
+// Name of blob backend chosen by config.
+func blobBackend() string {
+	if isDev() {
+		return "memory"
+	}
+	return config.GetConfig().StorageBackend
+}
+
+// Name of metadata backend chosen by config, by default it is chosen by database dsn scheme.
+func metadataBackend() string {
 	config := config.GetConfig()
-	db, err := sql.Open("pgx", config.Database)
+	switch {
+	case isDev():
+		return "memory"
+	case config.MetadataBackend != "":
+		return config.MetadataBackend
+	case backends.IsSqliteDSN(config.Database):
+		return "sqlite"
+	default:
+		return "postgres"
+	}
+}
+
+// Initialize files storage chosen by config.
+//
+// Mirror backend keeps replication status in metadata database, so that it is shared with maintenance commands.
+func GetFileStorage(metadata *backends.Metadata) filestorage.StreamingFileStorage {
+	name := blobBackend()
+	fileStorage, err := backends.OpenBlob(name, config.GetConfig().Backends[name])
 	if err != nil {
 		panic(err)
 	}
-	return db
+	if mirror, ok := fileStorage.(*filestorage.MirrorFileStorage); ok && metadata.Replication != nil {
+		mirror.UseReplicationStatus(metadata.Replication)
+	}
+	return fileStorage
 }
 
-// Initialize s3 connection.
-func GetS3() *filestorage.S3FileStorage {
-	s3Storage, err := filestorage.NewS3FileStorage()
+// Starts background repair of mirror blob backend if repair interval is configured.
+func runPeriodicRepair(fileStorage filestorage.StreamingFileStorage) {
+	if mirror, ok := fileStorage.(*filestorage.MirrorFileStorage); ok && mirror.RepairInterval > 0 {
+		go mirror.RunRepairPeriodically(context.Background(), mirror.RepairInterval)
+	}
+}
+
+// Initialize metadata and user storages chosen by config.
+func GetMetadata() *backends.Metadata {
+	name := metadataBackend()
+	metadata, err := backends.OpenMetadata(name, config.GetConfig().Backends[name])
 	if err != nil {
 		panic(err)
 	}
-	return s3Storage
+	return metadata
+}
+
+// Returns configured grace period of consistency check.
+//
+// Malformed value is an error rather than zero grace period which would remove in-flight uploads on repair.
+func fsckGracePeriod() (time.Duration, error) {
+	grace, err := time.ParseDuration(config.GetConfig().FsckGracePeriod)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fsck grace period: %w", err)
+	}
+	if grace <= 0 {
+		return 0, fmt.Errorf("invalid fsck grace period %s: must be positive", grace)
+	}
+	return grace, nil
+}
+
+// Starts background consistency check if interval is configured.
+func runPeriodicFsck(checker *fsck.Checker) error {
+	config := config.GetConfig()
+	if config.FsckInterval == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(config.FsckInterval)
+	if err != nil {
+		return fmt.Errorf("invalid fsck interval: %w", err)
+	}
+	if interval <= 0 {
+		return nil
+	}
+	grace, err := fsckGracePeriod()
+	if err != nil {
+		return err
+	}
+	repair, err := strconv.ParseBool(config.FsckRepair)
+	if err != nil {
+		return fmt.Errorf("invalid fsck repair flag: %w", err)
+	}
+	go checker.RunPeriodically(context.Background(), interval, repair, grace)
+	return nil
+}
+
+// Rotator re-wrapping keys of metadata storages in batches of configured size.
+func newRotator(metadata *backends.Metadata) *keyrotation.Rotator {
+	rotator := keyrotation.NewRotator(metadata.Files, metadata.Orgs, metadata.Users)
+	if batch, err := strconv.Atoi(config.GetConfig().KeyRotationBatch); err == nil && batch > 0 {
+		rotator.BatchSize = batch
+	}
+	return rotator
+}
+
+// Starts background re-wrapping of keys after server key rotation if interval is configured.
+func runPeriodicKeyRotation(rotator *keyrotation.Rotator) {
+	interval, err := time.ParseDuration(config.GetConfig().KeyRotationInterval)
+	if err != nil || interval <= 0 {
+		return
+	}
+	// Uploads stored around rotation are re-wrapped by one of passes before retired key is removed.
+	rotator.Grace = max(rotator.Grace, 2*interval)
+	go rotator.RunPeriodically(context.Background(), interval)
 }
 
 // Runs keeper service with given config.
@@ -43,24 +153,96 @@ func Run() error {
 	if err := logger.Initialize(config.LogLevel); err != nil {
 		return err
 	}
+	if isDev() {
+		logger.Log.Warn("Running in dev mode with in-memory storages, all data is lost on exit")
+	}
 
-	db := GetDB()
-	defer db.Close()
-	s3Storage := GetS3()
+	metadata := GetMetadata()
+	defer metadata.Close()
+	if metadata.Migrator != nil {
+		applied, err := metadata.Migrator.Up(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, migration := range applied {
+			logger.Log.Info("Applied migration", zap.Int("version", migration.Version), zap.String("name", migration.Name))
+		}
+	}
+	fileStorage := GetFileStorage(metadata)
+	runPeriodicRepair(fileStorage)
 
-	metaDataStorage := metadatastorage.NewPostgresqlStorageStorage(db)
-	service, err := service.NewGophKeeperService(s3Storage, metaDataStorage)
+	if err := runPeriodicFsck(fsck.NewChecker(fileStorage, metadata.Files)); err != nil {
+		return err
+	}
+	service, err := service.NewGophKeeperService(fileStorage, metadata.Files)
 	if err != nil {
 		return err
 	}
-	userStorage := userstorage.NewPostgresqlUserStorage(db)
+	if metadata.Orgs != nil {
+		service.Access = access.NewPolicy(metadata.Orgs)
+	}
+	if metadata.Emergency != nil {
+		service.Emergency = emergency.NewManager(metadata.Emergency)
+	}
 	encryption.InitData()
+	runPeriodicKeyRotation(newRotator(metadata))
 	auth := auth.NewAuthenticator(config.SecretKey)
-	grpcHandler, err := handlers.NewGophKeeperHandler(*service, *auth, userStorage)
+	if auth.AccessTokenTTL, err = time.ParseDuration(config.AccessTokenTTL); err != nil {
+		return err
+	}
+	if auth.RefreshTokenTTL, err = time.ParseDuration(config.RefreshTokenTTL); err != nil {
+		return err
+	}
+	if metadata.Tokens != nil {
+		auth.Tokens = metadata.Tokens
+	}
+	if metadata.Sessions != nil {
+		auth.Sessions = metadata.Sessions
+	}
+	if metadata.ApiTokens != nil {
+		auth.ApiTokens = metadata.ApiTokens
+	}
+	go auth.RunTokenCleanup(context.Background(), time.Hour)
+	grpcHandler, err := handlers.NewGophKeeperHandler(*service, *auth, metadata.Users)
+	if err != nil {
+		return err
+	}
+	if grpcHandler.PasswordPolicy.MinLength, err = strconv.Atoi(config.PasswordMinLength); err != nil {
+		return err
+	}
+	if grpcHandler.PasswordPolicy.MinStrength, err = strconv.Atoi(config.PasswordMinStrength); err != nil {
+		return err
+	}
+	options, err := transportOptions()
+	if err != nil {
+		return err
+	}
+	grpcSrv := handlers.KeeperGrpcRouter(*grpcHandler, options...)
+	listen, err := net.Listen("tcp", config.ServerURL)
 	if err != nil {
 		return err
 	}
-	grpcSrv := handlers.KeeperGrpcRouter(*grpcHandler)
-	listen, _ := net.Listen("tcp", "localhost:8080")
 	return grpcSrv.Serve(listen)
 }
+
+// Returns tls credentials of server from config, server listens in plaintext if certificate is not configured.
+func transportOptions() ([]grpc.ServerOption, error) {
+	config := config.GetConfig()
+	requireClientCert, err := strconv.ParseBool(config.TLSRequireClientCert)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tls require client cert flag: %w", err)
+	}
+	if config.TLSCertFile == "" {
+		if config.TLSClientCAFile != "" || requireClientCert {
+			return nil, fmt.Errorf("client certificates require server tls certificate")
+		}
+		logger.Log.Warn("TLS is not configured, passwords and tokens are sent in plaintext")
+		return nil, nil
+	}
+	tlsConfig, err := certs.ServerConfig{CertFile: config.TLSCertFile, KeyFile: config.TLSKeyFile,
+		ClientCAFile: config.TLSClientCAFile, RequireClientCert: requireClientCert}.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsConfig))}, nil
+}